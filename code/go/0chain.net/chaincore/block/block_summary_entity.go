@@ -25,6 +25,7 @@ type BlockSummary struct {
 	ClientStateHash       util.Key      `json:"state_hash"`
 	ReceiptMerkleTreeRoot string        `json:"receipt_merkle_tree_root"`
 	NumTxns               int           `json:"num_txns"`
+	MinFee                int64         `json:"min_fee,omitempty"`
 	*MagicBlock           `json:"maigc_block,omitempty"`
 }
 
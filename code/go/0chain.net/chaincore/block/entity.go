@@ -105,6 +105,10 @@ type UnverifiedBlockBody struct {
 
 	ClientStateHash util.Key `json:"state_hash"`
 
+	// The minimum fee of the transactions of the block, by the utilization
+	// of the previous blocks
+	MinFee int64 `json:"min_fee,omitempty"`
+
 	// The entire transaction payload to represent full block
 	Txns []*transaction.Transaction `json:"transactions,omitempty"`
 }
@@ -443,6 +447,9 @@ func (b *Block) getHashData() string {
 		}
 		hashData += ":" + b.MagicBlock.Hash
 	}
+	if b.MinFee != 0 {
+		hashData += ":" + strconv.FormatInt(b.MinFee, 10)
+	}
 	return hashData
 }
 
@@ -489,6 +496,7 @@ func (b *Block) GetSummary() *BlockSummary {
 	bs.ClientStateHash = b.ClientStateHash
 	bs.ReceiptMerkleTreeRoot = b.GetReceiptsMerkleTree().GetRoot()
 	bs.NumTxns = len(b.Txns)
+	bs.MinFee = b.MinFee
 	bs.MagicBlock = b.MagicBlock
 	return bs
 }
@@ -741,6 +749,7 @@ type Chainer interface {
 	ComputeState(ctx context.Context, pb *Block) error
 	GetStateDB() util.NodeDB
 	UpdateState(ctx context.Context, b *Block, txn *transaction.Transaction) error
+	UpdateStates(ctx context.Context, b *Block, txns []*transaction.Transaction) error
 }
 
 // ComputeState computes block client state
@@ -844,17 +853,17 @@ func (b *Block) ComputeState(ctx context.Context, c Chainer) error {
 		if datastore.IsEmpty(txn.ClientID) {
 			txn.ComputeClientID()
 		}
-		if err := c.UpdateState(ctx, b, txn); err != nil {
-			b.SetStateStatus(StateFailed)
-			logging.Logger.Error("compute state - update state failed",
-				zap.Int64("round", b.Round),
-				zap.String("block", b.Hash),
-				zap.String("client_state", util.ToHex(b.ClientStateHash)),
-				zap.String("prev_block", b.PrevHash),
-				zap.String("prev_client_state", util.ToHex(pb.ClientStateHash)),
-				zap.Error(err))
-			return common.NewError("state_update_error", "error updating state")
-		}
+	}
+	if err := c.UpdateStates(ctx, b, b.Txns); err != nil {
+		b.SetStateStatus(StateFailed)
+		logging.Logger.Error("compute state - update state failed",
+			zap.Int64("round", b.Round),
+			zap.String("block", b.Hash),
+			zap.String("client_state", util.ToHex(b.ClientStateHash)),
+			zap.String("prev_block", b.PrevHash),
+			zap.String("prev_client_state", util.ToHex(pb.ClientStateHash)),
+			zap.Error(err))
+		return common.NewError("state_update_error", "error updating state")
 	}
 
 	logging.Logger.Info("compute state", zap.Int64("round", b.Round),
@@ -938,7 +947,7 @@ func (b *Block) SaveChanges(ctx context.Context, c Chainer) error {
 		err = b.ClientState.SaveChanges(ctx, c.GetStateDB(), false)
 		lndb, ok := b.ClientState.GetNodeDB().(*util.LevelNodeDB)
 		if ok {
-			c.GetStateDB().(*util.PNodeDB).TrackDBVersion(lndb.GetDBVersion())
+			c.GetStateDB().(util.PersistentNodeDB).TrackDBVersion(lndb.GetDBVersion())
 		}
 	default:
 		return common.NewError("state_save_without_success", "State can't be saved without successful computation")
@@ -50,21 +50,35 @@ type Config struct {
 	OwnerID               datastore.Key `json:"owner_id"`                  // Client who created this chain
 	ParentChainID         datastore.Key `json:"parent_chain_id,omitempty"` // Chain from which this chain is forked off
 	GenesisBlockHash      string        `json:"genesis_block_hash"`
-	Decimals              int8          `json:"decimals"`                // Number of decimals allowed for the token on this chain
-	BlockSize             int32         `json:"block_size"`              // Number of transactions in a block
-	MinBlockSize          int32         `json:"min_block_size"`          // Number of transactions a block needs to have
-	MaxByteSize           int64         `json:"max_byte_size"`           // Max number of bytes a block can have
-	MinGenerators         int           `json:"min_generators"`          // Min number of block generators.
-	GeneratorsPercent     float64       `json:"generators_percent"`      // Percentage of all miners
-	NumReplicators        int           `json:"num_replicators"`         // Number of sharders that can store the block
-	ThresholdByCount      int           `json:"threshold_by_count"`      // Threshold count for a block to be notarized
-	ThresholdByStake      int           `json:"threshold_by_stake"`      // Stake threshold for a block to be notarized
-	ValidationBatchSize   int           `json:"validation_size"`         // Batch size of txns for crypto verification
-	TxnMaxPayload         int           `json:"transaction_max_payload"` // Max payload allowed in the transaction
-	PruneStateBelowCount  int           `json:"prune_state_below_count"` // Prune state below these many rounds
-	RoundRange            int64         `json:"round_range"`             // blocks are stored in separate directory for each range of rounds
-	BlocksToSharder       int           `json:"blocks_to_sharder"`       // send finalized or notarized blocks to sharder
-	VerificationTicketsTo int           `json:"verification_tickets_to"` // send verification tickets to generator or all miners
+	Decimals              int8          `json:"decimals"`                 // Number of decimals allowed for the token on this chain
+	BlockSize             int32         `json:"block_size"`               // Number of transactions in a block
+	MinBlockSize          int32         `json:"min_block_size"`           // Number of transactions a block needs to have
+	MaxByteSize           int64         `json:"max_byte_size"`            // Max number of bytes a block can have
+	MinGenerators         int           `json:"min_generators"`           // Min number of block generators.
+	GeneratorsPercent     float64       `json:"generators_percent"`       // Percentage of all miners
+	NumReplicators        int           `json:"num_replicators"`          // Number of sharders that can store the block
+	ThresholdByCount      int           `json:"threshold_by_count"`       // Threshold count for a block to be notarized
+	ThresholdByStake      int           `json:"threshold_by_stake"`       // Stake threshold for a block to be notarized
+	ValidationBatchSize   int           `json:"validation_size"`          // Batch size of txns for crypto verification
+	TxnMaxPayload         int           `json:"transaction_max_payload"`  // Max payload allowed in the transaction
+	TxnMaxBatch           int           `json:"transaction_max_batch"`    // Max number of transactions put in a batch
+	TxnDynamicFee         bool          `json:"transaction_dynamic_fee"`  // Adjust the min fee of the transactions by the utilization of the blocks
+	TxnFeeTarget          int64         `json:"transaction_fee_target"`   // Target utilization of the blocks in percent
+	TxnFeeQuotient        int64         `json:"transaction_fee_quotient"` // The min fee changes by 1/quotient at most per block
+	PruneStateBelowCount  int           `json:"prune_state_below_count"`  // Prune state below these many rounds
+	ArchiveState          bool          `json:"archive_state"`            // Keep the state of all rounds, no state pruning
+	StatePruneMode        string        `json:"state_prune_mode"`         // Prune the state by versions or by reference counts
+	StatePruneMaxRounds   int           `json:"state_prune_max_rounds"`   // Max number of rounds journals pruned at once (refcount mode)
+	StateTypedEncoding    bool          `json:"state_typed_encoding"`     // Encode the registered SC state values typed (msgpack)
+	StateParallelTxns     bool          `json:"state_parallel_execution"` // Execute the transactions of a block optimistically in parallel
+	StateParallelWorkers  int           `json:"state_parallel_workers"`   // Number of goroutines executing the transactions in parallel
+	StateParallelBatch    int           `json:"state_parallel_batch"`     // Number of transactions executed in parallel at once
+	StateSnapshotDir      string        `json:"state_snapshot_dir"`       // Directory of the state snapshots served to other nodes
+	StateSnapshotChunk    int           `json:"state_snapshot_chunk"`     // Max number of state nodes in a chunk of a state snapshot
+	StateSyncProgressFile string        `json:"state_sync_progress_file"` // File to persist the progress of state sync to resume it
+	RoundRange            int64         `json:"round_range"`              // blocks are stored in separate directory for each range of rounds
+	BlocksToSharder       int           `json:"blocks_to_sharder"`        // send finalized or notarized blocks to sharder
+	VerificationTicketsTo int           `json:"verification_tickets_to"`  // send verification tickets to generator or all miners
 
 	HealthShowCounters bool `json:"health_show_counters"` // display detail counters
 	// Health Check switches
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"runtime"
 	"sort"
 	"sync"
 	"time"
@@ -151,6 +152,7 @@ type Chain struct {
 	viewChanger                  ViewChanger
 	afterFetcher                 AfterFetcher
 	magicBlockSaver              MagicBlockSaver
+	blockSummaryProvider         BlockSummaryProvider
 
 	pruneStats *util.PruneStats
 
@@ -353,7 +355,25 @@ func NewChainFromConfig() *Chain {
 	chain.ValidationBatchSize = viper.GetInt("server_chain.block.validation.batch_size")
 	chain.RoundRange = viper.GetInt64("server_chain.round_range")
 	chain.TxnMaxPayload = viper.GetInt("server_chain.transaction.payload.max_size")
+	chain.TxnMaxBatch = viper.GetInt("server_chain.transaction.batch.max_size")
+	chain.TxnDynamicFee = viper.GetBool("server_chain.transaction.dynamic_fee.enabled")
+	chain.TxnFeeTarget = viper.GetInt64("server_chain.transaction.dynamic_fee.target_utilization")
+	chain.TxnFeeQuotient = viper.GetInt64("server_chain.transaction.dynamic_fee.quotient")
 	chain.PruneStateBelowCount = viper.GetInt("server_chain.state.prune_below_count")
+	chain.ArchiveState = viper.GetBool("server_chain.state.archive")
+	chain.StatePruneMode = viper.GetString("server_chain.state.prune_mode")
+	chain.StatePruneMaxRounds = viper.GetInt("server_chain.state.prune_max_rounds")
+	chain.StateTypedEncoding = viper.GetBool("server_chain.state.typed_encoding")
+	util.SetTypedValueEncoding(chain.StateTypedEncoding)
+	chain.StateParallelTxns = viper.GetBool("server_chain.state.parallel.enabled")
+	chain.StateParallelWorkers = viper.GetInt("server_chain.state.parallel.workers")
+	if chain.StateParallelWorkers <= 0 {
+		chain.StateParallelWorkers = runtime.NumCPU()
+	}
+	chain.StateParallelBatch = viper.GetInt("server_chain.state.parallel.batch_size")
+	chain.StateSnapshotDir = viper.GetString("server_chain.state.snapshot.dir")
+	chain.StateSnapshotChunk = viper.GetInt("server_chain.state.snapshot.chunk_size")
+	chain.StateSyncProgressFile = viper.GetString("server_chain.state.sync.progress_file")
 	verificationTicketsTo := viper.GetString("server_chain.messages.verification_tickets_to")
 	if verificationTicketsTo == "" || verificationTicketsTo == "all_miners" || verificationTicketsTo == "11" {
 		chain.VerificationTicketsTo = AllMiners
@@ -486,18 +506,54 @@ func SetupEntity(store datastore.Store) {
 	SetupStateDB()
 }
 
-var stateDB *util.PNodeDB
+var stateDB util.PersistentNodeDB
 
-//SetupStateDB - setup the state db
+// state db backends
+const (
+	StateDBBackendRocksDB = "rocksdb"
+	StateDBBackendBolt    = "bolt"
+)
+
+// state pruning modes
+const (
+	// StatePruneModeVersion - mark the nodes of the kept state with its
+	// version and sweep the nodes below it
+	StatePruneModeVersion = "version"
+	// StatePruneModeRefCount - release the references of the nodes replaced
+	// by the pruned rounds, see util.RefCountNodeDB
+	StatePruneModeRefCount = "refcount"
+)
+
+//SetupStateDB - setup the state db of the configured backend
 func SetupStateDB() {
-	db, err := util.NewPNodeDB("data/rocksdb/state", "/0chain/log/rocksdb/state")
+	var (
+		db  util.PersistentNodeDB
+		err error
+	)
+	switch backend := viper.GetString("server_chain.state.db.backend"); backend {
+	case "", StateDBBackendRocksDB: // the default one when the config is not loaded
+		db, err = util.NewPNodeDB("data/rocksdb/state", "/0chain/log/rocksdb/state")
+	case StateDBBackendBolt:
+		db, err = util.NewBoltNodeDB(viper.GetString("server_chain.state.db.bolt_file"))
+	default:
+		err = fmt.Errorf("unknown state db backend: %q", backend)
+	}
 	if err != nil {
 		panic(err)
 	}
+	if viper.GetString("server_chain.state.prune_mode") == StatePruneModeRefCount &&
+		!viper.GetBool("server_chain.state.archive") {
+		rdb, err := util.NewRefCountNodeDB(db,
+			viper.GetString("server_chain.state.prune_journal_file"))
+		if err != nil {
+			panic(err)
+		}
+		db = rdb
+	}
 	stateDB = db
 }
 
-// CloseStateDB closes the state db (rocksdb)
+// CloseStateDB closes the state db
 func CloseStateDB() {
 	stateDB.Close()
 }
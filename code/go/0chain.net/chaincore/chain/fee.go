@@ -0,0 +1,201 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+)
+
+const (
+	// DefaultTxnFeeTarget - the default target utilization of the blocks in percent
+	DefaultTxnFeeTarget = 50
+	// DefaultTxnFeeQuotient - by default the min fee changes by 1/8 at most per block
+	DefaultTxnFeeQuotient = 8
+
+	// the rounds of the full blocks the fast and normal estimations account for
+	fastFeeRounds   = 3
+	normalFeeRounds = 1
+)
+
+/*FeeEstimate - the fees suggested for the inclusion of a transaction by the
+* latest finalized block. The slow fee is the minimum fee of the next block,
+* the normal and fast fees stay above the minimum fee if the next blocks are
+* full and are not less than the median and 90th percentile of the fees paid */
+type FeeEstimate struct {
+	Round       int64 `json:"round"`
+	Utilization int64 `json:"utilization"` // percent
+	MinFee      int64 `json:"min_fee"`
+	Slow        int64 `json:"slow"`
+	Normal      int64 `json:"normal"`
+	Fast        int64 `json:"fast"`
+}
+
+var (
+	feeEstimate      = &FeeEstimate{}
+	feeEstimateMutex sync.RWMutex
+)
+
+/*NextMinFee - the minimum fee of a block by the minimum fee and the utilization
+* of the previous block. The fee goes up if the utilization is above the
+* target and down if below, by 1/quotient of the fee at most and by 1 at least
+* when it goes up, the floor is the static minimum fee */
+func NextMinFee(minFee, utilization, target, quotient, floor int64) int64 {
+	if minFee < floor {
+		minFee = floor
+	}
+	if target <= 0 || target > 100 {
+		target = DefaultTxnFeeTarget
+	}
+	if quotient <= 0 {
+		quotient = DefaultTxnFeeQuotient
+	}
+	switch {
+	case utilization > target:
+		delta := minFee * (utilization - target) / target / quotient
+		if delta < 1 {
+			delta = 1
+		}
+		minFee += delta
+	case utilization < target:
+		minFee -= minFee * (target - utilization) / target / quotient
+	}
+	if minFee < floor {
+		minFee = floor
+	}
+	return minFee
+}
+
+/*BlockUtilization - the utilization of the block in percent, the larger of
+* the number of its transactions relative to BlockSize and of their bytes
+* relative to MaxByteSize */
+func (c *Chain) BlockUtilization(b *block.Block) int64 {
+	var utilization int64
+	if c.BlockSize > 0 {
+		utilization = int64(len(b.Txns)) * 100 / int64(c.BlockSize)
+	}
+	if c.MaxByteSize > 0 {
+		var byteSize int64
+		for _, txn := range b.Txns {
+			byteSize += int64(len(txn.TransactionData)) + int64(len(txn.TransactionOutput))
+		}
+		if bu := byteSize * 100 / c.MaxByteSize; bu > utilization {
+			utilization = bu
+		}
+	}
+	if utilization > 100 {
+		utilization = 100
+	}
+	return utilization
+}
+
+/*ComputeMinFee - the minimum fee of the transactions of the block following
+* the given block, 0 if the dynamic fee is disabled */
+func (c *Chain) ComputeMinFee(pb *block.Block) int64 {
+	if !c.TxnDynamicFee {
+		return 0
+	}
+	return NextMinFee(pb.MinFee, c.BlockUtilization(pb), c.TxnFeeTarget,
+		c.TxnFeeQuotient, transaction.TXN_MIN_FEE)
+}
+
+/*ValidateBlockMinFee - validate the minimum fee of the block by its previous
+* block and the fees of its transactions. The transactions of the generator of
+* the block are exempted, the fees are paid to it. */
+func (c *Chain) ValidateBlockMinFee(b, pb *block.Block) error {
+	minFee := c.ComputeMinFee(pb)
+	if b.MinFee != minFee {
+		return common.NewErrorf("invalid_block_min_fee",
+			"block %v min fee %v, expected %v", b.Hash, b.MinFee, minFee)
+	}
+	for _, txn := range b.Txns {
+		if txn.GetFeePayerID() == b.MinerID {
+			continue
+		}
+		if err := txn.ValidateMinFee(minFee); err != nil {
+			return common.NewErrorf("invalid_block_min_fee",
+				"block %v txn %v fee %v less than %v", b.Hash, txn.Hash,
+				txn.Fee, minFee)
+		}
+	}
+	return nil
+}
+
+// feePercentile returns the fee of the percentile of the sorted fees.
+func feePercentile(fees []int64, percentile int) int64 {
+	if len(fees) == 0 {
+		return 0
+	}
+	return fees[(len(fees)-1)*percentile/100]
+}
+
+// EstimateFee - estimate the fees for the inclusion by the finalized block
+func (c *Chain) EstimateFee(fb *block.Block) *FeeEstimate {
+	utilization := c.BlockUtilization(fb)
+	minFee := NextMinFee(fb.MinFee, utilization, c.TxnFeeTarget,
+		c.TxnFeeQuotient, transaction.TXN_MIN_FEE)
+	if !c.TxnDynamicFee {
+		minFee = transaction.TXN_MIN_FEE
+	}
+	fees := make([]int64, 0, len(fb.Txns))
+	for _, txn := range fb.Txns {
+		if txn.GetFeePayerID() != fb.MinerID {
+			fees = append(fees, txn.Fee)
+		}
+	}
+	sort.Slice(fees, func(i, j int) bool { return fees[i] < fees[j] })
+
+	fullBlocksFee := func(rounds int) int64 {
+		fee := minFee
+		if c.TxnDynamicFee {
+			for i := 0; i < rounds; i++ {
+				fee = NextMinFee(fee, 100, c.TxnFeeTarget, c.TxnFeeQuotient,
+					transaction.TXN_MIN_FEE)
+			}
+		}
+		return fee
+	}
+	max := func(a, b int64) int64 {
+		if a > b {
+			return a
+		}
+		return b
+	}
+	return &FeeEstimate{
+		Round:       fb.Round,
+		Utilization: utilization,
+		MinFee:      minFee,
+		Slow:        minFee,
+		Normal:      max(fullBlocksFee(normalFeeRounds), feePercentile(fees, 50)),
+		Fast:        max(fullBlocksFee(fastFeeRounds), feePercentile(fees, 90)),
+	}
+}
+
+/*updateFeeEstimate - update the fee estimation and the minimum fee of the
+* transactions accepted by the finalized block */
+func (c *Chain) updateFeeEstimate(fb *block.Block) {
+	estimate := c.EstimateFee(fb)
+	feeEstimateMutex.Lock()
+	feeEstimate = estimate
+	feeEstimateMutex.Unlock()
+	if c.TxnDynamicFee {
+		transaction.SetTxnDynamicFee(estimate.MinFee)
+	}
+}
+
+// GetFeeEstimate - the fee estimation by the latest finalized block
+func GetFeeEstimate() *FeeEstimate {
+	feeEstimateMutex.RLock()
+	defer feeEstimateMutex.RUnlock()
+	return feeEstimate
+}
+
+/*FeeEstimateHandler - the fees suggested for the fast, normal and slow
+* inclusion of a transaction */
+func FeeEstimateHandler(ctx context.Context, r *http.Request) (interface{}, error) {
+	return GetFeeEstimate(), nil
+}
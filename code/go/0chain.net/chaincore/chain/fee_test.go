@@ -0,0 +1,128 @@
+package chain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/chaincore/transaction"
+)
+
+func TestNextMinFee(t *testing.T) {
+	tests := []struct {
+		name        string
+		minFee      int64
+		utilization int64
+		floor       int64
+		want        int64
+	}{
+		{"target", 800, 50, 0, 800},
+		{"full", 800, 100, 0, 900},
+		{"empty", 800, 0, 0, 700},
+		{"above target", 800, 75, 0, 850},
+		{"up by one at least", 0, 100, 0, 1},
+		{"floor", 100, 0, 95, 95},
+		{"below floor", 10, 50, 95, 95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want,
+				NextMinFee(tt.minFee, tt.utilization, 50, 8, tt.floor))
+		})
+	}
+	// the defaults for the invalid configuration
+	require.EqualValues(t, 900, NextMinFee(800, 100, 0, 0, 0))
+}
+
+func newFeeTestChain() *Chain {
+	c := &Chain{Config: &Config{}}
+	c.BlockSize = 10
+	c.MaxByteSize = 1000
+	c.TxnDynamicFee = true
+	c.TxnFeeTarget = 50
+	c.TxnFeeQuotient = 8
+	return c
+}
+
+func newFeeTestBlock(minFee int64, fees ...int64) *block.Block {
+	b := block.NewBlock("", 1)
+	b.MinerID = "miner"
+	b.MinFee = minFee
+	for _, fee := range fees {
+		b.Txns = append(b.Txns, &transaction.Transaction{ClientID: "client", Fee: fee})
+	}
+	return b
+}
+
+func TestChain_BlockUtilization(t *testing.T) {
+	c := newFeeTestChain()
+	b := newFeeTestBlock(0, 1, 1, 1)
+	require.EqualValues(t, 30, c.BlockUtilization(b))
+
+	b.Txns[0].TransactionData = strings.Repeat("d", 600)
+	require.EqualValues(t, 60, c.BlockUtilization(b))
+
+	b.Txns[1].TransactionOutput = strings.Repeat("o", 600)
+	require.EqualValues(t, 100, c.BlockUtilization(b))
+}
+
+func TestChain_ValidateBlockMinFee(t *testing.T) {
+	c := newFeeTestChain()
+	pb := newFeeTestBlock(800, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000)
+	require.EqualValues(t, 900, c.ComputeMinFee(pb))
+
+	b := newFeeTestBlock(900, 900, 1000)
+	require.NoError(t, c.ValidateBlockMinFee(b, pb))
+
+	b.MinFee = 800
+	require.Error(t, c.ValidateBlockMinFee(b, pb))
+
+	b = newFeeTestBlock(900, 899)
+	require.Error(t, c.ValidateBlockMinFee(b, pb))
+
+	// the fees are paid to the generator
+	b.Txns[0].ClientID = b.MinerID
+	require.NoError(t, c.ValidateBlockMinFee(b, pb))
+
+	c.TxnDynamicFee = false
+	require.EqualValues(t, 0, c.ComputeMinFee(pb))
+	b = newFeeTestBlock(0, 0)
+	require.NoError(t, c.ValidateBlockMinFee(b, pb))
+}
+
+func TestChain_EstimateFee(t *testing.T) {
+	c := newFeeTestChain()
+	fb := newFeeTestBlock(800, 800, 900, 1000, 1100, 2000)
+	fb.Round = 7
+
+	estimate := c.EstimateFee(fb)
+	require.EqualValues(t, 7, estimate.Round)
+	require.EqualValues(t, 50, estimate.Utilization)
+	require.EqualValues(t, 800, estimate.MinFee)
+	require.EqualValues(t, 800, estimate.Slow)
+	require.EqualValues(t, 1000, estimate.Normal)
+	require.EqualValues(t, 1138, estimate.Fast)
+
+	// the fees paid by the generator are not counted
+	fb = newFeeTestBlock(800, 1000, 3000, 3000)
+	require.EqualValues(t, 3000, c.EstimateFee(fb).Normal)
+	fb.Txns[1].FeePayerID, fb.Txns[2].FeePayerID = fb.MinerID, fb.MinerID
+	require.EqualValues(t, 1000, c.EstimateFee(fb).Normal)
+	fb.Txns[1].ClientID, fb.Txns[1].FeePayerID = fb.MinerID, "payer"
+	fb.Txns[2].ClientID, fb.Txns[2].FeePayerID = fb.MinerID, "payer"
+	require.EqualValues(t, 3000, c.EstimateFee(fb).Normal)
+
+	// the fees of the full blocks are above the fees paid
+	fb = newFeeTestBlock(800)
+	estimate = c.EstimateFee(fb)
+	require.EqualValues(t, 700, estimate.Slow)
+	require.EqualValues(t, 787, estimate.Normal)
+	require.EqualValues(t, 995, estimate.Fast)
+
+	c.updateFeeEstimate(fb)
+	require.Equal(t, estimate, GetFeeEstimate())
+	require.EqualValues(t, 700, transaction.GetTxnMinFee())
+	transaction.SetTxnDynamicFee(0)
+}
@@ -49,6 +49,7 @@ func SetupHandlers() {
 	http.HandleFunc("/v1/block/get/latest_finalized_magic_block", common.UserRateLimit(common.ToJSONResponse(LatestFinalizedMagicBlockHandler)))
 	http.HandleFunc("/v1/block/get/recent_finalized", common.UserRateLimit(common.ToJSONResponse(RecentFinalizedBlockHandler)))
 	http.HandleFunc("/v1/block/get/fee_stats", common.UserRateLimit(common.ToJSONResponse(LatestBlockFeeStatsHandler)))
+	http.HandleFunc("/v1/block/get/fee_estimate", common.UserRateLimit(common.ToJSONResponse(FeeEstimateHandler)))
 
 	http.HandleFunc("/", common.UserRateLimit(HomePageHandler))
 	http.HandleFunc("/_diagnostics", common.UserRateLimit(DiagnosticsHomepageHandler))
@@ -58,6 +59,9 @@ func SetupHandlers() {
 
 	transactionEntityMetadata := datastore.GetEntityMetadata("txn")
 	http.HandleFunc("/v1/transaction/put", common.UserRateLimit(datastore.ToJSONEntityReqResponse(datastore.DoAsyncEntityJSONHandler(memorystore.WithConnectionEntityJSONHandler(PutTransaction, transactionEntityMetadata), transaction.TransactionEntityChannel), transactionEntityMetadata)))
+	http.HandleFunc("/v1/transaction/put_batch", common.UserRateLimit(common.ToJSONResponse(memorystore.WithConnectionHandler(PutTransactionsBatch))))
+	http.HandleFunc("/v1/transaction/status", common.UserRateLimit(common.ToJSONResponse(transaction.GetTransactionStatus)))
+	http.HandleFunc("/v1/transaction/simulate", common.SimulateRateLimit(common.ToJSONResponse(memorystore.WithConnectionHandler(SimulateTransactionHandler))))
 
 	http.HandleFunc("/_diagnostics/state_dump", common.UserRateLimit(StateDumpHandler))
 
@@ -1207,19 +1211,53 @@ func PutTransaction(ctx context.Context, entity datastore.Entity) (interface{},
 	if !ok {
 		return nil, fmt.Errorf("invalid request %T", entity)
 	}
+	if err := validateTxnPut(txn); err != nil {
+		return nil, err
+	}
+	return transaction.PutTransaction(ctx, txn)
+}
+
+// validateTxnPut validates the payload and the fee of a transaction put.
+func validateTxnPut(txn *transaction.Transaction) error {
 	if GetServerChain().TxnMaxPayload > 0 {
 		if len(txn.TransactionData) > GetServerChain().TxnMaxPayload {
 			s := fmt.Sprintf("transaction payload exceeds the max payload (%d)", GetServerChain().TxnMaxPayload)
-			return nil, common.NewError("txn_exceed_max_payload", s)
+			return common.NewError("txn_exceed_max_payload", s)
 		}
 	}
 
 	// Calculate and update fee
-	if err := txn.ValidateFee(); err != nil {
-		return nil, err
-	}
+	return txn.ValidateFee()
+}
 
-	return transaction.PutTransaction(ctx, txn)
+/*PutTransactionsBatch - Given an array of transactions, it stores the valid
+* ones and returns the result of each of them */
+func PutTransactionsBatch(ctx context.Context, r *http.Request) (interface{}, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-type"), "application/json") {
+		return nil, common.InvalidRequest("Header Content-type=application/json not found")
+	}
+	var data []json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		return nil, common.InvalidRequest("error decoding json: " + err.Error())
+	}
+	sc := GetServerChain()
+	if len(data) == 0 {
+		return nil, common.InvalidRequest("no transactions")
+	}
+	if sc.TxnMaxBatch > 0 && len(data) > sc.TxnMaxBatch {
+		return nil, common.NewErrorf("txn_exceed_max_batch",
+			"number of the transactions exceeds the max batch (%d)", sc.TxnMaxBatch)
+	}
+	transactionEntityMetadata := datastore.GetEntityMetadata("txn")
+	txns := make([]*transaction.Transaction, len(data))
+	for idx, raw := range data {
+		txn := transactionEntityMetadata.Instance().(*transaction.Transaction)
+		if err := json.Unmarshal(raw, txn); err != nil {
+			return nil, common.InvalidRequest(fmt.Sprintf("error decoding transaction %d: %v", idx, err))
+		}
+		txns[idx] = txn
+	}
+	return transaction.PutTransactions(ctx, txns, sc.ValidationBatchSize, validateTxnPut), nil
 }
 
 //RoundInfoHandler collects and writes information about current round
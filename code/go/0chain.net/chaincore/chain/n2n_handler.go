@@ -82,8 +82,15 @@ func SetupX2XResponders() {
 //StateNodesHandler - return a list of state nodes
 func StateNodesHandler(ctx context.Context, r *http.Request) (interface{}, error) {
 	r.ParseForm() // this is needed as we get multiple values for the same key
-	nodes := r.Form["nodes"]
 	c := GetServerChain()
+	if root := r.Form.Get("root"); root != "" {
+		key, err := hex.DecodeString(root)
+		if err != nil {
+			return nil, err
+		}
+		return c.GetStateSubtreeNodes(ctx, key, util.Path(r.Form.Get("prefix")))
+	}
+	nodes := r.Form["nodes"]
 	keys := make([]util.Key, len(nodes))
 	for idx, nd := range nodes {
 		key, err := hex.DecodeString(nd)
@@ -9,6 +9,7 @@ import (
 	"0chain.net/chaincore/node"
 
 	"0chain.net/chaincore/block"
+	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
 	"0chain.net/core/logging"
 	"go.uber.org/zap"
@@ -283,6 +284,14 @@ func (c *Chain) finalizeBlock(ctx context.Context, fb *block.Block, bsh BlockSta
 	}
 	c.rebaseState(fb)
 	c.updateFeeStats(fb)
+	c.updateFeeEstimate(fb)
+	transaction.SetTxnsStatus(fb.Txns, transaction.TxnStatusFinalized, fb.Hash, fb.Round)
+	// the transactions of the forks of the round are back to the pool
+	for _, b := range c.GetRoundBlocks(fb.Round) {
+		if b.Hash != fb.Hash {
+			transaction.RevertTxnsStatus(b.Txns, b.Hash)
+		}
+	}
 
 	if fb.MagicBlock != nil {
 		c.UpdateMagicBlock(fb.MagicBlock)
@@ -476,6 +485,9 @@ func (c *Chain) commonAncestor(ctx context.Context, b1 *block.Block, b2 *block.B
 }
 
 func (c *Chain) updateFeeStats(fb *block.Block) {
+	if len(fb.Txns) == 0 {
+		return
+	}
 	var totalFees int64
 	for _, txn := range fb.Txns {
 		totalFees += txn.Fee
@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"0chain.net/chaincore/block"
+	bcstate "0chain.net/chaincore/chain/state"
+	"0chain.net/chaincore/node"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+)
+
+// TxnSimulation - the result of the simulation of a transaction
+type TxnSimulation struct {
+	Hash    string               `json:"hash"`
+	Round   int64                `json:"round"` // of the state the transaction is executed on
+	Status  int                  `json:"status"`
+	Output  string               `json:"output,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	Receipt *transaction.Receipt `json:"receipt,omitempty"`
+	Reads   []string             `json:"reads"`  // the state keys read
+	Writes  []string             `json:"writes"` // the state keys written in order
+}
+
+/*SimulateTransaction - execute the transaction on the latest finalized state
+* the way it's executed in a block and discard the changes. The transaction
+* is signed or unsigned, the signatures of the signed one are verified. */
+func (c *Chain) SimulateTransaction(ctx context.Context, txn *transaction.Transaction) (*TxnSimulation, error) {
+	txn.ComputeProperties()
+	if txn.ClientID == "" {
+		return nil, common.InvalidRequest("client_id or public_key is required")
+	}
+	signed := txn.Signature != ""
+	if !signed {
+		if txn.CreationDate == 0 {
+			txn.CreationDate = common.Now()
+		}
+		txn.Hash = txn.ComputeHash()
+	}
+	if err := validateTxnPut(txn); err != nil {
+		return nil, err
+	}
+	if err := txn.ValidateWrtTimeForBlock(ctx, common.Now(), signed); err != nil {
+		return nil, err
+	}
+
+	c.stateMutex.RLock()
+	defer c.stateMutex.RUnlock()
+	lfb := c.GetLatestFinalizedBlock()
+	if lfb == nil || lfb.ClientState == nil {
+		return nil, common.NewError("empty_lfb", "empty latest finalized block or state")
+	}
+	b := block.NewBlock(c.GetKey(), lfb.Round+1)
+	b.CreationDate = common.Now()
+	b.MinerID = node.Self.Underlying().GetKey()
+	b.SetPreviousBlock(lfb)
+	b.MagicBlock = lfb.MagicBlock
+	b.ClientState = CreateTxnMPT(lfb.ClientState) // the changes are discarded
+
+	var (
+		access = bcstate.NewStateAccess()
+		sctx   = c.NewStateContext(b, b.ClientState, txn)
+		sim    = &TxnSimulation{Hash: txn.Hash, Round: lfb.Round}
+	)
+	sctx.TrackStateAccess(access)
+	output, err := c.applyTxn(ctx, sctx)
+	for _, path := range access.GetReads() {
+		sim.Reads = append(sim.Reads, string(path))
+	}
+	for _, write := range access.GetWrites() {
+		sim.Writes = append(sim.Writes, string(write.Path))
+	}
+	if err != nil {
+		sim.Status = transaction.TxnFail
+		sim.Error = err.Error()
+		return sim, nil
+	}
+	sim.Status = transaction.TxnSuccess
+	sim.Output = output
+	sim.Receipt = sctx.GetReceipt()
+	return sim, nil
+}
+
+/*SimulateTransactionHandler - given a signed or unsigned transaction returns
+* the result of its execution on the latest finalized state, the output, the
+* transfers, the mints and the events or the error with the state keys it
+* touched. Nothing is stored and no fee is paid. */
+func SimulateTransactionHandler(ctx context.Context, r *http.Request) (interface{}, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-type"), "application/json") {
+		return nil, common.InvalidRequest("Header Content-type=application/json not found")
+	}
+	txn := datastore.GetEntityMetadata("txn").Instance().(*transaction.Transaction)
+	if err := json.NewDecoder(r.Body).Decode(txn); err != nil {
+		return nil, common.InvalidRequest("error decoding json: " + err.Error())
+	}
+	return GetServerChain().SimulateTransaction(ctx, txn)
+}
@@ -0,0 +1,89 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/smartcontract"
+	sci "0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/encryption"
+	"0chain.net/core/util"
+)
+
+func TestChain_SimulateTransaction(t *testing.T) {
+	smartcontract.ContractMap[parallelTestSCAddress] = &parallelTestSC{}
+	defer delete(smartcontract.ContractMap, parallelTestSCAddress)
+	defer transaction.SetTxnTimeout(transaction.TXN_TIME_TOLERANCE)
+	transaction.SetTxnTimeout(30)
+
+	var (
+		ndb, root = newParallelTestState(t)
+		c         = newParallelTestChain(false)
+		lfb       = newParallelTestBlock(ndb, root)
+		from      = parallelTestClient(0)
+	)
+	lfb.Round = parallelTestRound - 1
+	c.LatestFinalizedBlock = lfb
+	defer SetServerChain(GetServerChain())
+	SetServerChain(c)
+
+	newSCTxn := func(name string, input parallelTestInput, value int64) *transaction.Transaction {
+		data, _ := json.Marshal(input)
+		scData, _ := json.Marshal(sci.SmartContractTransactionData{
+			FunctionName: name,
+			InputData:    data,
+		})
+		return &transaction.Transaction{
+			ClientID:        from,
+			ToClientID:      parallelTestSCAddress,
+			Value:           value,
+			TransactionType: transaction.TxnTypeSmartContract,
+			TransactionData: string(scData),
+		}
+	}
+
+	sim, err := c.SimulateTransaction(context.Background(),
+		newSCTxn("pay", parallelTestInput{Key: "key-0"}, 10))
+	require.NoError(t, err)
+	require.Equal(t, transaction.TxnSuccess, sim.Status)
+	require.Equal(t, "key-0=60", sim.Output)
+	require.EqualValues(t, parallelTestRound-1, sim.Round)
+	require.NotEmpty(t, sim.Hash)
+	require.Equal(t, []*transaction.ReceiptTransfer{
+		{ClientID: from, ToClientID: parallelTestSCAddress, Amount: 10},
+	}, sim.Receipt.Transfers)
+	keyPath := string(util.Path(encryption.Hash("key-0")))
+	require.Contains(t, sim.Reads, keyPath)
+	require.Contains(t, sim.Writes, keyPath)
+	require.Contains(t, sim.Writes, string(util.Path(from)))
+
+	// the changes are discarded
+	require.Equal(t, root, lfb.ClientState.GetRoot())
+	sim, err = c.SimulateTransaction(context.Background(),
+		newSCTxn("pay", parallelTestInput{Key: "key-0"}, 10))
+	require.NoError(t, err)
+	require.Equal(t, "key-0=60", sim.Output)
+
+	sim, err = c.SimulateTransaction(context.Background(),
+		newSCTxn("move", parallelTestInput{Key: "key-1", To: "key-2", Value: 5}, 0))
+	require.NoError(t, err)
+	require.Equal(t, transaction.TxnFail, sim.Status)
+	require.Contains(t, sim.Error, "insufficient counter")
+	require.Nil(t, sim.Receipt)
+	require.Contains(t, sim.Reads, string(util.Path(encryption.Hash("key-1"))))
+
+	// the signature of a signed transaction is verified
+	txn := newSCTxn("pay", parallelTestInput{Key: "key-0"}, 10)
+	txn.Signature = "invalid"
+	_, err = c.SimulateTransaction(context.Background(), txn)
+	require.Error(t, err)
+
+	c.LatestFinalizedBlock = nil
+	_, err = c.SimulateTransaction(context.Background(),
+		newSCTxn("pay", parallelTestInput{Key: "key-0"}, 10))
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package chain
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -31,6 +32,9 @@ func init() {
 
 var ErrInsufficientBalance = common.NewError("insufficient_balance", "Balance not sufficient for transfer")
 
+//ErrInvalidNonce - the nonce of the transaction is not the next nonce of its client
+var ErrInvalidNonce = common.NewError("invalid_nonce", "Transaction nonce is not the next nonce of the client")
+
 /*ComputeState - compute the state for the block */
 func (c *Chain) ComputeState(ctx context.Context, b *block.Block) error {
 	return c.computeState(ctx, b)
@@ -155,6 +159,14 @@ func (c *Chain) NewStateContext(b *block.Block, s util.MerklePatriciaTrieI,
 func (c *Chain) updateState(ctx context.Context, b *block.Block, txn *transaction.Transaction) (
 	err error) {
 
+	return c.updateTxnState(ctx, b, txn, nil)
+}
+
+// updateTxnState updates the state of the block by the transaction, the
+// access of the state by the transaction is recorded if given.
+func (c *Chain) updateTxnState(ctx context.Context, b *block.Block,
+	txn *transaction.Transaction, access *bcstate.StateAccess) (err error) {
+
 	// check if the block's ClientState has root value
 	_, err = b.ClientState.GetNodeDB().GetNode(b.ClientState.GetRoot())
 	if err != nil {
@@ -167,24 +179,66 @@ func (c *Chain) updateState(ctx context.Context, b *block.Block, txn *transactio
 		clientState = CreateTxnMPT(b.ClientState) // begin transaction
 		startRoot   = clientState.GetRoot()
 		sctx        = c.NewStateContext(b, clientState, txn)
+		output      string
 	)
+	if access != nil {
+		sctx.TrackStateAccess(access)
+	}
+
+	if output, err = c.applyTxn(ctx, sctx); err != nil {
+		return
+	}
+
+	// commit transaction
+	if err = b.ClientState.MergeMPTChanges(clientState); err != nil {
+		if state.DebugTxn() {
+			logging.Logger.DPanic("update state - merge mpt error",
+				zap.Int64("round", b.Round), zap.String("block", b.Hash),
+				zap.Any("txn", txn), zap.Error(err))
+		}
+
+		logging.Logger.Error("error committing txn", zap.Any("error", err))
+		return
+	}
+
+	c.debugUpdatedState(b, txn, startRoot)
+	txn.TransactionOutput = output
+	txn.Status = transaction.TxnSuccess
+	txn.Receipt = sctx.GetReceipt()
+	return
+}
+
+// applyTxn executes the transaction and applies its transfers and mints to
+// the state of the context, returns the output of the smart contract.
+func (c *Chain) applyTxn(ctx context.Context, sctx *bcstate.StateContext) (
+	output string, err error) {
+
+	txn := sctx.GetTransaction()
+	if err = c.updateNonce(sctx, txn); err != nil {
+		return
+	}
 
 	switch txn.TransactionType {
 
 	case transaction.TxnTypeSmartContract:
-		var output string
 		t := time.Now()
 		if output, err = c.ExecuteSmartContract(ctx, txn, sctx); err != nil {
 			logging.Logger.Error("Error executing the SC", zap.Any("txn", txn),
 				zap.Error(err))
 			return
 		}
-		txn.TransactionOutput = output
 		logging.Logger.Info("SC executed with output",
-			zap.Any("txn_output", txn.TransactionOutput),
+			zap.Any("txn_output", output),
 			zap.Any("txn_hash", txn.Hash),
 			zap.Any("txn_exec_time", time.Since(t)))
 
+	case transaction.TxnTypeMultiCall:
+		if output, err = c.executeMultiCall(ctx, sctx); err != nil {
+			logging.Logger.Error("Error executing the multi-call", zap.Any("txn", txn),
+				zap.Error(err))
+			return
+		}
+
 	case transaction.TxnTypeData:
 
 	case transaction.TxnTypeSend:
@@ -195,11 +249,11 @@ func (c *Chain) updateState(ctx context.Context, b *block.Block, txn *transactio
 		}
 	default:
 		logging.Logger.Error("Invalid transaction type", zap.Int("txn type", txn.TransactionType))
-		return fmt.Errorf("invalid transaction type: %v", txn.TransactionType)
+		return "", fmt.Errorf("invalid transaction type: %v", txn.TransactionType)
 	}
 
 	if config.DevConfiguration.IsFeeEnabled {
-		err = sctx.AddTransfer(state.NewTransfer(txn.ClientID, minersc.ADDRESS,
+		err = sctx.AddTransfer(state.NewTransfer(txn.GetFeePayerID(), minersc.ADDRESS,
 			state.Balance(txn.Fee)))
 		if err != nil {
 			return
@@ -234,35 +288,85 @@ func (c *Chain) updateState(ctx context.Context, b *block.Block, txn *transactio
 			// return
 		}
 	}
+	return output, nil
+}
 
-	// commit transaction
-	if err = b.ClientState.MergeMPTChanges(clientState); err != nil {
-		if state.DebugTxn() {
-			logging.Logger.DPanic("update state - merge mpt error",
-				zap.Int64("round", b.Round), zap.String("block", b.Hash),
-				zap.Any("txn", txn), zap.Error(err))
-		}
+/*executeMultiCall - execute the calls of the multi-call transaction in order
+* on the state of the context within the timeout of a smart contract, the
+* transaction fails if any of them fails. The output is the outputs of the
+* calls. */
+func (c *Chain) executeMultiCall(ctx context.Context, sctx *bcstate.StateContext) (
+	string, error) {
 
-		logging.Logger.Error("error committing txn", zap.Any("error", err))
-		return
+	txn := sctx.GetTransaction()
+	if c.TxnMaxPayload > 0 && len(txn.TransactionData) > c.TxnMaxPayload {
+		return "", common.NewErrorf("txn_exceed_max_payload",
+			"transaction payload exceeds the max payload (%d)", c.TxnMaxPayload)
 	}
-
-	if state.DebugTxn() {
-		if err = block.ValidateState(context.TODO(), b, startRoot); err != nil {
-			logging.Logger.DPanic("update state - state validation failure",
-				zap.Any("txn", txn), zap.Error(err))
+	calls, err := txn.GetCalls()
+	if err != nil {
+		return "", err
+	}
+	cctx, cancel := context.WithTimeout(ctx, c.SmartContractTimeout)
+	defer cancel()
+	for idx, call := range calls {
+		ct, err := txn.NewCallTransaction(call)
+		if err != nil {
+			return "", err
 		}
-		var os *state.State
-		os, err = c.getState(b.ClientState, c.OwnerID)
-		if err != nil || os == nil || os.Balance == 0 {
-			logging.Logger.DPanic("update state - owner account",
-				zap.Int64("round", b.Round), zap.String("block", b.Hash),
-				zap.Any("txn", txn), zap.Any("os", os), zap.Error(err))
+		sctx.BeginCall(ct)
+		output, err := c.ExecuteSmartContract(cctx, ct, sctx)
+		sctx.EndCall(output)
+		if err != nil {
+			return "", common.NewErrorf("multi_call_failed", "call %d %v of %v: %v",
+				idx, call.FunctionName, call.Address, err)
 		}
 	}
+	output, err := json.Marshal(sctx.GetOutputs())
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
 
-	txn.Status = transaction.TxnSuccess
-	return
+func (c *Chain) debugUpdatedState(b *block.Block, txn *transaction.Transaction,
+	startRoot util.Key) {
+
+	if !state.DebugTxn() {
+		return
+	}
+	if err := block.ValidateState(context.TODO(), b, startRoot); err != nil {
+		logging.Logger.DPanic("update state - state validation failure",
+			zap.Any("txn", txn), zap.Error(err))
+	}
+	os, err := c.getState(b.ClientState, c.OwnerID)
+	if err != nil || os == nil || os.Balance == 0 {
+		logging.Logger.DPanic("update state - owner account",
+			zap.Int64("round", b.Round), zap.String("block", b.Hash),
+			zap.Any("txn", txn), zap.Any("os", os), zap.Error(err))
+	}
+}
+
+/*
+* updateNonce - checks the nonce of the transaction is the next nonce of its client and advances it,
+*   the transactions without the nonce are not checked and the replay protection is by their hash only
+ */
+func (c *Chain) updateNonce(sctx bcstate.StateContextI, txn *transaction.Transaction) error {
+	if txn.Nonce == 0 {
+		return nil
+	}
+	clientState := sctx.GetState()
+	s, err := c.getState(clientState, txn.ClientID)
+	if !isValid(err) {
+		return err
+	}
+	if txn.Nonce != s.NextNonce() {
+		return ErrInvalidNonce
+	}
+	sctx.SetStateContext(s)
+	s.Nonce = txn.Nonce
+	_, err = clientState.Insert(util.Path(txn.ClientID), s)
+	return err
 }
 
 /*
@@ -317,7 +421,8 @@ func (c *Chain) transferAmount(sctx bcstate.StateContextI, fromClient, toClient
 	}
 	sctx.SetStateContext(fs)
 	fs.Balance -= amount
-	if fs.Balance == 0 {
+	if fs.Balance == 0 && fs.Nonce == 0 {
+		// the clients having the nonce are kept for the replay protection
 		logging.Logger.Info("transfer amount - remove client", zap.Int64("round", b.Round), zap.String("block", b.Hash), zap.String("client", fromClient), zap.Any("txn", txn))
 		_, err = clientState.Delete(util.Path(fromClient))
 	} else {
@@ -448,6 +553,18 @@ func (c *Chain) GetState(b *block.Block, clientID string) (*state.State, error)
 	return st, nil
 }
 
+/*GetStateNonce - Get the nonce of a client in the given state, a client without the state has the zero nonce.
+Same as the GetState, don't call this from within state computation logic */
+func (c *Chain) GetStateNonce(clientState util.MerklePatriciaTrieI, clientID string) (int64, error) {
+	c.stateMutex.RLock()
+	defer c.stateMutex.RUnlock()
+	s, err := c.getState(clientState, clientID)
+	if !isValid(err) {
+		return 0, err
+	}
+	return s.Nonce, nil
+}
+
 func isValid(err error) bool {
 	if err == nil {
 		return true
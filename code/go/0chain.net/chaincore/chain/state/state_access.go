@@ -0,0 +1,208 @@
+package state
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"0chain.net/core/util"
+)
+
+// StateWrite - a write of a state path, a nil value is the delete of the path
+type StateWrite struct {
+	Path  util.Path
+	Value []byte
+}
+
+/*StateAccess - the paths of the state read and written through a state
+* context. The writes are recorded in their order with the encoded values to
+* replay them on another state. The access is untracked when the state is
+* used other than by the paths (iterated, merged, its root read), any path
+* is considered read then and the writes are not complete. */
+type StateAccess struct {
+	reads     map[string]struct{}
+	writes    []*StateWrite
+	untracked bool
+}
+
+// NewStateAccess - create a new state access
+func NewStateAccess() *StateAccess {
+	return &StateAccess{reads: make(map[string]struct{})}
+}
+
+// Reads - whether the path was read
+func (sa *StateAccess) Reads(path util.Path) bool {
+	if sa.untracked {
+		return true
+	}
+	_, ok := sa.reads[string(path)]
+	return ok
+}
+
+// GetReads - the paths read, sorted
+func (sa *StateAccess) GetReads() []util.Path {
+	reads := make([]util.Path, 0, len(sa.reads))
+	for path := range sa.reads {
+		reads = append(reads, util.Path(path))
+	}
+	sort.Slice(reads, func(i, j int) bool { return string(reads[i]) < string(reads[j]) })
+	return reads
+}
+
+// GetWrites - the writes of the state in their order
+func (sa *StateAccess) GetWrites() []*StateWrite {
+	return sa.writes
+}
+
+// IsUntracked - whether the state was used other than by the paths
+func (sa *StateAccess) IsUntracked() bool {
+	return sa.untracked
+}
+
+func (sa *StateAccess) read(path util.Path) {
+	sa.reads[string(path)] = struct{}{}
+}
+
+func (sa *StateAccess) write(path util.Path, value []byte) {
+	sa.writes = append(sa.writes, &StateWrite{Path: path, Value: value})
+}
+
+/*accessTrackingMPT - the state MPT recording the access to it, the values
+* are inserted encoded so that the recorded value is what the state got */
+type accessTrackingMPT struct {
+	util.MerklePatriciaTrieI
+	access *StateAccess
+}
+
+func (mpt *accessTrackingMPT) untracked() {
+	mpt.access.untracked = true
+}
+
+func (mpt *accessTrackingMPT) GetNodeValue(path util.Path) (util.Serializable, error) {
+	mpt.access.read(path)
+	return mpt.MerklePatriciaTrieI.GetNodeValue(path)
+}
+
+func (mpt *accessTrackingMPT) Insert(path util.Path, value util.Serializable) (util.Key, error) {
+	data := value.Encode()
+	key, err := mpt.MerklePatriciaTrieI.Insert(path, &util.SecureSerializableValue{Buffer: data})
+	if err == nil {
+		mpt.access.write(path, data)
+	}
+	return key, err
+}
+
+func (mpt *accessTrackingMPT) Delete(path util.Path) (util.Key, error) {
+	// the delete fails if there is no value, it depends on the path
+	mpt.access.read(path)
+	key, err := mpt.MerklePatriciaTrieI.Delete(path)
+	if err == nil {
+		mpt.access.write(path, nil)
+	}
+	return key, err
+}
+
+func (mpt *accessTrackingMPT) SetNodeDB(ndb util.NodeDB) {
+	mpt.untracked()
+	mpt.MerklePatriciaTrieI.SetNodeDB(ndb)
+}
+
+func (mpt *accessTrackingMPT) GetNodeDB() util.NodeDB {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.GetNodeDB()
+}
+
+func (mpt *accessTrackingMPT) SetVersion(version util.Sequence) {
+	mpt.untracked()
+	mpt.MerklePatriciaTrieI.SetVersion(version)
+}
+
+func (mpt *accessTrackingMPT) GetRoot() util.Key {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.GetRoot()
+}
+
+func (mpt *accessTrackingMPT) SetRoot(root util.Key) {
+	mpt.untracked()
+	mpt.MerklePatriciaTrieI.SetRoot(root)
+}
+
+func (mpt *accessTrackingMPT) Iterate(ctx context.Context, handler util.MPTIteratorHandler,
+	visitNodeTypes byte) error {
+
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.Iterate(ctx, handler, visitNodeTypes)
+}
+
+func (mpt *accessTrackingMPT) IterateFrom(ctx context.Context, node util.Key,
+	handler util.MPTIteratorHandler, visitNodeTypes byte) error {
+
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.IterateFrom(ctx, node, handler, visitNodeTypes)
+}
+
+func (mpt *accessTrackingMPT) GetChangeCollector() util.ChangeCollectorI {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.GetChangeCollector()
+}
+
+func (mpt *accessTrackingMPT) ResetChangeCollector(root util.Key) {
+	mpt.untracked()
+	mpt.MerklePatriciaTrieI.ResetChangeCollector(root)
+}
+
+func (mpt *accessTrackingMPT) SaveChanges(ctx context.Context, ndb util.NodeDB,
+	includeDeletes bool) error {
+
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.SaveChanges(ctx, ndb, includeDeletes)
+}
+
+func (mpt *accessTrackingMPT) GetPathNodes(path util.Path) ([]util.Node, error) {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.GetPathNodes(path)
+}
+
+func (mpt *accessTrackingMPT) GetPathProof(path util.Path) (*util.MPTProof, error) {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.GetPathProof(path)
+}
+
+func (mpt *accessTrackingMPT) UpdateVersion(ctx context.Context, version util.Sequence,
+	missingNodeHander util.MPTMissingNodeHandler) error {
+
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.UpdateVersion(ctx, version, missingNodeHander)
+}
+
+func (mpt *accessTrackingMPT) FindMissingNodes(ctx context.Context) ([]util.Path, []util.Key, error) {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.FindMissingNodes(ctx)
+}
+
+func (mpt *accessTrackingMPT) Diff(ctx context.Context, to util.MerklePatriciaTrieI,
+	handler util.MPTDiffHandler) error {
+
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.Diff(ctx, to, handler)
+}
+
+func (mpt *accessTrackingMPT) PrettyPrint(w io.Writer) error {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.PrettyPrint(w)
+}
+
+func (mpt *accessTrackingMPT) Validate() error {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.Validate()
+}
+
+func (mpt *accessTrackingMPT) MergeMPTChanges(mpt2 util.MerklePatriciaTrieI) error {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.MergeMPTChanges(mpt2)
+}
+
+func (mpt *accessTrackingMPT) MergeDB(ndb util.NodeDB, root util.Key) error {
+	mpt.untracked()
+	return mpt.MerklePatriciaTrieI.MergeDB(ndb, root)
+}
@@ -46,6 +46,8 @@ type StateContextI interface {
 	GetTransfers() []*state.Transfer
 	GetSignedTransfers() []*state.SignedTransfer
 	GetMints() []*state.Mint
+	EmitEvent(event *transaction.Event)
+	GetEvents() []*transaction.Event
 	Validate() error
 	GetBlockSharders(b *block.Block) []string
 	GetSignatureScheme() encryption.SignatureScheme
@@ -59,11 +61,16 @@ type StateContext struct {
 	transfers                     []*state.Transfer
 	signedTransfers               []*state.SignedTransfer
 	mints                         []*state.Mint
+	events                        []*transaction.Event
+	call                          *transaction.Transaction
+	callees                       map[datastore.Key]bool
+	outputs                       []string
 	clientStateDeserializer       state.DeserializerI
 	getSharders                   func(*block.Block) []string
 	getLastestFinalizedMagicBlock func() *block.Block
 	getChainCurrentMagicBlock     func() *block.MagicBlock
 	getSignature                  func() encryption.SignatureScheme
+	access                        *StateAccess
 }
 
 // NewStateContext - create a new state context
@@ -104,14 +111,52 @@ func (sc *StateContext) GetState() util.MerklePatriciaTrieI {
 	return sc.state
 }
 
-//GetTransaction - get the transaction associated with this context
+//TrackStateAccess - record the reads and the writes of the state through this context to the access
+func (sc *StateContext) TrackStateAccess(access *StateAccess) {
+	sc.state = &accessTrackingMPT{MerklePatriciaTrieI: sc.state, access: access}
+	sc.access = access
+}
+
+//GetStateAccess - get the recorded access of the state, nil if it's not tracked
+func (sc *StateContext) GetStateAccess() *StateAccess {
+	return sc.access
+}
+
+//GetTransaction - get the transaction associated with this context, the call being executed of a multi-call one
 func (sc *StateContext) GetTransaction() *transaction.Transaction {
+	if sc.call != nil {
+		return sc.call
+	}
 	return sc.txn
 }
 
+/*BeginCall - begin the execution of a call of the multi-call transaction,
+* the smart contract called can transfer its tokens as the one of a smart
+* contract transaction */
+func (sc *StateContext) BeginCall(call *transaction.Transaction) {
+	if sc.callees == nil {
+		sc.callees = make(map[datastore.Key]bool)
+	}
+	sc.callees[call.ToClientID] = true
+	sc.call = call
+}
+
+//EndCall - end the execution of the call with its output
+func (sc *StateContext) EndCall(output string) {
+	sc.call = nil
+	sc.outputs = append(sc.outputs, output)
+}
+
+//GetOutputs - get the outputs of the calls executed
+func (sc *StateContext) GetOutputs() []string {
+	return sc.outputs
+}
+
 //AddTransfer - add the transfer
 func (sc *StateContext) AddTransfer(t *state.Transfer) error {
-	if t.ClientID != sc.txn.ClientID && t.ClientID != sc.txn.ToClientID {
+	txn := sc.GetTransaction()
+	if t.ClientID != txn.ClientID && t.ClientID != txn.ToClientID &&
+		t.ClientID != txn.FeePayerID {
 		return state.ErrInvalidTransfer
 	}
 	sc.transfers = append(sc.transfers, t)
@@ -135,7 +180,7 @@ func (sc *StateContext) AddMint(m *state.Mint) error {
 
 func (sc *StateContext) isApprovedMinter(m *state.Mint) bool {
 	for _, minter := range approvedMinters {
-		if m.Minter == minter && sc.txn.ToClientID == minter {
+		if m.Minter == minter && sc.GetTransaction().ToClientID == minter {
 			return true
 		}
 	}
@@ -157,14 +202,52 @@ func (sc *StateContext) GetMints() []*state.Mint {
 	return sc.mints
 }
 
+//EmitEvent - emit the event of the execution of the transaction to its receipt
+func (sc *StateContext) EmitEvent(event *transaction.Event) {
+	sc.events = append(sc.events, event)
+}
+
+//GetEvents - get all the events emitted
+func (sc *StateContext) GetEvents() []*transaction.Event {
+	return sc.events
+}
+
+/*GetReceipt - get the receipt of the execution of the transaction, the
+* transfers include the signed ones after the others as they are applied */
+func (sc *StateContext) GetReceipt() *transaction.Receipt {
+	receipt := &transaction.Receipt{
+		Status:  transaction.TxnSuccess,
+		Events:  sc.events,
+		Outputs: sc.outputs,
+	}
+	if config.DevConfiguration.IsFeeEnabled {
+		receipt.FeeUsed = sc.txn.Fee
+	}
+	for _, t := range sc.transfers {
+		receipt.Transfers = append(receipt.Transfers, &transaction.ReceiptTransfer{
+			ClientID: t.ClientID, ToClientID: t.ToClientID, Amount: int64(t.Amount)})
+	}
+	for _, st := range sc.signedTransfers {
+		receipt.Transfers = append(receipt.Transfers, &transaction.ReceiptTransfer{
+			ClientID: st.ClientID, ToClientID: st.ToClientID, Amount: int64(st.Amount)})
+	}
+	for _, m := range sc.mints {
+		receipt.Mints = append(receipt.Mints, &transaction.ReceiptMint{
+			Minter: m.Minter, ToClientID: m.ToClientID, Amount: int64(m.Amount)})
+	}
+	return receipt
+}
+
 //Validate - implement interface
 func (sc *StateContext) Validate() error {
-	var amount state.Balance
+	var amount, feeAmount state.Balance
 	for _, transfer := range sc.transfers {
 		if transfer.ClientID == sc.txn.ClientID {
 			amount += transfer.Amount
+		} else if sc.txn.FeePayerID != "" && transfer.ClientID == sc.txn.FeePayerID {
+			feeAmount += transfer.Amount
 		} else {
-			if transfer.ClientID != sc.txn.ToClientID {
+			if transfer.ClientID != sc.txn.ToClientID && !sc.callees[transfer.ClientID] {
 				return state.ErrInvalidTransfer
 			}
 		}
@@ -174,9 +257,14 @@ func (sc *StateContext) Validate() error {
 	}
 	totalValue := state.Balance(sc.txn.Value)
 	if config.DevConfiguration.IsFeeEnabled {
-		totalValue += state.Balance(sc.txn.Fee)
+		// the fee of a sponsored transaction is paid by the fee payer only
+		if sc.txn.FeePayerID == "" {
+			totalValue += state.Balance(sc.txn.Fee)
+		} else if feeAmount > state.Balance(sc.txn.Fee) {
+			return state.ErrInvalidTransfer
+		}
 	}
-	if amount > totalValue {
+	if amount > totalValue || (!config.DevConfiguration.IsFeeEnabled && feeAmount > 0) {
 		return state.ErrInvalidTransfer
 	}
 
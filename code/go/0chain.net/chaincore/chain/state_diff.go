@@ -0,0 +1,188 @@
+package chain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"0chain.net/chaincore/smartcontract"
+	sci "0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/chaincore/state"
+	"0chain.net/core/common"
+	"0chain.net/core/util"
+)
+
+// StateDiffURL - the diagnostics endpoint of the state diff between rounds.
+const StateDiffURL = "/_diagnostics/state_diff"
+
+// DefaultStateDiffLimit - the default max number of changes of a state diff.
+const DefaultStateDiffLimit = 1000
+
+// ClientStateNodeName - the type name of the client state values.
+const ClientStateNodeName = "client_state"
+
+var errStateDiffLimit = errors.New("state diff limit reached")
+
+// StateDiffValue - a decoded value of the state.
+type StateDiffValue struct {
+	SmartContract string      `json:"sc,omitempty"`
+	Type          string      `json:"node_type,omitempty"`
+	Value         interface{} `json:"value"`
+}
+
+// StateDiffChange - a change of a state path between the rounds.
+type StateDiffChange struct {
+	Type string          `json:"type"`
+	Path string          `json:"path"`
+	Old  *StateDiffValue `json:"old,omitempty"`
+	New  *StateDiffValue `json:"new,omitempty"`
+}
+
+// StateDiff - the changes of the state between the finalized rounds.
+type StateDiff struct {
+	FromRound     int64              `json:"from_round"`
+	FromStateHash util.Key           `json:"from_state_hash"`
+	ToRound       int64              `json:"to_round"`
+	ToStateHash   util.Key           `json:"to_state_hash"`
+	Changes       []*StateDiffChange `json:"changes"`
+	Truncated     bool               `json:"truncated,omitempty"`
+}
+
+// GetStateDiff returns up to limit changes of the state between the
+// finalized rounds, the values of the smart contracts nodes are decoded.
+func (c *Chain) GetStateDiff(ctx context.Context, from, to int64, limit int) (
+	*StateDiff, error) {
+
+	fromFS, err := c.getFinalizedState(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toFS, err := c.getFinalizedState(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	c.stateMutex.RLock()
+	defer c.stateMutex.RUnlock()
+
+	var (
+		decoders = stateNodeDecoders()
+		sd       = &StateDiff{
+			FromRound:     fromFS.Round,
+			FromStateHash: fromFS.ClientStateHash,
+			ToRound:       toFS.Round,
+			ToStateHash:   toFS.ClientStateHash,
+			Changes:       make([]*StateDiffChange, 0),
+		}
+	)
+	err = fromFS.State.Diff(ctx, toFS.State,
+		func(ctx context.Context, change *util.StateChange) error {
+			if len(sd.Changes) >= limit {
+				sd.Truncated = true
+				return errStateDiffLimit
+			}
+			sd.Changes = append(sd.Changes, &StateDiffChange{
+				Type: change.Type,
+				Path: string(change.Path),
+				Old:  decodeStateDiffValue(decoders, change.Path, change.Old),
+				New:  decodeStateDiffValue(decoders, change.Path, change.New),
+			})
+			return nil
+		})
+	if err != nil && err != errStateDiffLimit {
+		// the older state is the one that could be pruned
+		if toFS.Round < fromFS.Round {
+			return nil, c.finalizedStateError(toFS, err)
+		}
+		return nil, c.finalizedStateError(fromFS, err)
+	}
+	return sd, nil
+}
+
+type stateNodeDecoder struct {
+	address string
+	decoder sci.StateNodeDecoder
+}
+
+// stateNodeDecoders returns the decoders of the smart contracts in the
+// order of their addresses.
+func stateNodeDecoders() []stateNodeDecoder {
+	var decoders []stateNodeDecoder
+	for address, sc := range smartcontract.ContractMap {
+		if decoder, ok := sc.(sci.StateNodeDecoder); ok {
+			decoders = append(decoders, stateNodeDecoder{address, decoder})
+		}
+	}
+	sort.Slice(decoders, func(i, j int) bool {
+		return decoders[i].address < decoders[j].address
+	})
+	return decoders
+}
+
+// DecodeStateValue - decode the value of the state path by the decoders of
+// the registered smart contracts, the value is returned as is if no decoder
+// knows it.
+func DecodeStateValue(path util.Path, value []byte) *StateDiffValue {
+	return decodeStateDiffValue(stateNodeDecoders(), path, value)
+}
+
+func decodeStateDiffValue(decoders []stateNodeDecoder, path util.Path,
+	value []byte) *StateDiffValue {
+
+	if value == nil {
+		return nil
+	}
+	for _, d := range decoders {
+		if name, node, ok := d.decoder.DecodeStateNode(path, value); ok {
+			return &StateDiffValue{SmartContract: d.address, Type: name, Value: node}
+		}
+	}
+	if util.IsTypedValue(value) {
+		if name, node, err := util.DecodeTypedValue(value); err == nil {
+			return &StateDiffValue{Type: name, Value: node}
+		}
+	}
+	if state.IsEncodedState(value) {
+		var s state.State
+		if err := s.Decode(value); err == nil {
+			s.ComputeProperties()
+			return &StateDiffValue{Type: ClientStateNodeName, Value: &s}
+		}
+	}
+	if json.Valid(value) {
+		return &StateDiffValue{Value: json.RawMessage(value)}
+	}
+	return &StateDiffValue{Value: hex.EncodeToString(value)}
+}
+
+func parseStateDiffRound(r *http.Request, name string) (int64, error) {
+	round, err := strconv.ParseInt(r.FormValue(name), 10, 64)
+	if err != nil || round < 0 {
+		return 0, common.NewError("invalid_round", "invalid "+name+" round parameter")
+	}
+	return round, nil
+}
+
+/*StateDiffHandler - the changes of the state between the 'from' and 'to'
+* finalized rounds, up to the optional 'limit' number of changes */
+func (c *Chain) StateDiffHandler(ctx context.Context, r *http.Request) (interface{}, error) {
+	from, err := parseStateDiffRound(r, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseStateDiffRound(r, "to")
+	if err != nil {
+		return nil, err
+	}
+	limit := DefaultStateDiffLimit
+	if lp := r.FormValue("limit"); lp != "" {
+		if limit, err = strconv.Atoi(lp); err != nil || limit <= 0 {
+			return nil, common.NewError("invalid_limit", "invalid limit parameter")
+		}
+	}
+	return c.GetStateDiff(ctx, from, to, limit)
+}
@@ -0,0 +1,112 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/state"
+	"0chain.net/core/util"
+)
+
+func TestChain_GetStateDiff(t *testing.T) {
+	c := newHistoryTestChain(t, 20)
+
+	sd, err := c.GetStateDiff(context.Background(), 16, 20, DefaultStateDiffLimit)
+	require.NoError(t, err)
+	require.EqualValues(t, 16, sd.FromRound)
+	require.EqualValues(t, 20, sd.ToRound)
+	require.False(t, sd.Truncated)
+	require.Len(t, sd.Changes, 1)
+
+	change := sd.Changes[0]
+	require.Equal(t, util.StateChangeModified, change.Type)
+	require.Equal(t, "aa", change.Path)
+	require.Equal(t, json.RawMessage("16"), change.Old.Value)
+	require.Equal(t, json.RawMessage("20"), change.New.Value)
+
+	sd, err = c.GetStateDiff(context.Background(), 20, 20, DefaultStateDiffLimit)
+	require.NoError(t, err)
+	require.Empty(t, sd.Changes)
+
+	_, err = c.GetStateDiff(context.Background(), 16, 21, DefaultStateDiffLimit)
+	require.Error(t, err)
+}
+
+func TestChain_GetStateDiff_limit(t *testing.T) {
+	c := newHistoryTestChain(t, 20)
+
+	sd, err := c.GetStateDiff(context.Background(), 16, 20, 1)
+	require.NoError(t, err)
+	require.Len(t, sd.Changes, 1)
+	require.False(t, sd.Truncated)
+
+	// add more changes to the latest state
+	mpt := util.NewMerklePatriciaTrie(c.stateDB, 20)
+	mpt.SetRoot(c.LatestFinalizedBlock.ClientStateHash)
+	for _, path := range []string{"ab", "bb", "cc"} {
+		_, err = mpt.Insert(util.Path(path), &testBalance{"1"})
+		require.NoError(t, err)
+	}
+	c.LatestFinalizedBlock.ClientState = mpt
+	c.LatestFinalizedBlock.ClientStateHash = mpt.GetRoot()
+
+	sd, err = c.GetStateDiff(context.Background(), 16, 20, 2)
+	require.NoError(t, err)
+	require.Len(t, sd.Changes, 2)
+	require.True(t, sd.Truncated)
+}
+
+func Test_decodeStateDiffValue(t *testing.T) {
+	s := &state.State{
+		TxnHashBytes: make([]byte, 32),
+		Round:        5,
+		Balance:      100,
+	}
+	value := decodeStateDiffValue(nil, util.Path("aa"), s.Encode())
+	require.Equal(t, ClientStateNodeName, value.Type)
+	require.Equal(t, state.Balance(100), value.Value.(*state.State).Balance)
+	require.EqualValues(t, 5, value.Value.(*state.State).Round)
+
+	value = decodeStateDiffValue(nil, util.Path("aa"), []byte{1, 2, 3})
+	require.Equal(t, "010203", value.Value)
+
+	require.Nil(t, decodeStateDiffValue(nil, util.Path("aa"), nil))
+}
+
+type testSCStateValue struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+func init() {
+	util.RegisterValueType(0xff10, "chain.test_sc_state_value", &testSCStateValue{})
+}
+
+func Test_decodeSCStateValue(t *testing.T) {
+	var v = &testSCStateValue{Name: "node", Count: 10}
+
+	legacy, err := util.EncodeValue(v)
+	require.NoError(t, err)
+	value, err := decodeSCStateValue(legacy)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "node", "count": 10.0}, value)
+
+	util.SetTypedValueEncoding(true)
+	defer util.SetTypedValueEncoding(false)
+
+	typed, err := util.EncodeValue(v)
+	require.NoError(t, err)
+	require.True(t, util.IsTypedValue(typed))
+	value, err = decodeSCStateValue(typed)
+	require.NoError(t, err)
+	require.Equal(t, v, value)
+	out, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.JSONEq(t, string(legacy), string(out))
+
+	_, err = decodeSCStateValue([]byte{util.TypedValueMagic, 0xff, 0, 0})
+	require.Error(t, err)
+}
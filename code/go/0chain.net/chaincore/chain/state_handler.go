@@ -2,15 +2,16 @@ package chain
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"0chain.net/chaincore/smartcontract"
+	"0chain.net/chaincore/state"
 	"0chain.net/chaincore/transaction"
 
 	"0chain.net/core/common"
@@ -26,6 +27,9 @@ func SetupStateHandlers() {
 	http.HandleFunc("/v1/scstats/", common.UserRateLimit(c.GetSCStats))
 	http.HandleFunc("/v1/screst/", common.UserRateLimit(c.HandleSCRest))
 	http.HandleFunc("/_smart_contract_stats", common.UserRateLimit(c.SCStats))
+	http.HandleFunc(StateSnapshotURL, common.UserRateLimit(common.ToJSONResponse(c.GetStateSnapshotHandler)))
+	http.HandleFunc(StateSnapshotChunkURL, common.UserRateLimit(c.GetStateSnapshotChunkHandler))
+	http.HandleFunc(StateDiffURL, common.UserRateLimit(common.ToJSONResponse(c.StateDiffHandler)))
 }
 
 func (c *Chain) HandleSCRest(w http.ResponseWriter, r *http.Request) {
@@ -76,33 +80,103 @@ func (c *Chain) GetSCRestOutput(ctx context.Context, r *http.Request) (interface
 func (c *Chain) GetNodeFromSCState(ctx context.Context, r *http.Request) (interface{}, error) {
 	scAddress := r.FormValue("sc_address")
 	key := r.FormValue("key")
-	lfb := c.GetLatestFinalizedBlock()
-	if lfb == nil {
-		return nil, common.NewError("failed to get sc state", "finalized block doesn't exist")
-	}
-	if lfb.ClientState == nil {
-		return nil, common.NewError("failed to get sc state", "finalized block's state doesn't exist")
+	fs, err := c.getRequestedState(ctx, r)
+	if err != nil {
+		return nil, err
 	}
 	c.stateMutex.RLock()
 	defer c.stateMutex.RUnlock()
-	node, err := lfb.ClientState.GetNodeValue(util.Path(encryption.Hash(scAddress + key)))
+	path := util.Path(encryption.Hash(scAddress + key))
+	if r.FormValue("proof") == "true" {
+		sp, node, err := c.newStateProof(fs, path)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			if sp.Value, err = decodeSCStateValue(node.Encode()); err != nil {
+				return nil, err
+			}
+		}
+		return sp, nil
+	}
+	node, err := fs.State.GetNodeValue(path)
 	if err != nil {
-		return nil, err
+		return nil, c.finalizedStateError(fs, err)
 	}
 	if node == nil {
 		return nil, common.NewError("key_not_found", "key was not found")
 	}
-	var retObj interface{}
-	err = json.Unmarshal(node.Encode(), &retObj)
-	if err != nil {
+	return decodeSCStateValue(node.Encode())
+}
+
+// decodeSCStateValue decodes the typed value to its registered type, or the
+// legacy JSON value
+func decodeSCStateValue(data []byte) (interface{}, error) {
+	if util.IsTypedValue(data) {
+		_, value, err := util.DecodeTypedValue(data)
+		return value, err
+	}
+	var value interface{}
+	if err := util.DecodeValue(data, &value); err != nil {
 		return nil, err
 	}
-	return retObj, nil
+	return value, nil
+}
+
+// getRequestedState returns the state of the finalized block of the round
+// given by the optional 'round' parameter, or the latest finalized state.
+func (c *Chain) getRequestedState(ctx context.Context, r *http.Request) (*finalizedState, error) {
+	roundParam := r.FormValue("round")
+	if roundParam == "" {
+		lfb := c.GetLatestFinalizedBlock()
+		if lfb == nil {
+			return nil, common.NewError("failed to get state", "finalized block doesn't exist")
+		}
+		if lfb.ClientState == nil {
+			return nil, common.NewError("failed to get state", "finalized block's state doesn't exist")
+		}
+		return newFinalizedState(lfb), nil
+	}
+	round, err := strconv.ParseInt(roundParam, 10, 64)
+	if err != nil || round < 0 {
+		return nil, common.NewError("invalid_round", "invalid round parameter")
+	}
+	return c.getFinalizedState(ctx, round)
+}
+
+// StateProof - a value of a finalized state along with the merkle proof of
+// its path, a missing value comes with the proof of its exclusion.
+type StateProof struct {
+	Round           int64          `json:"round"`
+	ClientStateHash util.Key       `json:"state_hash"`
+	Value           interface{}    `json:"value,omitempty"`
+	Proof           *util.MPTProof `json:"proof"`
+}
+
+// newStateProof should be called with the state mutex held.
+func (c *Chain) newStateProof(fs *finalizedState, path util.Path) (*StateProof, util.Serializable, error) {
+	proof, err := fs.State.GetPathProof(path)
+	if err != nil {
+		return nil, nil, c.finalizedStateError(fs, err)
+	}
+	value, err := fs.State.GetNodeValue(path)
+	if err != nil && err != util.ErrValueNotPresent {
+		return nil, nil, c.finalizedStateError(fs, err)
+	}
+	sp := &StateProof{
+		Round:           fs.Round,
+		ClientStateHash: fs.ClientStateHash,
+		Proof:           proof,
+	}
+	return sp, value, nil
 }
 
 /*GetBalanceHandler - get the balance of a client */
 func (c *Chain) GetBalanceHandler(ctx context.Context, r *http.Request) (interface{}, error) {
 	clientID := r.FormValue("client_id")
+	if r.FormValue("round") != "" || r.FormValue("proof") == "true" {
+		return c.getFinalizedBalance(ctx, r, clientID)
+	}
 	lfb := c.GetLatestFinalizedBlock()
 	if lfb == nil {
 		return nil, common.ErrTemporaryFailure
@@ -115,6 +189,37 @@ func (c *Chain) GetBalanceHandler(ctx context.Context, r *http.Request) (interfa
 	return state, nil
 }
 
+// getFinalizedBalance returns the client state of the requested round, along
+// with its merkle proof if asked.
+func (c *Chain) getFinalizedBalance(ctx context.Context, r *http.Request, clientID string) (interface{}, error) {
+	fs, err := c.getRequestedState(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	c.stateMutex.RLock()
+	defer c.stateMutex.RUnlock()
+	path := util.Path(clientID)
+	if r.FormValue("proof") == "true" {
+		sp, ss, err := c.newStateProof(fs, path)
+		if err != nil {
+			return nil, err
+		}
+		if ss != nil {
+			st := c.clientStateDeserializer.Deserialize(ss).(*state.State)
+			st.ComputeProperties()
+			sp.Value = st
+		}
+		return sp, nil
+	}
+	ss, err := fs.State.GetNodeValue(path)
+	if err != nil {
+		return nil, c.finalizedStateError(fs, err)
+	}
+	st := c.clientStateDeserializer.Deserialize(ss).(*state.State)
+	st.ComputeProperties()
+	return st, nil
+}
+
 func (c *Chain) GetSCStats(w http.ResponseWriter, r *http.Request) {
 	scRestRE := regexp.MustCompile(`/v1/scstats/(.*)`)
 	pathParams := scRestRE.FindStringSubmatch(r.URL.Path)
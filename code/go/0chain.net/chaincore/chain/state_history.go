@@ -0,0 +1,119 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/core/common"
+	"0chain.net/core/util"
+)
+
+// The BlockSummaryProvider represents a node able to look up the summary of
+// a finalized block by its round, e.g. a sharder that stores all of them.
+type BlockSummaryProvider interface {
+	GetBlockSummaryByRound(ctx context.Context, round int64) (
+		*block.BlockSummary, error)
+}
+
+// SetBlockSummaryProvider - setter for BlockSummaryProvider
+func (c *Chain) SetBlockSummaryProvider(bsp BlockSummaryProvider) {
+	c.blockSummaryProvider = bsp
+}
+
+// finalizedState is a read only client state of a finalized block.
+type finalizedState struct {
+	Round           int64
+	ClientStateHash util.Key
+	State           util.MerklePatriciaTrieI
+}
+
+func newFinalizedState(b *block.Block) *finalizedState {
+	return &finalizedState{
+		Round:           b.Round,
+		ClientStateHash: b.ClientStateHash,
+		State:           b.ClientState,
+	}
+}
+
+// getFinalizedBlockSummary returns summary of the finalized block of the given
+// round looking at recently finalized blocks first.
+func (c *Chain) getFinalizedBlockSummary(ctx context.Context, round int64) (
+	*block.BlockSummary, error) {
+
+	var lfb = c.GetLatestFinalizedBlock()
+	if lfb == nil {
+		return nil, common.ErrTemporaryFailure
+	}
+	if round > lfb.Round {
+		return nil, common.NewError("round_not_finalized",
+			fmt.Sprintf("round %d is not finalized yet, latest finalized round"+
+				" is %d", round, lfb.Round))
+	}
+	if round == lfb.Round {
+		return lfb.GetSummary(), nil
+	}
+
+	if bc := c.BlockChain.Prev(); bc.Value != nil {
+		var last = bc.Value.(*block.BlockSummary)
+		if diff := last.Round - round; diff >= 0 && diff < int64(bc.Len()) {
+			if bs, ok := bc.Move(-int(diff)).Value.(*block.BlockSummary); ok &&
+				bs.Round == round {
+				return bs, nil
+			}
+		}
+	}
+
+	if c.blockSummaryProvider == nil {
+		return nil, common.NewError("block_summary_not_found",
+			fmt.Sprintf("summary of the block of round %d is not available",
+				round))
+	}
+	return c.blockSummaryProvider.GetBlockSummaryByRound(ctx, round)
+}
+
+// getFinalizedState returns client state of the finalized block of given round.
+// The state is opened against the state db and it's available as long as it's
+// not pruned (see PruneStateBelowCount and ArchiveState).
+func (c *Chain) getFinalizedState(ctx context.Context, round int64) (
+	*finalizedState, error) {
+
+	if lfb := c.GetLatestFinalizedBlock(); lfb != nil &&
+		lfb.Round == round && lfb.ClientState != nil {
+		return newFinalizedState(lfb), nil
+	}
+	bs, err := c.getFinalizedBlockSummary(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	var fs = &finalizedState{
+		Round:           bs.Round,
+		ClientStateHash: bs.ClientStateHash,
+	}
+	if _, err = c.stateDB.GetNode(bs.ClientStateHash); err != nil {
+		return nil, c.finalizedStateError(fs, err)
+	}
+	var mpt = util.NewMerklePatriciaTrie(c.stateDB, util.Sequence(bs.Round))
+	mpt.SetRoot(bs.ClientStateHash)
+	fs.State = mpt
+	return fs, nil
+}
+
+// finalizedStateError converts missing node errors of a historical state to
+// a clear error of pruned state.
+func (c *Chain) finalizedStateError(fs *finalizedState, err error) error {
+	if err != util.ErrNodeNotFound {
+		return err
+	}
+	var lfb = c.GetLatestFinalizedBlock()
+	if lfb != nil && fs.Round >= lfb.Round-int64(c.PruneStateBelowCount) {
+		return err // recent state that is never pruned
+	}
+	if c.ArchiveState {
+		return common.NewError("state_not_found",
+			fmt.Sprintf("state of round %d is missing in state db", fs.Round))
+	}
+	return common.NewError("state_pruned",
+		fmt.Sprintf("state of round %d is pruned, only the state of the last"+
+			" %d rounds is kept", fs.Round, c.PruneStateBelowCount))
+}
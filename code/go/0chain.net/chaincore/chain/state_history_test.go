@@ -0,0 +1,120 @@
+package chain
+
+import (
+	"container/ring"
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/core/common"
+	"0chain.net/core/util"
+)
+
+type testBalance struct {
+	value string
+}
+
+func (tb *testBalance) Encode() []byte {
+	return []byte(tb.value)
+}
+
+func (tb *testBalance) Decode(buf []byte) error {
+	tb.value = string(buf)
+	return nil
+}
+
+// keepNodesDB keeps the nodes of all the versions as a state db does before
+// pruning.
+type keepNodesDB struct {
+	*util.MemoryNodeDB
+}
+
+func (kndb keepNodesDB) DeleteNode(key util.Key) error {
+	return nil
+}
+
+type testBlockSummaryProvider map[int64]*block.BlockSummary
+
+func (p testBlockSummaryProvider) GetBlockSummaryByRound(ctx context.Context,
+	round int64) (*block.BlockSummary, error) {
+
+	if bs, ok := p[round]; ok {
+		return bs, nil
+	}
+	return nil, common.NewError("not_found", "no block summary")
+}
+
+// newHistoryTestChain creates a chain that finalized rounds 1..lfbRound, the
+// client "aa" has the round number as its value in the state of each round.
+func newHistoryTestChain(t *testing.T, lfbRound int64) *Chain {
+	var (
+		ndb = keepNodesDB{util.NewMemoryNodeDB()}
+		c   = &Chain{
+			Config:     &Config{PruneStateBelowCount: 10},
+			stateDB:    ndb,
+			BlockChain: ring.New(5),
+			stateMutex: &sync.RWMutex{},
+		}
+		mpt = util.NewMerklePatriciaTrie(ndb, 0)
+		lfb *block.Block
+	)
+	for r := int64(1); r <= lfbRound; r++ {
+		mpt.SetVersion(util.Sequence(r))
+		_, err := mpt.Insert(util.Path("aa"),
+			&testBalance{strconv.FormatInt(r, 10)})
+		require.NoError(t, err)
+
+		lfb = block.NewBlock("", r)
+		lfb.ClientState = util.CloneMPT(mpt)
+		lfb.ClientStateHash = mpt.GetRoot()
+		c.BlockChain.Value = &block.BlockSummary{
+			Round:           r,
+			ClientStateHash: mpt.GetRoot(),
+		}
+		c.BlockChain = c.BlockChain.Next()
+	}
+	c.LatestFinalizedBlock = lfb
+	return c
+}
+
+func TestChain_getFinalizedState(t *testing.T) {
+	c := newHistoryTestChain(t, 20)
+
+	for _, round := range []int64{20, 19, 16} {
+		fs, err := c.getFinalizedState(context.Background(), round)
+		require.NoError(t, err)
+		require.Equal(t, round, fs.Round)
+		v, err := fs.State.GetNodeValue(util.Path("aa"))
+		require.NoError(t, err)
+		require.Equal(t, strconv.FormatInt(round, 10), string(v.Encode()))
+	}
+
+	_, err := c.getFinalizedState(context.Background(), 21)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "round_not_finalized")
+
+	// out of the recent finalized blocks and no provider
+	_, err = c.getFinalizedState(context.Background(), 5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "block_summary_not_found")
+}
+
+func TestChain_getFinalizedState_pruned(t *testing.T) {
+	c := newHistoryTestChain(t, 20)
+	c.SetBlockSummaryProvider(testBlockSummaryProvider{
+		5: {Round: 5, ClientStateHash: util.Key("pruned state root")},
+	})
+
+	_, err := c.getFinalizedState(context.Background(), 5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "state_pruned")
+
+	c.ArchiveState = true
+	_, err = c.getFinalizedState(context.Background(), 5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "state_not_found")
+}
@@ -0,0 +1,271 @@
+package chain
+
+import (
+	"context"
+	"sync"
+
+	"0chain.net/chaincore/block"
+	bcstate "0chain.net/chaincore/chain/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+	"0chain.net/core/util"
+	"0chain.net/smartcontract/minersc"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// DefaultStateParallelBatch - the default number of transactions executed in
+// parallel at once.
+const DefaultStateParallelBatch = 64
+
+var (
+	// ParallelTxnsCommitted - the transactions committed as executed in parallel
+	ParallelTxnsCommitted = metrics.GetOrRegisterCounter("parallel_txns_committed", nil)
+	// ParallelTxnsReexecuted - the transactions executed again in order due to a conflict
+	ParallelTxnsReexecuted = metrics.GetOrRegisterCounter("parallel_txns_reexecuted", nil)
+)
+
+// txnExecution - an execution of a transaction on a state snapshot.
+type txnExecution struct {
+	txn     *transaction.Transaction
+	state   util.MerklePatriciaTrieI
+	access  *bcstate.StateAccess
+	output  string
+	receipt *transaction.Receipt
+	err     error
+}
+
+/*TxnExecutor - executes the transactions of a block optimistically in
+* parallel. The transactions of a batch are executed concurrently, each on its
+* own overlay of the block state as of the beginning of the batch, recording
+* the paths of the state it reads and writes. Then they are committed in the
+* block order: the writes of a transaction are replayed on the block state if
+* it read none of the paths written since the beginning of the batch, else it
+* is executed again on the current state. The resulting state is the same as
+* of executing the transactions one by one.
+*
+* The miner SC transactions are always executed in order since they can
+* change the magic block of the block. With the fees enabled all the
+* transactions write the balance of the miner SC and so conflict. */
+type TxnExecutor struct {
+	c          *Chain
+	b          *block.Block
+	parallel   bool
+	workers    int
+	batchSize  int
+	executions map[string]*txnExecution
+	written    map[string]struct{} // the paths written since the beginning of the batch
+	untracked  bool                // unknown paths written since the beginning of the batch
+}
+
+// NewTxnExecutor - create a new executor of the transactions of the block
+func (c *Chain) NewTxnExecutor(b *block.Block) *TxnExecutor {
+	te := &TxnExecutor{
+		c:          c,
+		b:          b,
+		parallel:   c.StateParallelTxns && c.StateParallelWorkers > 0,
+		workers:    c.StateParallelWorkers,
+		batchSize:  c.StateParallelBatch,
+		executions: make(map[string]*txnExecution),
+		written:    make(map[string]struct{}),
+	}
+	if te.batchSize <= 0 {
+		te.batchSize = DefaultStateParallelBatch
+	}
+	return te
+}
+
+// IsParallel - whether the transactions are executed in parallel
+func (te *TxnExecutor) IsParallel() bool {
+	return te.parallel
+}
+
+// BatchSize - the number of the transactions to execute in parallel at once
+func (te *TxnExecutor) BatchSize() int {
+	return te.batchSize
+}
+
+func isParallelTxn(txn *transaction.Transaction) bool {
+	if txn.TransactionType == transaction.TxnTypeMultiCall {
+		calls, err := txn.GetCalls()
+		if err != nil {
+			return true // fails the same way in order
+		}
+		for _, call := range calls {
+			if call.Address == minersc.ADDRESS {
+				return false
+			}
+		}
+		return true
+	}
+	return txn.TransactionType != transaction.TxnTypeSmartContract ||
+		txn.ToClientID != minersc.ADDRESS
+}
+
+/*Execute - execute the transactions in parallel on the current state of the
+* block, the transactions are not committed to the state until UpdateState is
+* called for each of them. The executions of a previous batch are dropped. */
+func (te *TxnExecutor) Execute(ctx context.Context, txns []*transaction.Transaction) {
+	te.executions = make(map[string]*txnExecution, len(txns))
+	te.written = make(map[string]struct{})
+	te.untracked = false
+	if !te.parallel {
+		return
+	}
+
+	te.c.stateMutex.RLock()
+	defer te.c.stateMutex.RUnlock()
+
+	clientState := te.b.ClientState
+	if _, err := clientState.GetNodeDB().GetNode(clientState.GetRoot()); err != nil {
+		return // reported by the execution in order
+	}
+	var executions []*txnExecution
+	for _, txn := range txns {
+		if _, ok := te.executions[txn.Hash]; ok || !isParallelTxn(txn) {
+			continue
+		}
+		exec := &txnExecution{txn: txn, state: CreateTxnMPT(clientState)}
+		te.executions[txn.Hash] = exec
+		executions = append(executions, exec)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan *txnExecution)
+	)
+	for i := 0; i < te.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for exec := range jobs {
+				te.c.executeTxn(ctx, te.b, exec)
+			}
+		}()
+	}
+	for _, exec := range executions {
+		jobs <- exec
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+/*UpdateState - update the state of the block by the transaction, the same as
+* Chain.UpdateState. The transactions executed by Execute must be updated in
+* the order of the block. */
+func (te *TxnExecutor) UpdateState(ctx context.Context, txn *transaction.Transaction) error {
+	if !te.parallel {
+		return te.c.UpdateState(ctx, te.b, txn)
+	}
+	exec, ok := te.executions[txn.Hash]
+	if ok {
+		delete(te.executions, txn.Hash)
+	}
+
+	te.c.stateMutex.Lock()
+	defer te.c.stateMutex.Unlock()
+
+	// the failed executions are repeated since a failure can be due to the
+	// execution context (the timeout of the smart contract)
+	if !ok || exec.txn != txn || exec.err != nil || te.conflicts(exec.access) {
+		if ok {
+			ParallelTxnsReexecuted.Inc(1)
+		}
+		access := bcstate.NewStateAccess()
+		if err := te.c.updateTxnState(ctx, te.b, txn, access); err != nil {
+			return err
+		}
+		te.commitAccess(access)
+		return nil
+	}
+	if err := te.c.commitTxnExecution(te.b, exec); err != nil {
+		return err
+	}
+	ParallelTxnsCommitted.Inc(1)
+	te.commitAccess(exec.access)
+	return nil
+}
+
+func (te *TxnExecutor) conflicts(access *bcstate.StateAccess) bool {
+	if te.untracked || access.IsUntracked() {
+		return true
+	}
+	for path := range te.written {
+		if access.Reads(util.Path(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (te *TxnExecutor) commitAccess(access *bcstate.StateAccess) {
+	if access.IsUntracked() {
+		te.untracked = true
+		return
+	}
+	for _, w := range access.GetWrites() {
+		te.written[string(w.Path)] = struct{}{}
+	}
+}
+
+/*UpdateStates - update the state of the block by the transactions in their
+* order, stops at the first transaction failed. The transactions are executed
+* in parallel if it's configured, see TxnExecutor. */
+func (c *Chain) UpdateStates(ctx context.Context, b *block.Block,
+	txns []*transaction.Transaction) error {
+
+	te := c.NewTxnExecutor(b)
+	for len(txns) > 0 {
+		n := te.BatchSize()
+		if n > len(txns) {
+			n = len(txns)
+		}
+		te.Execute(ctx, txns[:n])
+		for _, txn := range txns[:n] {
+			if err := te.UpdateState(ctx, txn); err != nil {
+				return common.NewErrorf("update_state_failed", "txn %v: %v",
+					txn.Hash, err)
+			}
+		}
+		txns = txns[n:]
+	}
+	return nil
+}
+
+// executeTxn executes the transaction on its state snapshot recording the
+// access of the state.
+func (c *Chain) executeTxn(ctx context.Context, b *block.Block, exec *txnExecution) {
+	sctx := c.NewStateContext(b, exec.state, exec.txn)
+	exec.access = bcstate.NewStateAccess()
+	sctx.TrackStateAccess(exec.access)
+	exec.output, exec.err = c.applyTxn(ctx, sctx)
+	if exec.err == nil {
+		exec.receipt = sctx.GetReceipt()
+	}
+}
+
+// commitTxnExecution replays the writes of the execution of the transaction
+// on the state of the block.
+func (c *Chain) commitTxnExecution(b *block.Block, exec *txnExecution) (err error) {
+	var (
+		clientState = CreateTxnMPT(b.ClientState) // begin transaction
+		startRoot   = clientState.GetRoot()
+	)
+	for _, w := range exec.access.GetWrites() {
+		if w.Value == nil {
+			_, err = clientState.Delete(w.Path)
+		} else {
+			_, err = clientState.Insert(w.Path, &util.SecureSerializableValue{Buffer: w.Value})
+		}
+		if err != nil {
+			return
+		}
+	}
+	if err = b.ClientState.MergeMPTChanges(clientState); err != nil {
+		return
+	}
+	c.debugUpdatedState(b, exec.txn, startRoot)
+	exec.txn.TransactionOutput = exec.output
+	exec.txn.Status = transaction.TxnSuccess
+	exec.txn.Receipt = exec.receipt
+	return
+}
@@ -0,0 +1,583 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/block"
+	bcstate "0chain.net/chaincore/chain/state"
+	"0chain.net/chaincore/config"
+	"0chain.net/chaincore/smartcontract"
+	sci "0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/encryption"
+	"0chain.net/core/util"
+	"0chain.net/smartcontract/minersc"
+)
+
+var parallelTestSCAddress = encryption.Hash("parallel_test_sc")
+
+// parallelTestSC - a smart contract of counters, the txns of a small number
+// of the counters conflict.
+type parallelTestSC struct{}
+
+type parallelTestInput struct {
+	Key   string `json:"key"`
+	To    string `json:"to,omitempty"`
+	Value int    `json:"value"`
+}
+
+func (sc *parallelTestSC) get(balances bcstate.StateContextI, key string) (int, error) {
+	val, err := balances.GetTrieNode(key)
+	if err == util.ErrValueNotPresent {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(val.Encode()))
+}
+
+func (sc *parallelTestSC) set(balances bcstate.StateContextI, key string, value int) error {
+	_, err := balances.InsertTrieNode(key,
+		&util.SecureSerializableValue{Buffer: []byte(strconv.Itoa(value))})
+	return err
+}
+
+func (sc *parallelTestSC) Execute(t *transaction.Transaction, funcName string,
+	input []byte, balances bcstate.StateContextI) (string, error) {
+
+	var in parallelTestInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", err
+	}
+	from, err := sc.get(balances, in.Key)
+	if err != nil {
+		return "", err
+	}
+	switch funcName {
+	case "add":
+		from += in.Value
+	case "move":
+		if from < in.Value {
+			return "", errors.New("insufficient counter")
+		}
+		to, err := sc.get(balances, in.To)
+		if err != nil {
+			return "", err
+		}
+		from -= in.Value
+		if err := sc.set(balances, in.To, to+in.Value); err != nil {
+			return "", err
+		}
+		balances.EmitEvent(transaction.NewEvent("move",
+			transaction.StringAttribute("from", in.Key),
+			transaction.StringAttribute("to", in.To),
+			transaction.IntAttribute("value", int64(in.Value))))
+	case "delete":
+		if _, err := balances.DeleteTrieNode(in.Key); err != nil {
+			return "", err
+		}
+		return "deleted " + in.Key, nil
+	case "pay":
+		err := balances.AddTransfer(state.NewTransfer(t.ClientID, t.ToClientID,
+			state.Balance(t.Value)))
+		if err != nil {
+			return "", err
+		}
+		from += int(t.Value)
+	default:
+		return "", errors.New("unknown function")
+	}
+	if err := sc.set(balances, in.Key, from); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s=%d", in.Key, from), nil
+}
+
+func (sc *parallelTestSC) GetRestPoints() map[string]sci.SmartContractRestHandler {
+	return nil
+}
+
+func (sc *parallelTestSC) GetHandlerStats(ctx context.Context, params url.Values) (interface{}, error) {
+	return nil, nil
+}
+
+func (sc *parallelTestSC) GetExecutionStats() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (sc *parallelTestSC) GetName() string    { return "parallel_test" }
+func (sc *parallelTestSC) GetAddress() string { return parallelTestSCAddress }
+
+func newParallelTestChain(parallel bool) *Chain {
+	return &Chain{
+		Config: &Config{
+			SmartContractTimeout: time.Second,
+			StateParallelTxns:    parallel,
+			StateParallelWorkers: 4,
+			StateParallelBatch:   16,
+		},
+		clientStateDeserializer: &state.Deserializer{},
+		stateMutex:              &sync.RWMutex{},
+	}
+}
+
+const (
+	parallelTestRound   = 10
+	parallelTestClients = 12
+	parallelTestKeys    = 8
+)
+
+func parallelTestClient(i int) string {
+	return encryption.Hash(fmt.Sprintf("client-%d", i))
+}
+
+// newParallelTestState returns the db and the root of the initial state of
+// the clients and the counters.
+func newParallelTestState(t *testing.T) (util.NodeDB, util.Key) {
+	var (
+		ndb = util.NewMemoryNodeDB()
+		mpt = util.NewMerklePatriciaTrie(ndb, parallelTestRound-1)
+	)
+	for i := 0; i < parallelTestClients; i++ {
+		_, err := mpt.Insert(util.Path(parallelTestClient(i)), &state.State{
+			TxnHashBytes: make([]byte, 32),
+			Balance:      1000,
+		})
+		require.NoError(t, err)
+	}
+	sctx := bcstate.NewStateContext(block.NewBlock("", parallelTestRound-1), mpt,
+		&state.Deserializer{}, nil, nil, nil, nil, nil)
+	for i := 0; i < parallelTestKeys; i += 2 {
+		_, err := sctx.InsertTrieNode(fmt.Sprintf("key-%d", i),
+			&util.SecureSerializableValue{Buffer: []byte("50")})
+		require.NoError(t, err)
+	}
+	return ndb, mpt.GetRoot()
+}
+
+func newParallelTestBlock(ndb util.NodeDB, root util.Key) *block.Block {
+	b := block.NewBlock("", parallelTestRound)
+	b.ClientState = util.NewMerklePatriciaTrie(
+		util.NewLevelNodeDB(util.NewMemoryNodeDB(), ndb, false), parallelTestRound)
+	b.ClientState.SetRoot(root)
+	return b
+}
+
+// newParallelTestTxns returns the random txns of the seed, the txns of the
+// same clients and counters conflict, some of them fail.
+func newParallelTestTxns(seed int64, n int) []*transaction.Transaction {
+	var (
+		r      = rand.New(rand.NewSource(seed))
+		nonces = make(map[string]int64)
+		txns   = make([]*transaction.Transaction, 0, n)
+		key    = func() string { return fmt.Sprintf("key-%d", r.Intn(parallelTestKeys)) }
+	)
+	for i := 0; i < n; i++ {
+		txn := &transaction.Transaction{
+			ClientID: parallelTestClient(r.Intn(parallelTestClients)),
+		}
+		txn.Hash = encryption.Hash(fmt.Sprintf("txn-%d-%d", seed, i))
+		if r.Intn(4) == 0 {
+			nonces[txn.ClientID]++
+			txn.Nonce = nonces[txn.ClientID]
+			if r.Intn(10) == 0 {
+				txn.Nonce++ // invalid
+			}
+		}
+		switch r.Intn(8) {
+		case 0, 1, 2:
+			txn.TransactionType = transaction.TxnTypeSend
+			txn.ToClientID = parallelTestClient(r.Intn(parallelTestClients))
+			txn.Value = int64(r.Intn(400))
+		case 3:
+			txn.TransactionType = transaction.TxnTypeData
+		default:
+			var (
+				name  = []string{"add", "move", "delete", "pay"}[r.Intn(4)]
+				input = parallelTestInput{Key: key(), To: key(), Value: r.Intn(40)}
+			)
+			if name == "pay" {
+				txn.Value = int64(r.Intn(100))
+			}
+			data, _ := json.Marshal(input)
+			scData, _ := json.Marshal(sci.SmartContractTransactionData{
+				FunctionName: name,
+				InputData:    data,
+			})
+			txn.TransactionType = transaction.TxnTypeSmartContract
+			txn.ToClientID = parallelTestSCAddress
+			txn.TransactionData = string(scData)
+		}
+		txns = append(txns, txn)
+	}
+	return txns
+}
+
+func copyParallelTestTxns(txns []*transaction.Transaction) []*transaction.Transaction {
+	cp := make([]*transaction.Transaction, 0, len(txns))
+	for _, txn := range txns {
+		ctxn := *txn
+		cp = append(cp, &ctxn)
+	}
+	return cp
+}
+
+type parallelTestResult struct {
+	root     util.Key
+	failed   []bool
+	outputs  []string
+	receipts []string
+}
+
+func (res *parallelTestResult) add(txn *transaction.Transaction, err error) {
+	res.failed = append(res.failed, err != nil)
+	res.outputs = append(res.outputs, txn.TransactionOutput)
+	res.receipts = append(res.receipts, transaction.NewTransactionReceipt(txn).GetHash())
+}
+
+func runSequentialTxns(t *testing.T, ndb util.NodeDB, root util.Key,
+	txns []*transaction.Transaction) *parallelTestResult {
+
+	var (
+		c   = newParallelTestChain(false)
+		b   = newParallelTestBlock(ndb, root)
+		res = &parallelTestResult{}
+	)
+	for _, txn := range txns {
+		res.add(txn, c.UpdateState(context.Background(), b, txn))
+	}
+	res.root = b.ClientState.GetRoot()
+	require.NoError(t, b.ClientState.Validate())
+	return res
+}
+
+// runParallelTxns processes the txns in batches the way a block is generated.
+func runParallelTxns(t *testing.T, ndb util.NodeDB, root util.Key,
+	txns []*transaction.Transaction) *parallelTestResult {
+
+	var (
+		c   = newParallelTestChain(true)
+		b   = newParallelTestBlock(ndb, root)
+		te  = c.NewTxnExecutor(b)
+		ctx = context.Background()
+		res = &parallelTestResult{}
+	)
+	require.True(t, te.IsParallel())
+	for start := 0; start < len(txns); start += te.BatchSize() {
+		end := start + te.BatchSize()
+		if end > len(txns) {
+			end = len(txns)
+		}
+		te.Execute(ctx, txns[start:end])
+		for _, txn := range txns[start:end] {
+			res.add(txn, te.UpdateState(ctx, txn))
+		}
+	}
+	res.root = b.ClientState.GetRoot()
+	require.NoError(t, b.ClientState.Validate())
+	return res
+}
+
+func TestTxnExecutor_Determinism(t *testing.T) {
+	smartcontract.ContractMap[parallelTestSCAddress] = &parallelTestSC{}
+	defer delete(smartcontract.ContractMap, parallelTestSCAddress)
+
+	ndb, root := newParallelTestState(t)
+	for _, fees := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fees=%v", fees), func(t *testing.T) {
+			defer func(prev bool) { config.DevConfiguration.IsFeeEnabled = prev }(
+				config.DevConfiguration.IsFeeEnabled)
+			config.DevConfiguration.IsFeeEnabled = fees
+
+			committed := ParallelTxnsCommitted.Count()
+			for seed := int64(1); seed <= 20; seed++ {
+				txns := newParallelTestTxns(seed, 200)
+				if fees {
+					for _, txn := range txns {
+						txn.Fee = 1
+					}
+				}
+				var (
+					seq = runSequentialTxns(t, ndb, root, copyParallelTestTxns(txns))
+					par = runParallelTxns(t, ndb, root, copyParallelTestTxns(txns))
+				)
+				require.Equal(t, seq.failed, par.failed, "seed %d", seed)
+				require.Equal(t, seq.outputs, par.outputs, "seed %d", seed)
+				require.Equal(t, seq.receipts, par.receipts, "seed %d", seed)
+				require.Equal(t, util.ToHex(seq.root), util.ToHex(par.root), "seed %d", seed)
+				require.Contains(t, seq.failed, true)
+
+				// the successful txns as verified by the block state computation
+				var included []*transaction.Transaction
+				for i, txn := range copyParallelTestTxns(txns) {
+					if !seq.failed[i] {
+						included = append(included, txn)
+					}
+				}
+				b := newParallelTestBlock(ndb, root)
+				require.NoError(t, newParallelTestChain(true).UpdateStates(
+					context.Background(), b, included))
+				require.Equal(t, util.ToHex(seq.root), util.ToHex(b.ClientState.GetRoot()))
+			}
+			if !fees {
+				require.True(t, ParallelTxnsCommitted.Count() > committed)
+			}
+		})
+	}
+}
+
+func TestTxnExecutor_MinerSC(t *testing.T) {
+	var (
+		ndb, root = newParallelTestState(t)
+		c         = newParallelTestChain(true)
+		b         = newParallelTestBlock(ndb, root)
+		te        = c.NewTxnExecutor(b)
+		txn       = &transaction.Transaction{
+			TransactionType: transaction.TxnTypeSmartContract,
+			ToClientID:      minersc.ADDRESS,
+		}
+	)
+	txn.Hash = encryption.Hash("miner sc txn")
+	te.Execute(context.Background(), []*transaction.Transaction{txn})
+	require.Empty(t, te.executions)
+}
+
+func TestStateContext_TrackStateAccess(t *testing.T) {
+	ndb, root := newParallelTestState(t)
+	var (
+		b      = newParallelTestBlock(ndb, root)
+		txn    = &transaction.Transaction{ClientID: parallelTestClient(0)}
+		c      = newParallelTestChain(false)
+		sctx   = c.NewStateContext(b, CreateTxnMPT(b.ClientState), txn)
+		access = bcstate.NewStateAccess()
+	)
+	txn.Hash = encryption.Hash("txn")
+	sctx.TrackStateAccess(access)
+	require.Equal(t, access, sctx.GetStateAccess())
+
+	require.NoError(t, c.transferAmount(sctx, parallelTestClient(0), parallelTestClient(1), 10))
+	_, err := sctx.DeleteTrieNode("key-0")
+	require.NoError(t, err)
+	_, err = sctx.DeleteTrieNode("key-1")
+	require.Error(t, err)
+
+	for _, path := range []util.Path{
+		util.Path(parallelTestClient(0)),
+		util.Path(parallelTestClient(1)),
+		util.Path(encryption.Hash("key-0")),
+		util.Path(encryption.Hash("key-1")),
+	} {
+		require.True(t, access.Reads(path))
+	}
+	require.False(t, access.Reads(util.Path(parallelTestClient(2))))
+	writes := access.GetWrites()
+	require.Len(t, writes, 3)
+	require.Equal(t, util.Path(parallelTestClient(0)), writes[0].Path)
+	require.Equal(t, util.Path(parallelTestClient(1)), writes[1].Path)
+	require.NotNil(t, writes[1].Value)
+	require.Equal(t, util.Path(encryption.Hash("key-0")), writes[2].Path)
+	require.Nil(t, writes[2].Value)
+
+	require.False(t, access.IsUntracked())
+	sctx.GetState().GetRoot()
+	require.True(t, access.IsUntracked())
+	require.True(t, access.Reads(util.Path(parallelTestClient(2))))
+}
+
+func TestChain_UpdateState_Receipt(t *testing.T) {
+	smartcontract.ContractMap[parallelTestSCAddress] = &parallelTestSC{}
+	defer delete(smartcontract.ContractMap, parallelTestSCAddress)
+	defer func(prev bool) { config.DevConfiguration.IsFeeEnabled = prev }(
+		config.DevConfiguration.IsFeeEnabled)
+	config.DevConfiguration.IsFeeEnabled = true
+
+	var (
+		ndb, root = newParallelTestState(t)
+		c         = newParallelTestChain(false)
+		b         = newParallelTestBlock(ndb, root)
+		from      = parallelTestClient(0)
+	)
+	newSCTxn := func(name string, input parallelTestInput, value int64) *transaction.Transaction {
+		data, _ := json.Marshal(input)
+		scData, _ := json.Marshal(sci.SmartContractTransactionData{
+			FunctionName: name,
+			InputData:    data,
+		})
+		txn := &transaction.Transaction{
+			ClientID:        from,
+			ToClientID:      parallelTestSCAddress,
+			Value:           value,
+			Fee:             3,
+			TransactionType: transaction.TxnTypeSmartContract,
+			TransactionData: string(scData),
+		}
+		txn.Hash = encryption.Hash(txn.TransactionData)
+		return txn
+	}
+
+	pay := newSCTxn("pay", parallelTestInput{Key: "key-0"}, 10)
+	require.NoError(t, c.UpdateState(context.Background(), b, pay))
+	require.NotNil(t, pay.Receipt)
+	require.Equal(t, transaction.TxnSuccess, pay.Receipt.Status)
+	require.EqualValues(t, 3, pay.Receipt.FeeUsed)
+	require.Equal(t, []*transaction.ReceiptTransfer{
+		{ClientID: from, ToClientID: parallelTestSCAddress, Amount: 10},
+		{ClientID: from, ToClientID: minersc.ADDRESS, Amount: 3},
+	}, pay.Receipt.Transfers)
+	require.Empty(t, pay.Receipt.Events)
+
+	move := newSCTxn("move", parallelTestInput{Key: "key-0", To: "key-1", Value: 5}, 0)
+	require.NoError(t, c.UpdateState(context.Background(), b, move))
+	require.Equal(t, []*transaction.Event{{
+		Name: "move",
+		Attributes: []*transaction.EventAttribute{
+			{Key: "from", Type: transaction.EventAttributeString, Value: "key-0"},
+			{Key: "to", Type: transaction.EventAttributeString, Value: "key-1"},
+			{Key: "value", Type: transaction.EventAttributeInt, Value: "5"},
+		},
+	}}, move.Receipt.Events)
+
+	// the receipts are a part of the block hash
+	b.Txns = []*transaction.Transaction{pay, move}
+	for _, txn := range b.Txns {
+		b.AddTransaction(txn)
+	}
+	hash := b.ComputeHash()
+	move.Receipt.Events[0].Attributes[2].Value = "6"
+	require.NotEqual(t, hash, b.ComputeHash())
+}
+
+func TestChain_UpdateState_FeePayer(t *testing.T) {
+	defer func(prev bool) { config.DevConfiguration.IsFeeEnabled = prev }(
+		config.DevConfiguration.IsFeeEnabled)
+	config.DevConfiguration.IsFeeEnabled = true
+
+	var (
+		ndb, root = newParallelTestState(t)
+		c         = newParallelTestChain(false)
+		b         = newParallelTestBlock(ndb, root)
+		from      = parallelTestClient(0)
+		to        = parallelTestClient(1)
+		sponsor   = parallelTestClient(2)
+	)
+	balance := func(clientID string) state.Balance {
+		s, err := c.getState(b.ClientState, clientID)
+		require.NoError(t, err)
+		return s.Balance
+	}
+
+	txn := &transaction.Transaction{
+		ClientID:        from,
+		ToClientID:      to,
+		Value:           1000 - 30,
+		Fee:             30,
+		FeePayerID:      sponsor,
+		TransactionType: transaction.TxnTypeSend,
+	}
+	txn.Hash = encryption.Hash("sponsored")
+	require.NoError(t, c.UpdateState(context.Background(), b, txn))
+	require.EqualValues(t, 30, balance(from))
+	require.EqualValues(t, 1970, balance(to))
+	require.EqualValues(t, 970, balance(sponsor))
+	require.Equal(t, []*transaction.ReceiptTransfer{
+		{ClientID: from, ToClientID: to, Amount: 970},
+		{ClientID: sponsor, ToClientID: minersc.ADDRESS, Amount: 30},
+	}, txn.Receipt.Transfers)
+
+	// the fee payer can't afford the fee
+	txn = &transaction.Transaction{
+		ClientID:        to,
+		ToClientID:      from,
+		Value:           10,
+		Fee:             971,
+		FeePayerID:      sponsor,
+		TransactionType: transaction.TxnTypeSend,
+	}
+	txn.Hash = encryption.Hash("unaffordable")
+	require.Error(t, c.UpdateState(context.Background(), b, txn))
+	require.EqualValues(t, 1970, balance(to))
+	require.EqualValues(t, 970, balance(sponsor))
+}
+
+func TestChain_UpdateState_MultiCall(t *testing.T) {
+	smartcontract.ContractMap[parallelTestSCAddress] = &parallelTestSC{}
+	defer delete(smartcontract.ContractMap, parallelTestSCAddress)
+
+	var (
+		ndb, root = newParallelTestState(t)
+		c         = newParallelTestChain(false)
+		b         = newParallelTestBlock(ndb, root)
+		from      = parallelTestClient(0)
+	)
+	newMultiCallTxn := func(name string, calls ...*transaction.SmartContractCall) *transaction.Transaction {
+		data, _ := json.Marshal(&transaction.MultiCallData{Calls: calls})
+		txn := &transaction.Transaction{
+			ClientID:        from,
+			TransactionType: transaction.TxnTypeMultiCall,
+			TransactionData: string(data),
+		}
+		for _, call := range calls {
+			txn.Value += call.Value
+		}
+		txn.Hash = encryption.Hash(name)
+		return txn
+	}
+	newCall := func(name string, input parallelTestInput, value int64) *transaction.SmartContractCall {
+		data, _ := json.Marshal(input)
+		return &transaction.SmartContractCall{
+			Address:      parallelTestSCAddress,
+			FunctionName: name,
+			InputData:    data,
+			Value:        value,
+		}
+	}
+	counter := func(key string) int {
+		sctx := c.NewStateContext(b, b.ClientState, &transaction.Transaction{})
+		val, err := (&parallelTestSC{}).get(sctx, key)
+		require.NoError(t, err)
+		return val
+	}
+
+	txn := newMultiCallTxn("multi",
+		newCall("pay", parallelTestInput{Key: "key-1"}, 10),
+		newCall("move", parallelTestInput{Key: "key-1", To: "key-3", Value: 4}, 0),
+	)
+	require.NoError(t, c.UpdateState(context.Background(), b, txn))
+	require.Equal(t, []string{"key-1=10", "key-1=6"}, txn.Receipt.Outputs)
+	require.Equal(t, `["key-1=10","key-1=6"]`, txn.TransactionOutput)
+	require.Equal(t, []*transaction.ReceiptTransfer{
+		{ClientID: from, ToClientID: parallelTestSCAddress, Amount: 10},
+	}, txn.Receipt.Transfers)
+	require.Len(t, txn.Receipt.Events, 1)
+	require.Equal(t, 6, counter("key-1"))
+	require.Equal(t, 4, counter("key-3"))
+
+	// all or none of the calls
+	txn = newMultiCallTxn("failed",
+		newCall("add", parallelTestInput{Key: "key-1", Value: 100}, 0),
+		newCall("move", parallelTestInput{Key: "key-3", To: "key-5", Value: 5}, 0),
+	)
+	require.Error(t, c.UpdateState(context.Background(), b, txn))
+	require.Equal(t, 6, counter("key-1"))
+	require.Equal(t, 4, counter("key-3"))
+
+	// the payload is limited
+	c.TxnMaxPayload = 10
+	txn = newMultiCallTxn("limited",
+		newCall("add", parallelTestInput{Key: "key-1", Value: 100}, 0))
+	require.Error(t, c.UpdateState(context.Background(), b, txn))
+	require.Equal(t, 6, counter("key-1"))
+}
@@ -26,6 +26,10 @@ func init() {
 }
 
 func (c *Chain) pruneClientState(ctx context.Context) {
+	if jndb, ok := c.stateDB.(util.JournalNodeDB); ok {
+		c.pruneClientStateJournals(ctx, jndb)
+		return
+	}
 
 	var bc = c.BlockChain
 	bc = bc.Move(-c.PruneStateBelowCount)
@@ -186,3 +190,44 @@ func (c *Chain) pruneClientState(ctx context.Context) {
 			}
 		}*/
 }
+
+// pruneClientStateJournals prunes the oldest rounds journals of the reference
+// counting state db, at most StatePruneMaxRounds rounds at once.
+func (c *Chain) pruneClientStateJournals(ctx context.Context, jndb util.JournalNodeDB) {
+	var lfb = c.GetLatestFinalizedBlock()
+	if lfb == nil {
+		return
+	}
+	var below = lfb.Round - int64(c.PruneStateBelowCount)
+	if below <= 0 {
+		return
+	}
+
+	var (
+		pctx = util.WithPruneStats(ctx)
+		ps   = util.GetPruneStats(pctx)
+		t    = time.Now()
+	)
+	ps.Stage = util.PruneStateDelete
+	ps.Version = util.Sequence(below)
+	c.pruneStats = ps
+
+	rounds, err := jndb.PruneJournals(pctx, util.Sequence(below), c.StatePruneMaxRounds)
+	ps.DeleteTime = time.Since(t)
+	if rounds > 0 {
+		StatePruneDeleteTimer.Update(ps.DeleteTime)
+	}
+	if err != nil {
+		ps.Stage = util.PruneStateAbandoned
+		logging.Logger.Error("prune client state journals",
+			zap.Int64("round", below), zap.Int("rounds", rounds),
+			zap.Any("stats", ps), zap.Error(err))
+		return
+	}
+	ps.Stage = util.PruneStateCommplete
+	if rounds > 0 {
+		logging.Logger.Debug("prune client state journals",
+			zap.Int64("round", below), zap.Int("rounds", rounds),
+			zap.Any("stats", ps))
+	}
+}
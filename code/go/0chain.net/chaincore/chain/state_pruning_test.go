@@ -0,0 +1,59 @@
+package chain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/core/util"
+)
+
+type testJournalNodeDB struct {
+	util.PersistentNodeDB
+	version     util.Sequence
+	maxVersions int
+}
+
+func (tjndb *testJournalNodeDB) PutChanges(version util.Sequence, keys []util.Key,
+	nodes []util.Node, deletes []util.Key) error {
+	return nil
+}
+
+func (tjndb *testJournalNodeDB) PruneJournals(ctx context.Context,
+	version util.Sequence, maxVersions int) (int, error) {
+
+	tjndb.version, tjndb.maxVersions = version, maxVersions
+	util.GetPruneStats(ctx).Deleted = 7
+	return maxVersions, nil
+}
+
+func TestChain_pruneClientStateJournals(t *testing.T) {
+	var (
+		jndb = &testJournalNodeDB{}
+		c    = &Chain{
+			Config: &Config{
+				PruneStateBelowCount: 100,
+				StatePruneMaxRounds:  20,
+			},
+			stateDB: jndb,
+		}
+	)
+
+	// nothing to prune yet
+	c.LatestFinalizedBlock = block.NewBlock("", 50)
+	c.pruneClientState(context.Background())
+	require.Nil(t, c.GetPruneStats())
+
+	c.LatestFinalizedBlock = block.NewBlock("", 250)
+	c.pruneClientState(context.Background())
+	require.EqualValues(t, 150, jndb.version)
+	require.Equal(t, 20, jndb.maxVersions)
+
+	ps := c.GetPruneStats()
+	require.NotNil(t, ps)
+	require.Equal(t, util.PruneStateCommplete, ps.Stage)
+	require.EqualValues(t, 150, ps.Version)
+	require.EqualValues(t, 7, ps.Deleted)
+}
@@ -0,0 +1,335 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"go.uber.org/zap"
+
+	"0chain.net/chaincore/node"
+	"0chain.net/chaincore/state"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/logging"
+	"0chain.net/core/util"
+)
+
+const (
+	// stateSyncMaxFailures - number of failed requests in a row after which
+	// a sharder is not asked for subtrees anymore.
+	stateSyncMaxFailures = 5
+	// stateSyncSaveInterval - how often the progress is persisted.
+	stateSyncSaveInterval = 5 * time.Second
+)
+
+var (
+	stateSyncNodesMeter     = metrics.GetOrRegisterMeter("state_sync_nodes", nil)
+	stateSyncRemainingGauge = metrics.GetOrRegisterGauge("state_sync_remaining_subtrees", nil)
+)
+
+const hexNibbles = "0123456789abcdef"
+
+// StateSyncProgress - progress of a range based state sync, it's persisted to
+// be resumed after a restart.
+type StateSyncProgress struct {
+	Round     int64    `json:"round"`
+	Root      string   `json:"root"`
+	Pending   []string `json:"pending"`   // path prefixes of the subtrees left
+	Completed int64    `json:"completed"` // number of synced subtrees
+	Nodes     int64    `json:"nodes"`     // number of received nodes
+}
+
+func (c *Chain) loadStateSyncProgress(root util.Key) *StateSyncProgress {
+	if c.StateSyncProgressFile == "" {
+		return nil
+	}
+	buf, err := ioutil.ReadFile(c.StateSyncProgressFile)
+	if err != nil {
+		return nil
+	}
+	var p StateSyncProgress
+	if err = json.Unmarshal(buf, &p); err != nil {
+		logging.Logger.Error("state sync - invalid progress file", zap.Error(err))
+		return nil
+	}
+	if p.Root != util.ToHex(root) || len(p.Pending) == 0 {
+		return nil
+	}
+	return &p
+}
+
+func (c *Chain) saveStateSyncProgress(p *StateSyncProgress) {
+	if c.StateSyncProgressFile == "" {
+		return
+	}
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(c.StateSyncProgressFile), 0755); err == nil {
+		tmp := c.StateSyncProgressFile + ".tmp"
+		if err = ioutil.WriteFile(tmp, buf, 0644); err == nil {
+			err = os.Rename(tmp, c.StateSyncProgressFile)
+		}
+	}
+	if err != nil {
+		logging.Logger.Error("state sync - save progress", zap.Error(err))
+	}
+}
+
+func (c *Chain) removeStateSyncProgress() {
+	if c.StateSyncProgressFile != "" {
+		os.Remove(c.StateSyncProgressFile)
+	}
+}
+
+// hasStateSyncProgress returns true if there is an unfinished sync of the
+// given state root to resume.
+func (c *Chain) hasStateSyncProgress(root util.Key) bool {
+	return c.loadStateSyncProgress(root) != nil
+}
+
+// GetStateSubtreeNodes - get nodes of the subtree of a path prefix of the
+// given state, see util.MerklePatriciaTrie.GetSubtreeNodes.
+func (c *Chain) GetStateSubtreeNodes(ctx context.Context, root util.Key,
+	prefix util.Path) (*state.Nodes, error) {
+
+	for _, nibble := range prefix {
+		if !isHexNibble(nibble) {
+			return nil, common.InvalidRequest("invalid path prefix")
+		}
+	}
+	mpt := util.NewMerklePatriciaTrie(c.stateDB, 0)
+	mpt.SetRoot(root)
+	nodes, complete, err := mpt.GetSubtreeNodes(ctx, prefix, MaxStateNodesForSync)
+	if err != nil {
+		return nil, err
+	}
+	ns := state.NewStateNodes()
+	ns.Nodes = nodes
+	ns.Complete = complete
+	return ns, nil
+}
+
+func isHexNibble(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f')
+}
+
+type stateSyncResult struct {
+	prefix   string
+	nodes    int
+	complete bool
+	err      error
+	sharder  *node.Node
+	quit     bool
+}
+
+/*SyncStateByRanges - syncs the state of the root from sharders of the current
+* magic block. The state is requested by subtrees of path prefixes, the
+* subtrees are spread over the sharders and split when too big for a single
+* response. The progress is persisted, so the sync of the same root resumes
+* from the subtrees left. The given prefixes are used only if there is no
+* progress to resume. */
+func (c *Chain) SyncStateByRanges(ctx context.Context, round int64,
+	root util.Key, prefixes []util.Path) error {
+
+	var sharders []*node.Node
+	for _, sharder := range c.GetCurrentMagicBlock().Sharders.CopyNodes() {
+		if node.Self.IsEqual(sharder) || sharder.GetStatus() == node.NodeStatusInactive {
+			continue
+		}
+		sharders = append(sharders, sharder)
+	}
+	if len(sharders) == 0 {
+		return common.NewError("state_sync", "no active sharder to sync from")
+	}
+
+	progress := c.loadStateSyncProgress(root)
+	if progress == nil {
+		progress = &StateSyncProgress{Round: round, Root: util.ToHex(root)}
+		for _, prefix := range prefixes {
+			progress.Pending = append(progress.Pending, string(prefix))
+		}
+	} else {
+		logging.Logger.Info("state sync - resume",
+			zap.Int64("round", progress.Round),
+			zap.String("root", progress.Root),
+			zap.Int("pending", len(progress.Pending)),
+			zap.Int64("completed", progress.Completed))
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		taskC   = make(chan string)
+		resultC = make(chan stateSyncResult)
+		workers = len(sharders)
+	)
+	for _, sharder := range sharders {
+		go c.stateSyncWorker(cctx, sharder, root, taskC, resultC)
+	}
+
+	var (
+		pending  = progress.Pending
+		inFlight = make(map[string]bool)
+		start    = time.Now()
+		synced   int64
+		saveTk   = time.NewTicker(stateSyncSaveInterval)
+	)
+	defer saveTk.Stop()
+
+	snapshot := func() {
+		progress.Pending = append([]string{}, pending...)
+		for prefix := range inFlight {
+			progress.Pending = append(progress.Pending, prefix)
+		}
+		stateSyncRemainingGauge.Update(int64(len(progress.Pending)))
+	}
+
+	for len(pending) > 0 || len(inFlight) > 0 {
+		var (
+			sendC chan string
+			next  string
+		)
+		if len(pending) > 0 && workers > 0 {
+			sendC, next = taskC, pending[0]
+		}
+		select {
+		case sendC <- next:
+			pending = pending[1:]
+			inFlight[next] = true
+		case res := <-resultC:
+			delete(inFlight, res.prefix)
+			if res.quit {
+				workers--
+				logging.Logger.Error("state sync - sharder excluded",
+					zap.String("sharder", res.sharder.GetPseudoName()),
+					zap.Error(res.err))
+			}
+			switch {
+			case res.err != nil:
+				pending = append(pending, res.prefix)
+			case res.complete:
+				progress.Completed++
+			default:
+				for i := range hexNibbles {
+					pending = append(pending, res.prefix+hexNibbles[i:i+1])
+				}
+			}
+			progress.Nodes += int64(res.nodes)
+			synced += int64(res.nodes)
+			stateSyncNodesMeter.Mark(int64(res.nodes))
+			if workers == 0 {
+				snapshot()
+				c.saveStateSyncProgress(progress)
+				return common.NewError("state_sync", "no sharder left to sync from")
+			}
+		case <-saveTk.C:
+			snapshot()
+			c.saveStateSyncProgress(progress)
+			logging.Logger.Info("state sync - progress",
+				zap.Int64("round", progress.Round),
+				zap.Int64("nodes", progress.Nodes),
+				zap.Float64("nodes_per_second", float64(synced)/time.Since(start).Seconds()),
+				zap.Int("remaining_subtrees", len(progress.Pending)),
+				zap.Int64("completed_subtrees", progress.Completed))
+		case <-ctx.Done():
+			snapshot()
+			c.saveStateSyncProgress(progress)
+			return ctx.Err()
+		}
+	}
+
+	stateSyncRemainingGauge.Update(0)
+	c.removeStateSyncProgress()
+	logging.Logger.Info("state sync - done",
+		zap.Int64("round", progress.Round),
+		zap.String("root", progress.Root),
+		zap.Int64("nodes", progress.Nodes),
+		zap.Int64("subtrees", progress.Completed),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+func (c *Chain) stateSyncWorker(ctx context.Context, sharder *node.Node,
+	root util.Key, taskC <-chan string, resultC chan<- stateSyncResult) {
+
+	var failures int
+	for {
+		var prefix string
+		select {
+		case <-ctx.Done():
+			return
+		case prefix = <-taskC:
+		}
+		nodes, complete, err := c.syncStateSubtree(ctx, sharder, root, prefix)
+		if err != nil {
+			failures++
+			logging.Logger.Debug("state sync - subtree",
+				zap.String("sharder", sharder.GetPseudoName()),
+				zap.String("prefix", prefix), zap.Error(err))
+		} else {
+			failures = 0
+		}
+		res := stateSyncResult{
+			prefix:   prefix,
+			nodes:    nodes,
+			complete: complete,
+			err:      err,
+			sharder:  sharder,
+			quit:     failures >= stateSyncMaxFailures,
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case resultC <- res:
+		}
+		if res.quit {
+			return
+		}
+	}
+}
+
+// syncStateSubtree requests nodes of the subtree of the prefix from the
+// sharder, verifies and saves them.
+func (c *Chain) syncStateSubtree(ctx context.Context, sharder *node.Node,
+	root util.Key, prefix string) (int, bool, error) {
+
+	params := &url.Values{}
+	params.Add("root", util.ToHex(root))
+	params.Add("prefix", prefix)
+
+	var ns *state.Nodes
+	handler := func(ctx context.Context, entity datastore.Entity) (interface{}, error) {
+		rns, ok := entity.(*state.Nodes)
+		if !ok {
+			return nil, datastore.ErrInvalidEntity
+		}
+		// an incomplete subtree is split further, it can't be empty
+		if !rns.Complete && len(rns.Nodes) == 0 {
+			return nil, util.ErrNodeNotFound
+		}
+		err := util.VerifySubtreeNodes(root, util.Path(prefix), rns.Nodes, rns.Complete)
+		if err != nil {
+			return nil, err
+		}
+		ns = rns
+		return rns, nil
+	}
+	StateNodesRequestor(params, handler)(sharder)
+	if ns == nil {
+		return 0, false, common.NewError("state_sync_subtree",
+			"no valid subtree nodes received")
+	}
+	if err := c.SaveStateNodes(ctx, ns); err != nil {
+		return 0, false, err
+	}
+	return len(ns.Nodes), ns.Complete, nil
+}
@@ -0,0 +1,52 @@
+package chain
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/state"
+	"0chain.net/core/util"
+)
+
+func TestChain_StateSyncProgress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_sync")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := &Chain{Config: &Config{
+		StateSyncProgressFile: filepath.Join(dir, "sync", "progress.json"),
+	}}
+	root := util.Key("state root")
+	require.False(t, c.hasStateSyncProgress(root))
+
+	c.saveStateSyncProgress(&StateSyncProgress{
+		Round:   10,
+		Root:    util.ToHex(root),
+		Pending: []string{"0a", "1"},
+	})
+	require.True(t, c.hasStateSyncProgress(root))
+	require.False(t, c.hasStateSyncProgress(util.Key("other root")))
+	require.Equal(t, []string{"0a", "1"}, c.loadStateSyncProgress(root).Pending)
+
+	c.removeStateSyncProgress()
+	require.False(t, c.hasStateSyncProgress(root))
+}
+
+func TestChain_GetStateSubtreeNodes(t *testing.T) {
+	state.SetupStateNodes(nil)
+	c := newHistoryTestChain(t, 3)
+	root := c.GetLatestFinalizedBlock().ClientStateHash
+
+	ns, err := c.GetStateSubtreeNodes(context.Background(), root, util.Path("a"))
+	require.NoError(t, err)
+	require.True(t, ns.Complete)
+	require.NoError(t, util.VerifySubtreeNodes(root, util.Path("a"), ns.Nodes, true))
+
+	_, err = c.GetStateSubtreeNodes(context.Background(), root, util.Path("x"))
+	require.Error(t, err)
+}
@@ -0,0 +1,322 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"0chain.net/core/common"
+	"0chain.net/core/logging"
+	"0chain.net/core/util"
+)
+
+const (
+	// StateSnapshotURL - info of a state snapshot served by a node
+	StateSnapshotURL = "/v1/state/snapshot"
+	// StateSnapshotChunkURL - raw chunk of a state snapshot served by a node
+	StateSnapshotChunkURL = "/v1/state/snapshot/chunk"
+)
+
+const stateSnapshotFileFmt = "state_%d.snapshot"
+
+// stateSnapshotFile returns path of the snapshot of the given round in the
+// snapshots directory.
+func (c *Chain) stateSnapshotFile(round int64) string {
+	return filepath.Join(c.StateSnapshotDir, fmt.Sprintf(stateSnapshotFileFmt, round))
+}
+
+// latestStateSnapshotFile returns the snapshot of the highest round in the
+// snapshots directory.
+func (c *Chain) latestStateSnapshotFile() (string, error) {
+	files, err := filepath.Glob(filepath.Join(c.StateSnapshotDir, "state_*.snapshot"))
+	if err != nil {
+		return "", err
+	}
+	var (
+		latest string
+		max    int64 = -1
+	)
+	for _, file := range files {
+		var round int64
+		if _, err := fmt.Sscanf(filepath.Base(file), stateSnapshotFileFmt, &round); err != nil {
+			continue
+		}
+		if round > max {
+			latest, max = file, round
+		}
+	}
+	if latest == "" {
+		return "", common.NewError("state_snapshot_not_found", "no state snapshot available")
+	}
+	return latest, nil
+}
+
+/*ExportStateSnapshot - writes the state of the finalized block of the given
+* round (the latest finalized round if it's zero) to the file, the file is
+* placed to the snapshots directory if not given */
+func (c *Chain) ExportStateSnapshot(ctx context.Context, round int64, file string) (
+	*util.SnapshotInfo, error) {
+
+	if round <= 0 {
+		lfb := c.GetLatestFinalizedBlock()
+		if lfb == nil {
+			return nil, common.ErrTemporaryFailure
+		}
+		round = lfb.Round
+	}
+	fs, err := c.getFinalizedState(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		file = c.stateSnapshotFile(fs.Round)
+	}
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+
+	// write to a temporary file first to never serve a partial snapshot
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	info, err := util.WriteSnapshot(ctx, fs.State, fs.Round, c.StateSnapshotChunk, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, common.NewError("state_snapshot_export", err.Error())
+	}
+	if err = os.Rename(tmp, file); err != nil {
+		return nil, err
+	}
+	logging.Logger.Info("state snapshot exported",
+		zap.Int64("round", info.Round),
+		zap.String("root", info.Root),
+		zap.Uint64("nodes", info.Nodes),
+		zap.Int("chunks", len(info.Chunks)),
+		zap.String("file", file))
+	return info, nil
+}
+
+/*ImportStateSnapshot - bulk loads a snapshot into the state db, the source
+* is either a snapshot file or URL of a node serving snapshots */
+func (c *Chain) ImportStateSnapshot(ctx context.Context, source string) (
+	*util.SnapshotInfo, error) {
+
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	var (
+		info *util.SnapshotInfo
+		err  error
+	)
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		info, err = c.importStateSnapshotFrom(ctx, source)
+	} else {
+		info, err = c.importStateSnapshotFile(ctx, source)
+	}
+	if err != nil {
+		return nil, common.NewError("state_snapshot_import", err.Error())
+	}
+	if err = c.validateStateSnapshotRoot(ctx, info); err != nil {
+		return nil, err
+	}
+	if pndb, ok := c.stateDB.(util.PersistentNodeDB); ok {
+		pndb.Flush()
+	}
+	logging.Logger.Info("state snapshot imported",
+		zap.Int64("round", info.Round),
+		zap.String("root", info.Root),
+		zap.Uint64("nodes", info.Nodes),
+		zap.String("source", source))
+	return info, nil
+}
+
+func (c *Chain) importStateSnapshotFile(ctx context.Context, file string) (
+	*util.SnapshotInfo, error) {
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return util.ImportSnapshot(ctx, f, c.stateDB)
+}
+
+// importStateSnapshotFrom downloads the snapshot chunk by chunk from a node
+// serving it and imports the chunks as they arrive.
+func (c *Chain) importStateSnapshotFrom(ctx context.Context, baseURL string) (
+	*util.SnapshotInfo, error) {
+
+	var info = &util.SnapshotInfo{}
+	buf, err := getStateSnapshotData(ctx, baseURL+StateSnapshotURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(buf, info); err != nil {
+		return nil, err
+	}
+	if info.Version != util.SnapshotVersion {
+		return nil, fmt.Errorf("unsupported state snapshot version: %d", info.Version)
+	}
+	root, err := info.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	si := util.NewSnapshotImporter(c.stateDB, info.Round, root)
+	for idx, ci := range info.Chunks {
+		params := url.Values{}
+		params.Add("round", strconv.FormatInt(info.Round, 10))
+		params.Add("index", strconv.Itoa(idx))
+		chunk, err := getStateSnapshotData(ctx, baseURL+StateSnapshotChunkURL, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunk) < util.SnapshotChecksumSize ||
+			util.ToHex(chunk[len(chunk)-util.SnapshotChecksumSize:]) != ci.Checksum {
+			return nil, fmt.Errorf("chunk %d doesn't match the snapshot info", idx)
+		}
+		if err = si.ImportChunk(chunk); err != nil {
+			return nil, err
+		}
+		logging.Logger.Debug("state snapshot chunk imported",
+			zap.Int("index", idx), zap.Int("chunks", len(info.Chunks)))
+	}
+	if si.GetNodesCount() != info.Nodes {
+		return nil, util.ErrInvalidSnapshot
+	}
+	if err = si.Validate(ctx); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func getStateSnapshotData(ctx context.Context, uri string, params url.Values) (
+	[]byte, error) {
+
+	if len(params) > 0 {
+		uri += "?" + params.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s: %s", uri, resp.Status, string(buf))
+	}
+	return buf, nil
+}
+
+// validateStateSnapshotRoot checks the imported root against the finalized
+// block of the snapshot round if the block is known to this node.
+func (c *Chain) validateStateSnapshotRoot(ctx context.Context,
+	info *util.SnapshotInfo) error {
+
+	bs, err := c.getFinalizedBlockSummary(ctx, info.Round)
+	if err != nil {
+		logging.Logger.Info("state snapshot root can't be checked against"+
+			" the finalized block", zap.Int64("round", info.Round),
+			zap.Error(err))
+		return nil
+	}
+	root, err := info.GetRoot()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, bs.ClientStateHash) {
+		return common.NewError("state_snapshot_root_mismatch",
+			fmt.Sprintf("snapshot root %s, finalized block state hash %s",
+				info.Root, util.ToHex(bs.ClientStateHash)))
+	}
+	return nil
+}
+
+// openStateSnapshot opens the snapshot of the round given by the request, the
+// latest one if the round is not given.
+func (c *Chain) openStateSnapshot(r *http.Request) (*os.File, *util.SnapshotInfo, error) {
+	var (
+		file string
+		err  error
+	)
+	if roundStr := r.FormValue("round"); roundStr != "" {
+		round, err := strconv.ParseInt(roundStr, 10, 64)
+		if err != nil {
+			return nil, nil, common.InvalidRequest("invalid round: " + roundStr)
+		}
+		file = c.stateSnapshotFile(round)
+	} else if file, err = c.latestStateSnapshotFile(); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, common.NewError("state_snapshot_not_found",
+				"no state snapshot of the round")
+		}
+		return nil, nil, err
+	}
+	info, err := util.ReadSnapshotInfo(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+/*GetStateSnapshotHandler - get info of a state snapshot served by the node */
+func (c *Chain) GetStateSnapshotHandler(ctx context.Context, r *http.Request) (
+	interface{}, error) {
+
+	f, info, err := c.openStateSnapshot(r)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return info, nil
+}
+
+/*GetStateSnapshotChunkHandler - serves a raw chunk of a state snapshot */
+func (c *Chain) GetStateSnapshotChunkHandler(w http.ResponseWriter, r *http.Request) {
+	f, info, err := c.openStateSnapshot(r)
+	if err != nil {
+		common.Respond(w, r, nil, err)
+		return
+	}
+	defer f.Close()
+	idx, err := strconv.Atoi(r.FormValue("index"))
+	if err != nil || idx < 0 || idx >= len(info.Chunks) {
+		common.Respond(w, r, nil, common.InvalidRequest("invalid chunk index"))
+		return
+	}
+	ci := info.Chunks[idx]
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(ci.Size, 10))
+	if _, err = io.Copy(w, io.NewSectionReader(f, ci.Offset, ci.Size)); err != nil {
+		logging.Logger.Error("serve state snapshot chunk", zap.Int("index", idx),
+			zap.Error(err))
+	}
+}
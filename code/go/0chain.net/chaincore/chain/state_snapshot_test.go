@@ -0,0 +1,67 @@
+package chain
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/core/util"
+)
+
+func TestChain_ExportImportStateSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newHistoryTestChain(t, 20)
+	c.StateSnapshotDir = dir
+	c.StateSnapshotChunk = 1
+
+	info, err := c.ExportStateSnapshot(context.Background(), 18, "")
+	require.NoError(t, err)
+	require.EqualValues(t, 18, info.Round)
+	require.FileExists(t, filepath.Join(dir, "state_18.snapshot"))
+
+	// served by the handlers
+	r := httptest.NewRequest("GET", StateSnapshotURL, nil)
+	served, err := c.GetStateSnapshotHandler(context.Background(), r)
+	require.NoError(t, err)
+	require.Equal(t, info, served)
+
+	w := httptest.NewRecorder()
+	r = httptest.NewRequest("GET", StateSnapshotChunkURL+"?index=0", nil)
+	c.GetStateSnapshotChunkHandler(w, r)
+	_, err = util.DecodeSnapshotChunk(w.Body.Bytes())
+	require.NoError(t, err)
+
+	// imported by a new node knowing the round
+	other := newHistoryTestChain(t, 20)
+	other.stateDB = keepNodesDB{util.NewMemoryNodeDB()}
+	imported, err := other.ImportStateSnapshot(context.Background(),
+		filepath.Join(dir, "state_18.snapshot"))
+	require.NoError(t, err)
+	require.Equal(t, info.Root, imported.Root)
+
+	fs, err := other.getFinalizedState(context.Background(), 18)
+	require.NoError(t, err)
+	v, err := fs.State.GetNodeValue(util.Path("aa"))
+	require.NoError(t, err)
+	require.Equal(t, "18", string(v.Encode()))
+
+	// the snapshot doesn't match the finalized block
+	mismatch := newHistoryTestChain(t, 20)
+	mismatch.BlockChain.Move(-3).Value = &block.BlockSummary{
+		Round:           18,
+		ClientStateHash: util.Key("other state root"),
+	}
+	_, err = mismatch.ImportStateSnapshot(context.Background(),
+		filepath.Join(dir, "state_18.snapshot"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "state_snapshot_root_mismatch")
+}
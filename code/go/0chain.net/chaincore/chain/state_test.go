@@ -0,0 +1,68 @@
+package chain
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/encryption"
+	"0chain.net/core/util"
+)
+
+func TestChain_updateNonce(t *testing.T) {
+	var (
+		c = &Chain{
+			clientStateDeserializer: &state.Deserializer{},
+			stateMutex:              &sync.RWMutex{},
+		}
+		b        = block.NewBlock("", 10)
+		mpt      = util.NewMerklePatriciaTrie(util.NewMemoryNodeDB(), 10)
+		clientID = encryption.Hash("client")
+		toID     = encryption.Hash("to")
+	)
+	_, err := mpt.Insert(util.Path(clientID), &state.State{
+		TxnHashBytes: make([]byte, 32),
+		Balance:      10,
+	})
+	require.NoError(t, err)
+
+	newTxn := func(nonce int64) *transaction.Transaction {
+		txn := &transaction.Transaction{ClientID: clientID, Nonce: nonce}
+		txn.Hash = strings.Repeat("0", 63) + "1"
+		return txn
+	}
+
+	// not checked without the nonce
+	require.NoError(t, c.updateNonce(c.NewStateContext(b, mpt, newTxn(0)), newTxn(0)))
+	nonce, err := c.GetStateNonce(mpt, clientID)
+	require.NoError(t, err)
+	require.Zero(t, nonce)
+
+	for _, nonce := range []int64{2, 5} {
+		txn := newTxn(nonce)
+		require.Equal(t, ErrInvalidNonce, c.updateNonce(c.NewStateContext(b, mpt, txn), txn))
+	}
+	for nonce := int64(1); nonce <= 3; nonce++ {
+		txn := newTxn(nonce)
+		require.NoError(t, c.updateNonce(c.NewStateContext(b, mpt, txn), txn))
+	}
+	// replay
+	txn := newTxn(3)
+	require.Equal(t, ErrInvalidNonce, c.updateNonce(c.NewStateContext(b, mpt, txn), txn))
+
+	// the client with the nonce is kept with no balance
+	txn = newTxn(4)
+	sctx := c.NewStateContext(b, mpt, txn)
+	require.NoError(t, c.updateNonce(sctx, txn))
+	require.NoError(t, c.transferAmount(sctx, clientID, toID, 10))
+	s, err := c.getState(mpt, clientID)
+	require.NoError(t, err)
+	require.Zero(t, s.Balance)
+	require.EqualValues(t, 4, s.Nonce)
+	require.EqualValues(t, 10, s.Round)
+}
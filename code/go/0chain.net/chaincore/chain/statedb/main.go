@@ -0,0 +1,353 @@
+// The statedb command inspects and repairs the state db of a stopped node.
+//
+//	statedb -rocksdb data/rocksdb/state versions
+//	statedb -bolt data/state.bolt validate -root <hash>
+//	statedb -rocksdb data/rocksdb/state dump -root <hash> -sc <address prefix>
+//	statedb -rocksdb data/rocksdb/state delete-orphans -root <hash> -compact
+//
+// The hashes of the state roots are the client state hashes of the blocks.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"0chain.net/chaincore/chain"
+	"0chain.net/core/logging"
+	"0chain.net/core/util"
+	"0chain.net/core/viper"
+	"0chain.net/smartcontract/setupsc"
+)
+
+const usage = `usage: statedb (-rocksdb dir | -bolt file) [-journal file] command [flags]
+
+commands:
+  versions         the versions of the nodes of the db and their roots
+  validate         check the nodes of the state of the -root
+  missing          the missing nodes of the state of the -root
+  orphans          the nodes not reachable from any of the -root states
+  print            print the trie of the state of the -root
+  dump             dump the values of the state of the -root as JSON
+  delete-orphans   delete the nodes not reachable from any of the -root states
+  compact          reclaim the space of the deleted nodes
+`
+
+// rootsFlag - the repeatable -root flag
+type rootsFlag []util.Key
+
+func (rf *rootsFlag) String() string {
+	var roots []string
+	for _, root := range *rf {
+		roots = append(roots, util.ToHex(root))
+	}
+	return strings.Join(roots, ",")
+}
+
+func (rf *rootsFlag) Set(value string) error {
+	root, err := hex.DecodeString(value)
+	if err != nil || len(root) == 0 {
+		return fmt.Errorf("invalid root hash: %q", value)
+	}
+	*rf = append(*rf, root)
+	return nil
+}
+
+// dumpValue - a value of the state dump
+type dumpValue struct {
+	Path string `json:"path"`
+	*chain.StateDiffValue
+}
+
+// nodeReport - the missing or corrupted nodes of a state
+type nodeReport struct {
+	Root  string   `json:"root"`
+	Paths []string `json:"paths"`
+	Keys  []string `json:"keys"`
+}
+
+func newNodeReport(root util.Key, paths []util.Path, keys []util.Key) *nodeReport {
+	nr := &nodeReport{
+		Root:  util.ToHex(root),
+		Paths: make([]string, 0, len(paths)),
+		Keys:  make([]string, 0, len(keys)),
+	}
+	for i := range paths {
+		nr.Paths = append(nr.Paths, string(paths[i]))
+		nr.Keys = append(nr.Keys, util.ToHex(keys[i]))
+	}
+	return nr
+}
+
+type cmdStateDB struct {
+	ndb util.PersistentNodeDB
+	out *json.Encoder
+}
+
+func openNodeDB(rocksDir, rocksLogDir, boltFile, journalFile string) (
+	util.PersistentNodeDB, error) {
+
+	var (
+		ndb util.PersistentNodeDB
+		err error
+	)
+	switch {
+	case rocksDir != "" && boltFile == "":
+		if _, err = os.Stat(rocksDir); err != nil {
+			return nil, err
+		}
+		ndb, err = util.NewPNodeDB(rocksDir, rocksLogDir)
+	case boltFile != "" && rocksDir == "":
+		if _, err = os.Stat(boltFile); err != nil {
+			return nil, err
+		}
+		ndb, err = util.NewBoltNodeDB(boltFile)
+	default:
+		return nil, errors.New("either -rocksdb or -bolt db is required")
+	}
+	if err != nil || journalFile == "" {
+		return ndb, err
+	}
+	// the references of the deleted nodes are deleted from the journal too
+	rndb, err := util.NewRefCountNodeDB(ndb, journalFile)
+	if err != nil {
+		ndb.Close()
+		return nil, err
+	}
+	return rndb, nil
+}
+
+func (cmd *cmdStateDB) newMPT(root util.Key) *util.MerklePatriciaTrie {
+	mpt := util.NewMerklePatriciaTrie(cmd.ndb, 0)
+	mpt.SetRoot(root)
+	return mpt
+}
+
+func (cmd *cmdStateDB) versions(ctx context.Context) error {
+	versions, err := util.GetNodeDBVersions(ctx, cmd.ndb)
+	if err != nil {
+		return err
+	}
+	return cmd.out.Encode(versions)
+}
+
+func (cmd *cmdStateDB) missing(ctx context.Context, roots []util.Key) error {
+	var reports []*nodeReport
+	for _, root := range roots {
+		paths, keys, err := cmd.newMPT(root).FindMissingNodes(ctx)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, newNodeReport(root, paths, keys))
+	}
+	return cmd.out.Encode(reports)
+}
+
+func (cmd *cmdStateDB) validate(ctx context.Context, roots []util.Key) error {
+	var valid = true
+	for _, root := range roots {
+		mpt := cmd.newMPT(root)
+		if _, err := cmd.ndb.GetNode(root); err != nil {
+			return fmt.Errorf("root %s: %v", util.ToHex(root), err)
+		}
+		if err := mpt.Validate(); err != nil {
+			return err
+		}
+		paths, keys, err := mpt.FindMissingNodes(ctx)
+		if err != nil {
+			return err
+		}
+		cpaths, ckeys, err := mpt.FindCorruptedNodes(ctx)
+		if err != nil {
+			return err
+		}
+		err = cmd.out.Encode(struct {
+			Root      string      `json:"root"`
+			Valid     bool        `json:"valid"`
+			Missing   *nodeReport `json:"missing"`
+			Corrupted *nodeReport `json:"corrupted"`
+		}{
+			Root:      util.ToHex(root),
+			Valid:     len(keys) == 0 && len(ckeys) == 0,
+			Missing:   newNodeReport(root, paths, keys),
+			Corrupted: newNodeReport(root, cpaths, ckeys),
+		})
+		if err != nil {
+			return err
+		}
+		valid = valid && len(keys) == 0 && len(ckeys) == 0
+	}
+	if !valid {
+		return errors.New("invalid state")
+	}
+	return nil
+}
+
+func (cmd *cmdStateDB) orphans(ctx context.Context, roots []util.Key, remove, compact bool) error {
+	for _, root := range roots {
+		if _, err := cmd.ndb.GetNode(root); err != nil {
+			return fmt.Errorf("root %s: %v", util.ToHex(root), err)
+		}
+	}
+	orphans, err := util.FindOrphanNodes(ctx, cmd.ndb, roots, remove)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(orphans))
+	for _, key := range orphans {
+		keys = append(keys, util.ToHex(key))
+	}
+	if err := cmd.out.Encode(keys); err != nil {
+		return err
+	}
+	if !remove {
+		return nil
+	}
+	if err := util.DeleteNodes(ctx, cmd.ndb, orphans); err != nil {
+		return err
+	}
+	if compact {
+		return cmd.compact()
+	}
+	return nil
+}
+
+func (cmd *cmdStateDB) dump(ctx context.Context, root util.Key, prefix util.Path,
+	sc string) error {
+
+	var count int
+	fmt.Fprintln(os.Stdout, "[")
+	handler := func(ctx context.Context, path util.Path, key util.Key, node util.Node) error {
+		vn, ok := node.(*util.ValueNode)
+		if !ok || !vn.HasValue() {
+			return nil
+		}
+		value := chain.DecodeStateValue(path, vn.GetValue().Encode())
+		if sc != "" && !strings.HasPrefix(value.SmartContract, sc) {
+			return nil
+		}
+		data, err := json.Marshal(&dumpValue{Path: string(path), StateDiffValue: value})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			fmt.Fprintln(os.Stdout, ",")
+		}
+		count++
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	err := cmd.newMPT(root).IterateSubtree(ctx, prefix, handler, util.NodeTypeValueNode)
+	fmt.Fprintln(os.Stdout, "\n]")
+	return err
+}
+
+func (cmd *cmdStateDB) compact() error {
+	cndb, ok := cmd.ndb.(util.CompactNodeDB)
+	if !ok {
+		return errors.New("the db can't be compacted")
+	}
+	return cndb.Compact()
+}
+
+// setupSmartContracts registers all the smart contracts to decode their
+// nodes of the state.
+func setupSmartContracts() {
+	for _, name := range setupsc.SCNames {
+		viper.Set(fmt.Sprintf("development.smart_contract.%v", name), true)
+	}
+	setupsc.SetupSmartContracts()
+}
+
+func main() {
+	var (
+		rocksDir    = flag.String("rocksdb", "", "the rocksdb state db directory")
+		rocksLogDir = flag.String("rocksdb_log", "", "the rocksdb log directory, the db directory by default")
+		boltFile    = flag.String("bolt", "", "the bolt state db file")
+		journalFile = flag.String("journal", "", "the journal file of the reference counting pruning")
+		verbose     = flag.Bool("verbose", false, "log the db errors")
+	)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		command = flag.Arg(0)
+		cflags  = flag.NewFlagSet(command, flag.ExitOnError)
+		roots   rootsFlag
+		prefix  = cflags.String("path", "", "dump the values of the paths with the prefix only")
+		sc      = cflags.String("sc", "", "dump the values of the smart contracts with the address prefix only")
+		compact = cflags.Bool("compact", false, "compact the db after deleting the orphaned nodes")
+	)
+	cflags.Var(&roots, "root", "the state root hash, repeatable")
+	cflags.Parse(flag.Args()[1:])
+
+	logging.Logger = zap.NewNop()
+	if *verbose {
+		logging.Logger, _ = zap.NewDevelopment()
+	}
+	setupSmartContracts()
+
+	ndb, err := openNodeDB(*rocksDir, *rocksLogDir, *boltFile, *journalFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "opening the state db: %v\n", err)
+		os.Exit(1)
+	}
+	defer ndb.Close()
+
+	cmd := &cmdStateDB{ndb: ndb, out: json.NewEncoder(os.Stdout)}
+	cmd.out.SetIndent("", "  ")
+
+	var ctx = context.Background()
+	switch command {
+	case "versions", "compact":
+	case "print", "dump":
+		if len(roots) != 1 {
+			err = fmt.Errorf("%s: a single -root is required", command)
+		}
+	default:
+		if len(roots) == 0 {
+			err = fmt.Errorf("%s: -root is required", command)
+		}
+	}
+	if err == nil {
+		switch command {
+		case "versions":
+			err = cmd.versions(ctx)
+		case "validate":
+			err = cmd.validate(ctx, roots)
+		case "missing":
+			err = cmd.missing(ctx, roots)
+		case "orphans":
+			err = cmd.orphans(ctx, roots, false, false)
+		case "delete-orphans":
+			err = cmd.orphans(ctx, roots, true, *compact)
+		case "print":
+			err = cmd.newMPT(roots[0]).PrettyPrint(os.Stdout)
+		case "dump":
+			err = cmd.dump(ctx, roots[0], util.Path(*prefix), *sc)
+		case "compact":
+			err = cmd.compact()
+		default:
+			err = fmt.Errorf("unknown command: %s", command)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", command, err)
+		ndb.Close()
+		os.Exit(1)
+	}
+}
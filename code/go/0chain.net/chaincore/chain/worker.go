@@ -26,7 +26,11 @@ func init() {
 /*SetupWorkers - setup a blockworker for a chain */
 func (c *Chain) SetupWorkers(ctx context.Context) {
 	go c.StatusMonitor(ctx)
-	go c.PruneClientStateWorker(ctx)
+	if c.ArchiveState {
+		Logger.Info("archive state mode, client state pruning is disabled")
+	} else {
+		go c.PruneClientStateWorker(ctx)
+	}
 	go c.SyncLFBStateWorker(ctx)
 	go c.blockFetcher.StartBlockFetchWorker(ctx, c)
 	go c.StartLFBTicketWorker(ctx, c.GetLatestFinalizedBlock())
@@ -291,6 +295,10 @@ func (c *Chain) finalizeBlockProcess(ctx context.Context, fb *block.Block, bsh B
 /*PruneClientStateWorker - a worker that prunes the client state */
 func (c *Chain) PruneClientStateWorker(ctx context.Context) {
 	tick := time.Duration(c.PruneStateBelowCount) * time.Second
+	if _, ok := c.stateDB.(util.JournalNodeDB); ok {
+		// a few rounds are pruned at once, keep up with the finalized ones
+		tick = time.Second
+	}
 	timer := time.NewTimer(time.Second)
 	pruning := false
 	Logger.Debug("PruneClientStateWorker start")
@@ -425,6 +433,13 @@ func (c *Chain) SyncLFBStateWorker(ctx context.Context) {
 
 func (c *Chain) syncRoundStateToStateDB(ctx context.Context, round int64, rootStateHash util.Key) {
 	Logger.Info("Sync round state from network...")
+	if c.hasStateSyncProgress(rootStateHash) {
+		if err := c.SyncStateByRanges(ctx, round, rootStateHash, nil); err != nil {
+			Logger.Error("Sync round state, resume failed", zap.Error(err))
+		}
+		return
+	}
+
 	mpt := util.NewMerklePatriciaTrie(c.stateDB, util.Sequence(round))
 	mpt.SetRoot(rootStateHash)
 
@@ -432,7 +447,7 @@ func (c *Chain) syncRoundStateToStateDB(ctx context.Context, round int64, rootSt
 	cctx, cancel := context.WithTimeout(ctx, c.syncStateTimeout)
 	defer cancel()
 
-	_, keys, err := mpt.FindMissingNodes(cctx)
+	paths, keys, err := mpt.FindMissingNodes(cctx)
 	if err != nil {
 		switch err {
 		case context.Canceled:
@@ -461,7 +476,15 @@ func (c *Chain) syncRoundStateToStateDB(ctx context.Context, round int64, rootSt
 		zap.Int64("round", round),
 		zap.Int("missing_node_num", len(keys)))
 
-	c.GetStateNodes(ctx, keys)
+	// the missing nodes are roots of missing subtrees at their paths
+	if err := c.SyncStateByRanges(ctx, round, rootStateHash, paths); err != nil {
+		if err == context.Canceled {
+			return
+		}
+		Logger.Error("Sync round state by subtrees failed, sync by keys",
+			zap.Int64("round", round), zap.Error(err))
+		c.GetStateNodes(ctx, keys)
+	}
 }
 
 type MagicBlockSaveFunc func(context.Context, *block.Block) error
@@ -24,13 +24,33 @@ func SetupDefaultConfig() {
 	viper.SetDefault("server_chain.messages.verification_tickets_to", "generator")
 	viper.SetDefault("server_chain.round_range", 10000000)
 	viper.SetDefault("server_chain.transaction.payload.max_size", 32)
+	viper.SetDefault("server_chain.transaction.batch.max_size", 5000)
 	viper.SetDefault("server_chain.state.prune_below_count", 100)
+	viper.SetDefault("server_chain.state.prune_mode", "version")
+	viper.SetDefault("server_chain.state.prune_journal_file", "data/rocksdb/state_journal.bolt")
+	viper.SetDefault("server_chain.state.prune_max_rounds", 20)
+	viper.SetDefault("server_chain.state.archive", false)
+	viper.SetDefault("server_chain.state.typed_encoding", false)
+	viper.SetDefault("server_chain.state.parallel.enabled", false)
+	viper.SetDefault("server_chain.state.parallel.workers", 0)
+	viper.SetDefault("server_chain.state.parallel.batch_size", 64)
+	viper.SetDefault("server_chain.state.db.backend", "rocksdb")
+	viper.SetDefault("server_chain.state.db.bolt_file", "data/rocksdb/state.bolt")
+	viper.SetDefault("server_chain.state.snapshot.dir", "data/snapshots")
+	viper.SetDefault("server_chain.state.snapshot.chunk_size", 10000)
 	viper.SetDefault("server_chain.block.consensus.threshold_by_count", 66)
 	viper.SetDefault("server_chain.block.generation.timeout", 37)
 	viper.SetDefault("server_chain.state.sync.timeout", 10)
+	viper.SetDefault("server_chain.state.sync.progress_file", "data/state_sync/progress.json")
 	viper.SetDefault("server_chain.stuck.check_interval", 10)
 	viper.SetDefault("server_chain.stuck.time_threshold", 60)
 	viper.SetDefault("server_chain.transaction.timeout", 30)
+	viper.SetDefault("server_chain.transaction.pool.max_size", 60000000)
+	viper.SetDefault("server_chain.transaction.pool.max_per_client", 0)
+	viper.SetDefault("server_chain.transaction.pool.replace_fee_bump", 10)
+	viper.SetDefault("server_chain.transaction.dynamic_fee.enabled", false)
+	viper.SetDefault("server_chain.transaction.dynamic_fee.target_utilization", 50)
+	viper.SetDefault("server_chain.transaction.dynamic_fee.quotient", 8)
 	viper.SetDefault("server_chain.block.generation.retry_wait_time", 5)
 	viper.SetDefault("server_chain.block.proposal.max_wait_time", 200)
 	viper.SetDefault("server_chain.block.proposal.wait_mode", "static")
@@ -20,9 +20,9 @@ import (
 	"0chain.net/chaincore/block"
 	"0chain.net/chaincore/node"
 	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
 	"0chain.net/core/datastore"
-	"0chain.net/core/encryption"
 	"0chain.net/core/logging"
 	"0chain.net/core/util"
 	"go.uber.org/zap"
@@ -74,9 +74,15 @@ type Signer func(h string) (string, error)
 
 //ComputeHashAndSign compute Hash and sign the transaction
 func (t *Transaction) ComputeHashAndSign(handler Signer) error {
-	hashdata := fmt.Sprintf("%v:%v:%v:%v:%v", t.CreationDate, t.ClientID,
-		t.ToClientID, t.Value, encryption.Hash(t.TransactionData))
-	t.Hash = encryption.Hash(hashdata)
+	// the hash data of the chain's transaction, to keep the same format
+	t.Hash = (&transaction.Transaction{
+		CreationDate:    t.CreationDate,
+		ClientID:        t.ClientID,
+		ToClientID:      t.ToClientID,
+		Value:           t.Value,
+		TransactionData: t.TransactionData,
+		FeePayerID:      t.FeePayerID,
+	}).ComputeHash()
 	var err error
 	t.Signature, err = handler(t.Hash)
 	if err != nil {
@@ -85,6 +91,14 @@ func (t *Transaction) ComputeHashAndSign(handler Signer) error {
 	return nil
 }
 
+/*SignFeePayer - sign the hashed transaction with its fee by the fee payer of
+* the sponsored transaction */
+func (t *Transaction) SignFeePayer(handler Signer) error {
+	var err error
+	t.FeePayerSignature, err = handler(transaction.FeePayerHash(t.Hash, t.Fee))
+	return err
+}
+
 /////////////// Plain Transaction ///////////
 
 //NewHTTPRequest to use in sending http requests
@@ -17,6 +17,7 @@ import (
 	"0chain.net/chaincore/block"
 	"0chain.net/chaincore/node"
 	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
 	"0chain.net/core/encryption"
 	"0chain.net/core/logging"
@@ -77,6 +78,22 @@ func TestTransaction_ComputeHashAndSign(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	sponsored := *txn
+	sponsored.FeePayerID = "sponsor"
+	wantSponsored := sponsored
+	wantSponsored.Hash = (&transaction.Transaction{
+		CreationDate:    sponsored.CreationDate,
+		ClientID:        sponsored.ClientID,
+		ToClientID:      sponsored.ToClientID,
+		Value:           sponsored.Value,
+		TransactionData: sponsored.TransactionData,
+		FeePayerID:      sponsored.FeePayerID,
+	}).ComputeHash()
+	wantSponsored.Signature, err = handler(wantSponsored.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	type fields struct {
 		Hash              string
 		Version           string
@@ -92,6 +109,8 @@ func TestTransaction_ComputeHashAndSign(t *testing.T) {
 		TransactionType   int
 		TransactionOutput string
 		OutputHash        string
+		FeePayerID        string
+		FeePayerSignature string
 	}
 	type args struct {
 		handler Signer
@@ -110,6 +129,13 @@ func TestTransaction_ComputeHashAndSign(t *testing.T) {
 			wantErr: false,
 			want:    &want,
 		},
+		{
+			name:    "OK_Sponsored",
+			fields:  fields(sponsored),
+			args:    args{handler: handler},
+			wantErr: false,
+			want:    &wantSponsored,
+		},
 		{
 			name:   "ERR",
 			fields: fields(*txn),
@@ -140,6 +166,8 @@ func TestTransaction_ComputeHashAndSign(t *testing.T) {
 				TransactionType:   tt.fields.TransactionType,
 				TransactionOutput: tt.fields.TransactionOutput,
 				OutputHash:        tt.fields.OutputHash,
+				FeePayerID:        tt.fields.FeePayerID,
+				FeePayerSignature: tt.fields.FeePayerSignature,
 			}
 			if err := txn.ComputeHashAndSign(tt.args.handler); (err != nil) != tt.wantErr {
 				t.Errorf("ComputeHashAndSign() error = %v, wantErr %v", err, tt.wantErr)
@@ -20,6 +20,8 @@ type Transaction struct {
 	TransactionType   int              `json:"transaction_type,omitempty"`
 	TransactionOutput string           `json:"transaction_output,omitempty"`
 	OutputHash        string           `json:"txn_output_hash"`
+	FeePayerID        string           `json:"fee_payer_id,omitempty"`
+	FeePayerSignature string           `json:"fee_payer_signature,omitempty"`
 }
 
 const (
@@ -41,6 +43,9 @@ const (
 
 	//TxnTypeSmartContract A smart contract transaction type
 	TxnTypeSmartContract = 1000
+
+	//TxnTypeMultiCall A transaction of the smart contract calls executed all or none
+	TxnTypeMultiCall = 1002
 )
 
 //SmartContractTxnData Smart Contract Txn Data
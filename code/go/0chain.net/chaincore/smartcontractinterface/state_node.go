@@ -0,0 +1,115 @@
+package smartcontractinterface
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"0chain.net/core/datastore"
+	"0chain.net/core/encryption"
+	"0chain.net/core/util"
+)
+
+// StateNodeDecoder - implemented by the smart contracts that can decode
+// their nodes of the state, e.g. for the state diff diagnostics.
+type StateNodeDecoder interface {
+	// DecodeStateNode returns the type name and the decoded value if the
+	// value of the state path is a node of the smart contract.
+	DecodeStateNode(path util.Path, value []byte) (name string, node interface{}, ok bool)
+}
+
+// StateNodeType - a type of smart contract nodes of the state. A value is of
+// the type if its path is the path of one of the Keys, or, for the types
+// without the keys, if it is a JSON object having all the Fields and no
+// fields unknown to the type.
+type StateNodeType struct {
+	Name   string
+	Keys   []datastore.Key
+	Fields []string
+	New    func() interface{}
+}
+
+// StateNodePath - the state path of the smart contract node key.
+func StateNodePath(key datastore.Key) util.Path {
+	return util.Path(encryption.Hash(key))
+}
+
+// DecodeStateNode - decode the value by the first matching type.
+func DecodeStateNode(types []StateNodeType, path util.Path, value []byte) (
+	name string, node interface{}, ok bool) {
+
+	if util.IsTypedValue(value) {
+		return decodeTypedStateNode(types, path, value)
+	}
+	for _, nt := range types {
+		for _, key := range nt.Keys {
+			if bytes.Equal(StateNodePath(key), path) {
+				if node, ok = decodeStateNode(nt, value); ok {
+					return nt.Name, node, true
+				}
+			}
+		}
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return "", nil, false
+	}
+	for _, nt := range types {
+		if len(nt.Keys) > 0 || !hasStateNodeFields(fields, nt.Fields) {
+			continue
+		}
+		if node, ok = decodeStateNode(nt, value); ok {
+			return nt.Name, node, true
+		}
+	}
+	return "", nil, false
+}
+
+// decodeTypedStateNode matches the type of the typed value with the types,
+// the keyed types match the paths of their keys only.
+func decodeTypedStateNode(types []StateNodeType, path util.Path, value []byte) (
+	string, interface{}, bool) {
+
+	_, node, err := util.DecodeTypedValue(value)
+	if err != nil {
+		return "", nil, false
+	}
+	typ := reflect.TypeOf(node)
+	for _, nt := range types {
+		if reflect.TypeOf(nt.New()) != typ {
+			continue
+		}
+		if len(nt.Keys) == 0 {
+			return nt.Name, node, true
+		}
+		for _, key := range nt.Keys {
+			if bytes.Equal(StateNodePath(key), path) {
+				return nt.Name, node, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+func hasStateNodeFields(fields map[string]json.RawMessage, names []string) bool {
+	if len(names) == 0 {
+		return false
+	}
+	for _, name := range names {
+		if _, ok := fields[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeStateNode(nt StateNodeType, value []byte) (interface{}, bool) {
+	node := nt.New()
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(node); err != nil {
+		return nil, false
+	}
+	return node, true
+}
@@ -0,0 +1,105 @@
+package smartcontractinterface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/core/util"
+)
+
+type testStateNode struct {
+	ID    string `json:"id"`
+	Value int    `json:"value"`
+}
+
+type testStateConfig struct {
+	Limit int `json:"limit"`
+}
+
+func TestDecodeStateNode(t *testing.T) {
+	types := []StateNodeType{
+		{
+			Name: "config",
+			Keys: []string{"sc:config"},
+			New:  func() interface{} { return new(testStateConfig) },
+		},
+		{
+			Name:   "node",
+			Fields: []string{"id", "value"},
+			New:    func() interface{} { return new(testStateNode) },
+		},
+	}
+
+	name, node, ok := DecodeStateNode(types, StateNodePath("sc:config"),
+		[]byte(`{"limit":10}`))
+	require.True(t, ok)
+	require.Equal(t, "config", name)
+	require.Equal(t, &testStateConfig{Limit: 10}, node)
+
+	// the keyed types are not matched by fields
+	_, _, ok = DecodeStateNode(types, util.Path("other"), []byte(`{"limit":10}`))
+	require.False(t, ok)
+
+	name, node, ok = DecodeStateNode(types, util.Path("other"),
+		[]byte(`{"id":"a","value":1}`))
+	require.True(t, ok)
+	require.Equal(t, "node", name)
+	require.Equal(t, &testStateNode{ID: "a", Value: 1}, node)
+
+	// unknown fields
+	_, _, ok = DecodeStateNode(types, util.Path("other"),
+		[]byte(`{"id":"a","value":1,"extra":true}`))
+	require.False(t, ok)
+
+	_, _, ok = DecodeStateNode(types, util.Path("other"), []byte{1, 2, 3})
+	require.False(t, ok)
+}
+
+func init() {
+	util.RegisterValueType(0xff10, "sci.test_state_node", &testStateNode{})
+	util.RegisterValueType(0xff11, "sci.test_state_config", &testStateConfig{})
+}
+
+func TestDecodeStateNode_Typed(t *testing.T) {
+	prev := util.TypedValueEncoding()
+	util.SetTypedValueEncoding(true)
+	defer util.SetTypedValueEncoding(prev)
+
+	types := []StateNodeType{
+		{
+			Name: "config",
+			Keys: []string{"sc:config"},
+			New:  func() interface{} { return new(testStateConfig) },
+		},
+		{
+			Name:   "node",
+			Fields: []string{"id", "value"},
+			New:    func() interface{} { return new(testStateNode) },
+		},
+	}
+
+	value, err := util.EncodeValue(&testStateNode{ID: "a", Value: 1})
+	require.NoError(t, err)
+	require.True(t, util.IsTypedValue(value))
+	name, node, ok := DecodeStateNode(types, util.Path("other"), value)
+	require.True(t, ok)
+	require.Equal(t, "node", name)
+	require.Equal(t, &testStateNode{ID: "a", Value: 1}, node)
+
+	value, err = util.EncodeValue(&testStateConfig{Limit: 10})
+	require.NoError(t, err)
+	name, node, ok = DecodeStateNode(types, StateNodePath("sc:config"), value)
+	require.True(t, ok)
+	require.Equal(t, "config", name)
+	require.Equal(t, &testStateConfig{Limit: 10}, node)
+
+	// the keyed types are matched by the paths of the keys only
+	_, _, ok = DecodeStateNode(types, util.Path("other"), value)
+	require.False(t, ok)
+
+	// not a type of the smart contract
+	_, _, ok = DecodeStateNode(types[:1], util.Path("other"),
+		[]byte{util.TypedValueMagic, util.ValueCodecMsgpack, 0xff, 0x10, 0x80})
+	require.False(t, ok)
+}
@@ -23,6 +23,19 @@ type State struct {
 	TxnHashBytes []byte  `json:"-" msgpack:"t"`
 	Round        int64   `json:"round" msgpack:"r"`
 	Balance      Balance `json:"balance" msgpack:"b"`
+	Nonce        int64   `json:"nonce" msgpack:"n,omitempty"`
+}
+
+// sizes of the encoded states, the states of the clients that never used
+// the nonce keep the legacy encoding without it to keep their hashes
+const (
+	legacyStateSize = 48
+	nonceStateSize  = legacyStateSize + 8
+)
+
+/*IsEncodedState - check if the data has the size of an encoded state */
+func IsEncodedState(data []byte) bool {
+	return len(data) == legacyStateSize || len(data) == nonceStateSize
 }
 
 /*GetHash - implement SecureSerializableValueI interface */
@@ -41,6 +54,9 @@ func (s *State) Encode() []byte {
 	buf.Write(s.TxnHashBytes)
 	binary.Write(buf, binary.LittleEndian, s.Round)
 	binary.Write(buf, binary.LittleEndian, s.Balance)
+	if s.Nonce != 0 {
+		binary.Write(buf, binary.LittleEndian, s.Nonce)
+	}
 	return buf.Bytes()
 }
 
@@ -57,6 +73,10 @@ func (s *State) Decode(data []byte) error {
 	binary.Read(buf, binary.LittleEndian, &balance)
 	s.Round = origin
 	s.Balance = Balance(balance)
+	s.Nonce = 0
+	if len(data) == nonceStateSize {
+		binary.Read(buf, binary.LittleEndian, &s.Nonce)
+	}
 	return nil
 }
 
@@ -70,6 +90,11 @@ func (s *State) SetRound(round int64) {
 	s.Round = round
 }
 
+/*NextNonce - the nonce expected in the next transaction of the client */
+func (s *State) NextNonce() int64 {
+	return s.Nonce + 1
+}
+
 //SetTxnHash - set the hash of the txn that's modifying this state
 func (s *State) SetTxnHash(txnHash string) error {
 	hashBytes, err := hex.DecodeString(txnHash)
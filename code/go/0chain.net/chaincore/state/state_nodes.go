@@ -18,6 +18,9 @@ type Nodes struct {
 	datastore.IDField
 	Version string      `json:"version"`
 	Nodes   []util.Node `json:"-"`
+	// Complete is set when the nodes are the complete subtree of a path
+	// prefix requested by a range based state sync.
+	Complete bool `json:"-"`
 }
 
 //NewStateNodes - create a new partial state object with initialization
@@ -90,6 +93,9 @@ func (ns *Nodes) MartialStateNodes(data map[string]interface{}) ([]byte, error)
 		nodes[idx] = nd.Encode()
 	}
 	data["nodes"] = nodes
+	if ns.Complete {
+		data["complete"] = true
+	}
 	b, err := json.Marshal(data)
 	if err != nil {
 		logging.Logger.Error("marshal JSON - state nodes", zap.Error(err))
@@ -141,5 +147,6 @@ func (ns *Nodes) UnmarshalStateNodes(obj map[string]interface{}) error {
 		logging.Logger.Error("unmarshal json - no nodes", zap.Any("obj", obj))
 		return common.ErrInvalidData
 	}
+	ns.Complete, _ = obj["complete"].(bool)
 	return nil
 }
@@ -33,6 +33,7 @@ func TestState_GetHash(t *testing.T) {
 		TxnHashBytes []byte
 		Round        int64
 		Balance      Balance
+		Nonce        int64
 	}
 	tests := []struct {
 		name   string
@@ -55,6 +56,7 @@ func TestState_GetHash(t *testing.T) {
 				TxnHashBytes: tt.fields.TxnHashBytes,
 				Round:        tt.fields.Round,
 				Balance:      tt.fields.Balance,
+				Nonce:        tt.fields.Nonce,
 			}
 			if got := s.GetHash(); got != tt.want {
 				t.Errorf("GetHash() = %v, want %v", got, tt.want)
@@ -73,6 +75,7 @@ func TestState_GetHashBytes(t *testing.T) {
 		TxnHashBytes []byte
 		Round        int64
 		Balance      Balance
+		Nonce        int64
 	}
 	tests := []struct {
 		name   string
@@ -95,6 +98,7 @@ func TestState_GetHashBytes(t *testing.T) {
 				TxnHashBytes: tt.fields.TxnHashBytes,
 				Round:        tt.fields.Round,
 				Balance:      tt.fields.Balance,
+				Nonce:        tt.fields.Nonce,
 			}
 			if got := s.GetHashBytes(); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetHashBytes() = %v, want %v", got, tt.want)
@@ -113,6 +117,7 @@ func TestState_Encode(t *testing.T) {
 		TxnHashBytes []byte
 		Round        int64
 		Balance      Balance
+		Nonce        int64
 	}
 	tests := []struct {
 		name   string
@@ -135,6 +140,25 @@ func TestState_Encode(t *testing.T) {
 				return buf.Bytes()
 			}(),
 		},
+		{
+			name: "Nonce",
+			fields: fields{
+				TxnHashBytes: st.TxnHashBytes,
+				Round:        st.Round,
+				Balance:      st.Balance,
+				Nonce:        3,
+			},
+			want: func() []byte {
+				buf := bytes.NewBuffer(nil)
+				buf.Write(st.TxnHashBytes)
+				for _, v := range []int64{st.Round, int64(st.Balance), 3} {
+					if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return buf.Bytes()
+			}(),
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -146,6 +170,7 @@ func TestState_Encode(t *testing.T) {
 				TxnHashBytes: tt.fields.TxnHashBytes,
 				Round:        tt.fields.Round,
 				Balance:      tt.fields.Balance,
+				Nonce:        tt.fields.Nonce,
 			}
 			if got := s.Encode(); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Encode() = %v, want %v", got, tt.want)
@@ -161,11 +186,17 @@ func TestState_Decode(t *testing.T) {
 	st.TxnHash = ""
 	blob := st.Encode()
 
+	nst := makeTestState()
+	nst.TxnHash = ""
+	nst.Nonce = 7
+	nblob := nst.Encode()
+
 	type fields struct {
 		TxnHash      string
 		TxnHashBytes []byte
 		Round        int64
 		Balance      Balance
+		Nonce        int64
 	}
 	type args struct {
 		data []byte
@@ -183,6 +214,20 @@ func TestState_Decode(t *testing.T) {
 			wantErr: false,
 			want:    st,
 		},
+		{
+			name:    "Nonce",
+			fields:  fields{Nonce: 9},
+			args:    args{data: nblob},
+			wantErr: false,
+			want:    nst,
+		},
+		{
+			name:    "Legacy_Resets_Nonce",
+			fields:  fields{Nonce: 9},
+			args:    args{data: blob},
+			wantErr: false,
+			want:    st,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -194,6 +239,7 @@ func TestState_Decode(t *testing.T) {
 				TxnHashBytes: tt.fields.TxnHashBytes,
 				Round:        tt.fields.Round,
 				Balance:      tt.fields.Balance,
+				Nonce:        tt.fields.Nonce,
 			}
 			if err := s.Decode(tt.args.data); (err != nil) != tt.wantErr {
 				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
@@ -213,6 +259,7 @@ func TestState_ComputeProperties(t *testing.T) {
 		TxnHashBytes []byte
 		Round        int64
 		Balance      Balance
+		Nonce        int64
 	}
 	tests := []struct {
 		name   string
@@ -239,6 +286,7 @@ func TestState_ComputeProperties(t *testing.T) {
 				TxnHashBytes: tt.fields.TxnHashBytes,
 				Round:        tt.fields.Round,
 				Balance:      tt.fields.Balance,
+				Nonce:        tt.fields.Nonce,
 			}
 
 			s.ComputeProperties()
@@ -257,6 +305,7 @@ func TestState_Set(t *testing.T) {
 		TxnHashBytes []byte
 		Round        int64
 		Balance      Balance
+		Nonce        int64
 	}
 	type args struct {
 		round   int64
@@ -294,6 +343,7 @@ func TestState_Set(t *testing.T) {
 				TxnHashBytes: tt.fields.TxnHashBytes,
 				Round:        tt.fields.Round,
 				Balance:      tt.fields.Balance,
+				Nonce:        tt.fields.Nonce,
 			}
 
 			s.SetRound(tt.args.round)
@@ -25,6 +25,7 @@ import (
 
 var TXN_TIME_TOLERANCE int64
 var TXN_MIN_FEE int64
+var txnDynamicFee int64
 
 var transactionCount uint64 = 0
 var redis_txns string
@@ -53,11 +54,19 @@ type Transaction struct {
 	Signature       string           `json:"signature" msgpack:"s"`
 	CreationDate    common.Timestamp `json:"creation_date" msgpack:"ts"`
 	Fee             int64            `json:"transaction_fee" msgpack:"f"`
+	Nonce           int64            `json:"transaction_nonce,omitempty" msgpack:"n,omitempty"`
+	ValidFromRound  int64            `json:"valid_from_round,omitempty" msgpack:"vfr,omitempty"` // the first round the transaction can be included in
+	ExpiresAt       common.Timestamp `json:"expires_at,omitempty" msgpack:"exp,omitempty"`       // the transaction can't be included after it
+
+	FeePayerID        datastore.Key `json:"fee_payer_id,omitempty" msgpack:"fp,omitempty"` // the sponsor the fee is debited from
+	FeePayerSignature string        `json:"fee_payer_signature,omitempty" msgpack:"fps,omitempty"`
 
 	TransactionType   int    `json:"transaction_type" msgpack:"tt"`
 	TransactionOutput string `json:"transaction_output,omitempty" msgpack:"o,omitempty"`
 	OutputHash        string `json:"txn_output_hash" msgpack:"oh"`
 	Status            int    `json:"transaction_status" msgpack:"sot"`
+
+	Receipt *Receipt `json:"receipt,omitempty" msgpack:"rcp,omitempty"`
 }
 
 type TransactionFeeStats struct {
@@ -100,6 +109,11 @@ var exemptedSCFunctions = map[string]bool{
 
 // ValidateFee - Validate fee
 func (t *Transaction) ValidateFee() error {
+	return t.ValidateMinFee(GetTxnMinFee())
+}
+
+// ValidateMinFee - validate the fee is not less than the given minimum fee
+func (t *Transaction) ValidateMinFee(minFee int64) error {
 	if t.TransactionData != "" {
 		var smartContractData smartContractTransactionData
 		dataBytes := []byte(t.TransactionData)
@@ -110,7 +124,7 @@ func (t *Transaction) ValidateFee() error {
 			}
 		}
 	}
-	if t.Fee < TXN_MIN_FEE {
+	if t.Fee < minFee {
 		return common.InvalidRequest("The given fee is less than the minimum required fee to process the txn")
 	}
 	return nil
@@ -143,6 +157,9 @@ func (t *Transaction) ValidateWrtTimeForBlock(ctx context.Context, ts common.Tim
 	if t.Value < 0 {
 		return common.InvalidRequest("value must be greater than or equal to zero")
 	}
+	if t.Nonce < 0 {
+		return common.InvalidRequest("nonce must be greater than or equal to zero")
+	}
 	if !encryption.IsHash(t.ToClientID) && t.ToClientID != "" {
 		return common.InvalidRequest("to client id must be a hexadecimal hash")
 	}
@@ -160,9 +177,30 @@ func (t *Transaction) ValidateWrtTimeForBlock(ctx context.Context, ts common.Tim
 	if !common.WithinTime(int64(ts), int64(t.CreationDate), TXN_TIME_TOLERANCE) {
 		return common.InvalidRequest(fmt.Sprintf("Transaction creation time not within tolerance: ts=%v txn.creation_date=%v", ts, t.CreationDate))
 	}
+	if t.ValidFromRound < 0 {
+		return common.InvalidRequest("valid from round must be greater than or equal to zero")
+	}
+	if t.ExpiresAt != 0 && t.ExpiresAt < t.CreationDate {
+		return common.InvalidRequest("expires at must not be before the creation date")
+	}
+	if t.IsExpired(ts) {
+		return common.NewErrorf("txn_expired",
+			"transaction expired at %v, ts=%v", t.ExpiresAt, ts)
+	}
 	if t.ClientID == t.ToClientID {
 		return common.InvalidRequest("from and to client should be different")
 	}
+	if err = t.validateFeePayer(); err != nil {
+		return err
+	}
+	if t.TransactionType == TxnTypeMultiCall {
+		if t.ToClientID != "" {
+			return common.InvalidRequest("multi-call transaction has the addresses of its calls only")
+		}
+		if _, err = t.GetCalls(); err != nil {
+			return err
+		}
+	}
 	err = t.VerifyHash(ctx)
 	if err != nil {
 		return err
@@ -172,6 +210,9 @@ func (t *Transaction) ValidateWrtTimeForBlock(ctx context.Context, ts common.Tim
 		if err != nil {
 			return err
 		}
+		if err = t.VerifyFeePayerSignature(ctx); err != nil {
+			return err
+		}
 	}
 	if t.OutputHash != "" {
 		err = t.VerifyOutputHash(ctx)
@@ -236,12 +277,39 @@ func (t *Transaction) GetClient(ctx context.Context) (*client.Client, error) {
 	return co, nil
 }
 
-/*HashData - data used to hash the transaction */
+/*HashData - data used to hash the transaction, the nonce, the validity
+* window and the fee payer are a part of it only if given to keep the hashes of
+* the transactions without them */
 func (t *Transaction) HashData() string {
 	hashdata := common.TimeToString(t.CreationDate) + ":" + t.ClientID + ":" + t.ToClientID + ":" + strconv.FormatInt(t.Value, 10) + ":" + encryption.Hash(t.TransactionData)
+	hasWindow := t.ValidFromRound != 0 || t.ExpiresAt != 0 || t.FeePayerID != ""
+	if t.Nonce != 0 || hasWindow {
+		hashdata += ":" + strconv.FormatInt(t.Nonce, 10)
+	}
+	if hasWindow {
+		hashdata += ":" + strconv.FormatInt(t.ValidFromRound, 10) + ":" + common.TimeToString(t.ExpiresAt)
+	}
+	if t.FeePayerID != "" {
+		hashdata += ":" + t.FeePayerID
+	}
 	return hashdata
 }
 
+// IsExpired - whether the transaction is expired at the given time
+func (t *Transaction) IsExpired(ts common.Timestamp) bool {
+	return t.ExpiresAt != 0 && ts > t.ExpiresAt
+}
+
+/*ValidateWrtRound - validate the transaction can be included in a block of
+* the round */
+func (t *Transaction) ValidateWrtRound(round int64) error {
+	if round < t.ValidFromRound {
+		return common.NewErrorf("txn_not_yet_valid",
+			"transaction is valid from round %v, round %v", t.ValidFromRound, round)
+	}
+	return nil
+}
+
 /*ComputeHash - compute the hash from the various components of the transaction */
 func (t *Transaction) ComputeHash() string {
 	return encryption.Hash(t.HashData())
@@ -262,6 +330,10 @@ func (t *Transaction) VerifySignature(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	return t.verifySignature(sigScheme)
+}
+
+func (t *Transaction) verifySignature(sigScheme encryption.SignatureScheme) error {
 	correctSignature, err := sigScheme.Verify(t.Signature, t.Hash)
 	if err != nil {
 		return err
@@ -310,7 +382,7 @@ func SetupEntity(store datastore.Store) {
 	transactionEntityMetadata.Store = store
 
 	datastore.RegisterEntityMetadata("txn", transactionEntityMetadata)
-	txnEntityCollection = &datastore.EntityCollection{CollectionName: "collection.txn", CollectionSize: 60000000, CollectionDuration: time.Hour}
+	txnEntityCollection = &datastore.EntityCollection{CollectionName: "collection.txn", CollectionSize: TXN_POOL_MAX_SIZE, CollectionDuration: time.Hour}
 
 	var chunkingOptions = datastore.ChunkingOptions{
 		EntityMetadata:   transactionEntityMetadata,
@@ -387,6 +459,20 @@ func SetTxnFee(min int64) {
 	TXN_MIN_FEE = min
 }
 
+/*SetTxnDynamicFee - set the minimum fee of the transactions by the
+* utilization of the recent blocks, TXN_MIN_FEE is the floor of it */
+func SetTxnDynamicFee(fee int64) {
+	atomic.StoreInt64(&txnDynamicFee, fee)
+}
+
+// GetTxnMinFee - the minimum fee of the transactions accepted now
+func GetTxnMinFee() int64 {
+	if fee := atomic.LoadInt64(&txnDynamicFee); fee > TXN_MIN_FEE {
+		return fee
+	}
+	return TXN_MIN_FEE
+}
+
 func GetTransactionCount() uint64 {
 	return atomic.LoadUint64(&transactionCount)
 }
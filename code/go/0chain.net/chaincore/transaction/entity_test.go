@@ -0,0 +1,102 @@
+package transaction
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/config"
+	"0chain.net/core/common"
+	"0chain.net/core/encryption"
+)
+
+func TestTransaction_HashData_Nonce(t *testing.T) {
+	txn := &Transaction{
+		ClientID:        "client",
+		ToClientID:      "to",
+		Value:           10,
+		TransactionData: "data",
+		CreationDate:    common.Timestamp(1600000000),
+	}
+	legacy := txn.ComputeHash()
+	require.Equal(t, "1600000000:client:to:10:"+encryption.Hash("data"),
+		txn.HashData())
+
+	txn.Nonce = 1
+	require.NotEqual(t, legacy, txn.ComputeHash())
+	require.Equal(t, "1600000000:client:to:10:"+encryption.Hash("data")+":1",
+		txn.HashData())
+}
+
+func TestTransaction_ValidateMinFee(t *testing.T) {
+	txn := &Transaction{Fee: 10}
+	require.NoError(t, txn.ValidateMinFee(10))
+	require.Error(t, txn.ValidateMinFee(11))
+
+	txn.TransactionData = `{"name":"add_miner","input":{}}`
+	require.NoError(t, txn.ValidateMinFee(11))
+
+	defer SetTxnFee(TXN_MIN_FEE)
+	SetTxnFee(5)
+	SetTxnDynamicFee(11)
+	defer SetTxnDynamicFee(0)
+	require.EqualValues(t, 11, GetTxnMinFee())
+	txn.TransactionData = ""
+	require.Error(t, txn.ValidateFee())
+
+	SetTxnDynamicFee(3)
+	require.EqualValues(t, 5, GetTxnMinFee())
+	require.NoError(t, txn.ValidateFee())
+}
+
+func TestTransaction_HashData_ValidityWindow(t *testing.T) {
+	txn := &Transaction{
+		ClientID:        "client",
+		ToClientID:      "to",
+		Value:           10,
+		TransactionData: "data",
+		CreationDate:    common.Timestamp(1600000000),
+	}
+	prefix := "1600000000:client:to:10:" + encryption.Hash("data")
+
+	txn.ExpiresAt = 1600000030
+	require.Equal(t, prefix+":0:0:1600000030", txn.HashData())
+
+	txn.Nonce = 1
+	txn.ValidFromRound = 100
+	require.Equal(t, prefix+":1:100:1600000030", txn.HashData())
+
+	txn.ExpiresAt = 0
+	require.Equal(t, prefix+":1:100:0", txn.HashData())
+}
+
+func TestTransaction_ValidityWindow(t *testing.T) {
+	defer SetTxnTimeout(TXN_TIME_TOLERANCE)
+	SetTxnTimeout(30)
+	txn := &Transaction{
+		ClientID:     "client",
+		ChainID:      config.GetServerChainID(),
+		CreationDate: common.Now(),
+	}
+	txn.Hash = txn.ComputeHash()
+	require.False(t, txn.IsExpired(txn.CreationDate+1000))
+	require.NoError(t, txn.ValidateWrtRound(0))
+
+	txn.ValidFromRound = 100
+	txn.ExpiresAt = txn.CreationDate + 10
+	txn.Hash = txn.ComputeHash()
+	require.False(t, txn.IsExpired(txn.ExpiresAt))
+	require.True(t, txn.IsExpired(txn.ExpiresAt+1))
+	require.Error(t, txn.ValidateWrtRound(99))
+	require.NoError(t, txn.ValidateWrtRound(100))
+
+	err := txn.ValidateWrtTimeForBlock(context.Background(), txn.ExpiresAt, false)
+	require.NoError(t, err)
+	err = txn.ValidateWrtTimeForBlock(context.Background(), txn.ExpiresAt+1, false)
+	requireErrorCode(t, err, "txn_expired")
+
+	txn.ExpiresAt = txn.CreationDate - 1
+	txn.Hash = txn.ComputeHash()
+	require.Error(t, txn.ValidateWrtTimeForBlock(context.Background(), txn.CreationDate, false))
+}
@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"context"
+	"strconv"
+
+	"0chain.net/chaincore/client"
+	"0chain.net/core/common"
+	"0chain.net/core/encryption"
+)
+
+/*GetFeePayerID - the client the fee of the transaction is debited from, the
+* fee payer of a sponsored transaction and the client otherwise */
+func (t *Transaction) GetFeePayerID() string {
+	if t.FeePayerID != "" {
+		return t.FeePayerID
+	}
+	return t.ClientID
+}
+
+/*FeePayerHash - the hash the fee payer signs, the fee is not a part of the
+* hash of the transaction so it's signed by the fee payer along with it */
+func FeePayerHash(hash string, fee int64) string {
+	return encryption.Hash(hash + ":" + strconv.FormatInt(fee, 10))
+}
+
+// validateFeePayer - validate the fee payer of a sponsored transaction
+func (t *Transaction) validateFeePayer() error {
+	if t.FeePayerID == "" {
+		if t.FeePayerSignature != "" {
+			return common.InvalidRequest("fee payer signature without the fee payer")
+		}
+		return nil
+	}
+	if !encryption.IsHash(t.FeePayerID) {
+		return common.InvalidRequest("fee payer id must be a hexadecimal hash")
+	}
+	if t.FeePayerID == t.ClientID || t.FeePayerID == t.ToClientID {
+		return common.InvalidRequest("fee payer should be different from the from and to clients")
+	}
+	if t.FeePayerSignature == "" {
+		return common.InvalidRequest("fee payer signature required")
+	}
+	return nil
+}
+
+/*GetFeePayerSignatureScheme - get the signature scheme of the fee payer of a
+* sponsored transaction, the fee payer should be registered */
+func (t *Transaction) GetFeePayerSignatureScheme(ctx context.Context) (encryption.SignatureScheme, error) {
+	co, err := client.GetClient(ctx, t.FeePayerID)
+	if err != nil || co == nil || co.PublicKey == "" {
+		return nil, common.NewErrorf("invalid_fee_payer",
+			"fee payer %v doesn't exist, please register", t.FeePayerID)
+	}
+	return co.GetSignatureScheme(), nil
+}
+
+/*VerifyFeePayerSignature - verify the fee payer's signature of a sponsored
+* transaction, nothing to verify for the others */
+func (t *Transaction) VerifyFeePayerSignature(ctx context.Context) error {
+	if t.FeePayerID == "" {
+		return nil
+	}
+	sigScheme, err := t.GetFeePayerSignatureScheme(ctx)
+	if err != nil {
+		return err
+	}
+	return t.verifyFeePayerSignature(sigScheme)
+}
+
+func (t *Transaction) verifyFeePayerSignature(sigScheme encryption.SignatureScheme) error {
+	ok, err := sigScheme.Verify(t.FeePayerSignature, FeePayerHash(t.Hash, t.Fee))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return common.NewError("invalid_fee_payer_signature", "Invalid fee payer signature")
+	}
+	return nil
+}
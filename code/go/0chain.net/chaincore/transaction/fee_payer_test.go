@@ -0,0 +1,97 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/client"
+	"0chain.net/core/common"
+	"0chain.net/core/encryption"
+	"0chain.net/core/memorystore"
+)
+
+func TestTransaction_HashData_FeePayer(t *testing.T) {
+	txn := &Transaction{
+		ClientID:        "client",
+		ToClientID:      "to",
+		Value:           10,
+		TransactionData: "data",
+		CreationDate:    common.Timestamp(1600000000),
+		FeePayerID:      "sponsor",
+	}
+	prefix := "1600000000:client:to:10:" + encryption.Hash("data")
+	require.Equal(t, prefix+":0:0:0:sponsor", txn.HashData())
+	require.Equal(t, "sponsor", txn.GetFeePayerID())
+
+	txn.Nonce = 2
+	txn.ExpiresAt = 1600000030
+	require.Equal(t, prefix+":2:0:1600000030:sponsor", txn.HashData())
+
+	txn.FeePayerID = ""
+	require.Equal(t, "client", txn.GetFeePayerID())
+}
+
+func TestTransaction_FeePayer(t *testing.T) {
+	ctx := initMempoolTest(t, 100, 0)
+	client.SetClientSignatureScheme("ed25519")
+	defer client.SetClientSignatureScheme(clientSignatureScheme)
+	client.SetupEntity(memorystore.GetStorageProvider())
+	defer SetTxnTimeout(TXN_TIME_TOLERANCE)
+	SetTxnTimeout(30)
+
+	newClient := func() (string, encryption.SignatureScheme) {
+		scheme := encryption.NewED25519Scheme()
+		require.NoError(t, scheme.GenerateKeys())
+		co := client.NewClient()
+		co.SetPublicKey(scheme.GetPublicKey())
+		_, err := client.PutClient(ctx, co)
+		require.NoError(t, err)
+		return co.ID, scheme
+	}
+	var (
+		clientID, clientScheme   = newClient()
+		sponsorID, sponsorScheme = newClient()
+		_, otherScheme           = newClient()
+	)
+	newTxn := func(payerScheme encryption.SignatureScheme) *Transaction {
+		txn := transactionEntityMetadata.Instance().(*Transaction)
+		txn.ClientID = clientID
+		txn.ToClientID = encryption.Hash("to")
+		txn.Fee = 10
+		txn.FeePayerID = sponsorID
+		_, err := txn.Sign(clientScheme)
+		require.NoError(t, err)
+		txn.FeePayerSignature, err = payerScheme.Sign(FeePayerHash(txn.Hash, txn.Fee))
+		require.NoError(t, err)
+		return txn
+	}
+
+	txn := newTxn(sponsorScheme)
+	require.NoError(t, txn.Validate(ctx))
+
+	// the fee is signed by the fee payer only
+	txn.Fee = 20
+	requireErrorCode(t, txn.Validate(ctx), "invalid_fee_payer_signature")
+
+	txn = newTxn(otherScheme)
+	requireErrorCode(t, txn.Validate(ctx), "invalid_fee_payer_signature")
+	results := PutTransactions(ctx, []*Transaction{newTxn(sponsorScheme), txn}, 1, nil)
+	require.Equal(t, "", results[0].Code)
+	require.Equal(t, "invalid_fee_payer_signature", results[1].Code)
+
+	txn = newTxn(sponsorScheme)
+	txn.FeePayerSignature = ""
+	require.Error(t, txn.Validate(ctx))
+
+	txn.FeePayerID = clientID
+	txn.Hash = txn.ComputeHash()
+	require.Error(t, txn.Validate(ctx))
+
+	txn.FeePayerID = encryption.Hash("unregistered")
+	_, err := txn.Sign(clientScheme)
+	require.NoError(t, err)
+	txn.FeePayerSignature, err = sponsorScheme.Sign(FeePayerHash(txn.Hash, txn.Fee))
+	require.NoError(t, err)
+	requireErrorCode(t, txn.VerifyFeePayerSignature(ctx), "invalid_fee_payer")
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"0chain.net/core/common"
 	"0chain.net/core/datastore"
+	"0chain.net/core/encryption"
 	"0chain.net/core/logging"
 	"0chain.net/core/memorystore"
 	"go.uber.org/zap"
@@ -15,6 +17,7 @@ import (
 /*SetupHandlers sets up the necessary API end points */
 func SetupHandlers() {
 	http.HandleFunc("/v1/transaction/get", common.UserRateLimit(common.ToJSONResponse(memorystore.WithConnectionHandler(GetTransaction))))
+	http.HandleFunc("/v1/transaction/pending", common.UserRateLimit(common.ToJSONResponse(memorystore.WithConnectionHandler(GetPendingTransactions))))
 }
 
 /*GetTransaction - given an id returns the transaction information */
@@ -43,8 +46,13 @@ func PutTransaction(ctx context.Context, entity datastore.Entity) (interface{},
 	if err != nil || cli == nil  || cli.PublicKey == "" {
 		return nil, common.NewError("put transaction error", fmt.Sprintf("client %v doesn't exist, please register", txn.ClientID))
 	}
+	if err := admitTransaction(ctx, txn); err != nil {
+		logging.Logger.Info("put transaction - not admitted", zap.String("txn", txn.Hash), zap.Error(err))
+		return nil, err
+	}
 	if datastore.DoAsync(ctx, txn) {
 		IncTransactionCount()
+		SetTxnStatus(txn.Hash, TxnStatusPending, "")
 		return txn, nil
 	}
 	err = entity.GetEntityMetadata().GetStore().Write(ctx, txn)
@@ -53,5 +61,128 @@ func PutTransaction(ctx context.Context, entity datastore.Entity) (interface{},
 		return nil, err
 	}
 	IncTransactionCount()
+	SetTxnStatus(txn.Hash, TxnStatusPending, "")
 	return txn, nil
 }
+
+// PutTransactionResult - the result of the put of a transaction of a batch
+type PutTransactionResult struct {
+	Hash  string `json:"hash"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (r *PutTransactionResult) setError(err error) {
+	r.Code = "put_transaction_error"
+	if cerr, ok := err.(*common.Error); ok {
+		r.Code = cerr.Code
+	}
+	r.Error = err.Error()
+}
+
+/*PutTransactions - Given a batch of transactions, it stores the valid ones
+* at once. The signatures are verified in parallel, each of the given size of
+* the transactions by a goroutine. The results are in the order of the batch. */
+func PutTransactions(ctx context.Context, txns []*Transaction, batchSize int,
+	validate func(txn *Transaction) error) []*PutTransactionResult {
+
+	var (
+		results = make([]*PutTransactionResult, len(txns))
+		schemes = make([]encryption.SignatureScheme, len(txns))
+		payers  = make([]encryption.SignatureScheme, len(txns))
+		now     = common.Now()
+	)
+	for idx, txn := range txns {
+		txn.ComputeProperties()
+		results[idx] = &PutTransactionResult{Hash: txn.Hash}
+		if validate != nil {
+			if err := validate(txn); err != nil {
+				results[idx].setError(err)
+				continue
+			}
+		}
+		cli, err := txn.GetClient(ctx)
+		if err != nil || cli == nil || cli.PublicKey == "" {
+			results[idx].setError(common.NewError("put transaction error", fmt.Sprintf("client %v doesn't exist, please register", txn.ClientID)))
+			continue
+		}
+		if txn.FeePayerID != "" {
+			if payers[idx], err = txn.GetFeePayerSignatureScheme(ctx); err != nil {
+				results[idx].setError(err)
+				continue
+			}
+		}
+		if schemes[idx], err = txn.GetSignatureScheme(ctx); err != nil {
+			results[idx].setError(err)
+		}
+	}
+
+	if batchSize <= 0 {
+		batchSize = len(txns)
+	}
+	var wg sync.WaitGroup
+	for start := 0; start < len(txns); start += batchSize {
+		end := start + batchSize
+		if end > len(txns) {
+			end = len(txns)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for idx := start; idx < end; idx++ {
+				if schemes[idx] == nil {
+					continue
+				}
+				txn := txns[idx]
+				err := txn.ValidateWrtTimeForBlock(ctx, now, false)
+				if err == nil {
+					err = txn.verifySignature(schemes[idx])
+				}
+				if err == nil && payers[idx] != nil {
+					err = txn.verifyFeePayerSignature(payers[idx])
+				}
+				if err != nil {
+					results[idx].setError(err)
+					schemes[idx] = nil
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	mstore, ok := transactionEntityMetadata.GetStore().(*memorystore.Store)
+	var admission *txnAdmission
+	if ok {
+		admission = newTxnAdmission(mstore)
+	}
+	var valid []datastore.Entity
+	for idx, txn := range txns {
+		if schemes[idx] == nil {
+			continue
+		}
+		if admission != nil {
+			if err := admission.admit(ctx, txn); err != nil {
+				results[idx].setError(err)
+				continue
+			}
+		}
+		valid = append(valid, txn)
+	}
+	if len(valid) == 0 {
+		return results
+	}
+	if err := transactionEntityMetadata.GetStore().MultiWrite(ctx, transactionEntityMetadata, valid); err != nil {
+		logging.Logger.Info("put transactions", zap.Int("count", len(valid)), zap.Error(err))
+		for idx := range txns {
+			if schemes[idx] != nil && results[idx].Code == "" {
+				results[idx].setError(err)
+			}
+		}
+		return results
+	}
+	for _, entity := range valid {
+		IncTransactionCount()
+		SetTxnStatus(entity.GetKey(), TxnStatusPending, "")
+	}
+	return results
+}
@@ -0,0 +1,90 @@
+package transaction
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/client"
+	"0chain.net/core/common"
+	"0chain.net/core/encryption"
+	"0chain.net/core/memorystore"
+)
+
+func TestPutTransactions(t *testing.T) {
+	ctx := initMempoolTest(t, 100, 2)
+	client.SetClientSignatureScheme("ed25519")
+	defer client.SetClientSignatureScheme(clientSignatureScheme)
+	client.SetupEntity(memorystore.GetStorageProvider())
+	defer SetTxnTimeout(TXN_TIME_TOLERANCE)
+	SetTxnTimeout(30)
+
+	newClient := func(register bool) encryption.SignatureScheme {
+		scheme := encryption.NewED25519Scheme()
+		require.NoError(t, scheme.GenerateKeys())
+		if register {
+			co := client.NewClient()
+			co.SetPublicKey(scheme.GetPublicKey())
+			_, err := client.PutClient(ctx, co)
+			require.NoError(t, err)
+		}
+		return scheme
+	}
+	newTxn := func(scheme encryption.SignatureScheme, nonce int64) *Transaction {
+		co := client.NewClient()
+		co.SetPublicKey(scheme.GetPublicKey())
+		txn := transactionEntityMetadata.Instance().(*Transaction)
+		txn.ClientID = co.ID
+		txn.ToClientID = encryption.Hash("to")
+		txn.Nonce = nonce
+		txn.Fee = 10
+		_, err := txn.Sign(scheme)
+		require.NoError(t, err)
+		return txn
+	}
+
+	var (
+		alice        = newClient(true)
+		bob          = newClient(true)
+		unregistered = newClient(false)
+		badSignature = newTxn(bob, 2)
+		rejected     = newTxn(bob, 3)
+	)
+	badSignature.Signature = newTxn(alice, 1).Signature
+	txns := []*Transaction{
+		newTxn(alice, 1),
+		newTxn(alice, 2),
+		newTxn(alice, 3),
+		newTxn(bob, 1),
+		badSignature,
+		newTxn(unregistered, 1),
+		rejected,
+	}
+	validate := func(txn *Transaction) error {
+		if txn == rejected {
+			return common.NewError("txn_exceed_max_payload", "rejected")
+		}
+		return nil
+	}
+	results := PutTransactions(ctx, txns, 2, validate)
+	require.Len(t, results, len(txns))
+
+	codes := make([]string, len(results))
+	for idx, result := range results {
+		require.Equal(t, txns[idx].Hash, result.Hash)
+		codes[idx] = result.Code
+	}
+	require.Equal(t, []string{"", "", "too_many_pending", "", "invalid_signature",
+		"put transaction error", "txn_exceed_max_payload"}, codes)
+
+	for idx, txn := range txns {
+		require.Equal(t, codes[idx] == "", isPending(ctx, txn), idx)
+	}
+
+	// the results of the failed write
+	results = PutTransactions(ctx, []*Transaction{newTxn(bob, 2)}, 2,
+		func(*Transaction) error { return errors.New("invalid") })
+	require.Equal(t, "put_transaction_error", results[0].Code)
+	require.Equal(t, "invalid", results[0].Error)
+}
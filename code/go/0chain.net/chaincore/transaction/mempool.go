@@ -0,0 +1,340 @@
+package transaction
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/logging"
+	"0chain.net/core/memorystore"
+	"go.uber.org/zap"
+)
+
+/*TXN_POOL_MAX_SIZE - the maximum number of the transactions pending in the
+* pool, the transactions with the lowest scores are evicted beyond it */
+var TXN_POOL_MAX_SIZE int64 = 60000000
+
+/*TXN_POOL_MAX_PER_CLIENT - the maximum number of the transactions of a client
+* pending in the pool, 0 for no limit */
+var TXN_POOL_MAX_PER_CLIENT int64
+
+/*TXN_REPLACE_FEE_BUMP - the percent a replacement of a pending transaction
+* with the same nonce should increase the fee by */
+var TXN_REPLACE_FEE_BUMP int64 = 10
+
+// SetTxnPoolLimits - set the limits of the pending transactions pool
+func SetTxnPoolLimits(maxSize, maxPerClient, replaceFeeBump int64) {
+	TXN_POOL_MAX_SIZE = maxSize
+	TXN_POOL_MAX_PER_CLIENT = maxPerClient
+	TXN_REPLACE_FEE_BUMP = replaceFeeBump
+}
+
+/*GetClientCollectionName - the collection of the client's pending
+* transactions by their nonces, it indexes the pool's collection */
+func (t *Transaction) GetClientCollectionName() string {
+	return t.GetCollectionName() + ":client:" + t.ClientID
+}
+
+/*EvictFromCollection - delete the transactions evicted from the pool, it
+* implements datastore.CollectionEvictor */
+func (t *Transaction) EvictFromCollection(ctx context.Context, keys []datastore.Key) {
+	if err := deletePoolTxns(ctx, keys); err != nil {
+		logging.Logger.Error("evict transactions", zap.Int("count", len(keys)), zap.Error(err))
+		return
+	}
+	for _, key := range keys {
+		SetTxnStatus(key, TxnStatusRejected, "evicted from the full pool")
+	}
+}
+
+func deletePoolTxns(ctx context.Context, keys []datastore.Key) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	txns := make([]datastore.Entity, len(keys))
+	for idx, key := range keys {
+		txn := transactionEntityMetadata.Instance().(*Transaction)
+		txn.SetKey(key)
+		txns[idx] = txn
+	}
+	return transactionEntityMetadata.GetStore().MultiDelete(ctx, transactionEntityMetadata, txns)
+}
+
+/*getPendingTxnKeys - the keys of the client's transactions pending in the
+* pool in the order of their nonces with the nonces, the keys of the
+* transactions no longer in the pool are removed from the client's collection */
+func getPendingTxnKeys(ctx context.Context, mstore *memorystore.Store, txn *Transaction) ([]datastore.Key, []int64, error) {
+	clientCollection := txn.GetClientCollectionName()
+	keys, nonces, err := mstore.GetCollectionRange(ctx, transactionEntityMetadata, clientCollection, math.MinInt64, math.MaxInt64, math.MaxInt32)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		pending []datastore.Key
+		pnonces []int64
+		stale   []datastore.Key
+	)
+	_, inPool, err := mstore.GetCollectionMemberScores(ctx, transactionEntityMetadata, txn.GetCollectionName(), keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	for idx, key := range keys {
+		if !inPool[idx] {
+			stale = append(stale, key)
+			continue
+		}
+		pending = append(pending, key)
+		pnonces = append(pnonces, nonces[idx])
+	}
+	if err := mstore.DeleteKeysFromCollection(ctx, transactionEntityMetadata, clientCollection, stale); err != nil {
+		return nil, nil, err
+	}
+	return pending, pnonces, nil
+}
+
+// replacementFee - the minimum fee of a replacement of the transaction
+func replacementFee(fee int64) int64 {
+	bump := fee * TXN_REPLACE_FEE_BUMP / 100
+	if bump < 1 {
+		bump = 1
+	}
+	return fee + bump
+}
+
+/*admitTransaction - admit the transaction to the pool of the pending
+* transactions. A pending transaction of the client with the same nonce is
+* replaced if the fee of the new one is bumped enough. The client's number of
+* the pending transactions is limited. When the pool is full the transactions
+* with the lowest scores are evicted for the transaction if its score is
+* higher, the score is the fee or the time when the fees are disabled. */
+func admitTransaction(ctx context.Context, txn *Transaction) error {
+	mstore, ok := transactionEntityMetadata.GetStore().(*memorystore.Store)
+	if !ok {
+		return nil
+	}
+	return newTxnAdmission(mstore).admit(ctx, txn)
+}
+
+// clientPending - the transactions of a client pending in the pool
+type clientPending struct {
+	keys   []datastore.Key
+	nonces []int64
+}
+
+/*txnAdmission - the admission of the transactions to the pool before they
+* are written, the transactions admitted are accounted for the ones admitted
+* next so that a batch of them is written at once */
+type txnAdmission struct {
+	mstore   *memorystore.Store
+	clients  map[datastore.Key]*clientPending
+	admitted map[datastore.Key]bool
+}
+
+func newTxnAdmission(mstore *memorystore.Store) *txnAdmission {
+	return &txnAdmission{
+		mstore:   mstore,
+		clients:  make(map[datastore.Key]*clientPending),
+		admitted: make(map[datastore.Key]bool),
+	}
+}
+
+func (ta *txnAdmission) getClientPending(ctx context.Context, txn *Transaction) (*clientPending, error) {
+	if cp, ok := ta.clients[txn.ClientID]; ok {
+		return cp, nil
+	}
+	keys, nonces, err := getPendingTxnKeys(ctx, ta.mstore, txn)
+	if err != nil {
+		return nil, err
+	}
+	cp := &clientPending{keys: keys, nonces: nonces}
+	ta.clients[txn.ClientID] = cp
+	return cp, nil
+}
+
+func (ta *txnAdmission) admit(ctx context.Context, txn *Transaction) error {
+	mstore := ta.mstore
+	collection := txn.GetCollectionName()
+	if ta.admitted[txn.Hash] {
+		return common.NewError("duplicate_transaction", "the transaction is already admitted")
+	}
+	if _, ok, err := mstore.GetCollectionMemberScore(ctx, transactionEntityMetadata, collection, txn.Hash); err != nil || ok {
+		return err // already pending
+	}
+	cp, err := ta.getClientPending(ctx, txn)
+	if err != nil {
+		return err
+	}
+
+	var replaced []datastore.Key
+	if txn.Nonce > 0 {
+		for idx, key := range cp.keys {
+			if cp.nonces[idx] != txn.Nonce {
+				continue
+			}
+			if ta.admitted[key] {
+				return common.NewErrorf("duplicate_nonce",
+					"transaction %v with nonce %v is already admitted", key, txn.Nonce)
+			}
+			pending := transactionEntityMetadata.Instance().(*Transaction)
+			if err := pending.Read(ctx, key); err != nil {
+				continue
+			}
+			if minFee := replacementFee(pending.Fee); txn.Fee < minFee {
+				return common.NewErrorf("replacement_underpriced",
+					"pending transaction %v with nonce %v, the fee should be at least %v",
+					key, txn.Nonce, minFee)
+			}
+			replaced = append(replaced, key)
+		}
+	}
+	// the client's transaction is counted and added at once not to go beyond
+	// the limit with the transactions admitted concurrently
+	maxPerClient := TXN_POOL_MAX_PER_CLIENT
+	if maxPerClient <= 0 {
+		maxPerClient = math.MaxInt64
+	}
+	clientCollection := txn.GetClientCollectionName()
+	added, err := mstore.AddKeyToCollectionWithinSize(ctx, transactionEntityMetadata, clientCollection,
+		txn.Hash, txn.Nonce, maxPerClient, replaced, txnEntityCollection.GetCollectionDuration())
+	if err != nil {
+		return err
+	}
+	if !added {
+		return common.NewErrorf("too_many_pending",
+			"client %v has %v pending transactions", txn.ClientID, len(cp.keys))
+	}
+	if err := ta.admitToPool(ctx, txn, replaced); err != nil {
+		// not admitted, the client's pending transactions are as before
+		if derr := mstore.DeleteKeysFromCollection(ctx, transactionEntityMetadata, clientCollection, []datastore.Key{txn.Hash}); derr != nil {
+			logging.Logger.Error("admit transaction - undo", zap.String("txn", txn.Hash), zap.Error(derr))
+		}
+		return err
+	}
+
+	for _, key := range replaced {
+		for idx := range cp.keys {
+			if cp.keys[idx] == key {
+				cp.keys = append(cp.keys[:idx], cp.keys[idx+1:]...)
+				cp.nonces = append(cp.nonces[:idx], cp.nonces[idx+1:]...)
+				break
+			}
+		}
+	}
+	cp.keys = append(cp.keys, txn.Hash)
+	cp.nonces = append(cp.nonces, txn.Nonce)
+	ta.admitted[txn.Hash] = true
+	return nil
+}
+
+/*admitToPool - make the room in the pool for the transaction and delete the
+* transactions it replaces */
+func (ta *txnAdmission) admitToPool(ctx context.Context, txn *Transaction, replaced []datastore.Key) error {
+	mstore := ta.mstore
+	collection := txn.GetCollectionName()
+	if txn.GetCollectionScore() == 0 {
+		if txn.GetScore() != 0 {
+			txn.SetCollectionScore(txn.GetScore())
+		} else {
+			txn.InitCollectionScore()
+		}
+	}
+	var (
+		unwritten = int64(len(ta.admitted))
+		size      = mstore.GetCollectionSize(ctx, transactionEntityMetadata, collection) + unwritten - int64(len(replaced))
+	)
+	if TXN_POOL_MAX_SIZE > 0 && size >= TXN_POOL_MAX_SIZE {
+		lowest, scores, err := mstore.GetCollectionRange(ctx, transactionEntityMetadata, collection, math.MinInt64, math.MaxInt64, 1)
+		if err != nil {
+			return err
+		}
+		trimSize := TXN_POOL_MAX_SIZE - unwritten + int64(len(replaced)) - 1
+		if len(lowest) == 0 || trimSize < 0 {
+			return common.NewError("txn_pool_full", "the pool is full")
+		}
+		if txn.GetCollectionScore() <= scores[0] {
+			return common.NewErrorf("txn_pool_full",
+				"the fee should be higher than %v", scores[0])
+		}
+		evicted, err := mstore.TrimCollection(ctx, transactionEntityMetadata, collection, trimSize)
+		if err != nil {
+			return err
+		}
+		txn.EvictFromCollection(ctx, evicted)
+	}
+
+	if err := deletePoolTxns(ctx, replaced); err != nil {
+		return err
+	}
+	for _, key := range replaced {
+		SetTxnStatus(key, TxnStatusRejected, "replaced by "+txn.Hash)
+	}
+	return mstore.DeleteKeysFromCollection(ctx, transactionEntityMetadata, txn.GetClientCollectionName(), replaced)
+}
+
+// PendingTxn - a transaction pending in the pool
+type PendingTxn struct {
+	Hash         string           `json:"hash"`
+	Nonce        int64            `json:"nonce"`
+	Fee          int64            `json:"fee"`
+	CreationDate common.Timestamp `json:"creation_date"`
+	Position     int64            `json:"position"` // the number of the transactions ahead in the pool
+}
+
+// PendingTxns - the transactions of a client pending in the pool
+type PendingTxns struct {
+	ClientID     string        `json:"client_id"`
+	PoolSize     int64         `json:"pool_size"`
+	Transactions []*PendingTxn `json:"transactions"`
+}
+
+/*GetPendingTransactions - given a client id returns its transactions pending
+* in the pool by their nonces with their positions in the pool */
+func GetPendingTransactions(ctx context.Context, r *http.Request) (interface{}, error) {
+	clientID := r.FormValue("client_id")
+	if clientID == "" {
+		return nil, common.InvalidRequest("client_id is required")
+	}
+	mstore, ok := transactionEntityMetadata.GetStore().(*memorystore.Store)
+	if !ok {
+		return nil, common.NewError("pending_transactions", "the transactions pool is not available")
+	}
+	txn := transactionEntityMetadata.Instance().(*Transaction)
+	txn.ClientID = clientID
+	keys, _, err := getPendingTxnKeys(ctx, mstore, txn)
+	if err != nil {
+		return nil, err
+	}
+	collection := txn.GetCollectionName()
+	pending := &PendingTxns{
+		ClientID:     clientID,
+		PoolSize:     mstore.GetCollectionSize(ctx, transactionEntityMetadata, collection),
+		Transactions: make([]*PendingTxn, 0, len(keys)),
+	}
+	txns := datastore.AllocateEntities(len(keys), transactionEntityMetadata)
+	if err := mstore.MultiRead(ctx, transactionEntityMetadata, keys, txns); err != nil {
+		return nil, err
+	}
+	for _, entity := range txns {
+		ptxn := entity.(*Transaction)
+		if datastore.IsEmpty(ptxn.GetKey()) {
+			continue
+		}
+		position, err := mstore.GetCollectionRank(ctx, transactionEntityMetadata, collection, ptxn.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if position < 0 {
+			continue
+		}
+		pending.Transactions = append(pending.Transactions, &PendingTxn{
+			Hash:         ptxn.Hash,
+			Nonce:        ptxn.Nonce,
+			Fee:          ptxn.Fee,
+			CreationDate: ptxn.CreationDate,
+			Position:     position,
+		})
+	}
+	return pending, nil
+}
@@ -0,0 +1,232 @@
+package transaction
+
+import (
+	"context"
+	"math"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/config"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/logging"
+	"0chain.net/core/memorystore"
+)
+
+func initMempoolTest(t *testing.T, maxSize, maxPerClient int64) context.Context {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	pool := &redis.Pool{
+		MaxIdle:   10,
+		MaxActive: 100,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", mr.Addr())
+		},
+	}
+	memorystore.DefaultPool = pool
+	memorystore.AddPool("", pool)
+	memorystore.AddPool("txndb", pool)
+
+	logging.InitLogging("testing")
+	common.SetupRootContext(context.Background())
+	SetupEntity(memorystore.GetStorageProvider())
+	SetTxnPoolLimits(maxSize, maxPerClient, 10)
+	config.DevConfiguration.IsFeeEnabled = true
+	t.Cleanup(func() {
+		SetTxnPoolLimits(60000000, 0, 10)
+		config.DevConfiguration.IsFeeEnabled = false
+	})
+
+	ctx := memorystore.WithEntityConnection(context.Background(), transactionEntityMetadata)
+	t.Cleanup(func() { memorystore.Close(ctx) })
+	return ctx
+}
+
+var mempoolTxnSeq int
+
+func putPoolTxn(ctx context.Context, clientID string, nonce, fee int64) (*Transaction, error) {
+	mempoolTxnSeq++
+	txn := transactionEntityMetadata.Instance().(*Transaction)
+	txn.ClientID = clientID
+	txn.Nonce = nonce
+	txn.Fee = fee
+	txn.TransactionData = strconv.Itoa(mempoolTxnSeq)
+	txn.Hash = txn.ComputeHash()
+	if err := admitTransaction(ctx, txn); err != nil {
+		return nil, err
+	}
+	return txn, transactionEntityMetadata.GetStore().Write(ctx, txn)
+}
+
+func requireErrorCode(t *testing.T, err error, code string) {
+	require.Error(t, err)
+	cerr, ok := err.(*common.Error)
+	require.True(t, ok, err.Error())
+	require.Equal(t, code, cerr.Code)
+}
+
+func isPending(ctx context.Context, txn *Transaction) bool {
+	return transactionEntityMetadata.Instance().Read(ctx, txn.Hash) == nil
+}
+
+func TestAdmitTransaction_ClientLimit(t *testing.T) {
+	ctx := initMempoolTest(t, 100, 2)
+
+	first, err := putPoolTxn(ctx, "client1", 1, 10)
+	require.NoError(t, err)
+	_, err = putPoolTxn(ctx, "client1", 2, 10)
+	require.NoError(t, err)
+	_, err = putPoolTxn(ctx, "client1", 3, 10)
+	requireErrorCode(t, err, "too_many_pending")
+
+	_, err = putPoolTxn(ctx, "client2", 1, 10)
+	require.NoError(t, err)
+
+	// the same transaction put again is not counted
+	require.NoError(t, admitTransaction(ctx, first))
+
+	// included in a block
+	require.NoError(t, first.Delete(ctx))
+	_, err = putPoolTxn(ctx, "client1", 3, 10)
+	require.NoError(t, err)
+}
+
+func TestAdmitTransaction_ClientLimitConcurrent(t *testing.T) {
+	ctx := initMempoolTest(t, 100, 1)
+
+	// both admissions have seen no pending transactions of the client
+	mstore := transactionEntityMetadata.GetStore().(*memorystore.Store)
+	ta1, ta2 := newTxnAdmission(mstore), newTxnAdmission(mstore)
+	txns := make([]*Transaction, 2)
+	for idx, ta := range []*txnAdmission{ta1, ta2} {
+		txn := transactionEntityMetadata.Instance().(*Transaction)
+		txn.ClientID = "client"
+		txn.Nonce = int64(idx + 1)
+		txn.Fee = 10
+		txn.Hash = txn.ComputeHash()
+		_, err := ta.getClientPending(ctx, txn)
+		require.NoError(t, err)
+		txns[idx] = txn
+	}
+
+	require.NoError(t, ta1.admit(ctx, txns[0]))
+	requireErrorCode(t, ta2.admit(ctx, txns[1]), "too_many_pending")
+}
+
+func TestAdmitTransaction_PoolFullUndo(t *testing.T) {
+	ctx := initMempoolTest(t, 1, 1)
+
+	_, err := putPoolTxn(ctx, "client1", 1, 20)
+	require.NoError(t, err)
+	_, err = putPoolTxn(ctx, "client2", 1, 10)
+	requireErrorCode(t, err, "txn_pool_full")
+
+	// the rejected transaction doesn't count for the client
+	mstore := transactionEntityMetadata.GetStore().(*memorystore.Store)
+	txn := transactionEntityMetadata.Instance().(*Transaction)
+	txn.ClientID = "client2"
+	keys, _, err := mstore.GetCollectionRange(ctx, transactionEntityMetadata, txn.GetClientCollectionName(), math.MinInt64, math.MaxInt64, 10)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+	_, err = putPoolTxn(ctx, "client2", 1, 30)
+	require.NoError(t, err)
+}
+
+func TestAdmitTransaction_ReplaceByFee(t *testing.T) {
+	ctx := initMempoolTest(t, 100, 0)
+
+	stuck, err := putPoolTxn(ctx, "client", 1, 100)
+	require.NoError(t, err)
+	other, err := putPoolTxn(ctx, "client", 2, 1)
+	require.NoError(t, err)
+
+	_, err = putPoolTxn(ctx, "client", 1, 109)
+	requireErrorCode(t, err, "replacement_underpriced")
+	require.True(t, isPending(ctx, stuck))
+
+	replacement, err := putPoolTxn(ctx, "client", 1, 110)
+	require.NoError(t, err)
+	require.False(t, isPending(ctx, stuck))
+	require.True(t, isPending(ctx, replacement))
+	ts, err := GetTxnStatus(ctx, stuck.Hash)
+	require.NoError(t, err)
+	require.Equal(t, TxnStatusRejected, ts.Status)
+	require.Equal(t, "replaced by "+replacement.Hash, ts.Reason)
+	require.True(t, isPending(ctx, other))
+
+	// the legacy transactions without the nonce are never replaced
+	_, err = putPoolTxn(ctx, "client", 0, 1)
+	require.NoError(t, err)
+	_, err = putPoolTxn(ctx, "client", 0, 1)
+	require.NoError(t, err)
+}
+
+func TestAdmitTransaction_PoolFull(t *testing.T) {
+	ctx := initMempoolTest(t, 3, 0)
+
+	low, err := putPoolTxn(ctx, "client1", 1, 10)
+	require.NoError(t, err)
+	_, err = putPoolTxn(ctx, "client2", 1, 20)
+	require.NoError(t, err)
+	_, err = putPoolTxn(ctx, "client3", 1, 30)
+	require.NoError(t, err)
+
+	_, err = putPoolTxn(ctx, "client4", 1, 10)
+	requireErrorCode(t, err, "txn_pool_full")
+
+	high, err := putPoolTxn(ctx, "client4", 1, 25)
+	require.NoError(t, err)
+	require.False(t, isPending(ctx, low))
+	require.True(t, isPending(ctx, high))
+
+	mstore := transactionEntityMetadata.GetStore().(*memorystore.Store)
+	require.EqualValues(t, 3, mstore.GetCollectionSize(ctx, transactionEntityMetadata, high.GetCollectionName()))
+
+	// a replacement doesn't need the room in the pool
+	_, err = putPoolTxn(ctx, "client4", 1, 28)
+	require.NoError(t, err)
+	require.False(t, isPending(ctx, high))
+}
+
+func TestGetPendingTransactions(t *testing.T) {
+	ctx := initMempoolTest(t, 100, 0)
+
+	_, err := putPoolTxn(ctx, "other", 1, 50)
+	require.NoError(t, err)
+	first, err := putPoolTxn(ctx, "client", 1, 5)
+	require.NoError(t, err)
+	second, err := putPoolTxn(ctx, "client", 2, 100)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/v1/transaction/pending?client_id=client", nil)
+	resp, err := GetPendingTransactions(ctx, r)
+	require.NoError(t, err)
+	pending := resp.(*PendingTxns)
+	require.Equal(t, "client", pending.ClientID)
+	require.EqualValues(t, 3, pending.PoolSize)
+	require.Len(t, pending.Transactions, 2)
+	require.Equal(t, first.Hash, pending.Transactions[0].Hash)
+	require.EqualValues(t, 1, pending.Transactions[0].Nonce)
+	require.EqualValues(t, 2, pending.Transactions[0].Position)
+	require.Equal(t, second.Hash, pending.Transactions[1].Hash)
+	require.EqualValues(t, 0, pending.Transactions[1].Position)
+
+	r = httptest.NewRequest("GET", "/v1/transaction/pending", nil)
+	_, err = GetPendingTransactions(ctx, r)
+	require.Error(t, err)
+}
+
+func TestTransaction_EvictFromCollection(t *testing.T) {
+	ctx := initMempoolTest(t, 100, 0)
+
+	txn, err := putPoolTxn(ctx, "client", 1, 5)
+	require.NoError(t, err)
+	txn.EvictFromCollection(ctx, []datastore.Key{txn.Hash})
+	require.False(t, isPending(ctx, txn))
+}
@@ -0,0 +1,73 @@
+package transaction
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/encryption"
+)
+
+// SmartContractCall - a call of a smart contract by a multi-call transaction
+type SmartContractCall struct {
+	Address      datastore.Key   `json:"address"`
+	FunctionName string          `json:"name"`
+	InputData    json.RawMessage `json:"input"`
+	Value        int64           `json:"value,omitempty"`
+}
+
+// MultiCallData - the data of a multi-call transaction, the calls are in order
+type MultiCallData struct {
+	Calls []*SmartContractCall `json:"calls"`
+}
+
+/*GetCalls - get the calls of the multi-call transaction, the values of the
+* calls should add up to the value of the transaction */
+func (t *Transaction) GetCalls() ([]*SmartContractCall, error) {
+	if t.TransactionType != TxnTypeMultiCall {
+		return nil, common.InvalidRequest("not a multi-call transaction")
+	}
+	var data MultiCallData
+	if err := json.Unmarshal([]byte(t.TransactionData), &data); err != nil {
+		return nil, common.InvalidRequest("invalid multi-call data: " + err.Error())
+	}
+	if len(data.Calls) == 0 {
+		return nil, common.InvalidRequest("multi-call transaction without calls")
+	}
+	var value int64
+	for idx, call := range data.Calls {
+		if call == nil || !encryption.IsHash(call.Address) || call.FunctionName == "" {
+			return nil, common.InvalidRequest("invalid multi-call call " + strconv.Itoa(idx))
+		}
+		if call.Value < 0 {
+			return nil, common.InvalidRequest("value of a call must be greater than or equal to zero")
+		}
+		value += call.Value
+	}
+	if value != t.Value {
+		return nil, common.InvalidRequest("values of the calls should add up to the transaction value")
+	}
+	return data.Calls, nil
+}
+
+/*NewCallTransaction - the smart contract transaction of a call of the
+* multi-call transaction the smart contract is executed with, the calls have
+* the hash of the transaction so the ones creating the same entities fail */
+func (t *Transaction) NewCallTransaction(call *SmartContractCall) (*Transaction, error) {
+	data, err := json.Marshal(&smartContractTransactionData{
+		FunctionName: call.FunctionName,
+		InputData:    call.InputData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ct := t.Clone()
+	ct.ToClientID = call.Address
+	ct.Value = call.Value
+	ct.TransactionType = TxnTypeSmartContract
+	ct.TransactionData = string(data)
+	ct.TransactionOutput = ""
+	ct.Receipt = nil
+	return ct, nil
+}
@@ -0,0 +1,75 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/config"
+	"0chain.net/core/common"
+	"0chain.net/core/encryption"
+)
+
+func newMultiCallTxn(t *testing.T, calls ...*SmartContractCall) *Transaction {
+	data, err := json.Marshal(&MultiCallData{Calls: calls})
+	require.NoError(t, err)
+	txn := &Transaction{
+		ClientID:        "client",
+		ChainID:         config.GetServerChainID(),
+		CreationDate:    common.Now(),
+		TransactionType: TxnTypeMultiCall,
+		TransactionData: string(data),
+	}
+	for _, call := range calls {
+		txn.Value += call.Value
+	}
+	txn.Hash = txn.ComputeHash()
+	return txn
+}
+
+func TestTransaction_GetCalls(t *testing.T) {
+	var (
+		sc1 = encryption.Hash("sc1")
+		sc2 = encryption.Hash("sc2")
+	)
+	txn := newMultiCallTxn(t,
+		&SmartContractCall{Address: sc1, FunctionName: "lock", InputData: json.RawMessage(`{"duration":"1h"}`), Value: 10},
+		&SmartContractCall{Address: sc2, FunctionName: "new_allocation", InputData: json.RawMessage(`{}`)},
+	)
+	calls, err := txn.GetCalls()
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	require.NoError(t, txn.ValidateWrtTimeForBlock(context.Background(), txn.CreationDate, false))
+
+	ct, err := txn.NewCallTransaction(calls[0])
+	require.NoError(t, err)
+	require.Equal(t, TxnTypeSmartContract, ct.TransactionType)
+	require.Equal(t, sc1, ct.ToClientID)
+	require.EqualValues(t, 10, ct.Value)
+	require.Equal(t, txn.Hash, ct.Hash)
+	require.JSONEq(t, `{"name":"lock","input":{"duration":"1h"}}`, ct.TransactionData)
+	require.Equal(t, TxnTypeMultiCall, txn.TransactionType)
+
+	txn.Value = 11
+	_, err = txn.GetCalls()
+	require.Error(t, err)
+
+	txn = newMultiCallTxn(t)
+	_, err = txn.GetCalls()
+	require.Error(t, err)
+
+	txn = newMultiCallTxn(t, &SmartContractCall{Address: "sc", FunctionName: "lock"})
+	_, err = txn.GetCalls()
+	require.Error(t, err)
+
+	txn = newMultiCallTxn(t, &SmartContractCall{Address: sc1, FunctionName: "lock", Value: -1})
+	_, err = txn.GetCalls()
+	require.Error(t, err)
+
+	txn = newMultiCallTxn(t, &SmartContractCall{Address: sc1, FunctionName: "lock"})
+	txn.ToClientID = sc1
+	txn.Hash = txn.ComputeHash()
+	require.Error(t, txn.ValidateWrtTimeForBlock(context.Background(), txn.CreationDate, false))
+}
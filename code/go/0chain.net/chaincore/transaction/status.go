@@ -1,7 +1,318 @@
 package transaction
 
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"0chain.net/core/cache"
+	"0chain.net/core/common"
+)
+
 const (
 	TxnSuccess = 1 // Indicates the transaction is successful in updating the state or smart contract
 
 	TxnFail = 3 // Indicates a transaction has failed to update the state or smart contract
 )
+
+// the lifecycle statuses of a transaction
+const (
+	TxnStatusPending   = "pending"   // in the pool of the pending transactions
+	TxnStatusIncluded  = "included"  // in a notarized block
+	TxnStatusFinalized = "finalized" // in a finalized block
+	TxnStatusRejected  = "rejected"  // dropped from the pool
+	TxnStatusExpired   = "expired"   // not within the time tolerance anymore
+)
+
+const (
+	// TxnStatusCacheSize - the number of the transactions the statuses are kept for
+	TxnStatusCacheSize = 100000
+	// TxnStatusMaxWaiters - the maximum number of the waits for the statuses
+	TxnStatusMaxWaiters = 10000
+	// TxnStatusMaxWait - the maximum wait for a status, below the write timeout of the server
+	TxnStatusMaxWait = 25 * time.Second
+)
+
+/*txnStatusTransitions - the statuses a transaction can get to by its status,
+* the finalized status is the final one */
+var txnStatusTransitions = map[string][]string{
+	"":                 {TxnStatusPending, TxnStatusIncluded, TxnStatusFinalized, TxnStatusRejected, TxnStatusExpired},
+	TxnStatusPending:   {TxnStatusIncluded, TxnStatusFinalized, TxnStatusRejected, TxnStatusExpired},
+	TxnStatusRejected:  {TxnStatusPending, TxnStatusIncluded, TxnStatusFinalized, TxnStatusRejected, TxnStatusExpired},
+	TxnStatusExpired:   {TxnStatusPending, TxnStatusIncluded, TxnStatusFinalized},
+	TxnStatusIncluded:  {TxnStatusIncluded, TxnStatusFinalized, TxnStatusExpired},
+	TxnStatusFinalized: {},
+}
+
+// TxnStatus - the lifecycle status of a transaction
+type TxnStatus struct {
+	Hash      string           `json:"hash"`
+	Status    string           `json:"status"`
+	Reason    string           `json:"reason,omitempty"`
+	LastError string           `json:"last_error,omitempty"` // the last failure of the execution of the pending transaction
+	BlockHash string           `json:"block_hash,omitempty"`
+	Round     int64            `json:"round,omitempty"`
+	UpdatedAt common.Timestamp `json:"updated_at"`
+}
+
+func (ts *TxnStatus) isOneOf(statuses []string) bool {
+	for _, status := range statuses {
+		if ts.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+/*txnStatusTracker - the statuses of the recent transactions with the waits
+* for them to change */
+type txnStatusTracker struct {
+	mutex    sync.Mutex
+	statuses *cache.LRU
+	waiters  map[string][]chan struct{}
+	nwaiters int
+}
+
+func newTxnStatusTracker(size int) *txnStatusTracker {
+	return &txnStatusTracker{
+		statuses: cache.NewLRUCache(size),
+		waiters:  make(map[string][]chan struct{}),
+	}
+}
+
+func (st *txnStatusTracker) get(hash string) (*TxnStatus, bool) {
+	value, err := st.statuses.Get(hash)
+	if err != nil {
+		return nil, false
+	}
+	ts := *value.(*TxnStatus)
+	return &ts, true
+}
+
+func (st *txnStatusTracker) set(update *TxnStatus) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	var current string
+	if ts, ok := st.get(update.Hash); ok {
+		current = ts.Status
+		// the transaction dropped from the pool keeps the last failure
+		if update.LastError == "" && (update.Status == TxnStatusExpired || update.Status == TxnStatusRejected) {
+			update.LastError = ts.LastError
+		}
+	}
+	allowed := false
+	for _, status := range txnStatusTransitions[current] {
+		if status == update.Status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+	st.store(update)
+	return true
+}
+
+/*setLastError - set the last failure of the execution of the transaction
+* pending in the pool, it's not set for a transaction not pending anymore */
+func (st *txnStatusTracker) setLastError(hash, lastError string) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if ts, ok := st.get(hash); ok && ts.Status != TxnStatusPending {
+		return false
+	}
+	st.store(&TxnStatus{Hash: hash, Status: TxnStatusPending, LastError: lastError})
+	return true
+}
+
+/*revert - get the transaction included in the block back to the pending
+* status, it's kept if it's been included in another block since */
+func (st *txnStatusTracker) revert(hash, blockHash string) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	ts, ok := st.get(hash)
+	if !ok || ts.Status != TxnStatusIncluded || ts.BlockHash != blockHash {
+		return false
+	}
+	st.store(&TxnStatus{Hash: hash, Status: TxnStatusPending})
+	return true
+}
+
+// store - store the status and notify the waits, the mutex must be held
+func (st *txnStatusTracker) store(update *TxnStatus) {
+	update.UpdatedAt = common.Now()
+	st.statuses.Add(update.Hash, update)
+	for _, ch := range st.waiters[update.Hash] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (st *txnStatusTracker) subscribe(hash string) (chan struct{}, error) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if st.nwaiters >= TxnStatusMaxWaiters {
+		return nil, common.NewError("too_many_waiters",
+			"too many waits for the transaction statuses, try again later")
+	}
+	ch := make(chan struct{}, 1)
+	st.waiters[hash] = append(st.waiters[hash], ch)
+	st.nwaiters++
+	return ch, nil
+}
+
+func (st *txnStatusTracker) unsubscribe(hash string, ch chan struct{}) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	waiters := st.waiters[hash]
+	for idx := range waiters {
+		if waiters[idx] == ch {
+			waiters = append(waiters[:idx], waiters[idx+1:]...)
+			st.nwaiters--
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(st.waiters, hash)
+		return
+	}
+	st.waiters[hash] = waiters
+}
+
+/*wait - wait for the transaction to get to one of the statuses, the latest
+* status is returned with the error of the context when it's done first */
+func (st *txnStatusTracker) wait(ctx context.Context, hash string, statuses []string) (*TxnStatus, error) {
+	ch, err := st.subscribe(hash)
+	if err != nil {
+		return nil, err
+	}
+	defer st.unsubscribe(hash, ch)
+	for {
+		ts, ok := st.get(hash)
+		if ok && ts.isOneOf(statuses) {
+			return ts, nil
+		}
+		select {
+		case <-ctx.Done():
+			return ts, ctx.Err()
+		case <-ch:
+		}
+	}
+}
+
+var txnStatuses = newTxnStatusTracker(TxnStatusCacheSize)
+
+/*txnStatusFallback - the status of a transaction no longer tracked, a sharder
+* looks up the transactions of the finalized blocks it stores */
+var txnStatusFallback func(ctx context.Context, hash string) (*TxnStatus, error)
+
+// SetTxnStatusFallback - set the lookup of the transactions not tracked
+func SetTxnStatusFallback(fallback func(ctx context.Context, hash string) (*TxnStatus, error)) {
+	txnStatusFallback = fallback
+}
+
+/*SetTxnStatus - set the status of the transaction in the pool, the status is
+* not set if the transaction can't get to it from its current status */
+func SetTxnStatus(hash, status, reason string) bool {
+	return txnStatuses.set(&TxnStatus{Hash: hash, Status: status, Reason: reason})
+}
+
+/*SetTxnLastError - set the last failure of the execution of the transaction
+* that's kept pending in the pool to be executed again */
+func SetTxnLastError(hash, lastError string) bool {
+	return txnStatuses.setLastError(hash, lastError)
+}
+
+// SetTxnsStatus - set the status of the transactions of a block
+func SetTxnsStatus(txns []*Transaction, status, blockHash string, round int64) {
+	for _, txn := range txns {
+		txnStatuses.set(&TxnStatus{
+			Hash:      txn.Hash,
+			Status:    status,
+			BlockHash: blockHash,
+			Round:     round,
+		})
+	}
+}
+
+/*RevertTxnsStatus - get the transactions included in a block that's not been
+* finalized back to the pending status */
+func RevertTxnsStatus(txns []*Transaction, blockHash string) {
+	for _, txn := range txns {
+		txnStatuses.revert(txn.Hash, blockHash)
+	}
+}
+
+// GetTxnStatus - get the status of the transaction
+func GetTxnStatus(ctx context.Context, hash string) (*TxnStatus, error) {
+	if ts, ok := txnStatuses.get(hash); ok {
+		return ts, nil
+	}
+	if txnStatusFallback != nil {
+		if ts, err := txnStatusFallback(ctx, hash); err == nil && ts != nil {
+			return ts, nil
+		}
+	}
+	return nil, common.NewErrorf("unknown_transaction", "transaction %v is not known", hash)
+}
+
+/*WaitTxnStatus - wait for the transaction to get to one of the statuses, the
+* latest status is returned when the context is done first */
+func WaitTxnStatus(ctx context.Context, hash string, statuses []string) (*TxnStatus, error) {
+	if ts, err := GetTxnStatus(ctx, hash); err == nil && ts.isOneOf(statuses) {
+		return ts, nil
+	}
+	ts, err := txnStatuses.wait(ctx, hash, statuses)
+	if err != nil && err != ctx.Err() {
+		return nil, err
+	}
+	if ts == nil {
+		return GetTxnStatus(ctx, hash)
+	}
+	return ts, nil
+}
+
+/*GetTransactionStatus - given a transaction hash returns its status. With the
+* wait_for statuses it waits up to the timeout in seconds for the transaction
+* to get to one of them and returns its latest status, "final" stands for
+* the finalized, rejected and expired statuses */
+func GetTransactionStatus(ctx context.Context, r *http.Request) (interface{}, error) {
+	hash := r.FormValue("hash")
+	if hash == "" {
+		return nil, common.InvalidRequest("hash is required")
+	}
+	waitFor := r.FormValue("wait_for")
+	if waitFor == "" {
+		return GetTxnStatus(ctx, hash)
+	}
+	var statuses []string
+	for _, status := range strings.Split(waitFor, ",") {
+		switch status {
+		case "final":
+			statuses = append(statuses, TxnStatusFinalized, TxnStatusRejected, TxnStatusExpired)
+		case TxnStatusPending, TxnStatusIncluded, TxnStatusFinalized, TxnStatusRejected, TxnStatusExpired:
+			statuses = append(statuses, status)
+		default:
+			return nil, common.InvalidRequest("unknown status " + status)
+		}
+	}
+	timeout := TxnStatusMaxWait
+	if ts := r.FormValue("timeout"); ts != "" {
+		seconds, err := strconv.Atoi(ts)
+		if err != nil || seconds < 0 {
+			return nil, common.InvalidRequest("invalid timeout " + ts)
+		}
+		if wait := time.Duration(seconds) * time.Second; wait < timeout {
+			timeout = wait
+		}
+	}
+	wctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return WaitTxnStatus(wctx, hash, statuses)
+}
@@ -0,0 +1,170 @@
+package transaction
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/core/datastore"
+)
+
+func initTxnStatusTest(t *testing.T) {
+	txnStatuses = newTxnStatusTracker(100)
+	t.Cleanup(func() {
+		txnStatuses = newTxnStatusTracker(TxnStatusCacheSize)
+		SetTxnStatusFallback(nil)
+	})
+}
+
+func requireTxnStatus(t *testing.T, hash, status string) *TxnStatus {
+	ts, err := GetTxnStatus(context.Background(), hash)
+	require.NoError(t, err)
+	require.Equal(t, status, ts.Status)
+	return ts
+}
+
+func TestSetTxnStatus(t *testing.T) {
+	initTxnStatusTest(t)
+
+	_, err := GetTxnStatus(context.Background(), "txn")
+	require.Error(t, err)
+
+	require.True(t, SetTxnStatus("txn", TxnStatusPending, ""))
+	require.True(t, SetTxnStatus("txn", TxnStatusRejected, "insufficient balance"))
+	ts := requireTxnStatus(t, "txn", TxnStatusRejected)
+	require.Equal(t, "insufficient balance", ts.Reason)
+
+	// executed by another generator
+	txns := []*Transaction{{HashIDField: datastore.HashIDField{Hash: "txn"}}}
+	SetTxnsStatus(txns, TxnStatusIncluded, "block", 5)
+	ts = requireTxnStatus(t, "txn", TxnStatusIncluded)
+	require.Equal(t, "block", ts.BlockHash)
+	require.EqualValues(t, 5, ts.Round)
+	require.False(t, SetTxnStatus("txn", TxnStatusPending, ""))
+	require.False(t, SetTxnStatus("txn", TxnStatusRejected, "replaced"))
+
+	SetTxnsStatus(txns, TxnStatusFinalized, "block", 5)
+	requireTxnStatus(t, "txn", TxnStatusFinalized)
+	require.False(t, SetTxnStatus("txn", TxnStatusExpired, ""))
+	requireTxnStatus(t, "txn", TxnStatusFinalized)
+}
+
+func TestSetTxnLastError(t *testing.T) {
+	initTxnStatusTest(t)
+
+	// failed to be executed, kept pending with the failure
+	require.True(t, SetTxnStatus("txn", TxnStatusPending, ""))
+	require.True(t, SetTxnLastError("txn", "insufficient balance"))
+	r := httptest.NewRequest("GET", "/v1/transaction/status?hash=txn", nil)
+	resp, err := GetTransactionStatus(context.Background(), r)
+	require.NoError(t, err)
+	ts := resp.(*TxnStatus)
+	require.Equal(t, TxnStatusPending, ts.Status)
+	require.Equal(t, "insufficient balance", ts.LastError)
+
+	// the failure is kept when it's dropped from the pool
+	require.True(t, SetTxnStatus("txn", TxnStatusExpired, ""))
+	ts = requireTxnStatus(t, "txn", TxnStatusExpired)
+	require.Equal(t, "insufficient balance", ts.LastError)
+	require.False(t, SetTxnLastError("txn", "other"))
+
+	// executed later
+	txns := []*Transaction{{HashIDField: datastore.HashIDField{Hash: "other"}}}
+	require.True(t, SetTxnLastError("other", "insufficient balance"))
+	requireTxnStatus(t, "other", TxnStatusPending)
+	SetTxnsStatus(txns, TxnStatusIncluded, "block", 5)
+	ts = requireTxnStatus(t, "other", TxnStatusIncluded)
+	require.Empty(t, ts.LastError)
+	require.False(t, SetTxnLastError("other", "insufficient balance"))
+}
+
+func TestRevertTxnsStatus(t *testing.T) {
+	initTxnStatusTest(t)
+
+	fork := []*Transaction{
+		{HashIDField: datastore.HashIDField{Hash: "txn1"}},
+		{HashIDField: datastore.HashIDField{Hash: "txn2"}},
+		{HashIDField: datastore.HashIDField{Hash: "txn3"}},
+	}
+	SetTxnsStatus(fork, TxnStatusIncluded, "fork", 5)
+	// included in the finalized block of the round and in a later block
+	SetTxnsStatus(fork[1:2], TxnStatusFinalized, "block", 5)
+	SetTxnsStatus(fork[2:], TxnStatusIncluded, "later", 6)
+
+	RevertTxnsStatus(fork, "fork")
+	ts := requireTxnStatus(t, "txn1", TxnStatusPending)
+	require.Empty(t, ts.BlockHash)
+	require.Zero(t, ts.Round)
+	requireTxnStatus(t, "txn2", TxnStatusFinalized)
+	ts = requireTxnStatus(t, "txn3", TxnStatusIncluded)
+	require.Equal(t, "later", ts.BlockHash)
+
+	// included again on the finalized chain
+	SetTxnsStatus(fork[:1], TxnStatusIncluded, "block2", 6)
+	requireTxnStatus(t, "txn1", TxnStatusIncluded)
+}
+
+func TestWaitTxnStatus(t *testing.T) {
+	initTxnStatusTest(t)
+	SetTxnStatus("txn", TxnStatusPending, "")
+
+	done := make(chan *TxnStatus)
+	go func() {
+		ts, _ := WaitTxnStatus(context.Background(), "txn", []string{TxnStatusFinalized})
+		done <- ts
+	}()
+	txns := []*Transaction{{HashIDField: datastore.HashIDField{Hash: "txn"}}}
+	SetTxnsStatus(txns, TxnStatusIncluded, "block", 5)
+	SetTxnsStatus(txns, TxnStatusFinalized, "block", 5)
+	select {
+	case ts := <-done:
+		require.Equal(t, TxnStatusFinalized, ts.Status)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the wait for the status is not done")
+	}
+	require.Zero(t, txnStatuses.nwaiters)
+
+	// the latest status when the wait times out
+	SetTxnStatus("other", TxnStatusPending, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	ts, err := WaitTxnStatus(ctx, "other", []string{TxnStatusFinalized})
+	require.NoError(t, err)
+	require.Equal(t, TxnStatusPending, ts.Status)
+	require.Zero(t, txnStatuses.nwaiters)
+}
+
+func TestGetTransactionStatus(t *testing.T) {
+	initTxnStatusTest(t)
+	SetTxnStatus("txn", TxnStatusPending, "")
+
+	r := httptest.NewRequest("GET", "/v1/transaction/status?hash=txn", nil)
+	resp, err := GetTransactionStatus(context.Background(), r)
+	require.NoError(t, err)
+	require.Equal(t, TxnStatusPending, resp.(*TxnStatus).Status)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		SetTxnStatus("txn", TxnStatusExpired, "")
+	}()
+	r = httptest.NewRequest("GET", "/v1/transaction/status?hash=txn&wait_for=final&timeout=5", nil)
+	resp, err = GetTransactionStatus(context.Background(), r)
+	require.NoError(t, err)
+	require.Equal(t, TxnStatusExpired, resp.(*TxnStatus).Status)
+
+	r = httptest.NewRequest("GET", "/v1/transaction/status?hash=txn&wait_for=done", nil)
+	_, err = GetTransactionStatus(context.Background(), r)
+	require.Error(t, err)
+
+	// the transactions not tracked are looked up
+	SetTxnStatusFallback(func(ctx context.Context, hash string) (*TxnStatus, error) {
+		return &TxnStatus{Hash: hash, Status: TxnStatusFinalized, Round: 3}, nil
+	})
+	r = httptest.NewRequest("GET", "/v1/transaction/status?hash=old&wait_for=finalized&timeout=0", nil)
+	resp, err = GetTransactionStatus(context.Background(), r)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, resp.(*TxnStatus).Round)
+}
@@ -23,6 +23,7 @@ type Confirmation struct {
 	MerkleTreePath        *util.MTPath  `json:"merkle_tree_path"`
 	ReceiptMerkleTreeRoot string        `json:"receipt_merkle_tree_root"`
 	ReceiptMerkleTreePath *util.MTPath  `json:"receipt_merkle_tree_path"`
+	Receipt               *Receipt      `json:"receipt,omitempty"`
 }
 
 var transactionConfirmationEntityMetadata *datastore.EntityMetadataImpl
@@ -1,20 +1,107 @@
 package transaction
 
-import "0chain.net/core/util"
+import (
+	"encoding/json"
+	"strconv"
+
+	"0chain.net/core/encryption"
+	"0chain.net/core/logging"
+	"0chain.net/core/util"
+	"go.uber.org/zap"
+)
+
+// the types of the attributes of the events
+const (
+	EventAttributeString = "string"
+	EventAttributeInt    = "int"
+	EventAttributeBool   = "bool"
+)
+
+// EventAttribute - a typed attribute of an event
+type EventAttribute struct {
+	Key   string `json:"key" msgpack:"k"`
+	Type  string `json:"type" msgpack:"t"`
+	Value string `json:"value" msgpack:"v"`
+}
+
+// StringAttribute - a string attribute of an event
+func StringAttribute(key, value string) *EventAttribute {
+	return &EventAttribute{Key: key, Type: EventAttributeString, Value: value}
+}
+
+// IntAttribute - an integer attribute of an event
+func IntAttribute(key string, value int64) *EventAttribute {
+	return &EventAttribute{Key: key, Type: EventAttributeInt, Value: strconv.FormatInt(value, 10)}
+}
+
+// BoolAttribute - a boolean attribute of an event
+func BoolAttribute(key string, value bool) *EventAttribute {
+	return &EventAttribute{Key: key, Type: EventAttributeBool, Value: strconv.FormatBool(value)}
+}
+
+// Event - an event emitted by a smart contract executing a transaction
+type Event struct {
+	Name       string            `json:"name" msgpack:"n"`
+	Attributes []*EventAttribute `json:"attributes,omitempty" msgpack:"a,omitempty"`
+}
+
+// NewEvent - create a new event
+func NewEvent(name string, attributes ...*EventAttribute) *Event {
+	return &Event{Name: name, Attributes: attributes}
+}
+
+// ReceiptTransfer - a transfer of the tokens by a transaction
+type ReceiptTransfer struct {
+	ClientID   string `json:"from" msgpack:"f"`
+	ToClientID string `json:"to" msgpack:"t"`
+	Amount     int64  `json:"amount" msgpack:"a"`
+}
+
+// ReceiptMint - a mint of the tokens by a transaction
+type ReceiptMint struct {
+	Minter     string `json:"minter" msgpack:"m"`
+	ToClientID string `json:"to" msgpack:"t"`
+	Amount     int64  `json:"amount" msgpack:"a"`
+}
+
+/*Receipt - the structured result of the execution of a transaction, the
+* transfers and the mints are in the order they are applied */
+type Receipt struct {
+	Status    int                `json:"status" msgpack:"s"`
+	FeeUsed   int64              `json:"fee_used" msgpack:"f"`
+	Transfers []*ReceiptTransfer `json:"transfers,omitempty" msgpack:"t,omitempty"`
+	Mints     []*ReceiptMint     `json:"mints,omitempty" msgpack:"m,omitempty"`
+	Events    []*Event           `json:"events,omitempty" msgpack:"e,omitempty"`
+	Outputs   []string           `json:"outputs,omitempty" msgpack:"o,omitempty"` // of the calls of a multi-call transaction
+}
+
+// GetHash - the hash of the receipt, empty if it can't be encoded
+func (r *Receipt) GetHash() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		logging.Logger.Error("receipt hash - encoding the receipt", zap.Error(err))
+		return ""
+	}
+	return encryption.Hash(data)
+}
 
 //TxnReceipt - a transaction receipt is a processed transaction that contains the output
 type TxnReceipt struct {
 	Transaction *Transaction
 }
 
-//GetHash - implement interface
+/*GetHash - implement interface, the hash of the output of the transactions
+* without the structured receipt keeps the receipts of the blocks before it */
 func (rh *TxnReceipt) GetHash() string {
-	return rh.Transaction.OutputHash
+	if rh.Transaction.Receipt == nil {
+		return rh.Transaction.OutputHash
+	}
+	return encryption.Hash(rh.Transaction.OutputHash + ":" + rh.Transaction.Receipt.GetHash())
 }
 
 /*GetHashBytes - implement Hashable interface */
 func (rh *TxnReceipt) GetHashBytes() []byte {
-	return util.HashStringToBytes(rh.Transaction.OutputHash)
+	return util.HashStringToBytes(rh.GetHash())
 }
 
 //NewTransactionReceipt - create a new transaction receipt
@@ -0,0 +1,34 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/core/encryption"
+)
+
+func TestTxnReceipt_GetHash(t *testing.T) {
+	txn := &Transaction{TransactionOutput: "output"}
+	txn.OutputHash = txn.ComputeOutputHash()
+
+	// the receipts of the transactions without the structured receipt
+	receipt := NewTransactionReceipt(txn)
+	require.Equal(t, txn.OutputHash, receipt.GetHash())
+
+	txn.Receipt = &Receipt{
+		Status:    TxnSuccess,
+		Transfers: []*ReceiptTransfer{{ClientID: "from", ToClientID: "to", Amount: 10}},
+		Events: []*Event{NewEvent("event",
+			StringAttribute("name", "value"),
+			IntAttribute("count", -2),
+			BoolAttribute("ok", true))},
+	}
+	hash := receipt.GetHash()
+	require.Equal(t, encryption.Hash(txn.OutputHash+":"+txn.Receipt.GetHash()), hash)
+	require.Equal(t, "-2", txn.Receipt.Events[0].Attributes[1].Value)
+	require.Equal(t, EventAttributeBool, txn.Receipt.Events[0].Attributes[2].Type)
+
+	txn.Receipt.Transfers[0].Amount = 11
+	require.NotEqual(t, hash, receipt.GetHash())
+}
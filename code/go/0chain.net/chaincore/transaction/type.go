@@ -12,4 +12,5 @@ const (
 	TxnTypeData = 10 // A transaction to just store a piece of data on the block chain
 
 	TxnTypeSmartContract = 1000 // A smart contract transaction type
+	TxnTypeMultiCall     = 1002 // A transaction of the calls of the smart contracts executed all or none
 )
@@ -39,7 +39,7 @@ func CleanupWorker(ctx context.Context) {
 				logging.Logger.Error("Error in deleting txn in redis", zap.Error(err))
 			}
 		}
-		if !common.Within(int64(txn.CreationDate), TXN_TIME_TOLERANCE-1) {
+		if !common.Within(int64(txn.CreationDate), TXN_TIME_TOLERANCE-1) || txn.IsExpired(common.Now()) {
 			invalidTxns = append(invalidTxns, txn)
 		}
 		err := transactionEntityMetadata.GetStore().Read(ctx, txn.Hash, txn)
@@ -65,6 +65,9 @@ func CleanupWorker(ctx context.Context) {
 				if err != nil {
 					logging.Logger.Error("Error in MultiDelete", zap.Error(err))
 				} else {
+					for _, txn := range invalidTxns {
+						SetTxnStatus(txn.GetKey(), TxnStatusExpired, "")
+					}
 					invalidTxns = invalidTxns[:0]
 				}
 			}
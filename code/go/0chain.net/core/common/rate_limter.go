@@ -18,6 +18,7 @@ type ratelimit struct {
 
 var userRateLimit *ratelimit
 var n2nRateLimit *ratelimit
+var simulateRateLimit *ratelimit
 
 func (rl *ratelimit) init() {
 	if rl.RequestsPerSecond == 0 {
@@ -39,6 +40,10 @@ func ConfigRateLimits() {
 	n2nRl := viper.GetFloat64("network.n2n_handlers.rate_limit")
 	n2nRateLimit = &ratelimit{RequestsPerSecond: n2nRl}
 	n2nRateLimit.init()
+
+	simulateRl := viper.GetFloat64("network.simulate_handlers.rate_limit")
+	simulateRateLimit = &ratelimit{RequestsPerSecond: simulateRl}
+	simulateRateLimit.init()
 }
 
 //UserRateLimit - rate limiting for end user handlers
@@ -60,3 +65,14 @@ func N2NRateLimit(handler ReqRespHandlerf) ReqRespHandlerf {
 		tollbooth.LimitFuncHandler(n2nRateLimit.Limiter, Recover(handler)).ServeHTTP(writer, request)
 	}
 }
+
+/*SimulateRateLimit - rate limiting for the handlers executing the
+* transactions, the end user rate limiting applies if it's not configured */
+func SimulateRateLimit(handler ReqRespHandlerf) ReqRespHandlerf {
+	if !simulateRateLimit.RateLimit {
+		return UserRateLimit(handler)
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		tollbooth.LimitFuncHandler(simulateRateLimit.Limiter, Recover(handler)).ServeHTTP(writer, request)
+	}
+}
@@ -1,6 +1,7 @@
 package datastore
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -34,6 +35,14 @@ type (
 		GetCollectionScore() int64
 	}
 
+	// CollectionEvictor describes a collection entity whose collection is
+	// trimmed to its size by evicting the entities with the lowest scores
+	// rather than by the age of the scores.
+	CollectionEvictor interface {
+		// EvictFromCollection handles the keys evicted from the collection.
+		EvictFromCollection(ctx context.Context, keys []Key)
+	}
+
 	// EntityCollection describes an organized entities into collections
 	// and provides configuration for those collections.
 	EntityCollection struct {
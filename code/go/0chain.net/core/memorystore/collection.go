@@ -11,6 +11,7 @@ import (
 	"0chain.net/core/common"
 	"0chain.net/core/datastore"
 	. "0chain.net/core/logging"
+	"github.com/gomodule/redigo/redis"
 	"go.uber.org/zap"
 )
 
@@ -166,8 +167,226 @@ func CollectionTrimmer(entityMetadata datastore.EntityMetadata, collection strin
 			if size < trimSize {
 				continue
 			}
+			if evictor, ok := entityMetadata.Instance().(datastore.CollectionEvictor); ok {
+				keys, err := storageAPI.TrimCollection(ctx, entityMetadata, collection, trimSize)
+				if err != nil {
+					Logger.Error("collection trimmer", zap.String("collection", collection), zap.Time("time", t), zap.Error(err))
+					continue
+				}
+				if len(keys) > 0 {
+					Logger.Info("collection trimmer - evicted", zap.String("collection", collection), zap.Int("count", len(keys)))
+					evictor.EvictFromCollection(ctx, keys)
+				}
+				continue
+			}
 			score := datastore.GetCollectionScore(time.Now().Add(-trimBeyond))
 			con.Send("ZREMRANGEBYSCORE", collection, 0, score)
+			con.Flush()
+			if _, err := con.Receive(); err != nil {
+				Logger.Error("collection trimmer", zap.String("collection", collection), zap.Time("time", t), zap.Error(err))
+			}
 		}
 	}
 }
+
+/*AddKeyToCollection - add the key to the collection by the name with the score,
+* it's for the collections indexing the entities other than by their score */
+func (ms *Store) AddKeyToCollection(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, key datastore.Key, score int64) error {
+	con := GetEntityCon(ctx, entityMetadata)
+	con.Send("ZADD", collectionName, score, key)
+	con.Flush()
+	_, err := con.Receive()
+	return err
+}
+
+/*DeleteKeysFromCollection - delete the keys from the collection by the name */
+func (ms *Store) DeleteKeysFromCollection(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, keys []datastore.Key) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 1+len(keys))
+	args[0] = collectionName
+	for idx, key := range keys {
+		args[idx+1] = key
+	}
+	con := GetEntityCon(ctx, entityMetadata)
+	con.Send("ZREM", args...)
+	con.Flush()
+	_, err := con.Receive()
+	return err
+}
+
+/*addKeyWithinSizeScript - add the key ARGV[1] with the score ARGV[2] to the
+* collection KEYS[1] unless it has ARGV[3] keys or more not counting the key
+* and the keys ARGV[5:], then expire the collection after ARGV[4] seconds */
+var addKeyWithinSizeScript = redis.NewScript(1, `
+local count = redis.call('ZCARD', KEYS[1])
+for idx = 5, #ARGV do
+	if redis.call('ZSCORE', KEYS[1], ARGV[idx]) then
+		count = count - 1
+	end
+end
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) and count >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+/*AddKeyToCollectionWithinSize - atomically add the key to the collection by
+* the name with the score unless the collection has size keys or more not
+* counting the excluded ones, and set it to expire after the duration. False
+* is returned when the key is not added */
+func (ms *Store) AddKeyToCollectionWithinSize(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, key datastore.Key, score int64, size int64, excluded []datastore.Key, duration time.Duration) (bool, error) {
+	args := make([]interface{}, 0, 5+len(excluded))
+	args = append(args, collectionName, key, score, size, int64(duration/time.Second))
+	for _, key := range excluded {
+		args = append(args, key)
+	}
+	con := GetEntityCon(ctx, entityMetadata)
+	added, err := redis.Int(addKeyWithinSizeScript.Do(con, args...))
+	if err != nil {
+		return false, err
+	}
+	return added == 1, nil
+}
+
+/*ExpireCollection - set the collection by the name to expire after the duration */
+func (ms *Store) ExpireCollection(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, duration time.Duration) error {
+	con := GetEntityCon(ctx, entityMetadata)
+	con.Send("EXPIRE", collectionName, int64(duration/time.Second))
+	con.Flush()
+	_, err := con.Receive()
+	return err
+}
+
+/*GetCollectionMemberScore - get the score of the key in the collection, false
+* if the key is not in the collection */
+func (ms *Store) GetCollectionMemberScore(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, key datastore.Key) (int64, bool, error) {
+	con := GetEntityCon(ctx, entityMetadata)
+	con.Send("ZSCORE", collectionName, key)
+	con.Flush()
+	data, err := con.Receive()
+	if err != nil {
+		return 0, false, err
+	}
+	if data == nil {
+		return 0, false, nil
+	}
+	score, err := toScore(data)
+	if err != nil {
+		return 0, false, err
+	}
+	return score, true, nil
+}
+
+/*GetCollectionMemberScores - get the scores of the keys in the collection at
+* once, false for the keys not in the collection */
+func (ms *Store) GetCollectionMemberScores(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, keys []datastore.Key) ([]int64, []bool, error) {
+	con := GetEntityCon(ctx, entityMetadata)
+	for _, key := range keys {
+		con.Send("ZSCORE", collectionName, key)
+	}
+	con.Flush()
+	var (
+		scores  = make([]int64, len(keys))
+		members = make([]bool, len(keys))
+		rerr    error
+	)
+	// all the replies are received to keep the connection in sync
+	for idx := range keys {
+		data, err := con.Receive()
+		if err != nil || rerr != nil {
+			if rerr == nil {
+				rerr = err
+			}
+			continue
+		}
+		if data == nil {
+			continue
+		}
+		if scores[idx], rerr = toScore(data); rerr != nil {
+			continue
+		}
+		members[idx] = true
+	}
+	if rerr != nil {
+		return nil, nil, rerr
+	}
+	return scores, members, nil
+}
+
+/*GetCollectionRank - get the position of the key in the collection iterated
+* by IterateCollection, -1 if the key is not in the collection */
+func (ms *Store) GetCollectionRank(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, key datastore.Key) (int64, error) {
+	con := GetEntityCon(ctx, entityMetadata)
+	con.Send("ZREVRANK", collectionName, key)
+	con.Flush()
+	data, err := con.Receive()
+	if err != nil {
+		return -1, err
+	}
+	if data == nil {
+		return -1, nil
+	}
+	rank, ok := data.(int64)
+	if !ok {
+		return -1, common.NewError("error", fmt.Sprintf("error casting data to int64 : %T", data))
+	}
+	return rank, nil
+}
+
+/*GetCollectionRange - get up to limit keys of the collection with the scores
+* between the min and max scores in ascending order of the score */
+func (ms *Store) GetCollectionRange(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, minScore, maxScore int64, limit int) ([]datastore.Key, []int64, error) {
+	con := GetEntityCon(ctx, entityMetadata)
+	con.Send("ZRANGEBYSCORE", collectionName, minScore, maxScore, "WITHSCORES", "LIMIT", 0, limit)
+	con.Flush()
+	data, err := con.Receive()
+	if err != nil {
+		return nil, nil, err
+	}
+	bkeys, ok := data.([]interface{})
+	if !ok {
+		return nil, nil, common.NewError("error", fmt.Sprintf("error casting data to []interface{} : %T", data))
+	}
+	count := len(bkeys) / 2
+	keys := make([]datastore.Key, count)
+	scores := make([]int64, count)
+	for i := 0; i < count; i++ {
+		keys[i] = datastore.ToKey(bkeys[2*i])
+		if scores[i], err = toScore(bkeys[2*i+1]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, scores, nil
+}
+
+/*TrimCollection - remove the keys with the lowest scores from the collection
+* to keep it within the size, returns the keys removed */
+func (ms *Store) TrimCollection(ctx context.Context, entityMetadata datastore.EntityMetadata, collectionName string, size int64) ([]datastore.Key, error) {
+	count := ms.GetCollectionSize(ctx, entityMetadata, collectionName)
+	if count < 0 {
+		return nil, common.NewError("trim_collection", "error getting the collection size")
+	}
+	if count <= size {
+		return nil, nil
+	}
+	keys, _, err := ms.GetCollectionRange(ctx, entityMetadata, collectionName, math.MinInt64, math.MaxInt64, int(count-size))
+	if err != nil {
+		return nil, err
+	}
+	if err := ms.DeleteKeysFromCollection(ctx, entityMetadata, collectionName, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func toScore(data interface{}) (int64, error) {
+	scoredata, ok := data.([]byte)
+	if !ok {
+		return 0, common.NewError("error", fmt.Sprintf("error casting score to []byte : %T", data))
+	}
+	return strconv.ParseInt(string(scoredata), 10, 63)
+}
@@ -7,8 +7,12 @@ import (
 	"0chain.net/core/datastore"
 	"0chain.net/core/memorystore"
 	"context"
+	"math"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func init() {
@@ -291,3 +295,83 @@ func TestCollectionTrimmer(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_TrimCollection(t *testing.T) {
+	initDefaultTxnPool(t)
+
+	var (
+		ms  = memorystore.GetStorageProvider().(*memorystore.Store)
+		emd = transaction.Provider().GetEntityMetadata()
+		ctx = memorystore.WithEntityConnection(context.Background(), emd)
+	)
+	defer memorystore.Close(ctx)
+
+	txns := make([]*transaction.Transaction, 4)
+	for i := range txns {
+		txns[i] = &transaction.Transaction{}
+		txns[i].SetKey(datastore.ToKey(strconv.Itoa(i)))
+		txns[i].SetCollectionScore(int64(10 * (i + 1)))
+	}
+	addTxnsToCollection(t, txns[2], txns[0], txns[3], txns[1])
+	collection := txns[0].GetCollectionName()
+
+	rank, err := ms.GetCollectionRank(ctx, emd, collection, "0")
+	require.NoError(t, err)
+	require.EqualValues(t, 3, rank)
+	rank, err = ms.GetCollectionRank(ctx, emd, collection, "missing")
+	require.NoError(t, err)
+	require.EqualValues(t, -1, rank)
+
+	keys, err := ms.TrimCollection(ctx, emd, collection, 2)
+	require.NoError(t, err)
+	require.Equal(t, []datastore.Key{"0", "1"}, keys)
+
+	keys, scores, err := ms.GetCollectionRange(ctx, emd, collection, math.MinInt64, math.MaxInt64, 10)
+	require.NoError(t, err)
+	require.Equal(t, []datastore.Key{"2", "3"}, keys)
+	require.Equal(t, []int64{30, 40}, scores)
+
+	keys, err = ms.TrimCollection(ctx, emd, collection, 2)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestStore_AddKeyToCollectionWithinSize(t *testing.T) {
+	initDefaultTxnPool(t)
+
+	var (
+		ms         = memorystore.GetStorageProvider().(*memorystore.Store)
+		emd        = transaction.Provider().GetEntityMetadata()
+		ctx        = memorystore.WithEntityConnection(context.Background(), emd)
+		collection = "test:within_size"
+	)
+	defer memorystore.Close(ctx)
+
+	added, err := ms.AddKeyToCollectionWithinSize(ctx, emd, collection, "1", 1, 2, nil, time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+	added, err = ms.AddKeyToCollectionWithinSize(ctx, emd, collection, "2", 2, 2, nil, time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+	added, err = ms.AddKeyToCollectionWithinSize(ctx, emd, collection, "3", 3, 2, nil, time.Minute)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	// the key in the collection is updated, the excluded keys aren't counted
+	added, err = ms.AddKeyToCollectionWithinSize(ctx, emd, collection, "2", 5, 2, nil, time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+	added, err = ms.AddKeyToCollectionWithinSize(ctx, emd, collection, "3", 3, 2, []datastore.Key{"1", "missing"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	scores, members, err := ms.GetCollectionMemberScores(ctx, emd, collection, []datastore.Key{"1", "missing", "2", "3"})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false, true, true}, members)
+	require.Equal(t, []int64{1, 0, 5, 3}, scores)
+
+	scores, members, err = ms.GetCollectionMemberScores(ctx, emd, collection, nil)
+	require.NoError(t, err)
+	require.Empty(t, scores)
+	require.Empty(t, members)
+}
@@ -46,12 +46,16 @@ type MerklePatriciaTrieI interface {
 
 	// useful for syncing up
 	GetPathNodes(path Path) ([]Node, error)
+	// inclusion/exclusion proof of a path, see VerifyProof
+	GetPathProof(path Path) (*MPTProof, error)
 
 	// useful for pruning the state below a certain origin number
 	UpdateVersion(ctx context.Context, version Sequence, missingNodeHander MPTMissingNodeHandler) error // mark
 
 	// FindMissingNodes find all missing nodes in a MPT tree
 	FindMissingNodes(ctx context.Context) ([]Path, []Key, error)
+	// Diff reports the values changed from this state to the given one
+	Diff(ctx context.Context, to MerklePatriciaTrieI, handler MPTDiffHandler) error
 	// only for testing and debugging
 	PrettyPrint(w io.Writer) error
 
@@ -1001,7 +1001,7 @@ func (mpt *MerklePatriciaTrie) Validate() error {
 	case *MemoryNodeDB:
 	case *LevelNodeDB:
 		db = dbImpl.GetCurrent()
-	case *PNodeDB:
+	case PersistentNodeDB:
 		return nil
 	}
 	for _, c := range changes {
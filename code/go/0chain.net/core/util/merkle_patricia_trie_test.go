@@ -12,7 +12,6 @@ import (
 	"sync"
 	"testing"
 
-	"github.com/0chain/gorocksdb"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
@@ -40,23 +39,32 @@ func (as *AState) Decode(buf []byte) error {
 	return nil
 }
 
-func newPNodeDB(t *testing.T) (pndb *PNodeDB, cleanup func()) {
+const dataDir = "tmp"
+
+func cleanUp() error {
+	if err := os.RemoveAll(dataDir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func newBoltNodeDB(t *testing.T) (bndb *BoltNodeDB, cleanup func()) {
 	t.Helper()
 
-	var dirname, err = ioutil.TempDir("", "mpt-pndb")
+	var dirname, err = ioutil.TempDir("", "mpt-bndb")
 	require.NoError(t, err)
 
-	pndb, err = NewPNodeDB(filepath.Join(dirname, "mpt"),
-		filepath.Join(dirname, "log"))
+	bndb, err = NewBoltNodeDB(filepath.Join(dirname, "mpt.bolt"))
 	if err != nil {
 		if err := os.RemoveAll(dirname); err != nil {
 			t.Fatal(err)
 		}
-		t.Fatal(err) //
+		t.Fatal(err)
 	}
 
 	cleanup = func() {
-		pndb.db.Close()
+		bndb.Close()
 		if err := os.RemoveAll(dirname); err != nil {
 			t.Fatal(err)
 		}
@@ -65,9 +73,33 @@ func newPNodeDB(t *testing.T) (pndb *PNodeDB, cleanup func()) {
 	return
 }
 
+// runWithPersistentNodeDBs runs the test against every persistent node db
+// backend.
+func runWithPersistentNodeDBs(t *testing.T,
+	test func(t *testing.T, pndb PersistentNodeDB)) {
+
+	backends := []struct {
+		name  string
+		newDB func(t *testing.T) (PersistentNodeDB, func())
+	}{
+		{"rocksdb", func(t *testing.T) (PersistentNodeDB, func()) { return newPNodeDB(t) }},
+		{"bolt", func(t *testing.T) (PersistentNodeDB, func()) { return newBoltNodeDB(t) }},
+	}
+	for _, backend := range backends {
+		backend := backend
+		t.Run(backend.name, func(t *testing.T) {
+			pndb, cleanup := backend.newDB(t)
+			defer cleanup()
+			test(t, pndb)
+		})
+	}
+}
+
 func TestMerkleTreeSaveToDB(t *testing.T) {
-	pndb, cleanup := newPNodeDB(t)
-	defer cleanup()
+	runWithPersistentNodeDBs(t, testMerkleTreeSaveToDB)
+}
+
+func testMerkleTreeSaveToDB(t *testing.T, pndb PersistentNodeDB) {
 
 	mpt := NewMerklePatriciaTrie(pndb, Sequence(2016))
 	db := NewLevelNodeDB(NewMemoryNodeDB(), mpt.db, false)
@@ -99,8 +131,10 @@ func TestMerkleTreeSaveToDB(t *testing.T) {
 }
 
 func TestMerkeTreePruning(t *testing.T) {
-	pndb, cleanup := newPNodeDB(t)
-	defer cleanup()
+	runWithPersistentNodeDBs(t, testMerkeTreePruning)
+}
+
+func testMerkeTreePruning(t *testing.T, pndb PersistentNodeDB) {
 
 	mpt := NewMerklePatriciaTrie(pndb, Sequence(0))
 	db := NewLevelNodeDB(NewMemoryNodeDB(), mpt.db, false)
@@ -179,8 +213,10 @@ func TestMerkeTreePruning(t *testing.T) {
 }
 
 func TestMerkeTreeGetChanges(t *testing.T) {
-	pndb, cleanup := newPNodeDB(t)
-	defer cleanup()
+	runWithPersistentNodeDBs(t, testMerkeTreeGetChanges)
+}
+
+func testMerkeTreeGetChanges(t *testing.T, pndb PersistentNodeDB) {
 
 	mpt := NewMerklePatriciaTrie(pndb, Sequence(0))
 	var mndb = NewMemoryNodeDB()
@@ -272,10 +308,11 @@ func computeMPTRoot(t *testing.T, mpt MerklePatriciaTrieI) (rk Key) {
 }
 
 func TestMPT_blockGenerationFlow(t *testing.T) {
+	runWithPersistentNodeDBs(t, testMPTBlockGenerationFlow)
+}
 
-	// persistent node DB represents chain state DB
-	var stateDB, cleanup = newPNodeDB(t)
-	defer cleanup()
+// persistent node DB represents chain state DB
+func testMPTBlockGenerationFlow(t *testing.T, stateDB PersistentNodeDB) {
 
 	var mpt = NewMerklePatriciaTrie(stateDB, 0)
 
@@ -1004,69 +1041,6 @@ func TestMerklePatriciaTrie_GetNodeValue(t *testing.T) {
 	}
 }
 
-func TestMerklePatriciaTrie_Insert(t *testing.T) {
-	db, cleanup := newPNodeDB(t)
-	defer cleanup()
-
-	db.wo = gorocksdb.NewDefaultWriteOptions()
-	db.wo.SetSync(true)
-	db.wo.DisableWAL(true)
-
-	type fields struct {
-		mutex           *sync.RWMutex
-		Root            Key
-		db              NodeDB
-		ChangeCollector ChangeCollectorI
-		Version         Sequence
-	}
-	type args struct {
-		path  Path
-		value Serializable
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    Key
-		wantErr bool
-	}{
-		{
-			name:    "Test_MerklePatriciaTrie_Insert_Nil_Value_ERR",
-			fields:  fields{mutex: &sync.RWMutex{}, db: NewMemoryNodeDB()},
-			wantErr: true,
-		},
-		{
-			name:    "Test_MerklePatriciaTrie_Insert_Insert_Node_ERR",
-			fields:  fields{mutex: &sync.RWMutex{}, db: db},
-			args:    args{value: &SecureSerializableValue{Buffer: []byte("data")}},
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mpt := &MerklePatriciaTrie{
-				mutex:           tt.fields.mutex,
-				Root:            tt.fields.Root,
-				db:              tt.fields.db,
-				ChangeCollector: tt.fields.ChangeCollector,
-				Version:         tt.fields.Version,
-			}
-			got, err := mpt.Insert(tt.args.path, tt.args.value)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Insert() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Insert() got = %v, want %v", got, tt.want)
-			}
-		})
-	}
-
-	if err := cleanUp(); err != nil {
-		t.Fatal(err)
-	}
-}
-
 func TestMerklePatriciaTrie_GetPathNodes(t *testing.T) {
 	t.Parallel()
 
@@ -1403,151 +1377,6 @@ func TestMerklePatriciaTrie_insert(t *testing.T) {
 	}
 }
 
-func TestMerklePatriciaTrie_insertAtNode(t *testing.T) {
-	db, cleanup := newPNodeDB(t)
-	defer cleanup()
-	db.wo = gorocksdb.NewDefaultWriteOptions()
-	db.wo.SetSync(true)
-	db.wo.DisableWAL(true)
-
-	path := Path("path")
-
-	type fields struct {
-		mutex           *sync.RWMutex
-		Root            Key
-		db              NodeDB
-		ChangeCollector ChangeCollectorI
-		Version         Sequence
-	}
-	type args struct {
-		value  Serializable
-		node   Node
-		prefix Path
-		path   Path
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    Node
-		want1   Key
-		wantErr bool
-	}{
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Full_Node_ERR",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewFullNode(&SecureSerializableValue{}),
-				path: Path("01"),
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewLeafNode(Path(""), Path(""), 0, &SecureSerializableValue{}),
-				path: Path("01"),
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR2",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewLeafNode(Path(""), path, 0, &SecureSerializableValue{}),
-				path: append(path, []byte("123")...),
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR3",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewLeafNode(Path(""), append(path, []byte("098")...), 0, &SecureSerializableValue{}),
-				path: append(path, []byte("123")...),
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR4",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewLeafNode(Path(""), append(path, []byte("098")...), 0, &SecureSerializableValue{}),
-				path: path,
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewExtensionNode(path, Key("Key")),
-				path: path,
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR2",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewExtensionNode(path, Key("Key")),
-				path: append(path, []byte("123")...),
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR3",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewExtensionNode(append(path, []byte("0")...), Key("Key")),
-				path: append(path, []byte("123")...),
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR4",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewExtensionNode(append(path, []byte("0")...), Key("Key")),
-				path: path,
-			},
-			wantErr: true,
-		},
-		{
-			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR5",
-			fields: fields{mutex: &sync.RWMutex{}, db: db},
-			args: args{
-				node: NewExtensionNode(append(path, []byte("098")...), Key("Key")),
-				path: path,
-			},
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mpt := &MerklePatriciaTrie{
-				mutex:           tt.fields.mutex,
-				Root:            tt.fields.Root,
-				db:              tt.fields.db,
-				ChangeCollector: tt.fields.ChangeCollector,
-				Version:         tt.fields.Version,
-			}
-			got, got1, err := mpt.insertAtNode(tt.args.value, tt.args.node, tt.args.prefix, tt.args.path)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("insertAtNode() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("insertAtNode() got = %v, want %v", got, tt.want)
-			}
-			if !reflect.DeepEqual(got1, tt.want1) {
-				t.Errorf("insertAtNode() got1 = %v, want %v", got1, tt.want1)
-			}
-		})
-	}
-}
-
 func TestMerklePatriciaTrie_MergeDB(t *testing.T) {
 	t.Parallel()
 
@@ -1714,6 +1543,8 @@ func TestMerklePatriciaTrie_Validate(t *testing.T) {
 
 	pndb, cleanup := newPNodeDB(t)
 	defer cleanup()
+	bndb, bcleanup := newBoltNodeDB(t)
+	defer bcleanup()
 
 	mpt := NewMerklePatriciaTrie(nil, 0)
 
@@ -1745,6 +1576,17 @@ func TestMerklePatriciaTrie_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Test_MerklePatriciaTrie_Validate_BNDB_OK",
+			fields: fields{
+				mutex:           &sync.RWMutex{},
+				Root:            mpt.Root,
+				db:              bndb,
+				ChangeCollector: mpt.ChangeCollector,
+				Version:         mpt.Version,
+			},
+			wantErr: false,
+		},
 		{
 			name: "Test_MerklePatriciaTrie_Validate_MNDB_OK",
 			fields: fields{
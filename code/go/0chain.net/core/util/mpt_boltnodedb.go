@@ -0,0 +1,297 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	. "0chain.net/core/logging"
+)
+
+var boltNodesBucket = []byte("nodes")
+
+/*BoltNodeDB - a node db persisted in a pure Go embedded key-value store.
+* Every write is synced to the disk on commit, so the committed nodes survive
+* a crash without Flush. */
+type BoltNodeDB struct {
+	file     string
+	db       *bolt.DB
+	mutex    sync.Mutex
+	versions []int64
+}
+
+/*NewBoltNodeDB - create a new BoltNodeDB */
+func NewBoltNodeDB(file string) (*BoltNodeDB, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	db, err := openBoltNodesDB(file, false)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltNodeDB{file: file, db: db}, nil
+}
+
+// openBoltNodesDB opens the db, a db without sync should be synced
+// explicitly, otherwise committed nodes can be lost on a crash
+func openBoltNodesDB(file string, noSync bool) (*bolt.DB, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{
+		Timeout:        10 * time.Second,
+		NoSync:         noSync,
+		NoFreelistSync: true,
+		FreelistType:   bolt.FreelistMapType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltNodesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+/*GetNode - implement interface */
+func (bndb *BoltNodeDB) GetNode(key Key) (Node, error) {
+	var buf []byte
+	err := bndb.db.View(func(tx *bolt.Tx) error {
+		// the data is valid only during the transaction
+		buf = append(buf, tx.Bucket(boltNodesBucket).Get(key)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) == 0 {
+		return nil, ErrNodeNotFound
+	}
+	return CreateNode(bytes.NewReader(buf))
+}
+
+/*PutNode - implement interface */
+func (bndb *BoltNodeDB) PutNode(key Key, node Node) error {
+	return bndb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltNodesBucket).Put(key, node.Encode())
+	})
+}
+
+/*DeleteNode - implement interface */
+func (bndb *BoltNodeDB) DeleteNode(key Key) error {
+	return bndb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltNodesBucket).Delete(key)
+	})
+}
+
+/*MultiGetNode - get multiple nodes */
+func (bndb *BoltNodeDB) MultiGetNode(keys []Key) ([]Node, error) {
+	var nodes []Node
+	var err error
+	for _, key := range keys {
+		node, nerr := bndb.GetNode(key)
+		if nerr != nil {
+			err = nerr
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, err
+}
+
+/*MultiPutNode - implement interface */
+func (bndb *BoltNodeDB) MultiPutNode(keys []Key, nodes []Node) error {
+	return bndb.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltNodesBucket)
+		for idx, key := range keys {
+			if err := b.Put(key, nodes[idx].Encode()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+/*MultiDeleteNode - implement interface */
+func (bndb *BoltNodeDB) MultiDeleteNode(keys []Key) error {
+	return bndb.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltNodesBucket)
+		for _, key := range keys {
+			if err := b.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type boltNodeDBEntry struct {
+	key   Key
+	value []byte
+}
+
+/*Iterate - implement interface
+* The nodes are read in batches and no transaction is open while the handler
+* runs, so the handler is free to modify the db (e.g. to prune the nodes). */
+func (bndb *BoltNodeDB) Iterate(ctx context.Context, handler NodeDBIteratorHandler) error {
+	var (
+		last  Key
+		batch = make([]boltNodeDBEntry, 0, BatchSize)
+	)
+	for {
+		batch = batch[:0]
+		err := bndb.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(boltNodesBucket).Cursor()
+			var k, v []byte
+			if last == nil {
+				k, v = c.First()
+			} else if k, v = c.Seek(last); bytes.Equal(k, last) {
+				k, v = c.Next()
+			}
+			for ; k != nil && len(batch) < BatchSize; k, v = c.Next() {
+				batch = append(batch, boltNodeDBEntry{
+					key:   append(Key(nil), k...),
+					value: append([]byte(nil), v...),
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, entry := range batch {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			node, err := CreateNode(bytes.NewReader(entry.value))
+			if err != nil {
+				Logger.Error("iterate - create node", zap.String("key", ToHex(entry.key)), zap.Error(err))
+				continue
+			}
+			if err = handler(ctx, entry.key, node); err != nil {
+				Logger.Error("iterate - create node handler error", zap.String("key", ToHex(entry.key)), zap.Error(err))
+				return err
+			}
+		}
+		last = batch[len(batch)-1].key
+	}
+}
+
+/*Flush - sync the db, the writes are synced on commit anyway */
+func (bndb *BoltNodeDB) Flush() {
+	if err := bndb.db.Sync(); err != nil {
+		Logger.Error("bolt node db - sync", zap.Error(err))
+	}
+}
+
+/*Compact - rewrite the nodes to a new file replacing the db file, the file
+* of bolt never shrinks otherwise. The db must not be used meanwhile. */
+func (bndb *BoltNodeDB) Compact() error {
+	tmp := bndb.file + ".compact"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	dst, err := openBoltNodesDB(tmp, true) // synced after the copy
+	if err != nil {
+		return err
+	}
+	if err = copyBoltNodes(dst, bndb.db); err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := bndb.db.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, bndb.file); err != nil {
+		return err
+	}
+	bndb.db, err = openBoltNodesDB(bndb.file, false)
+	return err
+}
+
+// copyBoltNodes copies the nodes in the order of the keys with the pages
+// filled up, committing every BatchSize nodes.
+func copyBoltNodes(dst, src *bolt.DB) error {
+	return src.View(func(stx *bolt.Tx) error {
+		var (
+			c    = stx.Bucket(boltNodesBucket).Cursor()
+			k, v = c.First()
+		)
+		for k != nil {
+			err := dst.Update(func(dtx *bolt.Tx) error {
+				b := dtx.Bucket(boltNodesBucket)
+				b.FillPercent = 1
+				for i := 0; k != nil && i < BatchSize; i++ {
+					if err := b.Put(k, v); err != nil {
+						return err
+					}
+					k, v = c.Next()
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+/*PruneBelowVersion - prune the state below the given origin */
+func (bndb *BoltNodeDB) PruneBelowVersion(ctx context.Context, version Sequence) error {
+	return pruneBelowVersion(ctx, bndb, version)
+}
+
+/*Size - count number of keys in the db */
+func (bndb *BoltNodeDB) Size(ctx context.Context) int64 {
+	var count int64
+	err := bndb.db.View(func(tx *bolt.Tx) error {
+		count = int64(tx.Bucket(boltNodesBucket).Stats().KeyN)
+		return nil
+	})
+	if err != nil {
+		Logger.Error("count", zap.Error(err))
+		return -1
+	}
+	return count
+}
+
+// Close closes the db
+func (bndb *BoltNodeDB) Close() {
+	bndb.db.Close()
+}
+
+// GetDBVersions returns all tracked db versions
+func (bndb *BoltNodeDB) GetDBVersions() []int64 {
+	bndb.mutex.Lock()
+	defer bndb.mutex.Unlock()
+	vs := make([]int64, len(bndb.versions))
+	copy(vs, bndb.versions)
+	return vs
+}
+
+// TrackDBVersion appends the db version to tracked records
+func (bndb *BoltNodeDB) TrackDBVersion(v int64) {
+	bndb.mutex.Lock()
+	defer bndb.mutex.Unlock()
+	bndb.versions = append(bndb.versions, v)
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBoltTestNodes(n int, version Sequence) (keys []Key, nodes []Node) {
+	for i := 0; i < n; i++ {
+		node := NewLeafNode(Path(""), Path(fmt.Sprintf("%04x", i)), version,
+			&SecureSerializableValue{Buffer: []byte(fmt.Sprintf("value-%d", i))})
+		keys = append(keys, node.GetHashBytes())
+		nodes = append(nodes, node)
+	}
+	return
+}
+
+func TestBoltNodeDB_Nodes(t *testing.T) {
+	bndb, cleanup := newBoltNodeDB(t)
+	defer cleanup()
+
+	keys, nodes := newBoltTestNodes(10, 1)
+	require.NoError(t, bndb.MultiPutNode(keys, nodes))
+	require.EqualValues(t, 10, bndb.Size(context.Background()))
+
+	got, err := bndb.MultiGetNode(keys)
+	require.NoError(t, err)
+	for i, node := range got {
+		require.Equal(t, nodes[i].GetHash(), node.GetHash())
+	}
+
+	require.NoError(t, bndb.DeleteNode(keys[0]))
+	_, err = bndb.GetNode(keys[0])
+	require.Equal(t, ErrNodeNotFound, err)
+
+	require.NoError(t, bndb.MultiDeleteNode(keys[1:]))
+	require.EqualValues(t, 0, bndb.Size(context.Background()))
+}
+
+func TestBoltNodeDB_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mpt-bndb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "mpt.bolt")
+	bndb, err := NewBoltNodeDB(file)
+	require.NoError(t, err)
+	keys, nodes := newBoltTestNodes(3, 1)
+	require.NoError(t, bndb.MultiPutNode(keys, nodes))
+	bndb.Flush()
+	bndb.Close()
+
+	bndb, err = NewBoltNodeDB(file)
+	require.NoError(t, err)
+	defer bndb.Close()
+	node, err := bndb.GetNode(keys[2])
+	require.NoError(t, err)
+	require.Equal(t, nodes[2].GetHash(), node.GetHash())
+}
+
+func TestBoltNodeDB_PruneBelowVersion(t *testing.T) {
+	bndb, cleanup := newBoltNodeDB(t)
+	defer cleanup()
+
+	// more than a batch, so the nodes are deleted while iterating
+	oldKeys, oldNodes := newBoltTestNodes(BatchSize+10, 1)
+	require.NoError(t, bndb.MultiPutNode(oldKeys, oldNodes))
+	newKeys, newNodes := newBoltTestNodes(5, 2)
+	require.NoError(t, bndb.MultiPutNode(newKeys, newNodes))
+
+	ctx := WithPruneStats(context.Background())
+	require.NoError(t, bndb.PruneBelowVersion(ctx, 2))
+
+	ps := GetPruneStats(ctx)
+	require.EqualValues(t, BatchSize+15, ps.Total)
+	require.EqualValues(t, BatchSize+10, ps.Deleted)
+	require.EqualValues(t, 5, ps.Leaves)
+	require.EqualValues(t, 5, bndb.Size(context.Background()))
+	for _, key := range newKeys {
+		_, err := bndb.GetNode(key)
+		require.NoError(t, err)
+	}
+}
+
+func TestBoltNodeDB_TrackDBVersion(t *testing.T) {
+	bndb, cleanup := newBoltNodeDB(t)
+	defer cleanup()
+
+	bndb.TrackDBVersion(1)
+	bndb.TrackDBVersion(2)
+	require.Equal(t, []int64{1, 2}, bndb.GetDBVersions())
+}
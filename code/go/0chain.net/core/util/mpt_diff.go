@@ -0,0 +1,195 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"sort"
+)
+
+// types of the state changes
+const (
+	StateChangeAdded    = "added"
+	StateChangeRemoved  = "removed"
+	StateChangeModified = "modified"
+)
+
+/*StateChange - a value of a path changed between two states, Old is nil for
+* an added value and New is nil for a removed one */
+type StateChange struct {
+	Type string `json:"type"`
+	Path Path   `json:"path"`
+	Old  []byte `json:"old,omitempty"`
+	New  []byte `json:"new,omitempty"`
+}
+
+/*MPTDiffHandler - a handler of the changes found by the mpt diff */
+type MPTDiffHandler func(ctx context.Context, change *StateChange) error
+
+/*Diff - implement interface
+* Both tries are walked in parallel from the roots, the subtrees having the
+* same hash are skipped. The changes are reported in the order of paths. */
+func (mpt *MerklePatriciaTrie) Diff(ctx context.Context, to MerklePatriciaTrieI,
+	handler MPTDiffHandler) error {
+
+	mpt.mutex.RLock()
+	defer mpt.mutex.RUnlock()
+
+	d := &mptDiff{
+		from:    mpt.db,
+		to:      to.GetNodeDB(),
+		handler: handler,
+	}
+	return d.diff(ctx, Path{}, mpt.Root, to.GetRoot())
+}
+
+type mptDiff struct {
+	from    NodeDB
+	to      NodeDB
+	handler MPTDiffHandler
+}
+
+func (d *mptDiff) diff(ctx context.Context, path Path, from, to Key) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	if bytes.Equal(from, to) {
+		return nil
+	}
+	if len(from) == 0 || len(to) == 0 {
+		return d.diffValues(ctx, path, from, to)
+	}
+	fnode, err := d.from.GetNode(from)
+	if err != nil {
+		return err
+	}
+	tnode, err := d.to.GetNode(to)
+	if err != nil {
+		return err
+	}
+
+	switch fn := fnode.(type) {
+	case *FullNode:
+		tn, ok := tnode.(*FullNode)
+		if !ok {
+			break
+		}
+		if err := d.diffValue(ctx, path, fn.Value, tn.Value); err != nil {
+			return err
+		}
+		for i := byte(0); i < 16; i++ {
+			pe := fn.indexToByte(i)
+			err := d.diff(ctx, concatPath(path, Path{pe}), fn.GetChild(pe), tn.GetChild(pe))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ExtensionNode:
+		tn, ok := tnode.(*ExtensionNode)
+		if !ok || !bytes.Equal(fn.Path, tn.Path) {
+			break
+		}
+		return d.diff(ctx, concatPath(path, fn.Path), fn.NodeKey, tn.NodeKey)
+	case *LeafNode:
+		tn, ok := tnode.(*LeafNode)
+		if !ok || !bytes.Equal(fn.Path, tn.Path) {
+			break
+		}
+		return d.diffValue(ctx, concatPath(path, fn.Path), fn.Value, tn.Value)
+	}
+	// different structure of the subtrees
+	return d.diffValues(ctx, path, from, to)
+}
+
+// diffValues compares all the values of the subtrees.
+func (d *mptDiff) diffValues(ctx context.Context, path Path, from, to Key) error {
+	fvalues, err := subtreeValues(ctx, d.from, path, from)
+	if err != nil {
+		return err
+	}
+	tvalues, err := subtreeValues(ctx, d.to, path, to)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(fvalues)+len(tvalues))
+	for p := range fvalues {
+		paths = append(paths, p)
+	}
+	for p := range tvalues {
+		if _, ok := fvalues[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		change := newStateChange(Path(p), fvalues[p], tvalues[p])
+		if change == nil {
+			continue
+		}
+		if err := d.handler(ctx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *mptDiff) diffValue(ctx context.Context, path Path, from, to *ValueNode) error {
+	change := newStateChange(path, valueNodeBytes(from), valueNodeBytes(to))
+	if change == nil {
+		return nil
+	}
+	return d.handler(ctx, change)
+}
+
+func newStateChange(path Path, from, to []byte) *StateChange {
+	switch {
+	case from == nil && to == nil, bytes.Equal(from, to):
+		return nil
+	case from == nil:
+		return &StateChange{Type: StateChangeAdded, Path: path, New: to}
+	case to == nil:
+		return &StateChange{Type: StateChangeRemoved, Path: path, Old: from}
+	}
+	return &StateChange{Type: StateChangeModified, Path: path, Old: from, New: to}
+}
+
+func valueNodeBytes(vn *ValueNode) []byte {
+	if vn == nil || !vn.HasValue() {
+		return nil
+	}
+	return vn.GetValue().Encode()
+}
+
+// subtreeValues returns values of the subtree of the key by their paths.
+func subtreeValues(ctx context.Context, ndb NodeDB, path Path, key Key) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	if len(key) == 0 {
+		return values, nil
+	}
+	mpt := NewMerklePatriciaTrie(ndb, 0)
+	handler := func(ctx context.Context, path Path, key Key, node Node) error {
+		if vn, ok := node.(*ValueNode); ok {
+			values[string(path)] = valueNodeBytes(vn)
+		}
+		return nil
+	}
+	if err := mpt.iterate(ctx, concatPath(path), key, handler, NodeTypeValueNode); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// concatPath returns a new path not sharing memory with the given ones.
+func concatPath(paths ...Path) Path {
+	var n int
+	for _, p := range paths {
+		n += len(p)
+	}
+	path := make(Path, 0, n)
+	for _, p := range paths {
+		path = append(path, p...)
+	}
+	return path
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// expectedDiff compares all the values of the tries.
+func expectedDiff(t *testing.T, from, to MerklePatriciaTrieI) []*StateChange {
+	fvalues, err := subtreeValues(context.Background(), from.GetNodeDB(), nil, from.GetRoot())
+	require.NoError(t, err)
+	tvalues, err := subtreeValues(context.Background(), to.GetNodeDB(), nil, to.GetRoot())
+	require.NoError(t, err)
+
+	var changes []*StateChange
+	for p, v := range fvalues {
+		if change := newStateChange(Path(p), v, tvalues[p]); change != nil {
+			changes = append(changes, change)
+		}
+	}
+	for p, v := range tvalues {
+		if _, ok := fvalues[p]; !ok {
+			changes = append(changes, newStateChange(Path(p), nil, v))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Path, changes[j].Path) < 0
+	})
+	return changes
+}
+
+func diffMPT(t *testing.T, from, to MerklePatriciaTrieI) []*StateChange {
+	var changes []*StateChange
+	err := from.Diff(context.Background(), to,
+		func(ctx context.Context, change *StateChange) error {
+			changes = append(changes, change)
+			return nil
+		})
+	require.NoError(t, err)
+	return changes
+}
+
+func TestMerklePatriciaTrie_Diff(t *testing.T) {
+	from := newSnapshotTestMPT(t, 200)
+	to := NewMerklePatriciaTrie(NewLevelNodeDB(NewMemoryNodeDB(), from.db, false), Sequence(2))
+	to.SetRoot(from.GetRoot())
+
+	require.Empty(t, diffMPT(t, from, to))
+
+	for i := 0; i < 200; i += 10 {
+		doStrValInsert(t, to, fmt.Sprintf("%06x", i*7919), fmt.Sprintf("new value %d", i))
+	}
+	for i := 5; i < 200; i += 20 {
+		_, err := to.Delete(Path(fmt.Sprintf("%06x", i*7919)))
+		require.NoError(t, err)
+	}
+	for i := 0; i < 20; i++ {
+		// change the structure of the trie under the existing keys
+		doStrValInsert(t, to, fmt.Sprintf("%06x11", i*7919*3), "added value")
+	}
+
+	changes := diffMPT(t, from, to)
+	require.Equal(t, expectedDiff(t, from, to), changes)
+
+	var added, removed, modified int
+	for _, change := range changes {
+		switch change.Type {
+		case StateChangeAdded:
+			added++
+		case StateChangeRemoved:
+			removed++
+		case StateChangeModified:
+			modified++
+		}
+	}
+	require.Equal(t, 20, added)
+	require.Equal(t, 10, removed)
+	require.Equal(t, 20, modified)
+
+	// reversed
+	reversed := diffMPT(t, to, from)
+	require.Equal(t, expectedDiff(t, to, from), reversed)
+
+	// from the empty state
+	empty := NewMerklePatriciaTrie(NewMemoryNodeDB(), 0)
+	all := diffMPT(t, empty, from)
+	require.Len(t, all, 200)
+	for _, change := range all {
+		require.Equal(t, StateChangeAdded, change.Type)
+	}
+}
@@ -0,0 +1,205 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+)
+
+/*NodeDBVersion - the nodes of a version found in a node db, the roots are
+* the nodes of the version not referenced by any other node of the db: the
+* roots of the states saved with the version and of the orphaned subtrees */
+type NodeDBVersion struct {
+	Version Sequence `json:"version"`
+	Nodes   int64    `json:"nodes"`
+	Roots   []string `json:"roots,omitempty"`
+}
+
+/*CompactNodeDB - a node db that can reclaim the space of the deleted nodes */
+type CompactNodeDB interface {
+	Compact() error
+}
+
+// nodeChildren returns the keys of the nodes the node refers to.
+func nodeChildren(node Node) []Key {
+	switch nodeImpl := node.(type) {
+	case *FullNode:
+		var children []Key
+		for _, child := range nodeImpl.Children {
+			if child != nil {
+				children = append(children, child)
+			}
+		}
+		return children
+	case *ExtensionNode:
+		return []Key{nodeImpl.NodeKey}
+	}
+	return nil
+}
+
+/*GetNodeDBVersions - scan all the nodes of the db and return its versions in
+* ascending order. The keys of all the nodes are kept in memory, it's meant
+* for the offline inspection of the db. */
+func GetNodeDBVersions(ctx context.Context, ndb NodeDB) ([]*NodeDBVersion, error) {
+	var (
+		versions   = make(map[Sequence]*NodeDBVersion)
+		nodes      = make(map[StrKey]Sequence)
+		referenced = make(map[StrKey]struct{})
+	)
+	handler := func(ctx context.Context, key Key, node Node) error {
+		version := node.GetVersion()
+		v, ok := versions[version]
+		if !ok {
+			v = &NodeDBVersion{Version: version}
+			versions[version] = v
+		}
+		v.Nodes++
+		nodes[StrKey(key)] = version
+		for _, child := range nodeChildren(node) {
+			referenced[StrKey(child)] = struct{}{}
+		}
+		return nil
+	}
+	if err := ndb.Iterate(ctx, handler); err != nil {
+		return nil, err
+	}
+	for key, version := range nodes {
+		if _, ok := referenced[key]; !ok {
+			v := versions[version]
+			v.Roots = append(v.Roots, ToHex(Key(key)))
+		}
+	}
+	list := make([]*NodeDBVersion, 0, len(versions))
+	for _, v := range versions {
+		sort.Strings(v.Roots)
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+/*FindOrphanNodes - returns the keys of the nodes of the db not reachable from
+* any of the given roots. The roots must be in the db. The missing nodes of the
+* roots' tries are ignored unless strict, see FindMissingNodes; the strict mode
+* is for deleting the orphans, where a not visited subtree would be lost. */
+func FindOrphanNodes(ctx context.Context, ndb NodeDB, roots []Key, strict bool) ([]Key, error) {
+	reachable := make(map[StrKey]struct{})
+	handler := func(ctx context.Context, path Path, key Key, node Node) error {
+		if node != nil {
+			reachable[StrKey(key)] = struct{}{}
+		}
+		return nil
+	}
+	mpt := NewMerklePatriciaTrie(ndb, 0)
+	for _, root := range roots {
+		if _, ok := reachable[StrKey(root)]; ok {
+			continue
+		}
+		if _, err := ndb.GetNode(root); err != nil {
+			return nil, fmt.Errorf("root %s: %w", ToHex(root), err)
+		}
+		err := mpt.IterateFrom(ctx, root, handler,
+			NodeTypeLeafNode|NodeTypeFullNode|NodeTypeExtensionNode)
+		switch {
+		case err == nil:
+		case !strict && (err == ErrNodeNotFound || err == ErrIteratingChildNodes):
+		default:
+			return nil, fmt.Errorf("root %s: %w", ToHex(root), err)
+		}
+	}
+
+	var orphans []Key
+	err := ndb.Iterate(ctx, func(ctx context.Context, key Key, node Node) error {
+		if _, ok := reachable[StrKey(key)]; !ok {
+			orphans = append(orphans, append(Key(nil), key...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphans, nil
+}
+
+/*DeleteNodes - delete the nodes of the keys from the db in batches, the
+* persistent db is flushed */
+func DeleteNodes(ctx context.Context, ndb NodeDB, keys []Key) error {
+	for len(keys) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		n := BatchSize
+		if n > len(keys) {
+			n = len(keys)
+		}
+		if err := ndb.MultiDeleteNode(keys[:n]); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	if pndb, ok := ndb.(PersistentNodeDB); ok {
+		pndb.Flush()
+	}
+	return nil
+}
+
+/*FindCorruptedNodes - returns the paths and keys of the nodes of the trie
+* not stored by the hash of their content, the missing nodes are ignored */
+func (mpt *MerklePatriciaTrie) FindCorruptedNodes(ctx context.Context) ([]Path, []Key, error) {
+	var (
+		paths []Path
+		keys  []Key
+	)
+	handler := func(ctx context.Context, path Path, key Key, node Node) error {
+		if node != nil && !bytes.Equal(key, node.GetHashBytes()) {
+			paths = append(paths, concatPath(path))
+			keys = append(keys, key)
+		}
+		return nil
+	}
+	err := mpt.Iterate(ctx, handler, NodeTypeLeafNode|NodeTypeFullNode|NodeTypeExtensionNode)
+	switch err {
+	case nil, ErrNodeNotFound, ErrIteratingChildNodes:
+		return paths, keys, nil
+	}
+	return nil, nil, err
+}
+
+/*IterateSubtree - iterate the subtree of all the paths starting with the
+* prefix, unlike IterateFrom the handler gets the full paths */
+func (mpt *MerklePatriciaTrie) IterateSubtree(ctx context.Context, prefix Path,
+	handler MPTIteratorHandler, visitNodeTypes byte) error {
+
+	mpt.mutex.RLock()
+	defer mpt.mutex.RUnlock()
+
+	if len(mpt.Root) == 0 {
+		return nil
+	}
+	var (
+		key  = mpt.Root
+		path = Path{}
+		rest = prefix
+	)
+	for len(rest) > 0 {
+		node, err := mpt.db.GetNode(key)
+		if err != nil {
+			return err
+		}
+		next, nrest, inside := subtreeStep(node, rest)
+		if inside {
+			break
+		}
+		if next == nil {
+			return nil
+		}
+		path = concatPath(path, rest[:len(rest)-len(nrest)])
+		key, rest = next, nrest
+	}
+	return mpt.iterate(ctx, path, key, handler, visitNodeTypes)
+}
@@ -0,0 +1,157 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestGetNodeDBVersions(t *testing.T) {
+	bndb, cleanup := newBoltNodeDB(t)
+	defer cleanup()
+
+	roots := saveRefCountRounds(t, bndb, 10)
+	versions, err := GetNodeDBVersions(context.TODO(), bndb)
+	require.NoError(t, err)
+	require.Len(t, versions, 10)
+
+	var nodes int64
+	for i, v := range versions {
+		require.EqualValues(t, i, v.Version)
+		require.Contains(t, v.Roots, ToHex(roots[i]))
+		nodes += v.Nodes
+	}
+	require.Equal(t, bndb.Size(context.TODO()), nodes)
+}
+
+func TestFindOrphanNodes(t *testing.T) {
+	bndb, cleanup := newBoltNodeDB(t)
+	defer cleanup()
+
+	roots := saveRefCountRounds(t, bndb, 10)
+	kept := reachableNodes(t, bndb, roots[8:])
+	orphans, err := FindOrphanNodes(context.TODO(), bndb, roots[8:], true)
+	require.NoError(t, err)
+	require.NotEmpty(t, orphans)
+	require.EqualValues(t, bndb.Size(context.TODO()), len(kept)+len(orphans))
+	for _, key := range orphans {
+		require.False(t, kept[StrKey(key)])
+	}
+
+	require.NoError(t, DeleteNodes(context.TODO(), bndb, orphans))
+	require.EqualValues(t, len(kept), bndb.Size(context.TODO()))
+	require.NoError(t, bndb.Compact())
+	require.EqualValues(t, len(kept), bndb.Size(context.TODO()))
+
+	for _, root := range roots[8:] {
+		mpt := NewMerklePatriciaTrie(bndb, 0)
+		mpt.SetRoot(root)
+		paths, keys, err := mpt.FindMissingNodes(context.TODO())
+		require.NoError(t, err)
+		require.Empty(t, paths)
+		require.Empty(t, keys)
+	}
+
+	// the nodes of the older states are missing now
+	mpt := NewMerklePatriciaTrie(bndb, 0)
+	mpt.SetRoot(roots[0])
+	_, keys, err := mpt.FindMissingNodes(context.TODO())
+	require.NoError(t, err)
+	require.NotEmpty(t, keys)
+
+	// a missing root is an error, not an empty trie
+	_, err = FindOrphanNodes(context.TODO(), bndb, roots[:1], false)
+	require.Error(t, err)
+	_, err = FindOrphanNodes(context.TODO(), bndb, []Key{Key("unknown")}, false)
+	require.Error(t, err)
+
+	// a missing node of a trie is ignored only if not strict
+	for key := range reachableNodes(t, bndb, roots[9:]) {
+		if key != StrKey(roots[9]) {
+			require.NoError(t, bndb.DeleteNode(Key(key)))
+			break
+		}
+	}
+	_, err = FindOrphanNodes(context.TODO(), bndb, roots[9:], true)
+	require.Error(t, err)
+	_, err = FindOrphanNodes(context.TODO(), bndb, roots[9:], false)
+	require.NoError(t, err)
+}
+
+func TestMerklePatriciaTrie_FindCorruptedNodes(t *testing.T) {
+	bndb, cleanup := newBoltNodeDB(t)
+	defer cleanup()
+
+	roots := saveRefCountRounds(t, bndb, 3)
+	mpt := NewMerklePatriciaTrie(bndb, 0)
+	mpt.SetRoot(roots[2])
+	paths, keys, err := mpt.FindCorruptedNodes(context.TODO())
+	require.NoError(t, err)
+	require.Empty(t, paths)
+	require.Empty(t, keys)
+
+	// a node of the trie replaced by another one
+	var leaf Key
+	require.NoError(t, mpt.Iterate(context.TODO(),
+		func(ctx context.Context, path Path, key Key, node Node) error {
+			leaf = key
+			return nil
+		}, NodeTypeLeafNode))
+	keys, nodes := newBoltTestNodes(1, 2)
+	require.NoError(t, bndb.PutNode(leaf, nodes[0]))
+
+	paths, keys, err = mpt.FindCorruptedNodes(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+	require.Equal(t, []Key{leaf}, keys)
+}
+
+func TestRefCountNodeDB_MultiDeleteNode(t *testing.T) {
+	rndb, cleanup := newRefCountNodeDB(t)
+	defer cleanup()
+
+	roots := saveRefCountRounds(t, rndb, 3)
+	refs := func(key Key) (count uint32) {
+		rndb.journal.View(func(tx *bolt.Tx) error {
+			if v := tx.Bucket(refCountsBucket).Get(key); v != nil {
+				count = binary.BigEndian.Uint32(v)
+			}
+			return nil
+		})
+		return
+	}
+	require.NotZero(t, refs(roots[2]))
+	require.NoError(t, rndb.MultiDeleteNode([]Key{roots[2]}))
+	require.Zero(t, refs(roots[2]))
+	_, err := rndb.GetNode(roots[2])
+	require.Equal(t, ErrNodeNotFound, err)
+}
+
+func TestMerklePatriciaTrie_IterateSubtree(t *testing.T) {
+	mpt := NewMerklePatriciaTrie(NewMemoryNodeDB(), 0)
+	for _, key := range []string{"0101", "0102", "0111", "0201", "1201", "12"} {
+		doStateValInsert(t, mpt, key, 1)
+	}
+
+	subtree := func(prefix string) (paths []string) {
+		err := mpt.IterateSubtree(context.TODO(), Path(prefix),
+			func(ctx context.Context, path Path, key Key, node Node) error {
+				require.True(t, bytes.HasPrefix(path, Path(prefix)))
+				paths = append(paths, string(path))
+				return nil
+			}, NodeTypeValueNode)
+		require.NoError(t, err)
+		return
+	}
+	require.Equal(t, []string{"0101", "0102", "0111"}, subtree("01"))
+	require.Equal(t, []string{"0101", "0102"}, subtree("010"))
+	require.Equal(t, []string{"0102"}, subtree("0102"))
+	require.Equal(t, []string{"12", "1201"}, subtree("12"))
+	require.Len(t, subtree(""), 6)
+	require.Empty(t, subtree("03"))
+	require.Empty(t, subtree("0103"))
+}
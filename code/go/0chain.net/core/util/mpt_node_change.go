@@ -127,6 +127,18 @@ func (cc *ChangeCollector) UpdateChanges(ndb NodeDB, origin Sequence, includeDel
 		nodes[idx] = c.New
 		idx++
 	}
+	if jndb, ok := ndb.(JournalNodeDB); ok && !includeDeletes {
+		// the replaced nodes are deleted when the version is pruned
+		deletes := make([]Key, 0, len(cc.Deletes))
+		for _, d := range cc.Deletes {
+			deletes = append(deletes, d.GetHashBytes())
+		}
+		if err := jndb.PutChanges(origin, keys, nodes, deletes); err != nil {
+			return err
+		}
+		jndb.Flush()
+		return nil
+	}
 	err := ndb.MultiPutNode(keys, nodes)
 	if err != nil {
 		return err
@@ -142,7 +154,7 @@ func (cc *ChangeCollector) UpdateChanges(ndb NodeDB, origin Sequence, includeDel
 	if len(cc.Changes) == 0 && (!includeDeletes || len(cc.Deletes) == 0) {
 		return nil
 	}
-	if pndb, ok := ndb.(*PNodeDB); ok {
+	if pndb, ok := ndb.(PersistentNodeDB); ok {
 		pndb.Flush()
 	}
 	return nil
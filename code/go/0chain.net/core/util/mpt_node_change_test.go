@@ -6,7 +6,6 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/0chain/gorocksdb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -71,56 +70,6 @@ func TestChangeCollector_AddChange(t *testing.T) {
 	}
 }
 
-func TestChangeCollector_UpdateChanges(t *testing.T) {
-	pndb, cleanup := newPNodeDB(t)
-	defer cleanup()
-
-	pndb.wo = gorocksdb.NewDefaultWriteOptions()
-	pndb.wo.DisableWAL(true)
-	pndb.wo.SetSync(true)
-
-	type fields struct {
-		Changes map[string]*NodeChange
-		Deletes map[string]Node
-	}
-	type args struct {
-		ndb            NodeDB
-		origin         Sequence
-		includeDeletes bool
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
-	}{
-		{
-			name: "Test_ChangeCollector_UpdateChanges_OK",
-			fields: fields{
-				Changes: func() map[string]*NodeChange {
-					ch := make(map[string]*NodeChange)
-					n := NewValueNode()
-					ch[n.GetHash()] = &NodeChange{New: n}
-					return ch
-				}(),
-			},
-			args:    args{ndb: pndb},
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			cc := &ChangeCollector{
-				Changes: tt.fields.Changes,
-				Deletes: tt.fields.Deletes,
-			}
-			if err := cc.UpdateChanges(tt.args.ndb, tt.args.origin, tt.args.includeDeletes); (err != nil) != tt.wantErr {
-				t.Errorf("UpdateChanges() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
 func TestChangeCollector_PrintChanges(t *testing.T) {
 	t.Parallel()
 
@@ -57,6 +57,64 @@ type NodeDB interface {
 	GetDBVersions() []int64
 }
 
+/*PersistentNodeDB - a node db backed by a persistent storage */
+type PersistentNodeDB interface {
+	NodeDB
+	Flush()
+	TrackDBVersion(v int64)
+	Close()
+}
+
+// pruneBelowVersion deletes the nodes of the persistent db with a version
+// below the given one in batches and flushes the db.
+func pruneBelowVersion(ctx context.Context, pndb PersistentNodeDB, version Sequence) error {
+	ps := GetPruneStats(ctx)
+	var total int64
+	var count int64
+	var leaves int64
+	batch := make([]Key, 0, BatchSize)
+	handler := func(ctx context.Context, key Key, node Node) error {
+		total++
+		if node.GetVersion() >= version {
+			if _, ok := node.(*LeafNode); ok {
+				leaves++
+			}
+			return nil
+		}
+		count++
+		tkey := make([]byte, len(key))
+		copy(tkey, key)
+		batch = append(batch, tkey)
+		if len(batch) == BatchSize {
+			err := pndb.MultiDeleteNode(batch)
+			batch = batch[:0]
+			if err != nil {
+				Logger.Error("prune below origin - error deleting node", zap.String("key", ToHex(key)), zap.Any("old_version", node.GetVersion()), zap.Any("new_version", version), zap.Error(err))
+				return err
+			}
+		}
+		return nil
+	}
+	err := pndb.Iterate(ctx, handler)
+	if err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		err := pndb.MultiDeleteNode(batch)
+		if err != nil {
+			Logger.Error("prune below origin - error deleting node", zap.Any("new_version", version), zap.Error(err))
+			return err
+		}
+	}
+	pndb.Flush()
+	if ps != nil {
+		ps.Total = total
+		ps.Leaves = leaves
+		ps.Deleted = count
+	}
+	return err
+}
+
 // StrKey - data type for the key used to store the node into some storage
 // (this is needed as hashmap keys can't be []byte.
 type StrKey string
@@ -391,7 +449,7 @@ func (lndb *LevelNodeDB) SetPrev(prevDB NodeDB) {
 }
 
 func (lndb *LevelNodeDB) isCurrentPersistent() (ok bool) {
-	_, ok = lndb.current.(*PNodeDB)
+	_, ok = lndb.current.(PersistentNodeDB)
 	return
 }
 
@@ -546,7 +604,7 @@ func MergeState(ctx context.Context, fndb NodeDB, tndb NodeDB) error {
 	if err != nil {
 		return err
 	}
-	if pndb, ok := tndb.(*PNodeDB); ok {
+	if pndb, ok := tndb.(PersistentNodeDB); ok {
 		pndb.Flush()
 	}
 	return nil
@@ -10,7 +10,6 @@ import (
 	"sync"
 	"testing"
 
-	"github.com/0chain/gorocksdb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -1169,61 +1168,6 @@ func TestLevelNodeDB_GetNode(t *testing.T) {
 	}
 }
 
-func TestLevelNodeDB_MultiPutNode(t *testing.T) {
-	current, cleanup := newPNodeDB(t)
-	defer cleanup()
-
-	current.wo = gorocksdb.NewDefaultWriteOptions()
-	current.wo.DisableWAL(true)
-	current.wo.SetSync(true)
-
-	type fields struct {
-		mu               *sync.RWMutex
-		current          NodeDB
-		prev             NodeDB
-		PropagateDeletes bool
-		DeletedNodes     map[StrKey]bool
-		version          int64
-		versions         []int64
-	}
-	type args struct {
-		keys  []Key
-		nodes []Node
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
-	}{
-		{
-			name:   "Test_LevelNodeDB_MultiPutNode_ERR",
-			fields: fields{current: current, mu: &sync.RWMutex{}},
-			args: args{
-				keys:  []Key{Key("key")},
-				nodes: []Node{NewFullNode(nil)},
-			},
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			lndb := &LevelNodeDB{
-				mutex:            &sync.RWMutex{},
-				current:          tt.fields.current,
-				prev:             tt.fields.prev,
-				PropagateDeletes: tt.fields.PropagateDeletes,
-				DeletedNodes:     tt.fields.DeletedNodes,
-				version:          tt.fields.version,
-				versions:         tt.fields.versions,
-			}
-			if err := lndb.MultiPutNode(tt.args.keys, tt.args.nodes); (err != nil) != tt.wantErr {
-				t.Errorf("MultiPutNode() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
 func TestLevelNodeDB_Iterate(t *testing.T) {
 	t.Parallel()
 
@@ -1,3 +1,5 @@
+// +build cgo
+
 package util
 
 import (
@@ -163,53 +165,15 @@ func (pndb *PNodeDB) Flush() {
 	pndb.db.Flush(pndb.fo)
 }
 
+/*Compact - compact the whole db to reclaim the space of the deleted nodes */
+func (pndb *PNodeDB) Compact() error {
+	pndb.db.CompactRange(gorocksdb.Range{})
+	return nil
+}
+
 /*PruneBelowVersion - prune the state below the given origin */
 func (pndb *PNodeDB) PruneBelowVersion(ctx context.Context, version Sequence) error {
-	ps := GetPruneStats(ctx)
-	var total int64
-	var count int64
-	var leaves int64
-	batch := make([]Key, 0, BatchSize)
-	handler := func(ctx context.Context, key Key, node Node) error {
-		total++
-		if node.GetVersion() >= version {
-			if _, ok := node.(*LeafNode); ok {
-				leaves++
-			}
-			return nil
-		}
-		count++
-		tkey := make([]byte, len(key))
-		copy(tkey, key)
-		batch = append(batch, tkey)
-		if len(batch) == BatchSize {
-			err := pndb.MultiDeleteNode(batch)
-			batch = batch[:0]
-			if err != nil {
-				Logger.Error("prune below origin - error deleting node", zap.String("key", ToHex(key)), zap.Any("old_version", node.GetVersion()), zap.Any("new_version", version), zap.Error(err))
-				return err
-			}
-		}
-		return nil
-	}
-	err := pndb.Iterate(ctx, handler)
-	if err != nil {
-		return err
-	}
-	if len(batch) > 0 {
-		err := pndb.MultiDeleteNode(batch)
-		if err != nil {
-			Logger.Error("prune below origin - error deleting node", zap.Any("new_version", version), zap.Error(err))
-			return err
-		}
-	}
-	pndb.Flush()
-	if ps != nil {
-		ps.Total = total
-		ps.Leaves = leaves
-		ps.Deleted = count
-	}
-	return err
+	return pruneBelowVersion(ctx, pndb, version)
 }
 
 /*Size - count number of keys in the db */
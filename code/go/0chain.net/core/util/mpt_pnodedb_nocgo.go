@@ -0,0 +1,20 @@
+// +build !cgo
+
+package util
+
+import "errors"
+
+// ErrPNodeDBRequiresCgo - the rocksdb node db is not available in a build
+// without cgo, the BoltNodeDB should be used instead
+var ErrPNodeDBRequiresCgo = errors.New(
+	"rocksdb state node db requires cgo, use the bolt backend")
+
+/*PNodeDB - a node db that is persisted in rocksdb, not available without cgo */
+type PNodeDB struct {
+	PersistentNodeDB
+}
+
+/*NewPNodeDB - the rocksdb node db is not available without cgo */
+func NewPNodeDB(dataDir string, logDir string) (*PNodeDB, error) {
+	return nil, ErrPNodeDBRequiresCgo
+}
@@ -0,0 +1,11 @@
+// +build !cgo
+
+package util
+
+import "testing"
+
+func newPNodeDB(t *testing.T) (pndb *PNodeDB, cleanup func()) {
+	t.Helper()
+	t.Skip("rocksdb node db requires cgo")
+	return
+}
@@ -1,25 +1,45 @@
+// +build cgo
+
 package util
 
 import (
 	"context"
+	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/0chain/gorocksdb"
 	"github.com/stretchr/testify/require"
 )
 
-const dataDir = "tmp"
+func newPNodeDB(t *testing.T) (pndb *PNodeDB, cleanup func()) {
+	t.Helper()
+
+	var dirname, err = ioutil.TempDir("", "mpt-pndb")
+	require.NoError(t, err)
+
+	pndb, err = NewPNodeDB(filepath.Join(dirname, "mpt"),
+		filepath.Join(dirname, "log"))
+	if err != nil {
+		if err := os.RemoveAll(dirname); err != nil {
+			t.Fatal(err)
+		}
+		t.Fatal(err) //
+	}
 
-func cleanUp() error {
-	if err := os.RemoveAll(dataDir); err != nil {
-		return err
+	cleanup = func() {
+		pndb.db.Close()
+		if err := os.RemoveAll(dirname); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	return nil
+	return
 }
 
 func TestNewPNodeDB(t *testing.T) {
@@ -338,3 +358,316 @@ func TestPNodeDB_TrackDBVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestMerklePatriciaTrie_Insert(t *testing.T) {
+	db, cleanup := newPNodeDB(t)
+	defer cleanup()
+
+	db.wo = gorocksdb.NewDefaultWriteOptions()
+	db.wo.SetSync(true)
+	db.wo.DisableWAL(true)
+
+	type fields struct {
+		mutex           *sync.RWMutex
+		Root            Key
+		db              NodeDB
+		ChangeCollector ChangeCollectorI
+		Version         Sequence
+	}
+	type args struct {
+		path  Path
+		value Serializable
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    Key
+		wantErr bool
+	}{
+		{
+			name:    "Test_MerklePatriciaTrie_Insert_Nil_Value_ERR",
+			fields:  fields{mutex: &sync.RWMutex{}, db: NewMemoryNodeDB()},
+			wantErr: true,
+		},
+		{
+			name:    "Test_MerklePatriciaTrie_Insert_Insert_Node_ERR",
+			fields:  fields{mutex: &sync.RWMutex{}, db: db},
+			args:    args{value: &SecureSerializableValue{Buffer: []byte("data")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mpt := &MerklePatriciaTrie{
+				mutex:           tt.fields.mutex,
+				Root:            tt.fields.Root,
+				db:              tt.fields.db,
+				ChangeCollector: tt.fields.ChangeCollector,
+				Version:         tt.fields.Version,
+			}
+			got, err := mpt.Insert(tt.args.path, tt.args.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Insert() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Insert() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := cleanUp(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMerklePatriciaTrie_insertAtNode(t *testing.T) {
+	db, cleanup := newPNodeDB(t)
+	defer cleanup()
+	db.wo = gorocksdb.NewDefaultWriteOptions()
+	db.wo.SetSync(true)
+	db.wo.DisableWAL(true)
+
+	path := Path("path")
+
+	type fields struct {
+		mutex           *sync.RWMutex
+		Root            Key
+		db              NodeDB
+		ChangeCollector ChangeCollectorI
+		Version         Sequence
+	}
+	type args struct {
+		value  Serializable
+		node   Node
+		prefix Path
+		path   Path
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    Node
+		want1   Key
+		wantErr bool
+	}{
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Full_Node_ERR",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewFullNode(&SecureSerializableValue{}),
+				path: Path("01"),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewLeafNode(Path(""), Path(""), 0, &SecureSerializableValue{}),
+				path: Path("01"),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR2",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewLeafNode(Path(""), path, 0, &SecureSerializableValue{}),
+				path: append(path, []byte("123")...),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR3",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewLeafNode(Path(""), append(path, []byte("098")...), 0, &SecureSerializableValue{}),
+				path: append(path, []byte("123")...),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Leaf_Node_ERR4",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewLeafNode(Path(""), append(path, []byte("098")...), 0, &SecureSerializableValue{}),
+				path: path,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewExtensionNode(path, Key("Key")),
+				path: path,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR2",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewExtensionNode(path, Key("Key")),
+				path: append(path, []byte("123")...),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR3",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewExtensionNode(append(path, []byte("0")...), Key("Key")),
+				path: append(path, []byte("123")...),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR4",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewExtensionNode(append(path, []byte("0")...), Key("Key")),
+				path: path,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Test_MerklePatriciaTrie_insertAtNode_Extension_Node_ERR5",
+			fields: fields{mutex: &sync.RWMutex{}, db: db},
+			args: args{
+				node: NewExtensionNode(append(path, []byte("098")...), Key("Key")),
+				path: path,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mpt := &MerklePatriciaTrie{
+				mutex:           tt.fields.mutex,
+				Root:            tt.fields.Root,
+				db:              tt.fields.db,
+				ChangeCollector: tt.fields.ChangeCollector,
+				Version:         tt.fields.Version,
+			}
+			got, got1, err := mpt.insertAtNode(tt.args.value, tt.args.node, tt.args.prefix, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("insertAtNode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("insertAtNode() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("insertAtNode() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestChangeCollector_UpdateChanges(t *testing.T) {
+	pndb, cleanup := newPNodeDB(t)
+	defer cleanup()
+
+	pndb.wo = gorocksdb.NewDefaultWriteOptions()
+	pndb.wo.DisableWAL(true)
+	pndb.wo.SetSync(true)
+
+	type fields struct {
+		Changes map[string]*NodeChange
+		Deletes map[string]Node
+	}
+	type args struct {
+		ndb            NodeDB
+		origin         Sequence
+		includeDeletes bool
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "Test_ChangeCollector_UpdateChanges_OK",
+			fields: fields{
+				Changes: func() map[string]*NodeChange {
+					ch := make(map[string]*NodeChange)
+					n := NewValueNode()
+					ch[n.GetHash()] = &NodeChange{New: n}
+					return ch
+				}(),
+			},
+			args:    args{ndb: pndb},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &ChangeCollector{
+				Changes: tt.fields.Changes,
+				Deletes: tt.fields.Deletes,
+			}
+			if err := cc.UpdateChanges(tt.args.ndb, tt.args.origin, tt.args.includeDeletes); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLevelNodeDB_MultiPutNode(t *testing.T) {
+	current, cleanup := newPNodeDB(t)
+	defer cleanup()
+
+	current.wo = gorocksdb.NewDefaultWriteOptions()
+	current.wo.DisableWAL(true)
+	current.wo.SetSync(true)
+
+	type fields struct {
+		mu               *sync.RWMutex
+		current          NodeDB
+		prev             NodeDB
+		PropagateDeletes bool
+		DeletedNodes     map[StrKey]bool
+		version          int64
+		versions         []int64
+	}
+	type args struct {
+		keys  []Key
+		nodes []Node
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name:   "Test_LevelNodeDB_MultiPutNode_ERR",
+			fields: fields{current: current, mu: &sync.RWMutex{}},
+			args: args{
+				keys:  []Key{Key("key")},
+				nodes: []Node{NewFullNode(nil)},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lndb := &LevelNodeDB{
+				mutex:            &sync.RWMutex{},
+				current:          tt.fields.current,
+				prev:             tt.fields.prev,
+				PropagateDeletes: tt.fields.PropagateDeletes,
+				DeletedNodes:     tt.fields.DeletedNodes,
+				version:          tt.fields.version,
+				versions:         tt.fields.versions,
+			}
+			if err := lndb.MultiPutNode(tt.args.keys, tt.args.nodes); (err != nil) != tt.wantErr {
+				t.Errorf("MultiPutNode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidProof - error indicating the proof doesn't match the root or path.
+var ErrInvalidProof = errors.New("invalid merkle proof")
+
+/*MPTProof - a compact inclusion/exclusion proof of a path in the trie.
+* The nodes are ordered from the root down to the last node visited while
+* following the path. Each node is stored in its compact proof encoding
+* (type prefix, origin for leaf nodes and the hashed part of the node), so the
+* proof doesn't depend on the version a particular node db tracks. */
+type MPTProof struct {
+	Path  string   `json:"path"`
+	Nodes []string `json:"nodes"`
+}
+
+/*GetPathProof - implement interface */
+func (mpt *MerklePatriciaTrie) GetPathProof(path Path) (*MPTProof, error) {
+	mpt.mutex.RLock()
+	defer mpt.mutex.RUnlock()
+
+	proof := &MPTProof{Path: string(path)}
+	if len(mpt.Root) == 0 {
+		return proof, nil
+	}
+	nodes, err := mpt.getProofNodes(mpt.Root, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	proof.Nodes = make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		proof.Nodes = append(proof.Nodes, hex.EncodeToString(encodeProofNode(node)))
+	}
+	return proof, nil
+}
+
+// getProofNodes collects the nodes from the given key down the path, it stops
+// at the node where the path ends or diverges from the trie.
+func (mpt *MerklePatriciaTrie) getProofNodes(key Key, path Path, nodes []Node) ([]Node, error) {
+	node, err := mpt.db.GetNode(key)
+	if err != nil {
+		return nil, err
+	}
+	nodes = append(nodes, node)
+	switch nodeImpl := node.(type) {
+	case *LeafNode:
+		return nodes, nil
+	case *FullNode:
+		if len(path) == 0 {
+			return nodes, nil
+		}
+		ckey := nodeImpl.GetChild(path[0])
+		if ckey == nil {
+			return nodes, nil
+		}
+		return mpt.getProofNodes(ckey, path[1:], nodes)
+	case *ExtensionNode:
+		prefix := mpt.matchingPrefix(path, nodeImpl.Path)
+		if !bytes.Equal(nodeImpl.Path, prefix) {
+			return nodes, nil
+		}
+		return mpt.getProofNodes(nodeImpl.NodeKey, path[len(prefix):], nodes)
+	default:
+		panic(fmt.Sprintf("unknown node type: %T %v", node, node))
+	}
+}
+
+/*VerifyProof - checks the proof against the root and returns the value stored at
+* the path. ErrValueNotPresent is returned when the proof shows that the path is
+* not in the trie and ErrInvalidProof when the proof can't be trusted. */
+func VerifyProof(root Key, path Path, proof *MPTProof) (Serializable, error) {
+	if proof == nil || proof.Path != string(path) {
+		return nil, ErrInvalidProof
+	}
+	if len(root) == 0 {
+		if len(proof.Nodes) != 0 {
+			return nil, ErrInvalidProof
+		}
+		return nil, ErrValueNotPresent
+	}
+
+	var (
+		key = root
+		idx = 0
+	)
+	for {
+		if idx >= len(proof.Nodes) {
+			return nil, ErrInvalidProof
+		}
+		buf, err := hex.DecodeString(proof.Nodes[idx])
+		if err != nil {
+			return nil, ErrInvalidProof
+		}
+		node, err := decodeProofNode(buf)
+		if err != nil {
+			return nil, ErrInvalidProof
+		}
+		if !bytes.Equal(node.GetHashBytes(), key) {
+			return nil, ErrInvalidProof
+		}
+		idx++
+		last := idx == len(proof.Nodes)
+
+		var next Key
+		switch nodeImpl := node.(type) {
+		case *LeafNode:
+			if !last {
+				return nil, ErrInvalidProof
+			}
+			if !bytes.Equal(nodeImpl.Path, path) || !nodeImpl.HasValue() {
+				return nil, ErrValueNotPresent
+			}
+			return nodeImpl.GetValue(), nil
+		case *FullNode:
+			if len(path) == 0 {
+				if !last {
+					return nil, ErrInvalidProof
+				}
+				if !nodeImpl.HasValue() {
+					return nil, ErrValueNotPresent
+				}
+				return nodeImpl.GetValue(), nil
+			}
+			next = nodeImpl.GetChild(path[0])
+			path = path[1:]
+		case *ExtensionNode:
+			if !bytes.HasPrefix(path, nodeImpl.Path) {
+				break
+			}
+			next = nodeImpl.NodeKey
+			path = path[len(nodeImpl.Path):]
+		}
+
+		if next == nil {
+			// the path diverges from the trie at this node
+			if !last {
+				return nil, ErrInvalidProof
+			}
+			return nil, ErrValueNotPresent
+		}
+		key = next
+	}
+}
+
+// encodeProofNode encodes only what's needed to recompute the hash of a node.
+func encodeProofNode(node Node) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteByte(GetSerializationPrefix(node))
+	switch nodeImpl := node.(type) {
+	case *LeafNode:
+		binary.Write(buf, binary.LittleEndian, nodeImpl.GetOrigin())
+		nodeImpl.encode(buf)
+	case *FullNode:
+		nodeImpl.encode(buf)
+	case *ExtensionNode:
+		nodeImpl.encode(buf)
+	}
+	return buf.Bytes()
+}
+
+func decodeProofNode(buf []byte) (node Node, err error) {
+	// the proof comes from an untrusted source, don't let a malformed
+	// encoding crash the node decoders
+	defer func() {
+		if r := recover(); r != nil {
+			node, err = nil, ErrInvalidEncoding
+		}
+	}()
+	if len(buf) == 0 {
+		return nil, ErrInvalidEncoding
+	}
+	code, buf := buf[0], buf[1:]
+	switch code {
+	case NodeTypeLeafNode:
+		var origin Sequence
+		if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &origin); err != nil {
+			return nil, ErrInvalidEncoding
+		}
+		buf = buf[binary.Size(origin):]
+		node = NewLeafNode(nil, nil, origin, nil)
+	case NodeTypeFullNode:
+		node = NewFullNode(nil)
+	case NodeTypeExtensionNode:
+		node = NewExtensionNode(nil, nil)
+	default:
+		return nil, ErrInvalidEncoding
+	}
+	if err := node.Decode(buf); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
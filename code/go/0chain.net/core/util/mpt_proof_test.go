@@ -0,0 +1,111 @@
+package util
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newProofTestMPT(t *testing.T) *MerklePatriciaTrie {
+	t.Helper()
+
+	mpt := NewMerklePatriciaTrie(NewMemoryNodeDB(), Sequence(1))
+	doStrValInsert(t, mpt, "1234567890", "leaf")
+	doStrValInsert(t, mpt, "1234567891", "sibling")
+	doStrValInsert(t, mpt, "123456", "branch value")
+	doStrValInsert(t, mpt, "abcdef", "other")
+	return mpt
+}
+
+func TestMerklePatriciaTrie_GetPathProof(t *testing.T) {
+	mpt := newProofTestMPT(t)
+
+	tests := []struct {
+		name  string
+		path  string
+		value string
+		err   error
+	}{
+		{name: "leaf", path: "1234567890", value: "leaf"},
+		{name: "full node value", path: "123456", value: "branch value"},
+		{name: "other branch", path: "abcdef", value: "other"},
+		{name: "missing child", path: "1234567892", err: ErrValueNotPresent},
+		{name: "diverging leaf", path: "abcdee", err: ErrValueNotPresent},
+		{name: "inside extension", path: "1234", err: ErrValueNotPresent},
+		{name: "diverging extension", path: "1299", err: ErrValueNotPresent},
+		{name: "inside nested extension", path: "12345678", err: ErrValueNotPresent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := mpt.GetPathProof(Path(tt.path))
+			require.NoError(t, err)
+			require.NotEmpty(t, proof.Nodes)
+
+			v, err := VerifyProof(mpt.GetRoot(), Path(tt.path), proof)
+			if tt.err != nil {
+				require.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, (&Txn{tt.value}).Encode(), v.Encode())
+		})
+	}
+}
+
+func TestVerifyProof_Invalid(t *testing.T) {
+	mpt := newProofTestMPT(t)
+	path := Path("1234567890")
+
+	t.Run("wrong root", func(t *testing.T) {
+		proof, err := mpt.GetPathProof(path)
+		require.NoError(t, err)
+		_, err = VerifyProof(Key("wrong root"), path, proof)
+		require.Equal(t, ErrInvalidProof, err)
+	})
+
+	t.Run("wrong path", func(t *testing.T) {
+		proof, err := mpt.GetPathProof(path)
+		require.NoError(t, err)
+		_, err = VerifyProof(mpt.GetRoot(), Path("1234567891"), proof)
+		require.Equal(t, ErrInvalidProof, err)
+	})
+
+	t.Run("tampered value", func(t *testing.T) {
+		proof, err := mpt.GetPathProof(path)
+		require.NoError(t, err)
+		last := len(proof.Nodes) - 1
+		buf, err := hex.DecodeString(proof.Nodes[last])
+		require.NoError(t, err)
+		buf[len(buf)-1]++
+		proof.Nodes[last] = hex.EncodeToString(buf)
+		_, err = VerifyProof(mpt.GetRoot(), path, proof)
+		require.Equal(t, ErrInvalidProof, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		proof, err := mpt.GetPathProof(path)
+		require.NoError(t, err)
+		proof.Nodes = proof.Nodes[:len(proof.Nodes)-1]
+		_, err = VerifyProof(mpt.GetRoot(), path, proof)
+		require.Equal(t, ErrInvalidProof, err)
+	})
+
+	t.Run("extra nodes", func(t *testing.T) {
+		proof, err := mpt.GetPathProof(Path("1234567892"))
+		require.NoError(t, err)
+		other, err := mpt.GetPathProof(path)
+		require.NoError(t, err)
+		proof.Nodes = append(proof.Nodes, other.Nodes[len(other.Nodes)-1])
+		_, err = VerifyProof(mpt.GetRoot(), Path("1234567892"), proof)
+		require.Equal(t, ErrInvalidProof, err)
+	})
+
+	t.Run("malformed node", func(t *testing.T) {
+		proof, err := mpt.GetPathProof(path)
+		require.NoError(t, err)
+		proof.Nodes[0] = hex.EncodeToString([]byte{NodeTypeFullNode, 'x'})
+		_, err = VerifyProof(mpt.GetRoot(), path, proof)
+		require.Equal(t, ErrInvalidProof, err)
+	})
+}
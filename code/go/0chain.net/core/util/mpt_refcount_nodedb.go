@@ -0,0 +1,376 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	. "0chain.net/core/logging"
+)
+
+const refJournalMaxKeyLen = 255
+
+var (
+	refCountsBucket   = []byte("refs")
+	refJournalsBucket = []byte("journal")
+	errInvalidJournal = errors.New("invalid state journal entry")
+)
+
+/*JournalNodeDB - a node db that records the changes of each version (round)
+* to prune the nodes by references instead of by versions */
+type JournalNodeDB interface {
+	PersistentNodeDB
+	PutChanges(version Sequence, keys []Key, nodes []Node, deletes []Key) error
+	PruneJournals(ctx context.Context, version Sequence, maxVersions int) (int, error)
+}
+
+/*RefCountNodeDB - a persistent node db that counts references to the nodes.
+* Every saved version increments the references of the inserted nodes and
+* keeps a journal of the nodes it replaced. Pruning a version decrements the
+* references of the replaced nodes and deletes the ones not referenced
+* anymore, so the work is bounded by the size of the pruned changes.
+*
+* The nodes put to the db without the journal (e.g. synced from other nodes)
+* count for a single reference. */
+type RefCountNodeDB struct {
+	PersistentNodeDB
+	journal *bolt.DB
+	mutex   sync.Mutex
+}
+
+/*NewRefCountNodeDB - create a reference counting node db over the given one,
+* the references and the journals are stored in the journal file */
+func NewRefCountNodeDB(ndb PersistentNodeDB, journalFile string) (*RefCountNodeDB, error) {
+	if err := os.MkdirAll(filepath.Dir(journalFile), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(journalFile, 0600, &bolt.Options{
+		Timeout:      10 * time.Second,
+		NoSync:       true,
+		FreelistType: bolt.FreelistMapType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(refCountsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(refJournalsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &RefCountNodeDB{PersistentNodeDB: ndb, journal: db}, nil
+}
+
+type refJournal struct {
+	inserts []Key
+	deletes []Key
+}
+
+func writeJournalKeys(buf *bytes.Buffer, keys []Key) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(keys)))
+	buf.Write(n[:])
+	for _, key := range keys {
+		buf.WriteByte(byte(len(key)))
+		buf.Write(key)
+	}
+}
+
+func readJournalKeys(r *bytes.Reader) ([]Key, error) {
+	var n [4]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, errInvalidJournal
+	}
+	keys := make([]Key, binary.BigEndian.Uint32(n[:]))
+	for i := range keys {
+		ln, err := r.ReadByte()
+		if err != nil {
+			return nil, errInvalidJournal
+		}
+		keys[i] = make(Key, ln)
+		if _, err = io.ReadFull(r, keys[i]); err != nil {
+			return nil, errInvalidJournal
+		}
+	}
+	return keys, nil
+}
+
+func (rj *refJournal) encode() []byte {
+	var buf bytes.Buffer
+	writeJournalKeys(&buf, rj.inserts)
+	writeJournalKeys(&buf, rj.deletes)
+	return buf.Bytes()
+}
+
+func (rj *refJournal) decode(data []byte) (err error) {
+	r := bytes.NewReader(data)
+	if rj.inserts, err = readJournalKeys(r); err != nil {
+		return
+	}
+	rj.deletes, err = readJournalKeys(r)
+	return
+}
+
+func journalVersionKey(version Sequence) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], uint64(version))
+	return key[:]
+}
+
+// refCounter keeps the updated references of a journal transaction
+type refCounter struct {
+	ndb    NodeDB
+	refs   *bolt.Bucket
+	counts map[StrKey]uint32
+}
+
+func (rc *refCounter) get(key Key) (uint32, error) {
+	if count, ok := rc.counts[StrKey(key)]; ok {
+		return count, nil
+	}
+	if v := rc.refs.Get(key); v != nil {
+		return binary.BigEndian.Uint32(v), nil
+	}
+	_, err := rc.ndb.GetNode(key)
+	switch err {
+	case nil:
+		return 1, nil // not tracked node
+	case ErrNodeNotFound:
+		return 0, nil
+	}
+	return 0, err
+}
+
+func (rc *refCounter) add(key Key, delta int) error {
+	count, err := rc.get(key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case delta > 0:
+		count++
+	case count > 0:
+		count--
+	}
+	rc.counts[StrKey(key)] = count
+	return nil
+}
+
+// commit stores the references and returns the keys of the not referenced
+// nodes.
+func (rc *refCounter) commit() ([]Key, error) {
+	var unused []Key
+	for skey, count := range rc.counts {
+		key := Key(skey)
+		if count == 0 {
+			unused = append(unused, key)
+			if err := rc.refs.Delete(key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var v [4]byte
+		binary.BigEndian.PutUint32(v[:], count)
+		if err := rc.refs.Put(key, v[:]); err != nil {
+			return nil, err
+		}
+	}
+	return unused, nil
+}
+
+/*PutChanges - put the inserted nodes of the version and record the journal of
+* the version. If the version is already recorded, its journal is replaced. */
+func (rndb *RefCountNodeDB) PutChanges(version Sequence, keys []Key, nodes []Node, deletes []Key) error {
+	for _, ks := range [][]Key{keys, deletes} {
+		for _, key := range ks {
+			if len(key) > refJournalMaxKeyLen {
+				return errInvalidJournal
+			}
+		}
+	}
+	rndb.mutex.Lock()
+	defer rndb.mutex.Unlock()
+	return rndb.journal.Update(func(tx *bolt.Tx) error {
+		var (
+			journals = tx.Bucket(refJournalsBucket)
+			jkey     = journalVersionKey(version)
+			rc       = &refCounter{
+				ndb:    rndb.PersistentNodeDB,
+				refs:   tx.Bucket(refCountsBucket),
+				counts: make(map[StrKey]uint32, len(keys)),
+			}
+		)
+		if data := journals.Get(jkey); data != nil {
+			var prev refJournal
+			if err := prev.decode(data); err != nil {
+				return err
+			}
+			for _, key := range prev.inserts {
+				if err := rc.add(key, -1); err != nil {
+					return err
+				}
+			}
+		}
+		for _, key := range keys {
+			if err := rc.add(key, 1); err != nil {
+				return err
+			}
+		}
+		unused, err := rc.commit()
+		if err != nil {
+			return err
+		}
+		if err := rndb.PersistentNodeDB.MultiPutNode(keys, nodes); err != nil {
+			return err
+		}
+		if len(unused) > 0 {
+			// inserted by the replaced journal only
+			if err := rndb.PersistentNodeDB.MultiDeleteNode(unused); err != nil {
+				return err
+			}
+		}
+		rj := &refJournal{inserts: keys, deletes: deletes}
+		return journals.Put(jkey, rj.encode())
+	})
+}
+
+/*PruneJournals - release the references of the nodes replaced by the versions
+* up to the given one, oldest first and at most maxVersions of them. The nodes
+* not referenced anymore are deleted. Returns the number of pruned versions. */
+func (rndb *RefCountNodeDB) PruneJournals(ctx context.Context, version Sequence, maxVersions int) (int, error) {
+	ps := GetPruneStats(ctx)
+	var pruned int
+	for ; pruned < maxVersions; pruned++ {
+		select {
+		case <-ctx.Done():
+			return pruned, ctx.Err()
+		default:
+		}
+		var released, deleted int64
+		done := false
+		rndb.mutex.Lock()
+		err := rndb.journal.Update(func(tx *bolt.Tx) error {
+			k, data := tx.Bucket(refJournalsBucket).Cursor().First()
+			if k == nil || Sequence(binary.BigEndian.Uint64(k)) > version {
+				done = true
+				return nil
+			}
+			jkey := append([]byte(nil), k...)
+			var rj refJournal
+			if err := rj.decode(data); err != nil {
+				return err
+			}
+			rc := &refCounter{
+				ndb:    rndb.PersistentNodeDB,
+				refs:   tx.Bucket(refCountsBucket),
+				counts: make(map[StrKey]uint32, len(rj.deletes)),
+			}
+			for _, key := range rj.deletes {
+				if err := rc.add(key, -1); err != nil {
+					return err
+				}
+			}
+			unused, err := rc.commit()
+			if err != nil {
+				return err
+			}
+			if len(unused) > 0 {
+				if err := rndb.PersistentNodeDB.MultiDeleteNode(unused); err != nil {
+					return err
+				}
+			}
+			released, deleted = int64(len(rj.deletes)), int64(len(unused))
+			return tx.Bucket(refJournalsBucket).Delete(jkey)
+		})
+		rndb.mutex.Unlock()
+		if err != nil {
+			Logger.Error("prune journals", zap.Int64("version", int64(version)), zap.Error(err))
+			return pruned, err
+		}
+		if done {
+			break
+		}
+		if ps != nil {
+			ps.Total += released
+			ps.Deleted += deleted
+		}
+	}
+	if pruned > 0 {
+		rndb.Flush()
+	}
+	return pruned, nil
+}
+
+/*GetJournalVersions - returns the oldest and the latest versions having a
+* journal, ok is false if there are no journals */
+func (rndb *RefCountNodeDB) GetJournalVersions() (first, last Sequence, ok bool) {
+	rndb.journal.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(refJournalsBucket).Cursor()
+		fk, _ := c.First()
+		lk, _ := c.Last()
+		if fk != nil {
+			first = Sequence(binary.BigEndian.Uint64(fk))
+			last = Sequence(binary.BigEndian.Uint64(lk))
+			ok = true
+		}
+		return nil
+	})
+	return
+}
+
+/*DeleteNode - delete the node and its references */
+func (rndb *RefCountNodeDB) DeleteNode(key Key) error {
+	return rndb.MultiDeleteNode([]Key{key})
+}
+
+/*MultiDeleteNode - delete the nodes and their references, the nodes deleted
+* this way are still released by the journals recorded before */
+func (rndb *RefCountNodeDB) MultiDeleteNode(keys []Key) error {
+	rndb.mutex.Lock()
+	defer rndb.mutex.Unlock()
+	return rndb.journal.Update(func(tx *bolt.Tx) error {
+		refs := tx.Bucket(refCountsBucket)
+		for _, key := range keys {
+			if err := refs.Delete(key); err != nil {
+				return err
+			}
+		}
+		return rndb.PersistentNodeDB.MultiDeleteNode(keys)
+	})
+}
+
+/*Compact - compact the db if it supports the compaction */
+func (rndb *RefCountNodeDB) Compact() error {
+	if cndb, ok := rndb.PersistentNodeDB.(CompactNodeDB); ok {
+		return cndb.Compact()
+	}
+	return nil
+}
+
+/*Flush - flush the db and the journal */
+func (rndb *RefCountNodeDB) Flush() {
+	rndb.PersistentNodeDB.Flush()
+	if err := rndb.journal.Sync(); err != nil {
+		Logger.Error("ref count node db - sync journal", zap.Error(err))
+	}
+}
+
+// Close closes the db and the journal
+func (rndb *RefCountNodeDB) Close() {
+	rndb.journal.Close()
+	rndb.PersistentNodeDB.Close()
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRefCountNodeDB(t *testing.T) (rndb *RefCountNodeDB, cleanup func()) {
+	t.Helper()
+
+	bndb, bcleanup := newBoltNodeDB(t)
+	rndb, err := NewRefCountNodeDB(bndb,
+		filepath.Join(filepath.Dir(bndb.file), "journal.bolt"))
+	if err != nil {
+		bcleanup()
+		t.Fatal(err)
+	}
+	cleanup = func() {
+		rndb.journal.Close()
+		bcleanup()
+	}
+	return
+}
+
+// saveRefCountRounds saves the states of the rounds to the db and returns the
+// state roots of the rounds.
+func saveRefCountRounds(t *testing.T, ndb NodeDB, rounds int) []Key {
+	var (
+		mpt   = NewMerklePatriciaTrie(ndb, 0)
+		roots = make([]Key, 0, rounds)
+	)
+	for round := 0; round < rounds; round++ {
+		lmpt := NewMerklePatriciaTrie(NewLevelNodeDB(NewMemoryNodeDB(), ndb, false),
+			Sequence(round))
+		lmpt.SetRoot(mpt.GetRoot())
+		for i := 0; i < 5; i++ {
+			doStateValInsert(t, lmpt, fmt.Sprintf("%04d", (round*3+i)%40), int64(round))
+		}
+		if round > 5 {
+			_, err := lmpt.Delete(Path(fmt.Sprintf("%04d", (round*7)%40)))
+			if err != nil && err != ErrValueNotPresent {
+				require.NoError(t, err)
+			}
+		}
+		require.NoError(t, lmpt.SaveChanges(context.TODO(), ndb, false))
+		mpt.SetRoot(lmpt.GetRoot())
+		roots = append(roots, lmpt.GetRoot())
+	}
+	return roots
+}
+
+func reachableNodes(t *testing.T, ndb NodeDB, roots []Key) map[StrKey]bool {
+	keys := make(map[StrKey]bool)
+	for _, root := range roots {
+		mpt := NewMerklePatriciaTrie(ndb, 0)
+		mpt.SetRoot(root)
+		err := mpt.Iterate(context.TODO(),
+			func(ctx context.Context, path Path, key Key, node Node) error {
+				if node == nil {
+					return ErrNodeNotFound
+				}
+				keys[StrKey(key)] = true
+				return nil
+			}, NodeTypeLeafNode|NodeTypeFullNode|NodeTypeExtensionNode)
+		require.NoError(t, err)
+	}
+	return keys
+}
+
+func TestRefCountNodeDB_PruneJournals(t *testing.T) {
+	rndb, cleanup := newRefCountNodeDB(t)
+	defer cleanup()
+
+	roots := saveRefCountRounds(t, rndb, 30)
+	first, last, ok := rndb.GetJournalVersions()
+	require.True(t, ok)
+	require.EqualValues(t, 0, first)
+	require.EqualValues(t, 29, last)
+
+	// bounded number of rounds at once
+	ctx := WithPruneStats(context.TODO())
+	pruned, err := rndb.PruneJournals(ctx, 20, 5)
+	require.NoError(t, err)
+	require.Equal(t, 5, pruned)
+	require.True(t, GetPruneStats(ctx).Total > 0)
+
+	pruned, err = rndb.PruneJournals(context.TODO(), 20, 100)
+	require.NoError(t, err)
+	require.Equal(t, 16, pruned)
+	first, _, ok = rndb.GetJournalVersions()
+	require.True(t, ok)
+	require.EqualValues(t, 21, first)
+
+	// the kept states are complete and nothing else is left
+	kept := reachableNodes(t, rndb, roots[20:])
+	require.EqualValues(t, len(kept), rndb.Size(context.TODO()))
+
+	pruned, err = rndb.PruneJournals(context.TODO(), 20, 100)
+	require.NoError(t, err)
+	require.Zero(t, pruned)
+}
+
+func TestRefCountNodeDB_PutChangesTwice(t *testing.T) {
+	rndb, cleanup := newRefCountNodeDB(t)
+	defer cleanup()
+
+	roots := saveRefCountRounds(t, rndb, 10)
+
+	// the last round is saved again with different changes
+	var (
+		mpt  = NewMerklePatriciaTrie(rndb, 0)
+		lmpt = NewMerklePatriciaTrie(NewLevelNodeDB(NewMemoryNodeDB(), rndb, false), 9)
+	)
+	mpt.SetRoot(roots[8])
+	lmpt.SetRoot(roots[8])
+	doStateValInsert(t, lmpt, "0001", 1000)
+	require.NoError(t, lmpt.SaveChanges(context.TODO(), rndb, false))
+
+	_, err := rndb.PruneJournals(context.TODO(), 9, 100)
+	require.NoError(t, err)
+	kept := reachableNodes(t, rndb, []Key{lmpt.GetRoot()})
+	require.EqualValues(t, len(kept), rndb.Size(context.TODO()))
+}
+
+func TestRefCountNodeDB_NotTrackedNodes(t *testing.T) {
+	rndb, cleanup := newRefCountNodeDB(t)
+	defer cleanup()
+
+	// synced nodes are put without the journal
+	keys, nodes := newBoltTestNodes(3, 1)
+	require.NoError(t, rndb.MultiPutNode(keys, nodes))
+
+	// inserted again and replaced by later rounds
+	require.NoError(t, rndb.PutChanges(2, keys[:1], nodes[:1], nil))
+	require.NoError(t, rndb.PutChanges(3, nil, nil, keys[:2]))
+	require.NoError(t, rndb.PutChanges(4, nil, nil, keys[:1]))
+
+	_, err := rndb.PruneJournals(context.TODO(), 3, 100)
+	require.NoError(t, err)
+	_, err = rndb.GetNode(keys[0])
+	require.NoError(t, err)
+	_, err = rndb.GetNode(keys[1])
+	require.Equal(t, ErrNodeNotFound, err)
+
+	_, err = rndb.PruneJournals(context.TODO(), 4, 100)
+	require.NoError(t, err)
+	_, err = rndb.GetNode(keys[0])
+	require.Equal(t, ErrNodeNotFound, err)
+	_, err = rndb.GetNode(keys[2])
+	require.NoError(t, err)
+}
@@ -0,0 +1,437 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"0chain.net/core/encryption"
+)
+
+/*
+ * A state snapshot contains all the nodes reachable from a state root. All
+ * the integers are little endian.
+ *
+ *   header:  magic | version uint16 | round int64 | root size uint8 | root
+ *   chunk:   nodes uint32 | size uint32 | data | checksum (32 bytes)
+ *   data:    node size uint32 | node encoding, repeated for every node
+ *   trailer: nodes uint32 = 0 | size uint32 = 0 | total nodes uint64
+ *
+ * The checksum of a chunk is the hash of its data, so every chunk can be
+ * served, downloaded and verified separately.
+ */
+
+// SnapshotVersion - the current version of the state snapshot format.
+const SnapshotVersion uint16 = 1
+
+// snapshotMagic - first bytes of any state snapshot file.
+var snapshotMagic = []byte("0CHAINSS")
+
+// SnapshotChecksumSize - size of the checksum ending every snapshot chunk.
+const SnapshotChecksumSize = 32
+
+const (
+	snapshotChunkHeaderSize = 8
+	snapshotMaxChunkSize    = 256 * 1024 * 1024
+)
+
+// ErrInvalidSnapshot - error indicating a malformed or corrupted snapshot.
+var ErrInvalidSnapshot = errors.New("invalid state snapshot")
+
+/*SnapshotChunkInfo - position and checksum of a chunk in a snapshot file */
+type SnapshotChunkInfo struct {
+	Offset   int64  `json:"offset"`
+	Size     int64  `json:"size"`
+	Nodes    uint32 `json:"nodes"`
+	Checksum string `json:"checksum"`
+}
+
+/*SnapshotInfo - description of a state snapshot */
+type SnapshotInfo struct {
+	Version uint16               `json:"version"`
+	Round   int64                `json:"round"`
+	Root    string               `json:"root"`
+	Nodes   uint64               `json:"nodes"`
+	Chunks  []*SnapshotChunkInfo `json:"chunks"`
+}
+
+// GetRoot returns the state root the snapshot is taken for.
+func (si *SnapshotInfo) GetRoot() (Key, error) {
+	root, err := hex.DecodeString(si.Root)
+	if err != nil || len(root) == 0 {
+		return nil, ErrInvalidSnapshot
+	}
+	return root, nil
+}
+
+func writeSnapshotHeader(w io.Writer, round int64, root Key) (int64, error) {
+	buf := bytes.NewBuffer(nil)
+	buf.Write(snapshotMagic)
+	binary.Write(buf, binary.LittleEndian, SnapshotVersion)
+	binary.Write(buf, binary.LittleEndian, round)
+	buf.WriteByte(byte(len(root)))
+	buf.Write(root)
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+func readSnapshotHeader(r io.Reader) (*SnapshotInfo, int64, error) {
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, snapshotMagic) {
+		return nil, 0, ErrInvalidSnapshot
+	}
+	info := &SnapshotInfo{}
+	if err := binary.Read(r, binary.LittleEndian, &info.Version); err != nil {
+		return nil, 0, ErrInvalidSnapshot
+	}
+	if info.Version != SnapshotVersion {
+		return nil, 0, fmt.Errorf("unsupported state snapshot version: %d", info.Version)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &info.Round); err != nil {
+		return nil, 0, ErrInvalidSnapshot
+	}
+	var size uint8
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil || size == 0 {
+		return nil, 0, ErrInvalidSnapshot
+	}
+	root := make([]byte, size)
+	if _, err := io.ReadFull(r, root); err != nil {
+		return nil, 0, ErrInvalidSnapshot
+	}
+	info.Root = ToHex(root)
+	return info, int64(len(snapshotMagic) + 2 + 8 + 1 + int(size)), nil
+}
+
+// snapshotChunkWriter accumulates encoded nodes and writes them as a chunk.
+type snapshotChunkWriter struct {
+	w      io.Writer
+	offset int64
+	data   *bytes.Buffer
+	nodes  uint32
+	info   *SnapshotInfo
+}
+
+func (cw *snapshotChunkWriter) add(node Node) {
+	encoded := node.Encode()
+	binary.Write(cw.data, binary.LittleEndian, uint32(len(encoded)))
+	cw.data.Write(encoded)
+	cw.nodes++
+}
+
+func (cw *snapshotChunkWriter) flush() error {
+	if cw.nodes == 0 {
+		return nil
+	}
+	var (
+		data     = cw.data.Bytes()
+		checksum = encryption.RawHash(data)
+		hdr      = make([]byte, snapshotChunkHeaderSize)
+	)
+	binary.LittleEndian.PutUint32(hdr, cw.nodes)
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(len(data)))
+	for _, b := range [][]byte{hdr, data, checksum} {
+		if _, err := cw.w.Write(b); err != nil {
+			return err
+		}
+	}
+	ci := &SnapshotChunkInfo{
+		Offset:   cw.offset,
+		Size:     int64(len(hdr) + len(data) + len(checksum)),
+		Nodes:    cw.nodes,
+		Checksum: ToHex(checksum),
+	}
+	cw.info.Chunks = append(cw.info.Chunks, ci)
+	cw.info.Nodes += uint64(cw.nodes)
+	cw.offset += ci.Size
+	cw.data.Reset()
+	cw.nodes = 0
+	return nil
+}
+
+/*WriteSnapshot - writes all the nodes reachable from the root of the given
+* trie as a snapshot of the state of the given round, chunkSize is the maximum
+* number of nodes in a chunk. */
+func WriteSnapshot(ctx context.Context, mpt MerklePatriciaTrieI, round int64,
+	chunkSize int, w io.Writer) (*SnapshotInfo, error) {
+
+	root := mpt.GetRoot()
+	if len(root) == 0 {
+		return nil, errors.New("state snapshot: empty state root")
+	}
+	if chunkSize <= 0 {
+		chunkSize = BatchSize
+	}
+	bw := bufio.NewWriter(w)
+	offset, err := writeSnapshotHeader(bw, round, root)
+	if err != nil {
+		return nil, err
+	}
+	cw := &snapshotChunkWriter{
+		w:      bw,
+		offset: offset,
+		data:   bytes.NewBuffer(nil),
+		info: &SnapshotInfo{
+			Version: SnapshotVersion,
+			Round:   round,
+			Root:    ToHex(root),
+		},
+	}
+	handler := func(ctx context.Context, path Path, key Key, node Node) error {
+		if node == nil {
+			return ErrNodeNotFound
+		}
+		cw.add(node)
+		if cw.nodes >= uint32(chunkSize) {
+			return cw.flush()
+		}
+		return nil
+	}
+	err = mpt.Iterate(ctx, handler,
+		NodeTypeLeafNode|NodeTypeFullNode|NodeTypeExtensionNode)
+	if err != nil {
+		return nil, err
+	}
+	if err = cw.flush(); err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, snapshotChunkHeaderSize+8)
+	binary.LittleEndian.PutUint64(trailer[snapshotChunkHeaderSize:], cw.info.Nodes)
+	if _, err = bw.Write(trailer); err != nil {
+		return nil, err
+	}
+	if err = bw.Flush(); err != nil {
+		return nil, err
+	}
+	return cw.info, nil
+}
+
+// readSnapshotChunkHeader returns number of nodes and size of data of the next
+// chunk, zero nodes means the trailer is reached.
+func readSnapshotChunkHeader(r io.Reader) (nodes, size uint32, err error) {
+	hdr := make([]byte, snapshotChunkHeaderSize)
+	if _, err = io.ReadFull(r, hdr); err != nil {
+		return 0, 0, ErrInvalidSnapshot
+	}
+	nodes = binary.LittleEndian.Uint32(hdr)
+	size = binary.LittleEndian.Uint32(hdr[4:])
+	if (nodes == 0) != (size == 0) || size > snapshotMaxChunkSize {
+		return 0, 0, ErrInvalidSnapshot
+	}
+	return nodes, size, nil
+}
+
+func readSnapshotTrailer(r io.Reader) (uint64, error) {
+	var total uint64
+	if err := binary.Read(r, binary.LittleEndian, &total); err != nil {
+		return 0, ErrInvalidSnapshot
+	}
+	return total, nil
+}
+
+/*ReadSnapshotInfo - reads the header and locates the chunks of a snapshot
+* without loading the nodes. */
+func ReadSnapshotInfo(r io.ReadSeeker) (*SnapshotInfo, error) {
+	info, offset, err := readSnapshotHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		nodes, size, err := readSnapshotChunkHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if nodes == 0 {
+			break
+		}
+		if _, err = r.Seek(int64(size), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		checksum := make([]byte, SnapshotChecksumSize)
+		if _, err = io.ReadFull(r, checksum); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		ci := &SnapshotChunkInfo{
+			Offset:   offset,
+			Size:     int64(snapshotChunkHeaderSize + int(size) + SnapshotChecksumSize),
+			Nodes:    nodes,
+			Checksum: ToHex(checksum),
+		}
+		info.Chunks = append(info.Chunks, ci)
+		info.Nodes += uint64(nodes)
+		offset += ci.Size
+	}
+	total, err := readSnapshotTrailer(r)
+	if err != nil {
+		return nil, err
+	}
+	if total != info.Nodes {
+		return nil, ErrInvalidSnapshot
+	}
+	return info, nil
+}
+
+/*DecodeSnapshotChunk - verifies the checksum of a chunk as it's stored in a
+* snapshot file and decodes its nodes. */
+func DecodeSnapshotChunk(chunk []byte) ([]Node, error) {
+	r := bytes.NewReader(chunk)
+	nodes, size, err := readSnapshotChunkHeader(r)
+	if err != nil || nodes == 0 ||
+		len(chunk) != snapshotChunkHeaderSize+int(size)+SnapshotChecksumSize {
+		return nil, ErrInvalidSnapshot
+	}
+	data := chunk[snapshotChunkHeaderSize : snapshotChunkHeaderSize+int(size)]
+	if !bytes.Equal(encryption.RawHash(data), chunk[len(chunk)-SnapshotChecksumSize:]) {
+		return nil, errors.New("state snapshot: chunk checksum mismatch")
+	}
+	return decodeSnapshotNodes(data, nodes)
+}
+
+func decodeSnapshotNodes(data []byte, count uint32) (nodes []Node, err error) {
+	// the checksum protects against corruption, not against a crafted chunk
+	defer func() {
+		if r := recover(); r != nil {
+			nodes, err = nil, ErrInvalidSnapshot
+		}
+	}()
+	nodes = make([]Node, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(data) < 4 {
+			return nil, ErrInvalidSnapshot
+		}
+		size := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		if uint32(len(data)) < size {
+			return nil, ErrInvalidSnapshot
+		}
+		node, err := CreateNode(bytes.NewReader(data[:size]))
+		if err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		nodes = append(nodes, node)
+		data = data[size:]
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidSnapshot
+	}
+	return nodes, nil
+}
+
+/*SnapshotImporter - loads snapshot chunks into a node db */
+type SnapshotImporter struct {
+	ndb   NodeDB
+	round int64
+	root  Key
+	nodes uint64
+}
+
+/*NewSnapshotImporter - create an importer of the snapshot of the given state */
+func NewSnapshotImporter(ndb NodeDB, round int64, root Key) *SnapshotImporter {
+	return &SnapshotImporter{ndb: ndb, round: round, root: root}
+}
+
+/*ImportChunk - verifies a chunk and saves its nodes */
+func (si *SnapshotImporter) ImportChunk(chunk []byte) error {
+	nodes, err := DecodeSnapshotChunk(chunk)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(nodes); start += BatchSize {
+		end := start + BatchSize
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+		keys := make([]Key, 0, end-start)
+		for _, node := range nodes[start:end] {
+			keys = append(keys, node.GetHashBytes())
+		}
+		if err := si.ndb.MultiPutNode(keys, nodes[start:end]); err != nil {
+			return err
+		}
+	}
+	si.nodes += uint64(len(nodes))
+	return nil
+}
+
+/*GetNodesCount - number of nodes imported so far */
+func (si *SnapshotImporter) GetNodesCount() uint64 {
+	return si.nodes
+}
+
+/*Validate - checks the imported state is complete */
+func (si *SnapshotImporter) Validate(ctx context.Context) error {
+	if _, err := si.ndb.GetNode(si.root); err != nil {
+		return fmt.Errorf("state snapshot: root node %s: %v", ToHex(si.root), err)
+	}
+	mpt := NewMerklePatriciaTrie(si.ndb, Sequence(si.round))
+	mpt.SetRoot(si.root)
+	_, keys, err := mpt.FindMissingNodes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("state snapshot: %d nodes are missing", len(keys))
+	}
+	return nil
+}
+
+/*ImportSnapshot - bulk loads a snapshot into the node db and validates that
+* the state of its root is complete */
+func ImportSnapshot(ctx context.Context, r io.Reader, ndb NodeDB) (*SnapshotInfo, error) {
+	br := bufio.NewReader(r)
+	info, offset, err := readSnapshotHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	root, err := info.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+	si := NewSnapshotImporter(ndb, info.Round, root)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		nodes, size, err := readSnapshotChunkHeader(br)
+		if err != nil {
+			return nil, err
+		}
+		if nodes == 0 {
+			break
+		}
+		chunk := make([]byte, snapshotChunkHeaderSize+int(size)+SnapshotChecksumSize)
+		binary.LittleEndian.PutUint32(chunk, nodes)
+		binary.LittleEndian.PutUint32(chunk[4:], size)
+		if _, err = io.ReadFull(br, chunk[snapshotChunkHeaderSize:]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		if err = si.ImportChunk(chunk); err != nil {
+			return nil, err
+		}
+		info.Chunks = append(info.Chunks, &SnapshotChunkInfo{
+			Offset:   offset,
+			Size:     int64(len(chunk)),
+			Nodes:    nodes,
+			Checksum: ToHex(chunk[len(chunk)-SnapshotChecksumSize:]),
+		})
+		offset += int64(len(chunk))
+	}
+	total, err := readSnapshotTrailer(br)
+	if err != nil {
+		return nil, err
+	}
+	if total != si.GetNodesCount() {
+		return nil, ErrInvalidSnapshot
+	}
+	info.Nodes = total
+	if err = si.Validate(ctx); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
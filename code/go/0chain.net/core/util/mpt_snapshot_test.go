@@ -0,0 +1,94 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newSnapshotTestMPT(t *testing.T, n int) *MerklePatriciaTrie {
+	t.Helper()
+
+	mpt := NewMerklePatriciaTrie(NewMemoryNodeDB(), Sequence(1))
+	for i := 0; i < n; i++ {
+		doStrValInsert(t, mpt, fmt.Sprintf("%06x", i*7919), fmt.Sprintf("value %d", i))
+	}
+	return mpt
+}
+
+func TestWriteSnapshot_ImportSnapshot(t *testing.T) {
+	mpt := newSnapshotTestMPT(t, 100)
+	buf := bytes.NewBuffer(nil)
+	info, err := WriteSnapshot(context.Background(), mpt, 10, 16, buf)
+	require.NoError(t, err)
+	require.Equal(t, SnapshotVersion, info.Version)
+	require.EqualValues(t, 10, info.Round)
+	require.Equal(t, ToHex(mpt.GetRoot()), info.Root)
+	require.True(t, len(info.Chunks) > 1)
+
+	read, err := ReadSnapshotInfo(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, info, read)
+
+	for _, ci := range info.Chunks {
+		chunk := buf.Bytes()[ci.Offset : ci.Offset+ci.Size]
+		nodes, err := DecodeSnapshotChunk(chunk)
+		require.NoError(t, err)
+		require.Len(t, nodes, int(ci.Nodes))
+	}
+
+	ndb := NewMemoryNodeDB()
+	imported, err := ImportSnapshot(context.Background(), bytes.NewReader(buf.Bytes()), ndb)
+	require.NoError(t, err)
+	require.Equal(t, info, imported)
+
+	restored := NewMerklePatriciaTrie(ndb, Sequence(10))
+	restored.SetRoot(mpt.GetRoot())
+	for i := 0; i < 100; i++ {
+		v, err := restored.GetNodeValue(Path(fmt.Sprintf("%06x", i*7919)))
+		require.NoError(t, err)
+		require.Equal(t, (&Txn{fmt.Sprintf("value %d", i)}).Encode(), v.Encode())
+	}
+}
+
+func TestImportSnapshot_Invalid(t *testing.T) {
+	mpt := newSnapshotTestMPT(t, 50)
+	buf := bytes.NewBuffer(nil)
+	info, err := WriteSnapshot(context.Background(), mpt, 10, 16, buf)
+	require.NoError(t, err)
+	snapshot := buf.Bytes()
+
+	t.Run("corrupted chunk", func(t *testing.T) {
+		corrupted := append([]byte{}, snapshot...)
+		ci := info.Chunks[1]
+		corrupted[ci.Offset+ci.Size/2]++
+		_, err := ImportSnapshot(context.Background(), bytes.NewReader(corrupted), NewMemoryNodeDB())
+		require.Error(t, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		truncated := snapshot[:info.Chunks[1].Offset]
+		_, err := ImportSnapshot(context.Background(), bytes.NewReader(truncated), NewMemoryNodeDB())
+		require.Equal(t, ErrInvalidSnapshot, err)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		other := append([]byte{}, snapshot...)
+		other[len(snapshotMagic)]++
+		_, err := ImportSnapshot(context.Background(), bytes.NewReader(other), NewMemoryNodeDB())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported state snapshot version")
+	})
+
+	t.Run("missing chunk", func(t *testing.T) {
+		ndb := NewMemoryNodeDB()
+		si := NewSnapshotImporter(ndb, info.Round, mpt.GetRoot())
+		for _, ci := range info.Chunks[:len(info.Chunks)-1] {
+			require.NoError(t, si.ImportChunk(snapshot[ci.Offset:ci.Offset+ci.Size]))
+		}
+		require.Error(t, si.Validate(context.Background()))
+	})
+}
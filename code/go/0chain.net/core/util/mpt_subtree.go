@@ -0,0 +1,167 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// ErrInvalidSubtree - error indicating nodes of a subtree don't match the
+// requested root and prefix.
+var ErrInvalidSubtree = errors.New("invalid subtree nodes")
+
+var errSubtreeLimit = errors.New("subtree nodes limit reached")
+
+/*GetSubtreeNodes - returns the nodes on the way from the root to the subtree
+* of all the paths starting with the prefix followed by the nodes of the
+* subtree in depth first order. At most limit nodes of the subtree are
+* returned, complete is false if the subtree has more nodes. */
+func (mpt *MerklePatriciaTrie) GetSubtreeNodes(ctx context.Context, prefix Path, limit int) (
+	nodes []Node, complete bool, err error) {
+
+	mpt.mutex.RLock()
+	defer mpt.mutex.RUnlock()
+
+	if len(mpt.Root) == 0 {
+		return nil, true, nil
+	}
+	nodes, key, err := mpt.getSubtreePathNodes(prefix)
+	if err != nil || key == nil {
+		return nodes, err == nil, err
+	}
+
+	var count int
+	handler := func(ctx context.Context, path Path, key Key, node Node) error {
+		if node == nil {
+			return ErrNodeNotFound
+		}
+		if count >= limit {
+			return errSubtreeLimit
+		}
+		nodes = append(nodes, node)
+		count++
+		return nil
+	}
+	err = mpt.iterate(ctx, Path{}, key, handler,
+		NodeTypeLeafNode|NodeTypeFullNode|NodeTypeExtensionNode)
+	if err == errSubtreeLimit {
+		return nodes, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return nodes, true, nil
+}
+
+// getSubtreePathNodes returns the nodes above the subtree of the prefix and
+// the key of the subtree root, the key is nil if there is no such subtree.
+func (mpt *MerklePatriciaTrie) getSubtreePathNodes(prefix Path) ([]Node, Key, error) {
+	var nodes []Node
+	key := mpt.Root
+	for {
+		if len(prefix) == 0 {
+			return nodes, key, nil
+		}
+		node, err := mpt.db.GetNode(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		next, rest, inside := subtreeStep(node, prefix)
+		if inside {
+			return nodes, key, nil
+		}
+		nodes = append(nodes, node)
+		if next == nil {
+			return nodes, nil, nil
+		}
+		key, prefix = next, rest
+	}
+}
+
+// subtreeStep follows the prefix at the node. It returns inside if the whole
+// node is under the prefix, otherwise the child key and the rest of the
+// prefix, the key is nil if the prefix isn't in the trie.
+func subtreeStep(node Node, prefix Path) (next Key, rest Path, inside bool) {
+	switch nodeImpl := node.(type) {
+	case *LeafNode:
+		return nil, nil, bytes.HasPrefix(nodeImpl.Path, prefix)
+	case *FullNode:
+		return nodeImpl.GetChild(prefix[0]), prefix[1:], false
+	case *ExtensionNode:
+		if bytes.HasPrefix(nodeImpl.Path, prefix) {
+			return nil, nil, true
+		}
+		if bytes.HasPrefix(prefix, nodeImpl.Path) {
+			return nodeImpl.NodeKey, prefix[len(nodeImpl.Path):], false
+		}
+	}
+	return nil, nil, false
+}
+
+/*VerifySubtreeNodes - checks that all the nodes are on the way from the root
+* to the subtree of the prefix or inside the subtree. If complete is set, all
+* the nodes of the subtree must be present. */
+func VerifySubtreeNodes(root Key, prefix Path, nodes []Node, complete bool) error {
+	byKey := make(map[StrKey]Node, len(nodes))
+	for _, node := range nodes {
+		byKey[StrKey(node.GetHashBytes())] = node
+	}
+	if len(root) == 0 {
+		if len(nodes) != 0 {
+			return ErrInvalidSubtree
+		}
+		return nil
+	}
+
+	var (
+		visited = make(map[StrKey]bool, len(nodes))
+		key     = root
+	)
+	for len(prefix) > 0 {
+		node, ok := byKey[StrKey(key)]
+		if !ok {
+			return ErrInvalidSubtree
+		}
+		next, rest, inside := subtreeStep(node, prefix)
+		if inside {
+			break
+		}
+		visited[StrKey(key)] = true
+		if next == nil {
+			key = nil
+			break
+		}
+		key, prefix = next, rest
+	}
+
+	if key != nil {
+		// depth first walk over the received part of the subtree
+		stack := []Key{key}
+		for len(stack) > 0 {
+			key, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			node, ok := byKey[StrKey(key)]
+			if !ok {
+				if complete {
+					return ErrInvalidSubtree
+				}
+				continue
+			}
+			visited[StrKey(key)] = true
+			switch nodeImpl := node.(type) {
+			case *FullNode:
+				for _, child := range nodeImpl.Children {
+					if child != nil {
+						stack = append(stack, child)
+					}
+				}
+			case *ExtensionNode:
+				stack = append(stack, nodeImpl.NodeKey)
+			}
+		}
+	}
+
+	if len(visited) != len(byKey) {
+		return ErrInvalidSubtree
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerklePatriciaTrie_GetSubtreeNodes(t *testing.T) {
+	mpt := newSnapshotTestMPT(t, 200)
+
+	t.Run("whole trie", func(t *testing.T) {
+		nodes, complete, err := mpt.GetSubtreeNodes(context.Background(), nil, 10000)
+		require.NoError(t, err)
+		require.True(t, complete)
+		require.NoError(t, VerifySubtreeNodes(mpt.GetRoot(), nil, nodes, true))
+	})
+
+	t.Run("missing prefix", func(t *testing.T) {
+		nodes, complete, err := mpt.GetSubtreeNodes(context.Background(), Path("fffff"), 10000)
+		require.NoError(t, err)
+		require.True(t, complete)
+		require.NotEmpty(t, nodes)
+		require.NoError(t, VerifySubtreeNodes(mpt.GetRoot(), Path("fffff"), nodes, true))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		nodes, complete, err := mpt.GetSubtreeNodes(context.Background(), Path("1"), 10000)
+		require.NoError(t, err)
+		require.True(t, complete)
+
+		// a node out of the subtree
+		other, _, err := mpt.GetSubtreeNodes(context.Background(), Path("0"), 10000)
+		require.NoError(t, err)
+		extra := append(append([]Node{}, nodes...), other[len(other)-1])
+		require.Equal(t, ErrInvalidSubtree,
+			VerifySubtreeNodes(mpt.GetRoot(), Path("1"), extra, true))
+
+		// a missing node of a complete subtree
+		require.Equal(t, ErrInvalidSubtree,
+			VerifySubtreeNodes(mpt.GetRoot(), Path("1"), nodes[:len(nodes)-1], true))
+
+		// wrong root
+		require.Equal(t, ErrInvalidSubtree,
+			VerifySubtreeNodes(Key("other root"), Path("1"), nodes, true))
+	})
+}
+
+// TestMerklePatriciaTrie_GetSubtreeNodes_Split syncs a trie to an empty db by
+// splitting the subtrees exceeding the limit as the state sync does.
+func TestMerklePatriciaTrie_GetSubtreeNodes_Split(t *testing.T) {
+	mpt := newSnapshotTestMPT(t, 200)
+	ndb := NewMemoryNodeDB()
+
+	var (
+		pending  = []string{""}
+		requests int
+	)
+	for len(pending) > 0 {
+		prefix := pending[0]
+		pending = pending[1:]
+		requests++
+
+		nodes, complete, err := mpt.GetSubtreeNodes(context.Background(), Path(prefix), 20)
+		require.NoError(t, err)
+		require.NoError(t, VerifySubtreeNodes(mpt.GetRoot(), Path(prefix), nodes, complete))
+		for _, node := range nodes {
+			require.NoError(t, ndb.PutNode(node.GetHashBytes(), node))
+		}
+		if !complete {
+			for _, nibble := range "0123456789abcdef" {
+				pending = append(pending, prefix+string(nibble))
+			}
+		}
+	}
+	require.True(t, requests > 1)
+
+	synced := NewMerklePatriciaTrie(ndb, Sequence(1))
+	synced.SetRoot(mpt.GetRoot())
+	_, keys, err := synced.FindMissingNodes(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, keys)
+	require.Equal(t, len(mpt.db.(*MemoryNodeDB).Nodes), len(ndb.Nodes))
+}
@@ -0,0 +1,261 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vmihailenco/msgpack"
+)
+
+/*
+* A typed value of the state is prefixed with the header of
+*   TypedValueMagic | codec version | type tag (2 bytes, big endian)
+* followed by the value encoded by the codec of the version. The legacy
+* values are plain JSON that never starts with the magic byte.
+ */
+const (
+	TypedValueMagic      byte = 0x01
+	typedValueHeaderSize      = 4
+)
+
+// versions of the typed value codec
+const (
+	ValueCodecMsgpack byte = 1
+)
+
+/*ValueTypeTag - a tag of a type of the typed values, a tag once used by the
+* state must never be reassigned to another type. The smart contracts use
+* their own ranges: 0x01xx miner, 0x02xx storage and 0x03xx vesting SC */
+type ValueTypeTag uint16
+
+var (
+	ErrUnknownValueType  = errors.New("unknown value type")
+	ErrInvalidTypedValue = errors.New("invalid typed value")
+)
+
+type valueType struct {
+	tag  ValueTypeTag
+	name string
+	typ  reflect.Type
+}
+
+var (
+	valueTypesMutex  sync.RWMutex
+	valueTypesByTag  = make(map[ValueTypeTag]*valueType)
+	valueTypesByType = make(map[reflect.Type]*valueType)
+
+	typedValueEncoding int32
+)
+
+/*RegisterValueType - register the type of the value (a pointer to a struct)
+* for the typed encoding, the registration of a tag or a type twice panics */
+func RegisterValueType(tag ValueTypeTag, name string, value interface{}) {
+	typ := reflect.TypeOf(value)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("value type %s must be a pointer", name))
+	}
+	valueTypesMutex.Lock()
+	defer valueTypesMutex.Unlock()
+	if vt, ok := valueTypesByTag[tag]; ok {
+		panic(fmt.Sprintf("value type tag %d of %s is used by %s", tag, name, vt.name))
+	}
+	if vt, ok := valueTypesByType[typ.Elem()]; ok {
+		panic(fmt.Sprintf("value type %s is registered as %s", name, vt.name))
+	}
+	vt := &valueType{tag: tag, name: name, typ: typ.Elem()}
+	registerSortedMaps(vt.typ, make(map[reflect.Type]bool))
+	valueTypesByTag[tag] = vt
+	valueTypesByType[vt.typ] = vt
+}
+
+// registerSortedMaps makes the msgpack encoding of the maps of the type
+// deterministic, the encoder sorts only the keys of some of the map types.
+func registerSortedMaps(typ reflect.Type, seen map[reflect.Type]bool) {
+	if seen[typ] {
+		return
+	}
+	seen[typ] = true
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		registerSortedMaps(typ.Elem(), seen)
+	case reflect.Map:
+		switch typ.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16,
+			reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+			reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			panic(fmt.Sprintf("typed value map %v: keys can't be sorted", typ))
+		}
+		msgpack.Register(reflect.Zero(typ).Interface(), encodeSortedMap, nil)
+		registerSortedMaps(typ.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if f := typ.Field(i); f.PkgPath == "" || f.Anonymous {
+				registerSortedMaps(f.Type, seen)
+			}
+		}
+	}
+}
+
+func encodeSortedMap(e *msgpack.Encoder, v reflect.Value) error {
+	if v.IsNil() {
+		return e.EncodeNil()
+	}
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		switch ki := keys[i]; ki.Kind() {
+		case reflect.String:
+			return ki.String() < keys[j].String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return ki.Int() < keys[j].Int()
+		default:
+			return ki.Uint() < keys[j].Uint()
+		}
+	})
+	if err := e.EncodeMapLen(len(keys)); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := e.EncodeValue(key); err != nil {
+			return err
+		}
+		if err := e.EncodeValue(v.MapIndex(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getValueType(value interface{}) (*valueType, bool) {
+	typ := reflect.TypeOf(value)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return nil, false
+	}
+	valueTypesMutex.RLock()
+	defer valueTypesMutex.RUnlock()
+	vt, ok := valueTypesByType[typ.Elem()]
+	return vt, ok
+}
+
+/*SetTypedValueEncoding - enable or disable the typed encoding of the values,
+* all the nodes of a chain must agree on it since it changes the state hash */
+func SetTypedValueEncoding(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&typedValueEncoding, v)
+}
+
+/*TypedValueEncoding - whether the registered values are encoded typed */
+func TypedValueEncoding() bool {
+	return atomic.LoadInt32(&typedValueEncoding) == 1
+}
+
+/*IsTypedValue - check if the data is a typed value */
+func IsTypedValue(data []byte) bool {
+	return len(data) >= typedValueHeaderSize && data[0] == TypedValueMagic
+}
+
+/*EncodeValue - encode the value typed if the typed encoding is enabled and
+* its type is registered, as the legacy JSON otherwise */
+func EncodeValue(value interface{}) ([]byte, error) {
+	if !TypedValueEncoding() {
+		return json.Marshal(value)
+	}
+	vt, ok := getValueType(value)
+	if !ok {
+		return json.Marshal(value)
+	}
+	return encodeTypedValue(vt, value)
+}
+
+func encodeTypedValue(vt *valueType, value interface{}) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 256))
+	buf.Write([]byte{TypedValueMagic, ValueCodecMsgpack, 0, 0})
+	binary.BigEndian.PutUint16(buf.Bytes()[2:typedValueHeaderSize], uint16(vt.tag))
+	enc := msgpack.NewEncoder(buf)
+	enc.UseJSONTag(true)
+	enc.UseCompactEncoding(true)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/*DecodeValue - decode the typed or the legacy JSON data to the value */
+func DecodeValue(data []byte, value interface{}) error {
+	if !IsTypedValue(data) {
+		return json.Unmarshal(data, value)
+	}
+	vt, err := typedValueType(data)
+	if err != nil {
+		return err
+	}
+	if typ := reflect.TypeOf(value); typ.Kind() != reflect.Ptr || typ.Elem() != vt.typ {
+		return fmt.Errorf("%w: %s, expected %T", ErrInvalidTypedValue, vt.name, value)
+	}
+	return decodeTypedValue(data, value)
+}
+
+/*DecodeTypedValue - decode the typed data to a new value of its registered
+* type, returns the name of the type */
+func DecodeTypedValue(data []byte) (string, interface{}, error) {
+	if !IsTypedValue(data) {
+		return "", nil, ErrInvalidTypedValue
+	}
+	vt, err := typedValueType(data)
+	if err != nil {
+		return "", nil, err
+	}
+	value := reflect.New(vt.typ).Interface()
+	if sv, ok := value.(Serializable); ok {
+		err = sv.Decode(data) // let the type complete the decoded value
+	} else {
+		err = decodeTypedValue(data, value)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return vt.name, value, nil
+}
+
+/*DecodeTypedValueAs - decode the typed data to a value of another type of
+* the same encoded shape, for the registered types with fields of interfaces
+* the codec can't decode */
+func DecodeTypedValueAs(data []byte, value interface{}) error {
+	if !IsTypedValue(data) {
+		return ErrInvalidTypedValue
+	}
+	if _, err := typedValueType(data); err != nil {
+		return err
+	}
+	return decodeTypedValue(data, value)
+}
+
+func typedValueType(data []byte) (*valueType, error) {
+	if data[1] != ValueCodecMsgpack {
+		return nil, fmt.Errorf("%w: unknown codec version %d", ErrInvalidTypedValue, data[1])
+	}
+	tag := ValueTypeTag(binary.BigEndian.Uint16(data[2:typedValueHeaderSize]))
+	valueTypesMutex.RLock()
+	defer valueTypesMutex.RUnlock()
+	vt, ok := valueTypesByTag[tag]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownValueType, tag)
+	}
+	return vt, nil
+}
+
+func decodeTypedValue(data []byte, value interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data[typedValueHeaderSize:]))
+	dec.UseJSONTag(true)
+	return dec.Decode(value)
+}
@@ -0,0 +1,225 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCodecTerms struct {
+	ReadPrice     int64   `json:"read_price"`
+	WritePrice    int64   `json:"write_price"`
+	MinLockDemand float64 `json:"min_lock_demand"`
+}
+
+type testCodecBlobber struct {
+	BlobberID string          `json:"blobber_id"`
+	Size      int64           `json:"size"`
+	Terms     *testCodecTerms `json:"terms"`
+}
+
+// testCodecValue is shaped like the SC allocations.
+type testCodecValue struct {
+	ID         string                       `json:"id"`
+	Owner      string                       `json:"owner_id"`
+	Size       int64                        `json:"size"`
+	Expiration int64                        `json:"expiration_date"`
+	Blobbers   []*testCodecBlobber          `json:"blobber_details"`
+	Stakes     map[string]int64             `json:"stakes"`
+	Finalized  bool                         `json:"finalized,omitempty"`
+	Index      map[string]*testCodecBlobber `json:"-"`
+}
+
+func (v *testCodecValue) Encode() []byte {
+	buff, _ := EncodeValue(v)
+	return buff
+}
+
+func (v *testCodecValue) Decode(input []byte) error {
+	if err := DecodeValue(input, v); err != nil {
+		return err
+	}
+	v.Index = make(map[string]*testCodecBlobber)
+	for _, b := range v.Blobbers {
+		v.Index[b.BlobberID] = b
+	}
+	return nil
+}
+
+type testCodecOther struct {
+	Name string `json:"name"`
+}
+
+const (
+	testCodecValueTag ValueTypeTag = 0xff00 + iota
+	testCodecOtherTag
+)
+
+func init() {
+	RegisterValueType(testCodecValueTag, "util.test_value", &testCodecValue{})
+	RegisterValueType(testCodecOtherTag, "util.test_other", &testCodecOther{})
+}
+
+func withTypedValueEncoding(enabled bool) func() {
+	prev := TypedValueEncoding()
+	SetTypedValueEncoding(enabled)
+	return func() { SetTypedValueEncoding(prev) }
+}
+
+func newTestCodecValue(i int) *testCodecValue {
+	v := &testCodecValue{
+		ID:         fmt.Sprintf("%064x", i),
+		Owner:      fmt.Sprintf("%064x", i*7),
+		Size:       int64(i) * 1024 * 1024,
+		Expiration: 1600000000 + int64(i),
+		Stakes:     make(map[string]int64),
+	}
+	for j := 0; j < 6; j++ {
+		id := fmt.Sprintf("%064x", i*10+j)
+		v.Blobbers = append(v.Blobbers, &testCodecBlobber{
+			BlobberID: id,
+			Size:      v.Size / 6,
+			Terms:     &testCodecTerms{ReadPrice: 10, WritePrice: 100, MinLockDemand: 0.1},
+		})
+		v.Stakes[id] = int64(j) * 1e10
+	}
+	return v
+}
+
+func TestValueCodec(t *testing.T) {
+	defer withTypedValueEncoding(true)()
+
+	v := newTestCodecValue(1)
+	data := v.Encode()
+	require.True(t, IsTypedValue(data))
+	require.Equal(t, []byte{TypedValueMagic, ValueCodecMsgpack, 0xff, 0x00}, data[:4])
+
+	var got testCodecValue
+	require.NoError(t, got.Decode(data))
+	require.Equal(t, v.Blobbers, got.Blobbers)
+	require.Equal(t, v.Stakes, got.Stakes)
+	require.Len(t, got.Index, 6)
+
+	// deterministic regardless of the maps order
+	for i := 0; i < 10; i++ {
+		require.Equal(t, data, newTestCodecValue(1).Encode())
+	}
+
+	// self described
+	name, node, err := DecodeTypedValue(data)
+	require.NoError(t, err)
+	require.Equal(t, "util.test_value", name)
+	require.Len(t, node.(*testCodecValue).Index, 6)
+
+	// typed value of another type
+	other, err := EncodeValue(&testCodecOther{Name: "other"})
+	require.NoError(t, err)
+	require.True(t, errors.Is(got.Decode(other), ErrInvalidTypedValue))
+
+	// the same shape decoded as another type
+	var shape struct {
+		ID       string              `json:"id"`
+		Blobbers []*testCodecBlobber `json:"blobber_details"`
+	}
+	require.NoError(t, DecodeTypedValueAs(data, &shape))
+	require.Equal(t, v.ID, shape.ID)
+	require.Equal(t, v.Blobbers, shape.Blobbers)
+	require.True(t, errors.Is(DecodeTypedValueAs([]byte(`{"id":"x"}`), &shape),
+		ErrInvalidTypedValue))
+
+	// unknown tag and codec version
+	unknown := append([]byte{TypedValueMagic, ValueCodecMsgpack, 0xfe, 0xff}, data[4:]...)
+	require.True(t, errors.Is(got.Decode(unknown), ErrUnknownValueType))
+	unknown = append([]byte{TypedValueMagic, 0xff, 0xff, 0x00}, data[4:]...)
+	require.True(t, errors.Is(got.Decode(unknown), ErrInvalidTypedValue))
+
+	// not registered types are encoded as JSON
+	data, err = EncodeValue(&testCodecTerms{ReadPrice: 1})
+	require.NoError(t, err)
+	require.False(t, IsTypedValue(data))
+}
+
+func TestValueCodec_Legacy(t *testing.T) {
+	defer withTypedValueEncoding(false)()
+
+	v := newTestCodecValue(2)
+	data := v.Encode()
+	legacy, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, legacy, data)
+
+	// the legacy values are readable with the typed encoding enabled
+	SetTypedValueEncoding(true)
+	var got testCodecValue
+	require.NoError(t, got.Decode(legacy))
+	require.Equal(t, v.Blobbers, got.Blobbers)
+	require.Len(t, got.Index, 6)
+
+	// and re-encoded typed
+	require.True(t, IsTypedValue(got.Encode()))
+}
+
+func benchmarkValueCodec(b *testing.B, typed bool) {
+	defer withTypedValueEncoding(typed)()
+
+	v := newTestCodecValue(3)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var got testCodecValue
+		if err := got.Decode(v.Encode()); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(v.Encode())), "bytes/value")
+}
+
+func BenchmarkValueCodec_JSON(b *testing.B)  { benchmarkValueCodec(b, false) }
+func BenchmarkValueCodec_Typed(b *testing.B) { benchmarkValueCodec(b, true) }
+
+// benchmarkValueCodecState is what the state computation does with the SC
+// values, get them from the state, decode, update, encode and insert back.
+func benchmarkValueCodecState(b *testing.B, typed bool) {
+	defer withTypedValueEncoding(typed)()
+
+	const values = 1000
+	var (
+		mpt  = NewMerklePatriciaTrie(NewMemoryNodeDB(), 0)
+		size int
+	)
+	for i := 0; i < values; i++ {
+		v := newTestCodecValue(i)
+		size += len(v.Encode())
+		if _, err := mpt.Insert(Path(fmt.Sprintf("%06x", i)), v); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		path := Path(fmt.Sprintf("%06x", i%values))
+		sv, err := mpt.GetNodeValue(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var v testCodecValue
+		if err := v.Decode(sv.Encode()); err != nil {
+			b.Fatal(err)
+		}
+		v.Size++
+		if _, err := mpt.Insert(path, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(size)/values, "bytes/value")
+	require.NoError(b, mpt.Iterate(context.TODO(),
+		func(ctx context.Context, path Path, key Key, node Node) error {
+			return nil
+		}, NodeTypeValueNode))
+}
+
+func BenchmarkValueCodecState_JSON(b *testing.B)  { benchmarkValueCodecState(b, false) }
+func BenchmarkValueCodecState_Typed(b *testing.B) { benchmarkValueCodecState(b, true) }
@@ -43,6 +43,9 @@ func main() {
 	delayFile := flag.String("delay_file", "", "delay_file")
 	magicBlockFile := flag.String("magic_block_file", "", "magic_block_file")
 	initialStatesFile := flag.String("initial_states", "", "initial_states")
+	stateSnapshotExport := flag.String("state_snapshot_export", "", "export the state snapshot of a finalized round to the file and exit")
+	stateSnapshotRound := flag.Int64("state_snapshot_round", 0, "round of the exported state snapshot, the latest finalized round by default")
+	stateSnapshotImport := flag.String("state_snapshot_import", "", "import the state snapshot from the file or a node URL before start")
 	flag.Parse()
 	config.Configuration.DeploymentMode = byte(*deploymentMode)
 	config.SetupDefaultConfig()
@@ -58,6 +61,9 @@ func main() {
 	config.Configuration.ChainID = viper.GetString("server_chain.id")
 	transaction.SetTxnTimeout(int64(viper.GetInt("server_chain.transaction.timeout")))
 	transaction.SetTxnFee(viper.GetInt64("server_chain.transaction.min_fee"))
+	transaction.SetTxnPoolLimits(viper.GetInt64("server_chain.transaction.pool.max_size"),
+		viper.GetInt64("server_chain.transaction.pool.max_per_client"),
+		viper.GetInt64("server_chain.transaction.pool.replace_fee_bump"))
 
 	config.SetServerChainID(config.Configuration.ChainID)
 
@@ -157,6 +163,12 @@ func main() {
 	if node.Self.Underlying().Type != node.NodeTypeMiner {
 		logging.Logger.Panic("node not configured as miner")
 	}
+
+	if *stateSnapshotImport != "" {
+		if _, err = mc.ImportStateSnapshot(ctx, *stateSnapshotImport); err != nil {
+			logging.Logger.Panic("import state snapshot", zap.Error(err))
+		}
+	}
 	err = common.NewError("saving self as client", "client save")
 	for err != nil {
 		_, err = client.PutClient(ctx, &node.Self.Underlying().Client)
@@ -221,6 +233,18 @@ func main() {
 	// if there is errors
 	mc.SetupLatestAndPreviousMagicBlocks(ctx)
 
+	// export state of the LFB given by sharders, or of a round before it,
+	// the state should be in the state DB of the miner
+	if *stateSnapshotExport != "" {
+		if _, err = mc.LoadLatestFinalizedBlock(ctx); err != nil {
+			logging.Logger.Panic("export state snapshot", zap.Error(err))
+		}
+		if _, err = mc.ExportStateSnapshot(ctx, *stateSnapshotRound, *stateSnapshotExport); err != nil {
+			logging.Logger.Panic("export state snapshot", zap.Error(err))
+		}
+		return
+	}
+
 	mb = mc.GetLatestMagicBlock()
 	if mb.StartingRound == 0 && mb.IsActiveNode(node.Self.Underlying().GetKey(), mb.StartingRound) {
 		genesisDKG := viper.GetInt64("network.genesis_dkg")
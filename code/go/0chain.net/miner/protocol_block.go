@@ -91,11 +91,16 @@ func (mc *Chain) createBlockRewardTxn(b *block.Block) *transaction.Transaction {
 func (mc *Chain) txnToReuse(txn *transaction.Transaction) *transaction.Transaction {
 	ctxn := *txn
 	ctxn.OutputHash = ""
+	ctxn.Receipt = nil
 	return &ctxn
 }
 
+/*validateTransaction - whether the transaction is within the time tolerance
+* and not expired by the time of the block, the invalid transactions are
+* purged from the pool */
 func (mc *Chain) validateTransaction(b *block.Block, txn *transaction.Transaction) bool {
-	return common.WithinTime(int64(b.CreationDate), int64(txn.CreationDate), transaction.TXN_TIME_TOLERANCE)
+	return common.WithinTime(int64(b.CreationDate), int64(txn.CreationDate), transaction.TXN_TIME_TOLERANCE) &&
+		!txn.IsExpired(b.CreationDate)
 }
 
 // UpdatePendingBlock - updates the block that is generated and pending
@@ -112,9 +117,39 @@ func (mc *Chain) UpdatePendingBlock(ctx context.Context, b *block.Block, txns []
 	transactionMetadataProvider.GetStore().MultiAddToCollection(ctx, transactionMetadataProvider, txns)
 }
 
+// receiptHashes - the hashes of the receipts of the transactions of the block
+func receiptHashes(b *block.Block) []string {
+	hashes := make([]string, len(b.Txns))
+	for i, txn := range b.Txns {
+		hashes[i] = transaction.NewTransactionReceipt(txn).GetHash()
+	}
+	return hashes
+}
+
+/*verifyReceipts - the receipts computed by the state computation replace the
+* receipts received with the block, they should be the same */
+func verifyReceipts(b *block.Block, received []string, root string) error {
+	if len(received) != len(b.Txns) {
+		return common.NewError("txn_receipt_verification_failed",
+			"number of the receipts doesn't match the transactions")
+	}
+	for i, txn := range b.Txns {
+		if transaction.NewTransactionReceipt(txn).GetHash() != received[i] {
+			return common.NewErrorf("txn_receipt_verification_failed",
+				"receipt of the transaction %v doesn't match the computed one", txn.Hash)
+		}
+	}
+	if b.GetReceiptsMerkleTree().GetRoot() != root {
+		return common.NewError("txn_receipt_verification_failed",
+			"receipts root doesn't match the computed one")
+	}
+	return nil
+}
+
 func (mc *Chain) verifySmartContracts(ctx context.Context, b *block.Block) error {
 	for _, txn := range b.Txns {
-		if txn.TransactionType == transaction.TxnTypeSmartContract {
+		if txn.TransactionType == transaction.TxnTypeSmartContract ||
+			txn.TransactionType == transaction.TxnTypeMultiCall {
 			err := txn.VerifyOutputHash(ctx)
 			if err != nil {
 				logging.Logger.Error("Smart contract output verification failed", zap.Any("error", err), zap.Any("output", txn.TransactionOutput))
@@ -245,10 +280,19 @@ func (mc *Chain) VerifyBlock(ctx context.Context, b *block.Block) (
 		return nil, block.ErrPreviousBlockUnavailable
 	}
 
+	if err = mc.ValidateBlockMinFee(b, pb); err != nil {
+		return
+	}
+
 	if err = mc.ValidateTransactions(ctx, b); err != nil {
 		return
 	}
 
+	// the state computation replaces the receipts
+	var (
+		receipts     = receiptHashes(b)
+		receiptsRoot = b.GetReceiptsMerkleTree().GetRoot()
+	)
 	if err = mc.ComputeState(ctx, b); err != nil {
 		if err == context.Canceled {
 			logging.Logger.Warn("verify block canceled")
@@ -267,6 +311,13 @@ func (mc *Chain) VerifyBlock(ctx context.Context, b *block.Block) (
 		return
 	}
 
+	if err = verifyReceipts(b, receipts, receiptsRoot); err != nil {
+		logging.Logger.Error("verify block - receipts mismatch",
+			zap.Int64("round", b.Round), zap.String("block", b.Hash),
+			zap.Error(err))
+		return
+	}
+
 	if err = mc.VerifyBlockMagicBlock(ctx, b); err != nil {
 		return
 	}
@@ -288,6 +339,10 @@ func (mc *Chain) VerifyBlock(ctx context.Context, b *block.Block) (
 
 /*ValidateTransactions - validate the transactions in the block */
 func (mc *Chain) ValidateTransactions(ctx context.Context, b *block.Block) error {
+	if err := validateTxnNonces(b.Txns); err != nil {
+		logging.Logger.Error("validate transactions", zap.Any("round", b.Round), zap.Any("block", b.Hash), zap.Error(err))
+		return err
+	}
 	var roundMismatch bool
 	var cancel bool
 	numWorkers := len(b.Txns) / mc.ValidationBatchSize
@@ -322,6 +377,13 @@ func (mc *Chain) ValidateTransactions(ctx context.Context, b *block.Block) error
 				return
 			}
 			err := txn.ValidateWrtTimeForBlock(ctx, b.CreationDate, !aggregate)
+			if err == nil {
+				err = txn.ValidateWrtRound(b.Round)
+			}
+			if err == nil && aggregate {
+				// the fee payer's signature isn't a part of the aggregate
+				err = txn.VerifyFeePayerSignature(ctx)
+			}
 			if err != nil {
 				cancel = true
 				logging.Logger.Error("validate transactions", zap.Any("round", b.Round), zap.Any("block", b.Hash), zap.String("txn", datastore.ToJSON(txn).String()), zap.Error(err))
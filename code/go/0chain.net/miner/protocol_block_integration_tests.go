@@ -87,6 +87,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 
 	var clients = make(map[string]*client.Client)
 	b.Txns = make([]*transaction.Transaction, mc.BlockSize)
+	b.MinFee = mc.ComputeMinFee(b.PrevBlock)
 
 	// wasting this because []interface{} != []*transaction.Transaction in Go
 	var (
@@ -117,6 +118,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 		dstxn = pb.Txns[rand.Intn(len(pb.Txns))] // a random one
 	}
 
+	var executor = mc.NewTxnExecutor(b)
 	var txnProcessor = func(ctx context.Context, txn *transaction.Transaction) bool {
 		if _, ok := txnMap[txn.GetKey()]; ok {
 			return false
@@ -125,13 +127,19 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 		if !mc.validateTransaction(b, txn) {
 			invalidTxns = append(invalidTxns, txn)
 			if debugTxn {
-				logging.Logger.Info("generate block (debug transaction) error - txn creation not within tolerance", zap.String("txn", txn.Hash), zap.Int32("idx", idx), zap.Any("now", common.Now()))
+				logging.Logger.Info("generate block (debug transaction) error - txn creation not within tolerance or expired", zap.String("txn", txn.Hash), zap.Int32("idx", idx), zap.Any("now", common.Now()))
 			}
 			return false
 		}
 		if debugTxn {
 			logging.Logger.Info("generate block (debug transaction)", zap.String("txn", txn.Hash), zap.Int32("idx", idx), zap.String("txn_object", datastore.ToJSON(txn).String()))
 		}
+		if txn.ValidateWrtRound(b.Round) != nil {
+			return false // stays in the pool until the round it's valid from
+		}
+		if txn.GetFeePayerID() != b.MinerID && txn.ValidateMinFee(b.MinFee) != nil {
+			return false // stays in the pool for the min fee to go down
+		}
 		if dstxn == nil || (dstxn != nil && txn.Hash != dstxn.Hash) {
 			if ok, err := mc.ChainHasTransaction(ctx, b.PrevBlock, txn); ok || err != nil {
 				if err != nil {
@@ -140,11 +148,13 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 				return false
 			}
 		}
-		if err := mc.UpdateState(ctx, b, txn); err != nil {
+		if err := executor.UpdateState(ctx, txn); err != nil {
 			if debugTxn {
 				logging.Logger.Error("generate block (debug transaction) update state", zap.String("txn", txn.Hash), zap.Int32("idx", idx), zap.String("txn_object", datastore.ToJSON(txn).String()), zap.Error(err))
 			}
 			failedStateCount++
+			// kept in the pool, it can succeed in a later block
+			transaction.SetTxnLastError(txn.Hash, err.Error())
 			return false
 		}
 
@@ -165,6 +175,36 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 		idx++
 		return true
 	}
+	var nonces = newClientNonces(func(clientID datastore.Key) (int64, error) {
+		return mc.GetStateNonce(b.ClientState, clientID)
+	})
+	// the transactions of a client are processed in the order of their nonces
+	var nonceTxnProcessor = func(ctx context.Context, txn *transaction.Transaction) (included bool) {
+		if ready, err := nonces.ready(txn); !ready {
+			if err != nil {
+				ierr = err
+			}
+			return false
+		}
+		for txn != nil && txnProcessor(ctx, txn) {
+			included = true
+			txn = nonces.include(txn)
+			if idx >= mc.BlockSize || byteSize >= mc.MaxByteSize {
+				break
+			}
+		}
+		return
+	}
+	// the transactions are executed in parallel in batches if it's configured
+	var batch = newTxnBatch(executor)
+	var processTxn = func(ctx context.Context, txn *transaction.Transaction) bool {
+		if nonceTxnProcessor(ctx, txn) {
+			if idx >= mc.BlockSize || byteSize >= mc.MaxByteSize {
+				return false
+			}
+		}
+		return true
+	}
 	var roundTimeoutCount = mc.GetRoundTimeoutCount()
 	var txnIterHandler = func(ctx context.Context, qe datastore.CollectionEntity) bool {
 		count++
@@ -181,12 +221,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 			logging.Logger.Error("generate block (invalid entity)", zap.Any("entity", qe))
 			return true
 		}
-		if txnProcessor(ctx, txn) {
-			if idx >= mc.BlockSize || byteSize >= mc.MaxByteSize {
-				return false
-			}
-		}
-		return true
+		return batch.add(ctx, txn, processTxn)
 	}
 	start := time.Now()
 	b.CreationDate = common.Now()
@@ -201,9 +236,15 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 		txnIterHandler(ctx, dstxn) // inject double-spend transaction
 	}
 	err := transactionEntityMetadata.GetStore().IterateCollection(ctx, transactionEntityMetadata, collectionName, txnIterHandler)
+	if err == nil && !roundMismatch && !roundTimeout {
+		batch.flush(ctx, processTxn)
+	}
 	if len(invalidTxns) > 0 {
 		logging.Logger.Info("generate block (found txns very old)", zap.Any("round", b.Round), zap.Int("num_invalid_txns", len(invalidTxns)))
 		go mc.deleteTxns(invalidTxns) // OK to do in background
+		for _, txn := range invalidTxns {
+			transaction.SetTxnStatus(txn.GetKey(), transaction.TxnStatusExpired, "")
+		}
 	}
 	if roundMismatch {
 		logging.Logger.Debug("generate block (round mismatch)", zap.Any("round", b.Round), zap.Any("current_round", mc.GetCurrentRound()))
@@ -234,7 +275,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 						continue
 					}
 				}
-				if txnProcessor(ctx, rtxn) {
+				if nonceTxnProcessor(ctx, rtxn) {
 					if idx == mc.BlockSize || byteSize >= mc.MaxByteSize {
 						break
 					}
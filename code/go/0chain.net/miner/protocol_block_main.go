@@ -47,6 +47,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 	bsh chain.BlockStateHandler, waitOver bool) error {
 
 	b.Txns = make([]*transaction.Transaction, 0, mc.BlockSize)
+	b.MinFee = mc.ComputeMinFee(b.PrevBlock)
 
 	var (
 		clients          = make(map[string]*client.Client)
@@ -62,6 +63,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 		txnMap           = make(map[datastore.Key]bool, mc.BlockSize)
 	)
 
+	var executor = mc.NewTxnExecutor(b)
 	var txnProcessor = func(ctx context.Context, txn *transaction.Transaction) bool {
 		if _, ok := txnMap[txn.GetKey()]; ok {
 			return false
@@ -71,7 +73,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 			invalidTxns = append(invalidTxns, txn)
 			if debugTxn {
 				logging.Logger.Info("generate block (debug transaction) error - "+
-					"txn creation not within tolerance",
+					"txn creation not within tolerance or expired",
 					zap.String("txn", txn.Hash), zap.Int32("idx", idx),
 					zap.Any("now", common.Now()))
 			}
@@ -82,13 +84,19 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 				zap.String("txn", txn.Hash), zap.Int32("idx", idx),
 				zap.String("txn_object", datastore.ToJSON(txn).String()))
 		}
+		if txn.ValidateWrtRound(b.Round) != nil {
+			return false // stays in the pool until the round it's valid from
+		}
+		if txn.GetFeePayerID() != b.MinerID && txn.ValidateMinFee(b.MinFee) != nil {
+			return false // stays in the pool for the min fee to go down
+		}
 		if ok, err := mc.ChainHasTransaction(ctx, b.PrevBlock, txn); ok || err != nil {
 			if err != nil {
 				ierr = err
 			}
 			return false
 		}
-		if err := mc.UpdateState(ctx, b, txn); err != nil {
+		if err := executor.UpdateState(ctx, txn); err != nil {
 			if debugTxn {
 				logging.Logger.Error("generate block (debug transaction) update state",
 					zap.String("txn", txn.Hash), zap.Int32("idx", idx),
@@ -96,6 +104,8 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 					zap.Error(err))
 			}
 			failedStateCount++
+			// kept in the pool, it can succeed in a later block
+			transaction.SetTxnLastError(txn.Hash, err.Error())
 			return false
 		}
 
@@ -116,6 +126,36 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 		idx++
 		return true
 	}
+	var nonces = newClientNonces(func(clientID datastore.Key) (int64, error) {
+		return mc.GetStateNonce(b.ClientState, clientID)
+	})
+	// the transactions of a client are processed in the order of their nonces
+	var nonceTxnProcessor = func(ctx context.Context, txn *transaction.Transaction) (included bool) {
+		if ready, err := nonces.ready(txn); !ready {
+			if err != nil {
+				ierr = err
+			}
+			return false
+		}
+		for txn != nil && txnProcessor(ctx, txn) {
+			included = true
+			txn = nonces.include(txn)
+			if idx >= mc.BlockSize || byteSize >= mc.MaxByteSize {
+				break
+			}
+		}
+		return
+	}
+	// the transactions are executed in parallel in batches if it's configured
+	var batch = newTxnBatch(executor)
+	var processTxn = func(ctx context.Context, txn *transaction.Transaction) bool {
+		if nonceTxnProcessor(ctx, txn) {
+			if idx >= mc.BlockSize || byteSize >= mc.MaxByteSize {
+				return false
+			}
+		}
+		return true
+	}
 	var roundTimeoutCount = mc.GetRoundTimeoutCount()
 	var txnIterHandler = func(ctx context.Context, qe datastore.CollectionEntity) bool {
 		count++
@@ -132,12 +172,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 			logging.Logger.Error("generate block (invalid entity)", zap.Any("entity", qe))
 			return true
 		}
-		if txnProcessor(ctx, txn) {
-			if idx >= mc.BlockSize || byteSize >= mc.MaxByteSize {
-				return false
-			}
-		}
-		return true
+		return batch.add(ctx, txn, processTxn)
 	}
 	start := time.Now()
 	b.CreationDate = common.Now()
@@ -149,9 +184,15 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 	collectionName := txn.GetCollectionName()
 	logging.Logger.Info("generate block starting iteration", zap.Int64("round", b.Round), zap.String("prev_block", b.PrevHash), zap.String("prev_state_hash", util.ToHex(b.PrevBlock.ClientStateHash)))
 	err := transactionEntityMetadata.GetStore().IterateCollection(ctx, transactionEntityMetadata, collectionName, txnIterHandler)
+	if err == nil && !roundMismatch && !roundTimeout {
+		batch.flush(ctx, processTxn)
+	}
 	if len(invalidTxns) > 0 {
 		logging.Logger.Info("generate block (found txns very old)", zap.Any("round", b.Round), zap.Int("num_invalid_txns", len(invalidTxns)))
 		go mc.deleteTxns(invalidTxns) // OK to do in background
+		for _, txn := range invalidTxns {
+			transaction.SetTxnStatus(txn.GetKey(), transaction.TxnStatusExpired, "")
+		}
 	}
 	if roundMismatch {
 		logging.Logger.Debug("generate block (round mismatch)", zap.Any("round", b.Round), zap.Any("current_round", mc.GetCurrentRound()))
@@ -182,7 +223,7 @@ func (mc *Chain) GenerateBlock(ctx context.Context, b *block.Block,
 						continue
 					}
 				}
-				if txnProcessor(ctx, rtxn) {
+				if nonceTxnProcessor(ctx, rtxn) {
 					if idx == mc.BlockSize || byteSize >= mc.MaxByteSize {
 						break
 					}
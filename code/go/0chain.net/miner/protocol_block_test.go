@@ -0,0 +1,35 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/block"
+	"0chain.net/chaincore/transaction"
+)
+
+func TestVerifyReceipts(t *testing.T) {
+	b := new(block.Block)
+	for _, hash := range []string{"t1", "t2"} {
+		b.Txns = append(b.Txns, &transaction.Transaction{
+			OutputHash: "output:" + hash,
+			Receipt:    &transaction.Receipt{Status: transaction.TxnSuccess, FeeUsed: 1},
+		})
+	}
+	received, root := receiptHashes(b), b.GetReceiptsMerkleTree().GetRoot()
+
+	// the same receipts computed
+	b.Txns[0].Receipt = &transaction.Receipt{Status: transaction.TxnSuccess, FeeUsed: 1}
+	require.NoError(t, verifyReceipts(b, received, root))
+
+	// another receipt computed
+	b.Txns[1].Receipt = &transaction.Receipt{Status: transaction.TxnSuccess, FeeUsed: 2}
+	require.Error(t, verifyReceipts(b, received, root))
+	b.Txns[1].Receipt = nil
+	require.Error(t, verifyReceipts(b, received, root))
+	b.Txns[1].Receipt = &transaction.Receipt{Status: transaction.TxnSuccess, FeeUsed: 1}
+
+	require.Error(t, verifyReceipts(b, received[:1], root))
+	require.Error(t, verifyReceipts(b, received, "other"))
+}
@@ -994,6 +994,7 @@ func (mc *Chain) AddNotarizedBlock(ctx context.Context, r *Round, b *block.Block
 		mc.CancelRoundVerification(ctx, r)
 	}
 	b.SetBlockState(block.StateNotarized)
+	transaction.SetTxnsStatus(b.Txns, transaction.TxnStatusIncluded, b.Hash, b.Round)
 	return true
 }
 
@@ -1639,6 +1640,24 @@ func (mc *Chain) startProtocolOnLFB(ctx context.Context, lfb *block.Block) (
 	return mc.GetMinerRound(lfb.Round)
 }
 
+// LoadLatestFinalizedBlock requests the LFB from sharders and initializes
+// its state from the state DB. It's used before the protocol starts, by the
+// state snapshot export, and doesn't create a round for the block.
+func (mc *Chain) LoadLatestFinalizedBlock(ctx context.Context) (
+	lfb *block.Block, err error) {
+
+	if lfb = getLatestBlockFromSharders(ctx); lfb == nil {
+		return nil, common.NewError("load_lfb",
+			"no latest finalized block given by sharders")
+	}
+	if err = mc.InitBlockState(lfb); err != nil {
+		return nil, common.NewErrorf("load_lfb",
+			"initializing state of block of round %d: %v", lfb.Round, err)
+	}
+	mc.Chain.SetLatestFinalizedBlock(lfb)
+	return
+}
+
 func StartProtocol(ctx context.Context, gb *block.Block) {
 
 	var (
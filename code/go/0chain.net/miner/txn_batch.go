@@ -0,0 +1,55 @@
+package miner
+
+import (
+	"context"
+
+	"0chain.net/chaincore/chain"
+	"0chain.net/chaincore/transaction"
+)
+
+// txnProcessFunc processes a transaction into the block being generated,
+// returns false when no more transactions should be processed.
+type txnProcessFunc func(ctx context.Context, txn *transaction.Transaction) bool
+
+// txnBatch - the transactions collected for a block being generated to be
+// executed in parallel before they are processed in their order.
+type txnBatch struct {
+	executor *chain.TxnExecutor
+	txns     []*transaction.Transaction
+}
+
+func newTxnBatch(executor *chain.TxnExecutor) *txnBatch {
+	return &txnBatch{executor: executor}
+}
+
+// add the transaction to the batch, the full batch is processed; without the
+// parallel execution the transaction is processed right away.
+func (tb *txnBatch) add(ctx context.Context, txn *transaction.Transaction,
+	process txnProcessFunc) bool {
+
+	if !tb.executor.IsParallel() {
+		return process(ctx, txn)
+	}
+	tb.txns = append(tb.txns, txn)
+	if len(tb.txns) < tb.executor.BatchSize() {
+		return true
+	}
+	return tb.flush(ctx, process)
+}
+
+// flush executes the transactions of the batch in parallel and processes them
+// in their order.
+func (tb *txnBatch) flush(ctx context.Context, process txnProcessFunc) bool {
+	txns := tb.txns
+	tb.txns = nil
+	if len(txns) == 0 {
+		return true
+	}
+	tb.executor.Execute(ctx, txns)
+	for _, txn := range txns {
+		if !process(ctx, txn) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package miner
+
+import (
+	"fmt"
+
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+)
+
+// clientNonces - the next nonces of the clients of a block being generated,
+// the transactions ahead of the next nonce of their client wait for it.
+type clientNonces struct {
+	nonce   func(clientID datastore.Key) (int64, error)
+	next    map[datastore.Key]int64
+	waiting map[datastore.Key]map[int64]*transaction.Transaction
+}
+
+func newClientNonces(nonce func(clientID datastore.Key) (int64, error)) *clientNonces {
+	return &clientNonces{
+		nonce:   nonce,
+		next:    make(map[datastore.Key]int64),
+		waiting: make(map[datastore.Key]map[int64]*transaction.Transaction),
+	}
+}
+
+func (cn *clientNonces) getNext(clientID datastore.Key) (int64, error) {
+	if next, ok := cn.next[clientID]; ok {
+		return next, nil
+	}
+	nonce, err := cn.nonce(clientID)
+	if err != nil {
+		return 0, err
+	}
+	cn.next[clientID] = nonce + 1
+	return nonce + 1, nil
+}
+
+// ready returns true if the transaction can be processed now, the transaction
+// with a later nonce is kept to be returned by the include of the previous
+// one, the transaction with a used nonce is never ready.
+func (cn *clientNonces) ready(txn *transaction.Transaction) (bool, error) {
+	if txn.Nonce == 0 {
+		return true, nil
+	}
+	next, err := cn.getNext(txn.ClientID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case txn.Nonce == next:
+		return true, nil
+	case txn.Nonce > next:
+		waiting, ok := cn.waiting[txn.ClientID]
+		if !ok {
+			waiting = make(map[int64]*transaction.Transaction)
+			cn.waiting[txn.ClientID] = waiting
+		}
+		// of the same nonce the first seen, having the higher score, waits
+		if _, ok := waiting[txn.Nonce]; !ok {
+			waiting[txn.Nonce] = txn
+		}
+	}
+	return false, nil
+}
+
+// include advances the next nonce of the client of the transaction included
+// in the block and returns the waiting transaction of the next nonce, if any.
+func (cn *clientNonces) include(txn *transaction.Transaction) *transaction.Transaction {
+	if txn.Nonce == 0 {
+		return nil
+	}
+	cn.next[txn.ClientID] = txn.Nonce + 1
+	waiting, ok := cn.waiting[txn.ClientID]
+	if !ok {
+		return nil
+	}
+	ntxn, ok := waiting[txn.Nonce+1]
+	if !ok {
+		return nil
+	}
+	delete(waiting, txn.Nonce+1)
+	if len(waiting) == 0 {
+		delete(cn.waiting, txn.ClientID)
+	}
+	return ntxn
+}
+
+// validateTxnNonces checks the nonces of each client's transactions of a
+// block are unique and follow one another in the order of the block.
+func validateTxnNonces(txns []*transaction.Transaction) error {
+	var last = make(map[datastore.Key]int64)
+	for _, txn := range txns {
+		if txn.Nonce == 0 {
+			continue
+		}
+		if prev, ok := last[txn.ClientID]; ok && txn.Nonce != prev+1 {
+			return common.NewError("invalid_nonce_order",
+				fmt.Sprintf("nonce %d of txn %s doesn't follow nonce %d of the client",
+					txn.Nonce, txn.Hash, prev))
+		}
+		last[txn.ClientID] = txn.Nonce
+	}
+	return nil
+}
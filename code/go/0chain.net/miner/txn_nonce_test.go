@@ -0,0 +1,72 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/datastore"
+)
+
+func newNonceTxn(clientID datastore.Key, nonce int64) *transaction.Transaction {
+	return &transaction.Transaction{ClientID: clientID, Nonce: nonce}
+}
+
+func TestClientNonces(t *testing.T) {
+	cn := newClientNonces(func(clientID datastore.Key) (int64, error) {
+		if clientID == "a" {
+			return 5, nil
+		}
+		return 0, nil
+	})
+
+	// the transactions without the nonce are always ready
+	ready, err := cn.ready(newNonceTxn("a", 0))
+	require.NoError(t, err)
+	require.True(t, ready)
+
+	// used nonce
+	ready, err = cn.ready(newNonceTxn("a", 5))
+	require.NoError(t, err)
+	require.False(t, ready)
+
+	// wait for the previous nonces
+	t8, t7 := newNonceTxn("a", 8), newNonceTxn("a", 7)
+	for _, txn := range []*transaction.Transaction{t8, t7, newNonceTxn("a", 7)} {
+		ready, err = cn.ready(txn)
+		require.NoError(t, err)
+		require.False(t, ready)
+	}
+
+	t6 := newNonceTxn("a", 6)
+	ready, err = cn.ready(t6)
+	require.NoError(t, err)
+	require.True(t, ready)
+	require.True(t, cn.include(t6) == t7)
+	require.True(t, cn.include(t7) == t8)
+	require.Nil(t, cn.include(t8))
+	require.Empty(t, cn.waiting)
+
+	ready, err = cn.ready(newNonceTxn("b", 1))
+	require.NoError(t, err)
+	require.True(t, ready)
+}
+
+func TestValidateTxnNonces(t *testing.T) {
+	require.NoError(t, validateTxnNonces([]*transaction.Transaction{
+		newNonceTxn("a", 3),
+		newNonceTxn("b", 1),
+		newNonceTxn("a", 0),
+		newNonceTxn("a", 4),
+		newNonceTxn("b", 2),
+	}))
+	require.Error(t, validateTxnNonces([]*transaction.Transaction{
+		newNonceTxn("a", 3),
+		newNonceTxn("a", 3),
+	}))
+	require.Error(t, validateTxnNonces([]*transaction.Transaction{
+		newNonceTxn("a", 4),
+		newNonceTxn("a", 3),
+	}))
+}
@@ -91,6 +91,19 @@ func (sc *Chain) GetBlockSummary(ctx context.Context, hash string) (*block.Block
 	return blockSummary, nil
 }
 
+// GetBlockSummaryByRound - get the summary of the finalized block of given
+// round, implements chain.BlockSummaryProvider.
+func (sc *Chain) GetBlockSummaryByRound(ctx context.Context, roundNum int64) (*block.BlockSummary, error) {
+	hash, err := sc.GetBlockHash(ctx, roundNum)
+	if err != nil {
+		return nil, err
+	}
+	bSummaryEntityMetadata := datastore.GetEntityMetadata("block_summary")
+	bctx := ememorystore.WithEntityConnection(ctx, bSummaryEntityMetadata)
+	defer ememorystore.Close(bctx)
+	return sc.GetBlockSummary(bctx, hash)
+}
+
 /*GetBlockFromHash - given the block hash, get the block */
 func (sc *Chain) GetBlockFromHash(ctx context.Context, hash string, roundNum int64) (*block.Block, error) {
 	b, err := sc.GetBlock(ctx, hash)
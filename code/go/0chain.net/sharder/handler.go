@@ -14,6 +14,7 @@ import (
 	"0chain.net/chaincore/block"
 	"0chain.net/chaincore/chain"
 	"0chain.net/chaincore/diagnostics"
+	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
 )
 
@@ -26,6 +27,7 @@ func SetupHandlers() {
 	http.HandleFunc("/_chain_stats", common.UserRateLimit(ChainStatsWriter))
 	http.HandleFunc("/_health_check", common.UserRateLimit(HealthCheckWriter))
 	http.HandleFunc("/v1/sharder/get/stats", common.UserRateLimit(common.ToJSONResponse(SharderStatsHandler)))
+	transaction.SetTxnStatusFallback(GetSharderChain().GetTransactionStatus)
 }
 
 /*BlockHandler - a handler to respond to block queries */
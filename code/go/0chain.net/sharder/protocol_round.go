@@ -9,6 +9,7 @@ import (
 	"0chain.net/chaincore/block"
 	"0chain.net/chaincore/chain"
 	"0chain.net/chaincore/round"
+	"0chain.net/chaincore/transaction"
 	. "0chain.net/core/logging"
 	"go.uber.org/zap"
 )
@@ -42,6 +43,7 @@ func (sc *Chain) AddNotarizedBlock(ctx context.Context, r round.RoundI,
 		}
 	}
 	sc.UpdateNodeState(b)
+	transaction.SetTxnsStatus(b.Txns, transaction.TxnStatusIncluded, b.Hash, b.Round)
 
 	errC := make(chan error)
 	doneC := make(chan struct{})
@@ -89,6 +89,9 @@ func main() {
 	minioFile := flag.String("minio_file", "", "minio_file")
 	initialStatesFile := flag.String("initial_states", "", "initial_states")
 	flag.String("nodes_file", "", "nodes_file (deprecated)")
+	stateSnapshotExport := flag.String("state_snapshot_export", "", "export the state snapshot of a finalized round to the file and exit")
+	stateSnapshotRound := flag.Int64("state_snapshot_round", 0, "round of the exported state snapshot, the latest finalized round by default")
+	stateSnapshotImport := flag.String("state_snapshot_import", "", "import the state snapshot from the file or a node URL before start")
 	flag.Parse()
 	config.Configuration.DeploymentMode = byte(*deploymentMode)
 	config.SetupDefaultConfig()
@@ -213,6 +216,18 @@ func main() {
 		return
 	}
 
+	if *stateSnapshotExport != "" {
+		if _, err = sc.ExportStateSnapshot(ctx, *stateSnapshotRound, *stateSnapshotExport); err != nil {
+			Logger.Panic("export state snapshot", zap.Error(err))
+		}
+		return
+	}
+	if *stateSnapshotImport != "" {
+		if _, err = sc.ImportStateSnapshot(ctx, *stateSnapshotImport); err != nil {
+			Logger.Panic("import state snapshot", zap.Error(err))
+		}
+	}
+
 	startBlocksInfoLogs(sc)
 
 	mode := "main net"
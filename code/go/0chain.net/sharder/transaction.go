@@ -85,6 +85,7 @@ func (sc *Chain) GetTransactionConfirmation(ctx context.Context, hash string) (*
 	txn := b.GetTransaction(hash)
 	confirmation.Status = txn.Status
 	confirmation.Transaction = txn
+	confirmation.Receipt = txn.Receipt
 	mt := b.GetMerkleTree()
 	confirmation.MerkleTreeRoot = mt.GetRoot()
 	confirmation.MerkleTreePath = mt.GetPath(confirmation)
@@ -95,6 +96,34 @@ func (sc *Chain) GetTransactionConfirmation(ctx context.Context, hash string) (*
 	return confirmation, nil
 }
 
+/*GetTransactionStatus - the status of a transaction of the finalized blocks
+* stored, the transactions not tracked anymore are looked up by it */
+func (sc *Chain) GetTransactionStatus(ctx context.Context, hash string) (*transaction.TxnStatus, error) {
+	txnSummaryEntityMetadata := datastore.GetEntityMetadata("txn_summary")
+	ctx = persistencestore.WithEntityConnection(ctx, txnSummaryEntityMetadata)
+	defer persistencestore.Close(ctx)
+	var ts *transaction.TransactionSummary
+	t, err := sc.BlockTxnCache.Get(hash)
+	if err != nil {
+		ts, err = sc.GetTransactionSummary(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ts = t.(*transaction.TransactionSummary)
+	}
+	bhash, err := sc.GetBlockHash(ctx, ts.Round)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction.TxnStatus{
+		Hash:      hash,
+		Status:    transaction.TxnStatusFinalized,
+		BlockHash: bhash,
+		Round:     ts.Round,
+	}, nil
+}
+
 /*StoreTransactions - persists given list of transactions*/
 func (sc *Chain) StoreTransactions(ctx context.Context, b *block.Block) error {
 	var sTxns = make([]datastore.Entity, len(b.Txns))
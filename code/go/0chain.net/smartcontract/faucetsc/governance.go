@@ -0,0 +1,88 @@
+package faucetsc
+
+import (
+	"errors"
+	"fmt"
+
+	c_state "0chain.net/chaincore/chain/state"
+	"0chain.net/core/util"
+	"0chain.net/smartcontract/governance"
+)
+
+// governedSettings is global node settings can be changed by governance
+// proposals
+var governedSettings = map[string]struct{}{
+	"pour_amount":      {},
+	"max_pour_amount":  {},
+	"periodic_limit":   {},
+	"global_limit":     {},
+	"individual_reset": {},
+	"global_rest":      {},
+}
+
+// governance of the faucet limits, it replaces the owner's updateLimits
+// function when enabled
+func (fc *FaucetSmartContract) governance() *governance.Governance {
+	return governance.New(fc.ID, "smart_contracts.faucetsc.", fc)
+}
+
+func (gn *GlobalNode) validateLimits() error {
+	switch {
+	case gn.PourAmount <= 0:
+		return errors.New("pour_amount must be positive")
+	case gn.MaxPourAmount < gn.PourAmount:
+		return errors.New("max_pour_amount is less than pour_amount")
+	case gn.PeriodicLimit < gn.PourAmount:
+		return errors.New("periodic_limit is less than pour_amount")
+	case gn.GlobalLimit < gn.PeriodicLimit:
+		return errors.New("global_limit is less than periodic_limit")
+	case gn.IndividualReset <= 0:
+		return errors.New("individual_reset must be positive")
+	case gn.GlobalReset <= 0:
+		return errors.New("global_rest must be positive")
+	}
+	return nil
+}
+
+// updatedGlobalNode returns current global node with given setting changed
+func (fc *FaucetSmartContract) updatedGlobalNode(key, value string,
+	balances c_state.StateContextI) (gn *GlobalNode, err error) {
+
+	if _, ok := governedSettings[key]; !ok {
+		return nil, fmt.Errorf("setting %q can't be changed", key)
+	}
+	gn, err = fc.getGlobalNode(balances)
+	if err == util.ErrValueNotPresent {
+		gn, err = fc.configuredGlobalNode(), nil
+	}
+	if err != nil {
+		return nil, errors.New("can't get global node: " + err.Error())
+	}
+	if err = governance.SetSetting(gn, key, value); err != nil {
+		return
+	}
+	if err = gn.validateLimits(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// ValidateSetting implements governance.Settings interface.
+func (fc *FaucetSmartContract) ValidateSetting(key, value string,
+	balances c_state.StateContextI) (err error) {
+
+	_, err = fc.updatedGlobalNode(key, value, balances)
+	return
+}
+
+// ApplySetting implements governance.Settings interface.
+func (fc *FaucetSmartContract) ApplySetting(key, value string,
+	balances c_state.StateContextI) (err error) {
+
+	var gn *GlobalNode
+	if gn, err = fc.updatedGlobalNode(key, value, balances); err != nil {
+		return
+	}
+	_, err = balances.InsertTrieNode(gn.GetKey(), gn)
+	return
+}
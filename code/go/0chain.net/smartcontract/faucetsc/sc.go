@@ -14,6 +14,7 @@ import (
 	"0chain.net/core/common"
 	. "0chain.net/core/logging"
 	"0chain.net/core/util"
+	"0chain.net/smartcontract/governance"
 	metrics "github.com/rcrowley/go-metrics"
 	"go.uber.org/zap"
 )
@@ -68,9 +69,13 @@ func (fc *FaucetSmartContract) setSC(sc *smartcontractinterface.SmartContract, _
 	fc.SmartContractExecutionStats["refill"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", fc.ID, "refill"), nil)
 	fc.SmartContractExecutionStats["tokens Poured"] = metrics.GetOrRegisterHistogram(fmt.Sprintf("sc:%v:func:%v", fc.ID, "tokens Poured"), nil, metrics.NewUniformSample(1024))
 	fc.SmartContractExecutionStats["token refills"] = metrics.GetOrRegisterHistogram(fmt.Sprintf("sc:%v:func:%v", fc.ID, "token refills"), nil, metrics.NewUniformSample(1024))
+	fc.governance().SetRestHandlers(fc.SmartContract.RestHandlers)
+	for _, fn := range governance.Functions {
+		fc.SmartContractExecutionStats[fn] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", fc.ID, fn), nil)
+	}
 }
 
-func (un *UserNode) validPourRequest(t *transaction.Transaction, balances c_state.StateContextI, gn *GlobalNode) (bool, error) {
+func (un *UserNode) validPourRequest(t *transaction.Transaction, balances c_state.StateContextI, gn *GlobalNode, pourAmount, staked state.Balance) (bool, error) {
 	smartContractBalance, err := balances.GetClientBalance(gn.ID)
 	if err == util.ErrValueNotPresent {
 		return false, common.NewError("invalid_request", "faucet has no tokens and needs to be refilled")
@@ -78,8 +83,10 @@ func (un *UserNode) validPourRequest(t *transaction.Transaction, balances c_stat
 	if err != nil {
 		return false, common.NewError("invalid_request", fmt.Sprintf("getting faucet balance resulted in an error: %v", err.Error()))
 	}
-	if gn.PourAmount > smartContractBalance {
-		return false, common.NewError("invalid_request", fmt.Sprintf("amount asked to be poured (%v) exceeds contract's wallet ballance (%v)", t.Value, smartContractBalance))
+	// governance stake is not for pouring
+	smartContractBalance -= staked
+	if pourAmount > smartContractBalance {
+		return false, common.NewError("invalid_request", fmt.Sprintf("amount asked to be poured (%v) exceeds contract's wallet ballance (%v)", pourAmount, smartContractBalance))
 	}
 	if state.Balance(gn.PourAmount)+un.Used > gn.PeriodicLimit {
 		return false, common.NewError("invalid_request", fmt.Sprintf("amount asked to be poured (%v) plus previous amounts (%v) exceeds allowed periodic limit (%v/%vhr)", t.Value, un.Used, gn.PeriodicLimit, gn.IndividualReset.String()))
@@ -95,6 +102,9 @@ func (fc *FaucetSmartContract) updateLimits(t *transaction.Transaction, inputDat
 	if t.ClientID != owner {
 		return "", common.NewError("unauthorized_access", "only the owner can update the limits")
 	}
+	if enabled, err := fc.governance().Enabled(); err != nil || enabled {
+		return "", common.NewError("unauthorized_access", "the limits are changed by governance proposals")
+	}
 	var newRequest limitRequest
 	err := newRequest.decode(inputData)
 	if err != nil {
@@ -128,12 +138,16 @@ func (fc *FaucetSmartContract) updateLimits(t *transaction.Transaction, inputDat
 
 func (fc *FaucetSmartContract) pour(t *transaction.Transaction, inputData []byte, balances c_state.StateContextI, gn *GlobalNode) (string, error) {
 	user := fc.getUserVariables(t, gn, balances)
-	ok, err := user.validPourRequest(t, balances, gn)
+	staked, err := fc.governance().TotalStake(balances)
+	if err != nil {
+		return "", common.NewError("invalid_request", "can't get governance stake: "+err.Error())
+	}
+	var pourAmount = gn.PourAmount
+	if t.Value > 0 && t.Value < int64(gn.MaxPourAmount) {
+		pourAmount = state.Balance(t.Value)
+	}
+	ok, err := user.validPourRequest(t, balances, gn, pourAmount, staked)
 	if ok {
-		var pourAmount = gn.PourAmount
-		if t.Value > 0 && t.Value < int64(gn.MaxPourAmount) {
-			pourAmount = state.Balance(t.Value)
-		}
 		tokensPoured := fc.SmartContractExecutionStats["tokens Poured"].(metrics.Histogram)
 		transfer := state.NewTransfer(t.ToClientID, t.ClientID, pourAmount)
 		balances.AddTransfer(transfer)
@@ -218,18 +232,28 @@ func (fc *FaucetSmartContract) getGlobalVariables(t *transaction.Transaction, ba
 		}
 		return gn
 	}
+	gn = fc.configuredGlobalNode()
+	gn.StartTime = common.ToTime(t.CreationDate)
+	return gn
+}
+
+// configuredGlobalNode returns global node with limits from sc.yaml
+func (fc *FaucetSmartContract) configuredGlobalNode() *GlobalNode {
+	gn := &GlobalNode{ID: fc.ID}
 	gn.PourAmount = state.Balance(config.SmartContractConfig.GetInt("smart_contracts.faucetsc.pour_amount"))
 	gn.MaxPourAmount = state.Balance(config.SmartContractConfig.GetInt("smart_contracts.faucetsc.max_pour_amount"))
 	gn.PeriodicLimit = state.Balance(config.SmartContractConfig.GetInt("smart_contracts.faucetsc.periodic_limit"))
 	gn.GlobalLimit = state.Balance(config.SmartContractConfig.GetInt("smart_contracts.faucetsc.global_limit"))
 	gn.IndividualReset = config.SmartContractConfig.GetDuration("smart_contracts.faucetsc.individual_reset")
 	gn.GlobalReset = config.SmartContractConfig.GetDuration("smart_contracts.faucetsc.global_reset")
-	gn.Used = 0
-	gn.StartTime = common.ToTime(t.CreationDate)
 	return gn
 }
 
 func (fc *FaucetSmartContract) Execute(t *transaction.Transaction, funcName string, inputData []byte, balances c_state.StateContextI) (string, error) {
+	// apply accepted limits changes first
+	if err := fc.governance().ApplyDue(balances); err != nil {
+		return "", common.NewError("governance_failed", err.Error())
+	}
 	gn := fc.getGlobalVariables(t, balances)
 	switch funcName {
 	case "updateLimits":
@@ -238,6 +262,9 @@ func (fc *FaucetSmartContract) Execute(t *transaction.Transaction, funcName stri
 		return fc.pour(t, inputData, balances, gn)
 	case "refill":
 		return fc.refill(t, balances, gn)
+	case governance.FuncStakeLock, governance.FuncStakeUnlock,
+		governance.FuncPropose, governance.FuncVote:
+		return fc.governance().Execute(t, funcName, inputData, balances)
 	default:
 		return "", common.NewError("failed execution", "no function with that name")
 	}
@@ -0,0 +1,108 @@
+package governance
+
+import (
+	"0chain.net/chaincore/block"
+	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/datastore"
+	"0chain.net/core/encryption"
+	"0chain.net/core/util"
+)
+
+//
+// helper for tests implements chainState.StateContextI
+//
+
+type testBalances struct {
+	balances  map[datastore.Key]state.Balance
+	txn       *transaction.Transaction
+	transfers []*state.Transfer
+	tree      map[datastore.Key]util.Serializable
+	block     *block.Block
+	events    []*transaction.Event
+}
+
+func newTestBalances() *testBalances {
+	return &testBalances{
+		balances: make(map[datastore.Key]state.Balance),
+		tree:     make(map[datastore.Key]util.Serializable),
+		block:    new(block.Block),
+	}
+}
+
+func (tb *testBalances) setRound(round int64) {
+	tb.block.Round = round
+}
+
+// stubs
+func (tb *testBalances) GetBlock() *block.Block                       { return tb.block }
+func (tb *testBalances) GetState() util.MerklePatriciaTrieI           { return nil }
+func (tb *testBalances) GetTransaction() *transaction.Transaction     { return nil }
+func (tb *testBalances) GetBlockSharders(b *block.Block) []string     { return nil }
+func (tb *testBalances) Validate() error                              { return nil }
+func (tb *testBalances) GetMints() []*state.Mint                      { return nil }
+func (tb *testBalances) SetStateContext(*state.State) error           { return nil }
+func (tb *testBalances) AddMint(*state.Mint) error                    { return nil }
+func (tb *testBalances) GetTransfers() []*state.Transfer              { return nil }
+func (tb *testBalances) GetChainCurrentMagicBlock() *block.MagicBlock { return nil }
+func (tb *testBalances) AddSignedTransfer(st *state.SignedTransfer)   {}
+func (tb *testBalances) SetMagicBlock(block *block.MagicBlock)        {}
+func (tb *testBalances) GetLastestFinalizedMagicBlock() *block.Block  { return nil }
+func (tb *testBalances) GetSignedTransfers() []*state.SignedTransfer  { return nil }
+
+func (tb *testBalances) GetSignatureScheme() encryption.SignatureScheme {
+	return encryption.NewBLS0ChainScheme()
+}
+
+func (tb *testBalances) DeleteTrieNode(key datastore.Key) (
+	datastore.Key, error) {
+
+	if _, ok := tb.tree[key]; !ok {
+		return "", util.ErrValueNotPresent
+	}
+	delete(tb.tree, key)
+	return key, nil
+}
+
+func (tb *testBalances) GetClientBalance(clientID datastore.Key) (
+	b state.Balance, err error) {
+
+	var ok bool
+	if b, ok = tb.balances[clientID]; !ok {
+		return 0, util.ErrValueNotPresent
+	}
+	return
+}
+
+func (tb *testBalances) GetTrieNode(key datastore.Key) (
+	node util.Serializable, err error) {
+
+	var ok bool
+	if node, ok = tb.tree[key]; !ok {
+		return nil, util.ErrValueNotPresent
+	}
+	return
+}
+
+func (tb *testBalances) InsertTrieNode(key datastore.Key,
+	node util.Serializable) (_ datastore.Key, _ error) {
+
+	tb.tree[key] = node
+	return
+}
+
+func (tb *testBalances) AddTransfer(t *state.Transfer) error {
+	if t.ClientID != tb.txn.ClientID && t.ClientID != tb.txn.ToClientID {
+		return state.ErrInvalidTransfer
+	}
+	tb.balances[t.ClientID] -= t.Amount
+	tb.balances[t.ToClientID] += t.Amount
+	tb.transfers = append(tb.transfers, t)
+	return nil
+}
+
+func (tb *testBalances) EmitEvent(ev *transaction.Event) {
+	tb.events = append(tb.events, ev)
+}
+
+func (tb *testBalances) GetEvents() []*transaction.Event { return tb.events }
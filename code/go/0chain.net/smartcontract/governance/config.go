@@ -0,0 +1,85 @@
+package governance
+
+import (
+	"errors"
+	"fmt"
+
+	configpkg "0chain.net/chaincore/config"
+	"0chain.net/chaincore/state"
+)
+
+// Config of governance of a smart contract.
+type Config struct {
+	// VotingPeriod is number of rounds a proposal is open for voting,
+	// the governance is disabled if it's zero.
+	VotingPeriod int64 `json:"voting_period"`
+	// ApplyDelay is min number of rounds between end of voting and applying
+	// of an accepted proposal.
+	ApplyDelay int64 `json:"apply_delay"`
+	// Quorum is min part of total governance stake should vote for a
+	// proposal to be accepted, value in (0; 1] range.
+	Quorum float64 `json:"quorum"`
+	// Threshold is min part of the voted stake should approve a proposal
+	// to be accepted, value in (0; 1] range.
+	Threshold float64 `json:"threshold"`
+	// MinStake is min governance stake of a client to make a proposal.
+	MinStake state.Balance `json:"min_stake"`
+	// MaxOpenProposals is max number of proposals open for voting or
+	// waiting for the apply round at the same time.
+	MaxOpenProposals int64 `json:"max_open_proposals"`
+}
+
+// defaultMaxOpenProposals is used if max_open_proposals is not configured
+const defaultMaxOpenProposals = 20
+
+// Enabled returns true if the governance is configured.
+func (c *Config) Enabled() bool {
+	return c.VotingPeriod > 0
+}
+
+func (c *Config) validate() (err error) {
+	switch {
+	case c.VotingPeriod < 0:
+		return fmt.Errorf("negative voting_period: %d", c.VotingPeriod)
+	case c.ApplyDelay < 0:
+		return fmt.Errorf("negative apply_delay: %d", c.ApplyDelay)
+	case c.Quorum <= 0 || c.Quorum > 1:
+		return fmt.Errorf("quorum not in (0; 1] range: %v", c.Quorum)
+	case c.Threshold <= 0 || c.Threshold > 1:
+		return fmt.Errorf("threshold not in (0; 1] range: %v", c.Threshold)
+	case c.MinStake < 0:
+		return errors.New("negative min_stake")
+	case c.MaxOpenProposals <= 0:
+		return fmt.Errorf("max_open_proposals is not positive: %d",
+			c.MaxOpenProposals)
+	}
+	return
+}
+
+// configurations from sc.yaml, the prefix is the smart contract section,
+// for example 'smart_contracts.storagesc.'
+func getConfig(prefix string) (conf *Config, err error) {
+
+	prefix += "governance."
+
+	conf = new(Config)
+
+	var scconf = configpkg.SmartContractConfig
+	conf.VotingPeriod = scconf.GetInt64(prefix + "voting_period")
+	if !conf.Enabled() {
+		return // disabled
+	}
+	conf.ApplyDelay = scconf.GetInt64(prefix + "apply_delay")
+	conf.Quorum = scconf.GetFloat64(prefix + "quorum")
+	conf.Threshold = scconf.GetFloat64(prefix + "threshold")
+	conf.MinStake = state.Balance(scconf.GetFloat64(prefix+"min_stake") * 1e10)
+	conf.MaxOpenProposals = defaultMaxOpenProposals
+	if scconf.IsSet(prefix + "max_open_proposals") {
+		conf.MaxOpenProposals = scconf.GetInt64(prefix + "max_open_proposals")
+	}
+
+	if err = conf.validate(); err != nil {
+		return nil, fmt.Errorf("invalid governance configurations: %v", err)
+	}
+	return
+}
@@ -0,0 +1,913 @@
+// Package governance implements on-chain stake-weighted governance of
+// smart contracts configurations. A client locks tokens as a governance
+// stake of a smart contract, makes a proposal to change a configuration
+// key, and the stake holders vote for it during a voting period measured
+// in rounds. An accepted proposal is applied automatically at its apply
+// round by the first transaction of the smart contract executed at or
+// after the round.
+package governance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cstate "0chain.net/chaincore/chain/state"
+	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/util"
+)
+
+// Functions of the governance executed by a smart contract.
+const (
+	FuncStakeLock   = "gov_stake_lock"
+	FuncStakeUnlock = "gov_stake_unlock"
+	FuncPropose     = "gov_propose"
+	FuncVote        = "gov_vote"
+)
+
+// Functions is list of all functions of the governance.
+var Functions = []string{
+	FuncStakeLock,
+	FuncStakeUnlock,
+	FuncPropose,
+	FuncVote,
+}
+
+// IsFunction returns true if given smart contract function name is
+// a function of the governance.
+func IsFunction(name string) bool {
+	for _, fn := range Functions {
+		if fn == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maxDescriptionLength of a proposal
+const maxDescriptionLength = 256
+
+// Settings of a smart contract changed by the governance. A key is dot
+// separated JSON path of a configuration, and a value is JSON value of
+// the configuration.
+type Settings interface {
+	// ValidateSetting returns error if the setting can't be changed to
+	// given value.
+	ValidateSetting(key, value string, balances cstate.StateContextI) error
+	// ApplySetting changes the setting.
+	ApplySetting(key, value string, balances cstate.StateContextI) error
+}
+
+// Status of a proposal.
+type Status string
+
+// Statuses of a proposal.
+const (
+	StatusVoting   Status = "voting"   // open for votes
+	StatusAccepted Status = "accepted" // waiting for the apply round
+	StatusRejected Status = "rejected" // no quorum or threshold not reached
+	StatusApplied  Status = "applied"  // the setting has changed
+	StatusFailed   Status = "failed"   // the setting can't be applied
+)
+
+// Vote of a stake holder.
+type Vote struct {
+	Approve bool          `json:"approve"`
+	Weight  state.Balance `json:"weight"`
+	Round   int64         `json:"round"`
+}
+
+// Proposal to change a setting of a smart contract.
+type Proposal struct {
+	ID          string `json:"id"`
+	Proposer    string `json:"proposer"`
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	// rounds
+	CreatedRound   int64 `json:"created_round"`
+	VotingEndRound int64 `json:"voting_end_round"`
+	ApplyRound     int64 `json:"apply_round"`
+	ClosedRound    int64 `json:"closed_round,omitempty"`
+	// votes by clients, and total weights
+	Votes   map[string]*Vote `json:"votes"`
+	For     state.Balance    `json:"for"`
+	Against state.Balance    `json:"against"`
+	// TotalStake is total governance stake at the end of the voting.
+	TotalStake state.Balance `json:"total_stake,omitempty"`
+	Status     Status        `json:"status"`
+	Reason     string        `json:"reason,omitempty"`
+}
+
+func proposalKey(scKey, id string) datastore.Key {
+	return datastore.Key(scKey + ":governance:proposal:" + id)
+}
+
+// Encode implements util.Serializable interface.
+func (p *Proposal) Encode() []byte {
+	var b, err = json.Marshal(p)
+	if err != nil {
+		panic(err) // must never happen
+	}
+	return b
+}
+
+// Decode implements util.Serializable interface.
+func (p *Proposal) Decode(b []byte) error {
+	return json.Unmarshal(b, p)
+}
+
+// vote of the client replacing its previous vote
+func (p *Proposal) vote(clientID string, v *Vote) {
+	if prev, ok := p.Votes[clientID]; ok {
+		if prev.Approve {
+			p.For -= prev.Weight
+		} else {
+			p.Against -= prev.Weight
+		}
+	}
+	if v.Approve {
+		p.For += v.Weight
+	} else {
+		p.Against += v.Weight
+	}
+	p.Votes[clientID] = v
+}
+
+// tally closes voting of the proposal
+func (p *Proposal) tally(conf *Config, total state.Balance, round int64) {
+	var voted = p.For + p.Against
+	p.TotalStake = total
+	switch {
+	case voted == 0 || float64(voted) < conf.Quorum*float64(total):
+		p.Status, p.Reason = StatusRejected, "no quorum"
+	case float64(p.For) < conf.Threshold*float64(voted):
+		p.Status, p.Reason = StatusRejected, "threshold not reached"
+	default:
+		p.Status = StatusAccepted
+		return
+	}
+	p.ClosedRound = round
+}
+
+func (p *Proposal) isOpen() bool {
+	return p.Status == StatusVoting || p.Status == StatusAccepted
+}
+
+// dueRound is the round the open proposal closes voting or is applied
+func (p *Proposal) dueRound() int64 {
+	if p.Status == StatusVoting {
+		return p.VotingEndRound + 1
+	}
+	return p.ApplyRound
+}
+
+// Registry of open proposals and total governance stake of a smart
+// contract.
+type Registry struct {
+	TotalStake state.Balance `json:"total_stake"`
+	// Open proposals in order of creation.
+	Open []string `json:"open"`
+}
+
+func registryKey(scKey string) datastore.Key {
+	return datastore.Key(scKey + ":governance")
+}
+
+// Encode implements util.Serializable interface.
+func (r *Registry) Encode() []byte {
+	var b, err = json.Marshal(r)
+	if err != nil {
+		panic(err) // must never happen
+	}
+	return b
+}
+
+// Decode implements util.Serializable interface.
+func (r *Registry) Decode(b []byte) error {
+	return json.Unmarshal(b, r)
+}
+
+// maxClosedProposals is number of the last closed proposals listed
+const maxClosedProposals = 100
+
+// Closed proposals of a smart contract, the last maxClosedProposals of
+// them in order of closing. It's kept apart from the registry and it's
+// read by the REST API only.
+type Closed struct {
+	IDs []string `json:"ids"`
+}
+
+func closedKey(scKey string) datastore.Key {
+	return datastore.Key(scKey + ":governance:closed")
+}
+
+// Encode implements util.Serializable interface.
+func (c *Closed) Encode() []byte {
+	var b, err = json.Marshal(c)
+	if err != nil {
+		panic(err) // must never happen
+	}
+	return b
+}
+
+// Decode implements util.Serializable interface.
+func (c *Closed) Decode(b []byte) error {
+	return json.Unmarshal(b, c)
+}
+
+// add closed proposals dropping the oldest ones beyond the limit
+func (c *Closed) add(ids ...string) {
+	c.IDs = append(c.IDs, ids...)
+	if over := len(c.IDs) - maxClosedProposals; over > 0 {
+		c.IDs = append(c.IDs[:0:0], c.IDs[over:]...)
+	}
+}
+
+// Due is the first round an open proposal of a smart contract is due. It's
+// kept apart from the registry, the smart contract checks it before every
+// execution and the open proposals are not loaded before the round.
+type Due struct {
+	Round int64 `json:"round"`
+}
+
+func dueKey(scKey string) datastore.Key {
+	return datastore.Key(scKey + ":governance:due")
+}
+
+// Encode implements util.Serializable interface.
+func (d *Due) Encode() []byte {
+	var b, err = json.Marshal(d)
+	if err != nil {
+		panic(err) // must never happen
+	}
+	return b
+}
+
+// Decode implements util.Serializable interface.
+func (d *Due) Decode(b []byte) error {
+	return json.Unmarshal(b, d)
+}
+
+// earlierDue of the due round and the due round of an open proposal, zero
+// due round is no open proposals
+func earlierDue(due, round int64) int64 {
+	if due == 0 || round < due {
+		return round
+	}
+	return due
+}
+
+// Stake of a client, it's locked while the client has votes for proposals
+// open for voting.
+type Stake struct {
+	ClientID string        `json:"client_id"`
+	Balance  state.Balance `json:"balance"`
+	// Votes is proposals the client has voted.
+	Votes []string `json:"votes"`
+}
+
+func stakeKey(scKey, clientID string) datastore.Key {
+	return datastore.Key(scKey + ":governance:stake:" + clientID)
+}
+
+// Encode implements util.Serializable interface.
+func (s *Stake) Encode() []byte {
+	var b, err = json.Marshal(s)
+	if err != nil {
+		panic(err) // must never happen
+	}
+	return b
+}
+
+// Decode implements util.Serializable interface.
+func (s *Stake) Decode(b []byte) error {
+	return json.Unmarshal(b, s)
+}
+
+func (s *Stake) hasVote(id string) bool {
+	for _, v := range s.Votes {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Governance of a smart contract.
+type Governance struct {
+	scKey    string
+	prefix   string
+	settings Settings
+}
+
+// New governance of the smart contract with given address, the prefix is
+// section of the smart contract in sc.yaml, for example
+// 'smart_contracts.storagesc.'.
+func New(scKey, prefix string, settings Settings) *Governance {
+	return &Governance{scKey: scKey, prefix: prefix, settings: settings}
+}
+
+// Config returns configurations of the governance.
+func (g *Governance) Config() (*Config, error) {
+	return getConfig(g.prefix)
+}
+
+// Enabled returns true if the governance is configured, the error is
+// returned for invalid configurations.
+func (g *Governance) Enabled() (bool, error) {
+	var conf, err = g.Config()
+	if err != nil {
+		return false, err
+	}
+	return conf.Enabled(), nil
+}
+
+func (g *Governance) getRegistry(balances cstate.StateContextI) (
+	r *Registry, err error) {
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(registryKey(g.scKey))
+	if err == util.ErrValueNotPresent {
+		return new(Registry), nil
+	}
+	if err != nil {
+		return
+	}
+	r = new(Registry)
+	if err = r.Decode(val.Encode()); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return
+}
+
+func (g *Governance) saveRegistry(r *Registry,
+	balances cstate.StateContextI) (err error) {
+
+	_, err = balances.InsertTrieNode(registryKey(g.scKey), r)
+	return
+}
+
+// getClosed proposals, or empty list
+func (g *Governance) getClosed(balances cstate.StateContextI) (
+	c *Closed, err error) {
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(closedKey(g.scKey))
+	if err == util.ErrValueNotPresent {
+		return new(Closed), nil
+	}
+	if err != nil {
+		return
+	}
+	c = new(Closed)
+	if err = c.Decode(val.Encode()); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return
+}
+
+func (g *Governance) saveClosed(c *Closed,
+	balances cstate.StateContextI) (err error) {
+
+	_, err = balances.InsertTrieNode(closedKey(g.scKey), c)
+	return
+}
+
+// getDue round, zero if no proposal is open
+func (g *Governance) getDue(balances cstate.StateContextI) (
+	round int64, err error) {
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(dueKey(g.scKey))
+	if err == util.ErrValueNotPresent {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+	var d Due
+	if err = d.Decode(val.Encode()); err != nil {
+		return 0, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return d.Round, nil
+}
+
+// saveDue round, it's removed if no proposal is open
+func (g *Governance) saveDue(round int64,
+	balances cstate.StateContextI) (err error) {
+
+	if round == 0 {
+		_, err = balances.DeleteTrieNode(dueKey(g.scKey))
+		if err == util.ErrValueNotPresent {
+			err = nil
+		}
+		return
+	}
+	_, err = balances.InsertTrieNode(dueKey(g.scKey), &Due{Round: round})
+	return
+}
+
+func (g *Governance) getProposal(id string, balances cstate.StateContextI) (
+	p *Proposal, err error) {
+
+	var val util.Serializable
+	if val, err = balances.GetTrieNode(proposalKey(g.scKey, id)); err != nil {
+		return
+	}
+	p = new(Proposal)
+	if err = p.Decode(val.Encode()); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	if p.Votes == nil {
+		p.Votes = make(map[string]*Vote)
+	}
+	return
+}
+
+func (g *Governance) saveProposal(p *Proposal,
+	balances cstate.StateContextI) (err error) {
+
+	_, err = balances.InsertTrieNode(proposalKey(g.scKey, p.ID), p)
+	return
+}
+
+// getStake of the client, or empty one
+func (g *Governance) getStake(clientID string, balances cstate.StateContextI) (
+	s *Stake, err error) {
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(stakeKey(g.scKey, clientID))
+	if err == util.ErrValueNotPresent {
+		return &Stake{ClientID: clientID}, nil
+	}
+	if err != nil {
+		return
+	}
+	s = new(Stake)
+	if err = s.Decode(val.Encode()); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return
+}
+
+func (g *Governance) saveStake(s *Stake, balances cstate.StateContextI) (
+	err error) {
+
+	if s.Balance == 0 {
+		_, err = balances.DeleteTrieNode(stakeKey(g.scKey, s.ClientID))
+		if err == util.ErrValueNotPresent {
+			err = nil
+		}
+		return
+	}
+	_, err = balances.InsertTrieNode(stakeKey(g.scKey, s.ClientID), s)
+	return
+}
+
+// TotalStake returns total governance stake of the smart contract. The
+// stake is kept on the smart contract balance, and the smart contract must
+// not spend it.
+func (g *Governance) TotalStake(balances cstate.StateContextI) (
+	total state.Balance, err error) {
+
+	var r *Registry
+	if r, err = g.getRegistry(balances); err != nil {
+		return
+	}
+	return r.TotalStake, nil
+}
+
+// enabledConfig returns configurations of enabled governance
+func (g *Governance) enabledConfig() (conf *Config, err error) {
+	if conf, err = g.Config(); err != nil {
+		return
+	}
+	if !conf.Enabled() {
+		return nil, errors.New("governance is not enabled")
+	}
+	return
+}
+
+// Execute a function of the governance.
+func (g *Governance) Execute(t *transaction.Transaction, function string,
+	input []byte, balances cstate.StateContextI) (resp string, err error) {
+
+	switch function {
+	case FuncStakeLock:
+		return g.stakeLock(t, input, balances)
+	case FuncStakeUnlock:
+		return g.stakeUnlock(t, input, balances)
+	case FuncPropose:
+		return g.propose(t, input, balances)
+	case FuncVote:
+		return g.vote(t, input, balances)
+	}
+	return "", common.NewError("governance_failed",
+		fmt.Sprintf("no function with %q name", function))
+}
+
+// stakeLock locks the transaction value as governance stake of the client
+func (g *Governance) stakeLock(t *transaction.Transaction, _ []byte,
+	balances cstate.StateContextI) (resp string, err error) {
+
+	if _, err = g.enabledConfig(); err != nil {
+		return "", common.NewError("gov_stake_lock_failed", err.Error())
+	}
+
+	if t.Value <= 0 {
+		return "", common.NewError("gov_stake_lock_failed",
+			"no tokens to lock")
+	}
+
+	var balance state.Balance
+	balance, err = balances.GetClientBalance(t.ClientID)
+	if err != nil && err != util.ErrValueNotPresent {
+		return "", common.NewError("gov_stake_lock_failed",
+			"can't get client balance: "+err.Error())
+	}
+	if state.Balance(t.Value) > balance {
+		return "", common.NewError("gov_stake_lock_failed",
+			"lock amount is greater than balance")
+	}
+
+	var r *Registry
+	if r, err = g.getRegistry(balances); err != nil {
+		return "", common.NewError("gov_stake_lock_failed",
+			"can't get registry: "+err.Error())
+	}
+	var s *Stake
+	if s, err = g.getStake(t.ClientID, balances); err != nil {
+		return "", common.NewError("gov_stake_lock_failed",
+			"can't get stake: "+err.Error())
+	}
+
+	var transfer = state.NewTransfer(t.ClientID, g.scKey,
+		state.Balance(t.Value))
+	if err = balances.AddTransfer(transfer); err != nil {
+		return "", common.NewError("gov_stake_lock_failed",
+			"transferring tokens: "+err.Error())
+	}
+
+	s.Balance += transfer.Amount
+	r.TotalStake += transfer.Amount
+
+	if err = g.saveStake(s, balances); err != nil {
+		return "", common.NewError("gov_stake_lock_failed",
+			"saving stake: "+err.Error())
+	}
+	if err = g.saveRegistry(r, balances); err != nil {
+		return "", common.NewError("gov_stake_lock_failed",
+			"saving registry: "+err.Error())
+	}
+
+	return string(s.Encode()), nil
+}
+
+// unlockRequest unlocks given amount of stake, or all stake if the
+// amount is zero
+type unlockRequest struct {
+	Amount state.Balance `json:"amount,omitempty"`
+}
+
+// stakeUnlock unlocks governance stake of the client not used by votes
+// for proposals open for voting
+func (g *Governance) stakeUnlock(t *transaction.Transaction, input []byte,
+	balances cstate.StateContextI) (resp string, err error) {
+
+	var req unlockRequest
+	if len(input) > 0 {
+		if err = json.Unmarshal(input, &req); err != nil {
+			return "", common.NewError("gov_stake_unlock_failed",
+				"malformed request: "+err.Error())
+		}
+	}
+
+	var s *Stake
+	if s, err = g.getStake(t.ClientID, balances); err != nil {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"can't get stake: "+err.Error())
+	}
+	if s.Balance == 0 {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"no stake to unlock")
+	}
+	if req.Amount < 0 || req.Amount > s.Balance {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"invalid amount to unlock")
+	}
+	if req.Amount == 0 {
+		req.Amount = s.Balance
+	}
+
+	// drop closed votes, the stake is locked by the rest ones
+	var votes []string
+	for _, id := range s.Votes {
+		var p *Proposal
+		if p, err = g.getProposal(id, balances); err != nil {
+			return "", common.NewError("gov_stake_unlock_failed",
+				"can't get proposal "+id+": "+err.Error())
+		}
+		if p.Status == StatusVoting {
+			votes = append(votes, id)
+		}
+	}
+	s.Votes = votes
+	if len(s.Votes) > 0 {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"stake is locked by votes for proposals open for voting")
+	}
+
+	var r *Registry
+	if r, err = g.getRegistry(balances); err != nil {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"can't get registry: "+err.Error())
+	}
+
+	var transfer = state.NewTransfer(g.scKey, t.ClientID, req.Amount)
+	if err = balances.AddTransfer(transfer); err != nil {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"transferring tokens: "+err.Error())
+	}
+
+	s.Balance -= req.Amount
+	r.TotalStake -= req.Amount
+
+	if err = g.saveStake(s, balances); err != nil {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"saving stake: "+err.Error())
+	}
+	if err = g.saveRegistry(r, balances); err != nil {
+		return "", common.NewError("gov_stake_unlock_failed",
+			"saving registry: "+err.Error())
+	}
+
+	return string(transfer.Encode()), nil
+}
+
+// proposeRequest is request to make a proposal, the apply round is
+// optional and it can't be less than the voting end plus the apply delay
+type proposeRequest struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	ApplyRound  int64  `json:"apply_round,omitempty"`
+}
+
+// propose a setting change
+func (g *Governance) propose(t *transaction.Transaction, input []byte,
+	balances cstate.StateContextI) (resp string, err error) {
+
+	var conf *Config
+	if conf, err = g.enabledConfig(); err != nil {
+		return "", common.NewError("gov_propose_failed", err.Error())
+	}
+
+	var req proposeRequest
+	if err = json.Unmarshal(input, &req); err != nil {
+		return "", common.NewError("gov_propose_failed",
+			"malformed request: "+err.Error())
+	}
+	if req.Key == "" {
+		return "", common.NewError("gov_propose_failed", "missing key")
+	}
+	if len(req.Description) > maxDescriptionLength {
+		return "", common.NewError("gov_propose_failed",
+			"description is too long")
+	}
+
+	var s *Stake
+	if s, err = g.getStake(t.ClientID, balances); err != nil {
+		return "", common.NewError("gov_propose_failed",
+			"can't get stake: "+err.Error())
+	}
+	if s.Balance == 0 || s.Balance < conf.MinStake {
+		return "", common.NewError("gov_propose_failed",
+			"not enough stake to make a proposal")
+	}
+
+	err = g.settings.ValidateSetting(req.Key, req.Value, balances)
+	if err != nil {
+		return "", common.NewError("gov_propose_failed",
+			"invalid setting: "+err.Error())
+	}
+
+	var (
+		round = balances.GetBlock().Round
+		p     = &Proposal{
+			ID:             t.Hash,
+			Proposer:       t.ClientID,
+			Key:            req.Key,
+			Value:          req.Value,
+			Description:    req.Description,
+			CreatedRound:   round,
+			VotingEndRound: round + conf.VotingPeriod,
+			ApplyRound:     round + conf.VotingPeriod + conf.ApplyDelay,
+			Votes:          make(map[string]*Vote),
+			Status:         StatusVoting,
+		}
+	)
+	if req.ApplyRound > p.ApplyRound {
+		p.ApplyRound = req.ApplyRound
+	}
+
+	var r *Registry
+	if r, err = g.getRegistry(balances); err != nil {
+		return "", common.NewError("gov_propose_failed",
+			"can't get registry: "+err.Error())
+	}
+	if int64(len(r.Open)) >= conf.MaxOpenProposals {
+		return "", common.NewError("gov_propose_failed",
+			"too many open proposals")
+	}
+	var due int64
+	if due, err = g.getDue(balances); err != nil {
+		return "", common.NewError("gov_propose_failed",
+			"can't get due round: "+err.Error())
+	}
+	r.Open = append(r.Open, p.ID)
+
+	if err = g.saveProposal(p, balances); err != nil {
+		return "", common.NewError("gov_propose_failed",
+			"saving proposal: "+err.Error())
+	}
+	if err = g.saveRegistry(r, balances); err != nil {
+		return "", common.NewError("gov_propose_failed",
+			"saving registry: "+err.Error())
+	}
+	if next := earlierDue(due, p.dueRound()); next != due {
+		if err = g.saveDue(next, balances); err != nil {
+			return "", common.NewError("gov_propose_failed",
+				"saving due round: "+err.Error())
+		}
+	}
+
+	balances.EmitEvent(transaction.NewEvent("governance_proposal",
+		transaction.StringAttribute("proposal_id", p.ID),
+		transaction.StringAttribute("key", p.Key),
+		transaction.IntAttribute("voting_end_round", p.VotingEndRound),
+		transaction.IntAttribute("apply_round", p.ApplyRound)))
+
+	return string(p.Encode()), nil
+}
+
+// voteRequest is request to vote for a proposal
+type voteRequest struct {
+	ProposalID string `json:"proposal_id"`
+	Approve    bool   `json:"approve"`
+}
+
+// vote for a proposal with all governance stake of the client, a next vote
+// of the client replaces its previous one
+func (g *Governance) vote(t *transaction.Transaction, input []byte,
+	balances cstate.StateContextI) (resp string, err error) {
+
+	if _, err = g.enabledConfig(); err != nil {
+		return "", common.NewError("gov_vote_failed", err.Error())
+	}
+
+	var req voteRequest
+	if err = json.Unmarshal(input, &req); err != nil {
+		return "", common.NewError("gov_vote_failed",
+			"malformed request: "+err.Error())
+	}
+
+	var p *Proposal
+	if p, err = g.getProposal(req.ProposalID, balances); err != nil {
+		return "", common.NewError("gov_vote_failed",
+			"can't get proposal: "+err.Error())
+	}
+
+	var round = balances.GetBlock().Round
+	if p.Status != StatusVoting || round > p.VotingEndRound {
+		return "", common.NewError("gov_vote_failed",
+			"proposal is not open for voting")
+	}
+
+	var s *Stake
+	if s, err = g.getStake(t.ClientID, balances); err != nil {
+		return "", common.NewError("gov_vote_failed",
+			"can't get stake: "+err.Error())
+	}
+	if s.Balance == 0 {
+		return "", common.NewError("gov_vote_failed", "no stake to vote")
+	}
+
+	p.vote(t.ClientID, &Vote{
+		Approve: req.Approve,
+		Weight:  s.Balance,
+		Round:   round,
+	})
+	if !s.hasVote(p.ID) {
+		s.Votes = append(s.Votes, p.ID)
+	}
+
+	if err = g.saveProposal(p, balances); err != nil {
+		return "", common.NewError("gov_vote_failed",
+			"saving proposal: "+err.Error())
+	}
+	if err = g.saveStake(s, balances); err != nil {
+		return "", common.NewError("gov_vote_failed",
+			"saving stake: "+err.Error())
+	}
+
+	return string(p.Encode()), nil
+}
+
+// ApplyDue closes voting of proposals their voting period is over, and
+// applies accepted proposals their apply round has come. A smart contract
+// calls it before executing its functions; it does nothing if the
+// governance is not enabled or no proposal is due, and only the due round
+// is loaded then. A proposal missing or not decoded is closed.
+func (g *Governance) ApplyDue(balances cstate.StateContextI) (err error) {
+
+	var conf *Config
+	if conf, err = g.Config(); err != nil || !conf.Enabled() {
+		return
+	}
+
+	var due int64
+	if due, err = g.getDue(balances); err != nil {
+		return fmt.Errorf("can't get governance due round: %v", err)
+	}
+	var round = balances.GetBlock().Round
+	if due == 0 || round < due {
+		return // nothing to do
+	}
+
+	var r *Registry
+	if r, err = g.getRegistry(balances); err != nil {
+		return fmt.Errorf("can't get governance registry: %v", err)
+	}
+
+	var (
+		open    = make([]string, 0, len(r.Open))
+		closed  []string
+		nextDue int64
+	)
+	for _, id := range r.Open {
+		var p *Proposal
+		p, err = g.getProposal(id, balances)
+		if err == util.ErrValueNotPresent || errors.Is(err, common.ErrDecoding) {
+			closed = append(closed, id)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("can't get proposal %s: %v", id, err)
+		}
+		var status = p.Status
+		if p.Status == StatusVoting && round > p.VotingEndRound {
+			p.tally(conf, r.TotalStake, round)
+		}
+		if p.Status == StatusAccepted && round >= p.ApplyRound {
+			err = g.settings.ApplySetting(p.Key, p.Value, balances)
+			if err != nil {
+				p.Status, p.Reason = StatusFailed, err.Error()
+			} else {
+				p.Status = StatusApplied
+			}
+			p.ClosedRound = round
+		}
+		if p.isOpen() {
+			nextDue = earlierDue(nextDue, p.dueRound())
+			open = append(open, id)
+		} else {
+			closed = append(closed, id)
+		}
+		if p.Status == status {
+			continue // not changed
+		}
+		if err = g.saveProposal(p, balances); err != nil {
+			return fmt.Errorf("saving proposal %s: %v", id, err)
+		}
+		balances.EmitEvent(transaction.NewEvent("governance_"+string(p.Status),
+			transaction.StringAttribute("proposal_id", p.ID),
+			transaction.StringAttribute("key", p.Key),
+			transaction.IntAttribute("for", int64(p.For)),
+			transaction.IntAttribute("against", int64(p.Against))))
+	}
+
+	if len(closed) > 0 {
+		var c *Closed
+		if c, err = g.getClosed(balances); err != nil {
+			return fmt.Errorf("can't get closed proposals: %v", err)
+		}
+		c.add(closed...)
+		if err = g.saveClosed(c, balances); err != nil {
+			return fmt.Errorf("saving closed proposals: %v", err)
+		}
+		r.Open = open
+		if err = g.saveRegistry(r, balances); err != nil {
+			return fmt.Errorf("saving governance registry: %v", err)
+		}
+	}
+	if nextDue != due {
+		if err = g.saveDue(nextDue, balances); err != nil {
+			return fmt.Errorf("saving governance due round: %v", err)
+		}
+	}
+	return
+}
@@ -0,0 +1,467 @@
+package governance
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	cstate "0chain.net/chaincore/chain/state"
+	configpkg "0chain.net/chaincore/config"
+	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	scKey  = "sc_key"
+	prefix = "smart_contracts.testsc."
+)
+
+type testNested struct {
+	Limit int64 `json:"limit"`
+}
+
+type testConfig struct {
+	Rate    float64       `json:"rate"`
+	Period  time.Duration `json:"period"`
+	Nested  *testNested   `json:"nested"`
+	Enabled bool          `json:"enabled"`
+}
+
+// testSettings keeps the configurations in memory, the rate can't be
+// greater than 1
+type testSettings struct {
+	conf testConfig
+}
+
+func (ts *testSettings) updated(key, value string) (conf testConfig,
+	err error) {
+
+	conf = ts.conf
+	if ts.conf.Nested != nil {
+		var nested = *ts.conf.Nested
+		conf.Nested = &nested
+	}
+	if err = SetSetting(&conf, key, value); err != nil {
+		return
+	}
+	if conf.Rate > 1 {
+		return conf, errors.New("rate is too big")
+	}
+	return
+}
+
+func (ts *testSettings) ValidateSetting(key, value string,
+	_ cstate.StateContextI) (err error) {
+
+	_, err = ts.updated(key, value)
+	return
+}
+
+func (ts *testSettings) ApplySetting(key, value string,
+	_ cstate.StateContextI) (err error) {
+
+	var conf testConfig
+	if conf, err = ts.updated(key, value); err != nil {
+		return
+	}
+	ts.conf = conf
+	return
+}
+
+func setGovernanceConfig(t *testing.T, votingPeriod int64) {
+	var pfx = prefix + "governance."
+	configpkg.SmartContractConfig.Set(pfx+"voting_period", votingPeriod)
+	configpkg.SmartContractConfig.Set(pfx+"apply_delay", 5)
+	configpkg.SmartContractConfig.Set(pfx+"quorum", 0.5)
+	configpkg.SmartContractConfig.Set(pfx+"threshold", 0.6)
+	configpkg.SmartContractConfig.Set(pfx+"min_stake", 1.0)
+	t.Cleanup(func() {
+		configpkg.SmartContractConfig.Set(pfx+"voting_period", 0)
+	})
+}
+
+func newTestGovernance() (*Governance, *testSettings) {
+	var ts = &testSettings{conf: testConfig{
+		Rate:   0.5,
+		Period: time.Minute,
+		Nested: &testNested{Limit: 10},
+	}}
+	return New(scKey, prefix, ts), ts
+}
+
+var txnCount int
+
+func newTxn(balances *testBalances, clientID string, value int64) (
+	t *transaction.Transaction) {
+
+	txnCount++
+	t = new(transaction.Transaction)
+	t.Hash = "txn:" + strconv.Itoa(txnCount)
+	t.ClientID = clientID
+	t.ToClientID = scKey
+	t.Value = value
+	balances.txn = t
+	return
+}
+
+func (g *Governance) call(t *testing.T, balances *testBalances,
+	clientID, function string, value int64, input string) (
+	string, error) {
+
+	t.Helper()
+	var txn = newTxn(balances, clientID, value)
+	return g.Execute(txn, function, []byte(input), balances)
+}
+
+func TestGovernance_disabled(t *testing.T) {
+	setGovernanceConfig(t, 0)
+	var (
+		balances = newTestBalances()
+		g, _     = newTestGovernance()
+	)
+	balances.balances["alice"] = 100e10
+	_, err := g.call(t, balances, "alice", FuncStakeLock, 10e10, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "governance is not enabled")
+	require.NoError(t, g.ApplyDue(balances))
+}
+
+func TestGovernance_stake(t *testing.T) {
+	setGovernanceConfig(t, 10)
+	var (
+		balances = newTestBalances()
+		g, _     = newTestGovernance()
+		resp     string
+		err      error
+	)
+	balances.balances["alice"] = 100e10
+
+	_, err = g.call(t, balances, "alice", FuncStakeLock, 200e10, "")
+	requireErrMsg(t, err, "lock amount is greater than balance")
+
+	_, err = g.call(t, balances, "alice", FuncStakeLock, 0, "")
+	requireErrMsg(t, err, "no tokens to lock")
+
+	resp, err = g.call(t, balances, "alice", FuncStakeLock, 30e10, "")
+	require.NoError(t, err)
+	assert.Contains(t, resp, `"balance":300000000000`)
+	assert.EqualValues(t, 70e10, balances.balances["alice"])
+	assert.EqualValues(t, 30e10, balances.balances[scKey])
+
+	_, err = g.call(t, balances, "alice", FuncStakeUnlock, 0,
+		`{"amount":40000000000000}`)
+	requireErrMsg(t, err, "invalid amount to unlock")
+
+	_, err = g.call(t, balances, "alice", FuncStakeUnlock, 0,
+		`{"amount":100000000000}`)
+	require.NoError(t, err)
+	assert.EqualValues(t, 80e10, balances.balances["alice"])
+
+	var total state.Balance
+	total, err = g.TotalStake(balances)
+	require.NoError(t, err)
+	assert.EqualValues(t, 20e10, total)
+
+	// unlock all
+	_, err = g.call(t, balances, "alice", FuncStakeUnlock, 0, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 100e10, balances.balances["alice"])
+	_, err = balances.GetTrieNode(stakeKey(scKey, "alice"))
+	require.Error(t, err)
+
+	_, err = g.call(t, balances, "alice", FuncStakeUnlock, 0, "")
+	requireErrMsg(t, err, "no stake to unlock")
+}
+
+func TestGovernance_proposal(t *testing.T) {
+	setGovernanceConfig(t, 10)
+	var (
+		balances = newTestBalances()
+		g, ts    = newTestGovernance()
+		resp     string
+		err      error
+	)
+	for _, id := range []string{"alice", "bob", "carol"} {
+		balances.balances[id] = 100e10
+	}
+	balances.setRound(100)
+
+	_, err = g.call(t, balances, "alice", FuncStakeLock, 50e10, "")
+	require.NoError(t, err)
+	_, err = g.call(t, balances, "bob", FuncStakeLock, 30e10, "")
+	require.NoError(t, err)
+	_, err = g.call(t, balances, "carol", FuncStakeLock, 0.5e10, "")
+	require.NoError(t, err)
+
+	// not enough stake
+	_, err = g.call(t, balances, "carol", FuncPropose, 0,
+		`{"key":"rate","value":"0.7"}`)
+	requireErrMsg(t, err, "not enough stake to make a proposal")
+
+	// invalid settings
+	_, err = g.call(t, balances, "alice", FuncPropose, 0,
+		`{"key":"unknown","value":"1"}`)
+	requireErrMsg(t, err, `unknown setting "unknown"`)
+	_, err = g.call(t, balances, "alice", FuncPropose, 0,
+		`{"key":"rate","value":"2"}`)
+	requireErrMsg(t, err, "rate is too big")
+
+	resp, err = g.call(t, balances, "alice", FuncPropose, 0,
+		`{"key":"nested.limit","value":"20","description":"more"}`)
+	require.NoError(t, err)
+	var p Proposal
+	require.NoError(t, p.Decode([]byte(resp)))
+	assert.Equal(t, StatusVoting, p.Status)
+	assert.EqualValues(t, 110, p.VotingEndRound)
+	assert.EqualValues(t, 115, p.ApplyRound)
+
+	var vote = `{"proposal_id":"` + p.ID + `","approve":%t}`
+	_, err = g.call(t, balances, "alice", FuncVote, 0, fmt.Sprintf(vote, false))
+	require.NoError(t, err)
+	// the next vote replaces the previous one
+	resp, err = g.call(t, balances, "alice", FuncVote, 0, fmt.Sprintf(vote, true))
+	require.NoError(t, err)
+	require.NoError(t, p.Decode([]byte(resp)))
+	assert.EqualValues(t, 50e10, p.For)
+	assert.EqualValues(t, 0, p.Against)
+	_, err = g.call(t, balances, "bob", FuncVote, 0, fmt.Sprintf(vote, false))
+	require.NoError(t, err)
+
+	// the stake is locked by the votes
+	_, err = g.call(t, balances, "bob", FuncStakeUnlock, 0, "")
+	requireErrMsg(t, err, "stake is locked by votes")
+
+	// voting is not over
+	balances.setRound(110)
+	require.NoError(t, g.ApplyDue(balances))
+	got, err := g.getProposal(p.ID, balances)
+	require.NoError(t, err)
+	assert.Equal(t, StatusVoting, got.Status)
+
+	// accepted: 50 of 80 is 62.5% >= 60%, and 80 of 80.5 voted
+	balances.setRound(111)
+	_, err = g.call(t, balances, "carol", FuncVote, 0, fmt.Sprintf(vote, true))
+	requireErrMsg(t, err, "proposal is not open for voting")
+	require.NoError(t, g.ApplyDue(balances))
+	got, err = g.getProposal(p.ID, balances)
+	require.NoError(t, err)
+	assert.Equal(t, StatusAccepted, got.Status)
+	assert.EqualValues(t, 10, ts.conf.Nested.Limit)
+
+	// the stake is free now
+	_, err = g.call(t, balances, "bob", FuncStakeUnlock, 0, "")
+	require.NoError(t, err)
+
+	// applied
+	balances.setRound(115)
+	require.NoError(t, g.ApplyDue(balances))
+	got, err = g.getProposal(p.ID, balances)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApplied, got.Status)
+	assert.EqualValues(t, 115, got.ClosedRound)
+	assert.EqualValues(t, 20, ts.conf.Nested.Limit)
+
+	var r *Registry
+	r, err = g.getRegistry(balances)
+	require.NoError(t, err)
+	assert.Empty(t, r.Open)
+	c, err := g.getClosed(balances)
+	require.NoError(t, err)
+	assert.Equal(t, []string{p.ID}, c.IDs)
+
+	var names []string
+	for _, ev := range balances.events {
+		names = append(names, ev.Name)
+	}
+	assert.Equal(t, []string{"governance_proposal", "governance_accepted",
+		"governance_applied"}, names)
+}
+
+func TestGovernance_rejected(t *testing.T) {
+	setGovernanceConfig(t, 10)
+	var (
+		balances = newTestBalances()
+		g, ts    = newTestGovernance()
+		err      error
+	)
+	for _, id := range []string{"alice", "bob"} {
+		balances.balances[id] = 100e10
+	}
+	balances.setRound(1)
+
+	_, err = g.call(t, balances, "alice", FuncStakeLock, 10e10, "")
+	require.NoError(t, err)
+	_, err = g.call(t, balances, "bob", FuncStakeLock, 30e10, "")
+	require.NoError(t, err)
+
+	var propose = func(key, value string) string {
+		var resp, err = g.call(t, balances, "alice", FuncPropose, 0,
+			`{"key":"`+key+`","value":"`+value+`"}`)
+		require.NoError(t, err)
+		var p Proposal
+		require.NoError(t, p.Decode([]byte(resp)))
+		return p.ID
+	}
+
+	var (
+		noQuorum  = propose("rate", "0.1")
+		threshold = propose("enabled", "true")
+	)
+	// only 10 of 40 voted
+	_, err = g.call(t, balances, "alice", FuncVote, 0,
+		`{"proposal_id":"`+noQuorum+`","approve":true}`)
+	require.NoError(t, err)
+	// 10 of 40 approved
+	_, err = g.call(t, balances, "alice", FuncVote, 0,
+		`{"proposal_id":"`+threshold+`","approve":true}`)
+	require.NoError(t, err)
+	_, err = g.call(t, balances, "bob", FuncVote, 0,
+		`{"proposal_id":"`+threshold+`","approve":false}`)
+	require.NoError(t, err)
+
+	balances.setRound(100)
+	require.NoError(t, g.ApplyDue(balances))
+
+	for id, reason := range map[string]string{
+		noQuorum:  "no quorum",
+		threshold: "threshold not reached",
+	} {
+		var p, err = g.getProposal(id, balances)
+		require.NoError(t, err)
+		assert.Equal(t, StatusRejected, p.Status)
+		assert.Equal(t, reason, p.Reason)
+		assert.EqualValues(t, 40e10, p.TotalStake)
+	}
+	assert.EqualValues(t, 0.5, ts.conf.Rate)
+	assert.False(t, ts.conf.Enabled)
+}
+
+func TestGovernance_failed(t *testing.T) {
+	setGovernanceConfig(t, 10)
+	var (
+		balances = newTestBalances()
+		g, ts    = newTestGovernance()
+		err      error
+	)
+	balances.balances["alice"] = 100e10
+	balances.setRound(1)
+
+	_, err = g.call(t, balances, "alice", FuncStakeLock, 10e10, "")
+	require.NoError(t, err)
+	resp, err := g.call(t, balances, "alice", FuncPropose, 0,
+		`{"key":"nested.limit","value":"30","apply_round":50}`)
+	require.NoError(t, err)
+	var p Proposal
+	require.NoError(t, p.Decode([]byte(resp)))
+	assert.EqualValues(t, 50, p.ApplyRound)
+	_, err = g.call(t, balances, "alice", FuncVote, 0,
+		`{"proposal_id":"`+p.ID+`","approve":true}`)
+	require.NoError(t, err)
+
+	balances.setRound(20)
+	require.NoError(t, g.ApplyDue(balances))
+
+	// the setting is not available anymore
+	ts.conf.Nested = nil
+
+	balances.setRound(50)
+	require.NoError(t, g.ApplyDue(balances))
+	got, err := g.getProposal(p.ID, balances)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, got.Status)
+	assert.NotEmpty(t, got.Reason)
+}
+
+func TestGovernance_openProposals(t *testing.T) {
+	setGovernanceConfig(t, 10)
+	const key = prefix + "governance.max_open_proposals"
+	configpkg.SmartContractConfig.Set(key, 2)
+	t.Cleanup(func() { configpkg.SmartContractConfig.Set(key, nil) })
+	var (
+		balances = newTestBalances()
+		g, _     = newTestGovernance()
+		err      error
+	)
+	balances.balances["alice"] = 100e10
+	balances.setRound(1)
+	_, err = g.call(t, balances, "alice", FuncStakeLock, 10e10, "")
+	require.NoError(t, err)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		var resp string
+		resp, err = g.call(t, balances, "alice", FuncPropose, 0,
+			`{"key":"rate","value":"0.1"}`)
+		if i == 2 {
+			requireErrMsg(t, err, "too many open proposals")
+			break
+		}
+		require.NoError(t, err)
+		var p Proposal
+		require.NoError(t, p.Decode([]byte(resp)))
+		ids = append(ids, p.ID)
+		balances.setRound(balances.block.Round + 1)
+	}
+
+	due, err := g.getDue(balances)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, due)
+
+	// a missing proposal is closed, the registry is not loaded before the
+	// due round
+	delete(balances.tree, proposalKey(scKey, ids[0]))
+	registry := balances.tree[registryKey(scKey)]
+	balances.tree[registryKey(scKey)] = &util.SecureSerializableValue{
+		Buffer: []byte("not a registry")}
+	balances.setRound(11)
+	require.NoError(t, g.ApplyDue(balances))
+	balances.tree[registryKey(scKey)] = registry
+
+	balances.setRound(12)
+	require.NoError(t, g.ApplyDue(balances))
+	r, err := g.getRegistry(balances)
+	require.NoError(t, err)
+	assert.Equal(t, ids[1:], r.Open)
+	c, err := g.getClosed(balances)
+	require.NoError(t, err)
+	assert.Equal(t, ids[:1], c.IDs)
+	due, err = g.getDue(balances)
+	require.NoError(t, err)
+	assert.EqualValues(t, 13, due)
+
+	// rejected, no quorum
+	balances.setRound(13)
+	require.NoError(t, g.ApplyDue(balances))
+	r, err = g.getRegistry(balances)
+	require.NoError(t, err)
+	assert.Empty(t, r.Open)
+	c, err = g.getClosed(balances)
+	require.NoError(t, err)
+	assert.Equal(t, ids, c.IDs)
+	_, ok := balances.tree[dueKey(scKey)]
+	assert.False(t, ok)
+}
+
+func TestClosed_add(t *testing.T) {
+	var c Closed
+	for i := 0; i < maxClosedProposals; i++ {
+		c.add(fmt.Sprint(i))
+	}
+	require.Len(t, c.IDs, maxClosedProposals)
+	c.add("last1", "last2")
+	require.Len(t, c.IDs, maxClosedProposals)
+	assert.Equal(t, "2", c.IDs[0])
+	assert.Equal(t, []string{"last1", "last2"}, c.IDs[maxClosedProposals-2:])
+}
+
+func requireErrMsg(t *testing.T, err error, msg string) {
+	t.Helper()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), msg)
+}
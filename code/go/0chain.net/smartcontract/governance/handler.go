@@ -0,0 +1,114 @@
+package governance
+
+import (
+	"context"
+	"net/url"
+
+	cstate "0chain.net/chaincore/chain/state"
+	sci "0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/core/common"
+	"0chain.net/smartcontract"
+)
+
+// SetRestHandlers adds REST handlers of the governance to given handlers
+// of a smart contract.
+func (g *Governance) SetRestHandlers(
+	handlers map[string]sci.SmartContractRestHandler) {
+
+	handlers["/governance/config"] = g.configHandler
+	handlers["/governance/proposals"] = g.proposalsHandler
+	handlers["/governance/proposal"] = g.proposalHandler
+	handlers["/governance/stake"] = g.stakeHandler
+}
+
+// configHandler returns configurations of the governance
+func (g *Governance) configHandler(context.Context, url.Values,
+	cstate.StateContextI) (interface{}, error) {
+
+	var conf, err = g.Config()
+	if err != nil {
+		return nil, common.NewErrInternal("can't get config", err.Error())
+	}
+	return conf, nil
+}
+
+// proposalsInfo is response of the proposals handler
+type proposalsInfo struct {
+	TotalStake int64       `json:"total_stake"`
+	Proposals  []*Proposal `json:"proposals"`
+}
+
+// proposalsHandler returns open and last closed proposals, or only open
+// ones if the 'status' query parameter is 'open'
+func (g *Governance) proposalsHandler(_ context.Context, params url.Values,
+	balances cstate.StateContextI) (interface{}, error) {
+
+	var r, err = g.getRegistry(balances)
+	if err != nil {
+		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true,
+			"can't get governance registry")
+	}
+
+	var ids = r.Open
+	switch params.Get("status") {
+	case "open":
+	case "":
+		var c *Closed
+		if c, err = g.getClosed(balances); err != nil {
+			return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true,
+				"can't get closed proposals")
+		}
+		ids = append(append([]string{}, r.Open...), c.IDs...)
+	default:
+		return nil, common.NewErrBadRequest("invalid status, expected 'open'")
+	}
+
+	var info = proposalsInfo{
+		TotalStake: int64(r.TotalStake),
+		Proposals:  make([]*Proposal, 0, len(ids)),
+	}
+	for _, id := range ids {
+		var p *Proposal
+		if p, err = g.getProposal(id, balances); err != nil {
+			return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true,
+				"can't get proposal "+id)
+		}
+		info.Proposals = append(info.Proposals, p)
+	}
+	return &info, nil
+}
+
+// proposalHandler returns proposal by its 'id'
+func (g *Governance) proposalHandler(_ context.Context, params url.Values,
+	balances cstate.StateContextI) (interface{}, error) {
+
+	var id = params.Get("id")
+	if id == "" {
+		return nil, common.NewErrBadRequest("missing proposal id")
+	}
+	var p, err = g.getProposal(id, balances)
+	if err != nil {
+		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true,
+			"can't get proposal")
+	}
+	return p, nil
+}
+
+// stakeHandler returns governance stake of a client by its 'client_id'
+func (g *Governance) stakeHandler(_ context.Context, params url.Values,
+	balances cstate.StateContextI) (interface{}, error) {
+
+	var clientID = params.Get("client_id")
+	if clientID == "" {
+		return nil, common.NewErrBadRequest("missing client_id")
+	}
+	var s, err = g.getStake(clientID, balances)
+	if err != nil {
+		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true,
+			"can't get stake")
+	}
+	if s.Balance == 0 {
+		return nil, common.NewErrNoResource("can't get stake", "no stake")
+	}
+	return s, nil
+}
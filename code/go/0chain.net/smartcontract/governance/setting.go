@@ -0,0 +1,77 @@
+package governance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// SetSetting sets field of given configurations structure pointed by
+// dot separated path of JSON tags, for example 'readpool.min_lock', to
+// given JSON value. A time.Duration field accepts a quoted duration string
+// like "1h" as well as a number of nanoseconds.
+func SetSetting(v interface{}, key, value string) (err error) {
+
+	var rv = reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("configurations must be a non-nil pointer")
+	}
+	rv = rv.Elem()
+
+	for _, name := range strings.Split(key, ".") {
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return fmt.Errorf("unknown setting %q", key)
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+		if rv, err = fieldByTag(rv, name); err != nil {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	}
+
+	var ptr = reflect.New(rv.Type())
+	if rv.Type() == durationType {
+		var str string
+		if json.Unmarshal([]byte(value), &str) == nil {
+			var d time.Duration
+			if d, err = time.ParseDuration(str); err != nil {
+				return fmt.Errorf("invalid value of %q: %v", key, err)
+			}
+			rv.Set(reflect.ValueOf(d))
+			return
+		}
+	}
+	if err = json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+		return fmt.Errorf("invalid value of %q: %v", key, err)
+	}
+	rv.Set(ptr.Elem())
+	return
+}
+
+// fieldByTag returns exported field of given structure by its JSON tag
+func fieldByTag(rv reflect.Value, name string) (reflect.Value, error) {
+	var rt = rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		var sf = rt.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		var tag = strings.Split(sf.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == name || (tag == "" && sf.Name == name) {
+			return rv.Field(i), nil
+		}
+	}
+	return reflect.Value{}, errors.New("no such field")
+}
@@ -0,0 +1,44 @@
+package governance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSetting(t *testing.T) {
+	var conf = testConfig{Nested: &testNested{}}
+
+	require.NoError(t, SetSetting(&conf, "rate", "0.25"))
+	assert.Equal(t, 0.25, conf.Rate)
+
+	require.NoError(t, SetSetting(&conf, "nested.limit", "42"))
+	assert.EqualValues(t, 42, conf.Nested.Limit)
+
+	require.NoError(t, SetSetting(&conf, "enabled", "true"))
+	assert.True(t, conf.Enabled)
+
+	// duration as string and as nanoseconds
+	require.NoError(t, SetSetting(&conf, "period", `"1h30m"`))
+	assert.Equal(t, 90*time.Minute, conf.Period)
+	require.NoError(t, SetSetting(&conf, "period", "1000000000"))
+	assert.Equal(t, time.Second, conf.Period)
+
+	for key, value := range map[string]string{
+		"unknown":       "1",
+		"rate.unknown":  "1",
+		"nested.other":  "1",
+		"nested.limit":  `"str"`,
+		"period":        `"one hour"`,
+		"Rate":          "1",
+		"enabled.limit": "true",
+	} {
+		assert.Error(t, SetSetting(&conf, key, value), key)
+	}
+
+	conf.Nested = nil
+	assert.Error(t, SetSetting(&conf, "nested.limit", "1"))
+	assert.Error(t, SetSetting(conf, "rate", "1"))
+}
@@ -124,3 +124,6 @@ func (tb *testBalances) AddMint(mint *state.Mint) error {
 	tb.balances[mint.ToClientID] += mint.Amount // mint!
 	return nil
 }
+
+func (tb *testBalances) EmitEvent(*transaction.Event)    {}
+func (tb *testBalances) GetEvents() []*transaction.Event { return nil }
@@ -426,3 +426,11 @@ func (f formulae) tokensEarned() state.Balance {
 
 	return state.Balance(amount * apr * duration / year)
 }
+
+func (sc *mockStateContext) EmitEvent(event *transaction.Event) {
+	sc.ctx.EmitEvent(event)
+}
+
+func (sc *mockStateContext) GetEvents() []*transaction.Event {
+	return sc.ctx.GetEvents()
+}
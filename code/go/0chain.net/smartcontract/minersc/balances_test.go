@@ -175,3 +175,6 @@ func (tb *testBalances) AddMint(mint *state.Mint) error {
 func (tb *testBalances) GetChainCurrentMagicBlock() *block.MagicBlock {
 	return nil
 }
+
+func (tb *testBalances) EmitEvent(*transaction.Event)    {}
+func (tb *testBalances) GetEvents() []*transaction.Event { return nil }
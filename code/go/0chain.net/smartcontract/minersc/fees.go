@@ -427,18 +427,27 @@ func (msc *MinerSmartContract) payFees(t *transaction.Transaction,
 func (msc *MinerSmartContract) sumFee(b *block.Block,
 	updateStats bool) state.Balance {
 
-	var totalMaxFee int64
-	var feeStats metrics.Counter
+	var totalMaxFee, sponsoredFee int64
+	var feeStats, sponsoredFeeStats metrics.Counter
 	if stat := msc.SmartContractExecutionStats["feesPaid"]; stat != nil {
 		feeStats = stat.(metrics.Counter)
 	}
+	if stat := msc.SmartContractExecutionStats["sponsoredFeesPaid"]; stat != nil {
+		sponsoredFeeStats = stat.(metrics.Counter)
+	}
 	for _, txn := range b.Txns {
 		totalMaxFee += txn.Fee
+		if txn.FeePayerID != "" {
+			sponsoredFee += txn.Fee // paid by the fee payers
+		}
 	}
 
 	if updateStats && feeStats != nil {
 		feeStats.Inc(totalMaxFee)
 	}
+	if updateStats && sponsoredFeeStats != nil {
+		sponsoredFeeStats.Inc(sponsoredFee)
+	}
 	return state.Balance(totalMaxFee)
 }
 
@@ -8,7 +8,9 @@ import (
 	cstate "0chain.net/chaincore/chain/state"
 	"0chain.net/chaincore/node"
 	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
 
+	"github.com/rcrowley/go-metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -552,3 +554,25 @@ func Test_payFees(t *testing.T) {
 	})
 
 }
+
+func TestMinerSmartContract_sumFee(t *testing.T) {
+	var (
+		msc       = newTestMinerSC()
+		paid      = metrics.NewCounter()
+		sponsored = metrics.NewCounter()
+		b         = new(block.Block)
+	)
+	msc.SmartContractExecutionStats["feesPaid"] = paid
+	msc.SmartContractExecutionStats["sponsoredFeesPaid"] = sponsored
+	b.Txns = []*transaction.Transaction{
+		{Fee: 10},
+		{Fee: 20, FeePayerID: "sponsor"},
+		{Fee: 5, FeePayerID: "sponsor"},
+	}
+
+	require.EqualValues(t, 35, msc.sumFee(b, false))
+	require.Zero(t, paid.Count())
+	require.EqualValues(t, 35, msc.sumFee(b, true))
+	require.EqualValues(t, 35, paid.Count())
+	require.EqualValues(t, 25, sponsored.Count())
+}
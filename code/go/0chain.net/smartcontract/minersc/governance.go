@@ -0,0 +1,116 @@
+package minersc
+
+import (
+	"errors"
+	"fmt"
+
+	cstate "0chain.net/chaincore/chain/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/smartcontract/governance"
+)
+
+// governedSettings is global node settings can be changed by governance
+// proposals, the rest of the node is state of the SC
+var governedSettings = map[string]struct{}{
+	"max_n":                  {},
+	"min_n":                  {},
+	"max_s":                  {},
+	"min_s":                  {},
+	"max_delegates":          {},
+	"t_percent":              {},
+	"k_percent":              {},
+	"x_percent":              {},
+	"max_stake":              {},
+	"min_stake":              {},
+	"interest_rate":          {},
+	"reward_rate":            {},
+	"share_ratio":            {},
+	"block_reward":           {},
+	"max_charge":             {},
+	"epoch":                  {},
+	"reward_decline_rate":    {},
+	"interest_decline_rate":  {},
+	"max_mint":               {},
+	"reward_round_frequency": {},
+}
+
+// governance of the global node settings
+func (msc *MinerSmartContract) governance() *governance.Governance {
+	return governance.New(msc.ID, "smart_contracts.minersc.", msc)
+}
+
+// setGovernanceFunctions adds functions of the governance to the SC
+func (msc *MinerSmartContract) setGovernanceFunctions() {
+	for _, fn := range governance.Functions {
+		var name = fn
+		msc.smartContractFunctions[name] = func(t *transaction.Transaction,
+			input []byte, _ *GlobalNode, balances cstate.StateContextI) (
+			string, error) {
+
+			return msc.governance().Execute(t, name, input, balances)
+		}
+	}
+}
+
+// validateSettings checks bounds of the global node settings
+func (gn *GlobalNode) validateSettings() error {
+	switch {
+	case gn.MinN < 1:
+		return fmt.Errorf("min_n is too small: %d", gn.MinN)
+	case gn.MaxN < gn.MinN:
+		return fmt.Errorf("max_n is less than min_n: %d < %d", gn.MaxN, gn.MinN)
+	case gn.MinS < 1:
+		return fmt.Errorf("min_s is too small: %d", gn.MinS)
+	case gn.MaxS < gn.MinS:
+		return fmt.Errorf("max_s is less than min_s: %d < %d", gn.MaxS, gn.MinS)
+	case gn.MaxDelegates <= 0:
+		return fmt.Errorf("max_delegates is too small: %d", gn.MaxDelegates)
+	case gn.MinStake < 0 || gn.MaxStake < gn.MinStake:
+		return errors.New("invalid min_stake, max_stake range")
+	case gn.ShareRatio < 0 || gn.ShareRatio > 1:
+		return fmt.Errorf("share_ratio not in [0; 1] range: %v", gn.ShareRatio)
+	case gn.Epoch <= 0:
+		return fmt.Errorf("epoch is too small: %d", gn.Epoch)
+	case gn.RewardRoundFrequency < 0:
+		return errors.New("negative reward_round_frequency")
+	}
+	return nil
+}
+
+// updatedGlobalNode returns current global node with given setting changed
+func updatedGlobalNode(key, value string, balances cstate.StateContextI) (
+	gn *GlobalNode, err error) {
+
+	if _, ok := governedSettings[key]; !ok {
+		return nil, fmt.Errorf("setting %q can't be changed", key)
+	}
+	if gn, err = getGlobalNode(balances); err != nil {
+		return nil, errors.New("can't get global node: " + err.Error())
+	}
+	if err = governance.SetSetting(gn, key, value); err != nil {
+		return
+	}
+	if err = gn.validateSettings(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// ValidateSetting implements governance.Settings interface.
+func (msc *MinerSmartContract) ValidateSetting(key, value string,
+	balances cstate.StateContextI) (err error) {
+
+	_, err = updatedGlobalNode(key, value, balances)
+	return
+}
+
+// ApplySetting implements governance.Settings interface.
+func (msc *MinerSmartContract) ApplySetting(key, value string,
+	balances cstate.StateContextI) (err error) {
+
+	var gn *GlobalNode
+	if gn, err = updatedGlobalNode(key, value, balances); err != nil {
+		return
+	}
+	return gn.save(balances)
+}
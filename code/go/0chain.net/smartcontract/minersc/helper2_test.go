@@ -421,3 +421,11 @@ func (f formulae) totalInterest() int64 {
 
 	return int64(totalInterest)
 }
+
+func (sc *mockStateContext) EmitEvent(event *transaction.Event) {
+	sc.ctx.EmitEvent(event)
+}
+
+func (sc *mockStateContext) GetEvents() []*transaction.Event {
+	return sc.ctx.GetEvents()
+}
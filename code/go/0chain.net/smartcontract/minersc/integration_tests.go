@@ -46,6 +46,7 @@ func (msc *MinerSmartContract) initSC() {
 	msc.smartContractFunctions["update_settings"] = msc.UpdateSettings
 	msc.smartContractFunctions["addToDelegatePool"] = msc.addToDelegatePool
 	msc.smartContractFunctions["deleteFromDelegatePool"] = msc.deleteFromDelegatePool
+	msc.setGovernanceFunctions()
 }
 
 func (msc *MinerSmartContract) AddMinerIntegrationTests(
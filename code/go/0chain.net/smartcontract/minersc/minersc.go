@@ -51,4 +51,6 @@ func (msc *MinerSmartContract) InitSmartContractFunctions() {
 	msc.smartContractFunctions["deleteFromDelegatePool"] = msc.deleteFromDelegatePool
 
 	msc.smartContractFunctions["sharder_keep"] = msc.sharderKeep
+
+	msc.setGovernanceFunctions()
 }
@@ -461,12 +461,12 @@ func (gn *GlobalNode) save(balances cstate.StateContextI) (err error) {
 }
 
 func (gn *GlobalNode) Encode() []byte {
-	buff, _ := json.Marshal(gn)
+	buff, _ := util.EncodeValue(gn)
 	return buff
 }
 
 func (gn *GlobalNode) Decode(input []byte) error {
-	return json.Unmarshal(input, gn)
+	return util.DecodeValue(input, gn)
 }
 
 func (gn *GlobalNode) GetHash() string {
@@ -540,7 +540,7 @@ func (mn *MinerNode) save(balances cstate.StateContextI) error {
 }
 
 func (mn *MinerNode) Encode() []byte {
-	buff, _ := json.Marshal(mn)
+	buff, _ := util.EncodeValue(mn)
 	return buff
 }
 
@@ -556,6 +556,21 @@ func (mn *MinerNode) decodeFromValues(params url.Values) error {
 }
 
 func (mn *MinerNode) Decode(input []byte) error {
+	if mn.SimpleNode == nil {
+		mn.SimpleNode = &SimpleNode{}
+	}
+	if mn.Pending == nil {
+		mn.Pending = make(map[string]*sci.DelegatePool)
+	}
+	if mn.Active == nil {
+		mn.Active = make(map[string]*sci.DelegatePool)
+	}
+	if mn.Deleting == nil {
+		mn.Deleting = make(map[string]*sci.DelegatePool)
+	}
+	if util.IsTypedValue(input) {
+		return mn.decodeTyped(input)
+	}
 	var objMap map[string]json.RawMessage
 	err := json.Unmarshal(input, &objMap)
 	if err != nil {
@@ -592,6 +607,51 @@ func (mn *MinerNode) Decode(input []byte) error {
 	return nil
 }
 
+// typedMinerNode is the shape of the typed encoding of the MinerNode, the
+// codec inlines the embedded structs and can't decode the locks interfaces
+type typedMinerNode struct {
+	SimpleNode
+	Pending  map[string]*typedDelegatePool `json:"pending,omitempty"`
+	Active   map[string]*typedDelegatePool `json:"active,omitempty"`
+	Deleting map[string]*typedDelegatePool `json:"deleting,omitempty"`
+}
+
+type typedDelegatePool struct {
+	sci.PoolStats
+	tokenpool.ZcnPool
+	Lock *ViewChangeLock `json:"lock"`
+}
+
+func (mn *MinerNode) decodeTyped(input []byte) (err error) {
+	var tmn typedMinerNode
+	if err = util.DecodeTypedValueAs(input, &tmn); err != nil {
+		return
+	}
+	*mn.SimpleNode = tmn.SimpleNode
+	for _, pools := range []struct {
+		typed map[string]*typedDelegatePool
+		pools map[string]*sci.DelegatePool
+	}{
+		{tmn.Pending, mn.Pending},
+		{tmn.Active, mn.Active},
+		{tmn.Deleting, mn.Deleting},
+	} {
+		for _, tdp := range pools.typed {
+			var dp = sci.NewDelegatePool()
+			*dp.PoolStats = tdp.PoolStats
+			dp.ZcnPool = tdp.ZcnPool
+			dp.TokenLockInterface = &ViewChangeLock{}
+			if tdp.Lock != nil {
+				dp.TokenLockInterface = tdp.Lock
+			}
+			if err = AddPool(pools.pools, dp); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
 func (mn *MinerNode) GetHash() string {
 	return util.ToHex(mn.GetHashBytes())
 }
@@ -907,12 +967,12 @@ func (pn *PhaseNode) GetKey() datastore.Key {
 }
 
 func (pn *PhaseNode) Encode() []byte {
-	buff, _ := json.Marshal(pn)
+	buff, _ := util.EncodeValue(pn)
 	return buff
 }
 
 func (pn *PhaseNode) Decode(input []byte) error {
-	return json.Unmarshal(input, pn)
+	return util.DecodeValue(input, pn)
 }
 
 func HasPool(pools map[string]*sci.DelegatePool, poolID datastore.Key) bool {
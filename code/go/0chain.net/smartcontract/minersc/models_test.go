@@ -3,8 +3,12 @@ package minersc
 import (
 	"testing"
 
+	"0chain.net/chaincore/block"
 	"0chain.net/chaincore/node"
+	sci "0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/core/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func createTestSimpleNodesAndNodePool() (SimpleNodes, *node.Pool) {
@@ -134,3 +138,72 @@ func TestValidateSimpleNode(t *testing.T) {
 	}
 	assert.NoError(t, sn.Validate(), "len(id) == 64")
 }
+
+func TestGlobalNode_typedEncoding(t *testing.T) {
+	var mb = block.NewMagicBlock()
+	mb.Miners = node.NewPool(node.NodeTypeMiner)
+	mb.Sharders = node.NewPool(node.NodeTypeSharder)
+	for _, id := range []string{"m1", "m2"} {
+		var n = node.Provider()
+		n.SetID(id)
+		n.Type = node.NodeTypeMiner
+		mb.Miners.AddNode(n)
+	}
+	mb.StartingRound = 10
+
+	var gn = &GlobalNode{MaxN: 8, MinN: 2, TPercent: 0.51, LastRound: 100,
+		MaxStake: 1e10, Epoch: 15e6, PrevMagicBlock: mb}
+	var legacy = gn.Encode()
+
+	util.SetTypedValueEncoding(true)
+	defer util.SetTypedValueEncoding(false)
+
+	var typed = gn.Encode()
+	require.True(t, util.IsTypedValue(typed))
+	require.Equal(t, typed, gn.Encode())
+	for _, data := range [][]byte{legacy, typed} {
+		var gnd = new(GlobalNode)
+		require.NoError(t, gnd.Decode(data))
+		assert.Equal(t, gn.MaxN, gnd.MaxN)
+		assert.Equal(t, gn.TPercent, gnd.TPercent)
+		assert.Equal(t, gn.MaxStake, gnd.MaxStake)
+		require.NotNil(t, gnd.PrevMagicBlock)
+		assert.Equal(t, mb.StartingRound, gnd.PrevMagicBlock.StartingRound)
+		assert.ElementsMatch(t, mb.Miners.Keys(), gnd.PrevMagicBlock.Miners.Keys())
+	}
+}
+
+func TestMinerNode_typedEncoding(t *testing.T) {
+	var mn = NewMinerNode()
+	mn.ID, mn.N2NHost, mn.TotalStaked = "m1", "m1.host", 100
+	var dp = sci.NewDelegatePool()
+	dp.ID, dp.Balance, dp.DelegateID = "pool1", 50, "client1"
+	dp.TokenLockInterface = &ViewChangeLock{Owner: "client1", DeleteVC: 10}
+	mn.Active[dp.ID] = dp
+	var legacy = mn.Encode()
+
+	util.SetTypedValueEncoding(true)
+	defer util.SetTypedValueEncoding(false)
+
+	var typed = mn.Encode()
+	require.True(t, util.IsTypedValue(typed))
+	for _, data := range [][]byte{legacy, typed} {
+		var mnd = new(MinerNode) // zero value as util.DecodeTypedValue does
+		require.NoError(t, mnd.Decode(data))
+		assert.Equal(t, mn.ID, mnd.ID)
+		assert.Equal(t, mn.N2NHost, mnd.N2NHost)
+		assert.Equal(t, mn.TotalStaked, mnd.TotalStaked)
+		require.Contains(t, mnd.Active, dp.ID)
+		var dpd = mnd.Active[dp.ID]
+		assert.Equal(t, dp.Balance, dpd.Balance)
+		assert.Equal(t, dp.DelegateID, dpd.DelegateID)
+		require.IsType(t, &ViewChangeLock{}, dpd.TokenLockInterface)
+		assert.Equal(t, dp.TokenLockInterface, dpd.TokenLockInterface)
+	}
+
+	var name, value, err = util.DecodeTypedValue(typed)
+	require.NoError(t, err)
+	assert.Equal(t, "minersc.miner_node", name)
+	require.IsType(t, &MinerNode{}, value)
+	assert.Equal(t, mn.ID, value.(*MinerNode).ID)
+}
@@ -16,6 +16,7 @@ import (
 	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
 	"0chain.net/core/util"
+	"0chain.net/smartcontract/governance"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/rcrowley/go-metrics"
@@ -105,6 +106,7 @@ func (msc *MinerSmartContract) setSC(sc *sci.SmartContract, bcContext sci.BCCont
 	msc.SmartContract.RestHandlers["/nodeStat"] = msc.nodeStatHandler
 	msc.SmartContract.RestHandlers["/nodePoolStat"] = msc.nodePoolStatHandler
 	msc.SmartContract.RestHandlers["/configs"] = msc.configsHandler
+	msc.governance().SetRestHandlers(msc.SmartContract.RestHandlers)
 
 	msc.bcContext = bcContext
 	msc.SmartContractExecutionStats["add_miner"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", msc.ID, "add_miner"), nil)
@@ -112,8 +114,12 @@ func (msc *MinerSmartContract) setSC(sc *sci.SmartContract, bcContext sci.BCCont
 	msc.SmartContractExecutionStats["miner_health_check"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", msc.ID, "miner_health_check"), nil)
 	msc.SmartContractExecutionStats["sharder_health_check"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", msc.ID, "sharder_health_check"), nil)
 	msc.SmartContractExecutionStats["update_settings"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", msc.ID, "update_settings"), nil)
+	for _, fn := range governance.Functions {
+		msc.SmartContractExecutionStats[fn] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", msc.ID, fn), nil)
+	}
 	msc.SmartContractExecutionStats["payFees"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", msc.ID, "payFees"), nil)
 	msc.SmartContractExecutionStats["feesPaid"] = metrics.GetOrRegisterCounter("feesPaid", nil)
+	msc.SmartContractExecutionStats["sponsoredFeesPaid"] = metrics.GetOrRegisterCounter("sponsoredFeesPaid", nil)
 	msc.SmartContractExecutionStats["mintedTokens"] = metrics.GetOrRegisterCounter("mintedTokens", nil)
 }
 
@@ -133,6 +139,11 @@ func (msc *MinerSmartContract) Execute(t *transaction.Transaction,
 	funcName string, input []byte, balances cstate.StateContextI) (
 	string, error) {
 
+	// apply accepted settings changes first
+	if err := msc.governance().ApplyDue(balances); err != nil {
+		return "", common.NewError("governance_failed", err.Error())
+	}
+
 	gn, err := getGlobalNode(balances)
 	if err != nil {
 		return "", common.NewError("failed_to_get_global_node", err.Error())
@@ -0,0 +1,41 @@
+package minersc
+
+import (
+	"0chain.net/chaincore/block"
+	sci "0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/core/datastore"
+	"0chain.net/core/util"
+)
+
+// stateNodeTypes - the miner SC nodes of the state
+var stateNodeTypes = []sci.StateNodeType{
+	{Name: "global_node", Keys: []datastore.Key{GlobalNodeKey},
+		New: func() interface{} { return &GlobalNode{} }},
+	{Name: "all_miners", Keys: []datastore.Key{AllMinersKey},
+		New: func() interface{} { return &MinerNodes{} }},
+	{Name: "all_sharders", Keys: []datastore.Key{AllShardersKey},
+		New: func() interface{} { return &MinerNodes{} }},
+	{Name: "sharders_keep", Keys: []datastore.Key{ShardersKeepKey},
+		New: func() interface{} { return &MinerNodes{} }},
+	{Name: "dkg_miners", Keys: []datastore.Key{DKGMinersKey},
+		New: func() interface{} { return NewDKGMinerNodes() }},
+	{Name: "miners_mpk", Keys: []datastore.Key{MinersMPKKey},
+		New: func() interface{} { return block.NewMpks() }},
+	{Name: "magic_block", Keys: []datastore.Key{MagicBlockKey},
+		New: func() interface{} { return block.NewMagicBlock() }},
+	{Name: "group_share_or_signs", Keys: []datastore.Key{GroupShareOrSignsKey},
+		New: func() interface{} { return block.NewGroupSharesOrSigns() }},
+	{Name: "phase", Keys: []datastore.Key{PhaseKey},
+		New: func() interface{} { return &PhaseNode{} }},
+	{Name: "miner_node", Fields: []string{"simple_miner"},
+		New: func() interface{} { return NewMinerNode() }},
+	{Name: "user_node", Fields: []string{"id", "pool_map"},
+		New: func() interface{} { return NewUserNode() }},
+}
+
+// DecodeStateNode - implement smartcontractinterface.StateNodeDecoder
+func (msc *MinerSmartContract) DecodeStateNode(path util.Path, value []byte) (
+	string, interface{}, bool) {
+
+	return sci.DecodeStateNode(stateNodeTypes, path, value)
+}
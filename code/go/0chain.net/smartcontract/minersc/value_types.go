@@ -0,0 +1,18 @@
+package minersc
+
+import (
+	"0chain.net/core/util"
+)
+
+// tags of the typed state values of the miner SC, never reuse a tag
+const (
+	globalNodeValueTag util.ValueTypeTag = 0x0100 + iota
+	phaseNodeValueTag
+	minerNodeValueTag
+)
+
+func init() {
+	util.RegisterValueType(globalNodeValueTag, "minersc.global_node", &GlobalNode{})
+	util.RegisterValueType(phaseNodeValueTag, "minersc.phase", &PhaseNode{})
+	util.RegisterValueType(minerNodeValueTag, "minersc.miner_node", &MinerNode{})
+}
@@ -730,6 +730,7 @@ func setupMocksFinishAllocation(
 	blobberList.Nodes = blobbers
 	_, err = ctx.InsertTrieNode(ALL_BLOBBERS_KEY, blobberList)
 	require.NoError(t, err)
+	mustMigrateRegistries(t, ssc, ctx)
 
 	require.EqualValues(t, len(blobbers), len(bStakes))
 	for i, blobber := range blobbers {
@@ -998,6 +999,7 @@ func testNewAllocation(t *testing.T, request newAllocationRequest, blobbers sort
 	blobberList.Nodes = blobbers
 	_, err = ctx.InsertTrieNode(ALL_BLOBBERS_KEY, blobberList)
 	require.NoError(t, err)
+	mustMigrateRegistries(t, ssc, ctx)
 
 	for i, blobber := range blobbers {
 		var stakePool = newStakePool()
@@ -50,20 +50,44 @@ func (sc *StorageSmartContract) getAllocationsList(clientID string,
 	return clientAlloc.Allocations, nil
 }
 
+func legacyAllocationsItems(b []byte) (items []*partitionItem, err error) {
+	var all Allocations
+	if err = all.Decode(b); err != nil {
+		return
+	}
+	for _, id := range all.List {
+		items = append(items, &partitionItem{ID: id})
+	}
+	return
+}
+
+func (sc *StorageSmartContract) getAllocationsPartitions(
+	balances chainstate.StateContextI) (*partitions, error) {
+
+	return getPartitions(allAllocationsName, allocationsPartitionSize,
+		ALL_ALLOCATIONS_KEY, balances)
+}
+
+// getAllAllocationsList returns IDs of all allocations loading
+// all partitions of the registry
 func (sc *StorageSmartContract) getAllAllocationsList(
 	balances chainstate.StateContextI) (*Allocations, error) {
 
-	allocationList := &Allocations{}
-
-	allocationListBytes, err := balances.GetTrieNode(ALL_ALLOCATIONS_KEY)
-	if allocationListBytes == nil {
-		return allocationList, nil
+	all, err := sc.getAllocationsPartitions(balances)
+	if err != nil {
+		return nil, common.NewError("getAllAllocationsList_failed",
+			"Failed to retrieve existing allocations list")
 	}
-	err = json.Unmarshal(allocationListBytes.Encode(), allocationList)
+	items, err := all.all(balances)
 	if err != nil {
 		return nil, common.NewError("getAllAllocationsList_failed",
 			"Failed to retrieve existing allocations list")
 	}
+	allocationList := &Allocations{List: make(sortedList, 0, len(items))}
+	for _, it := range items {
+		allocationList.List = append(allocationList.List, it.ID)
+	}
+	sort.Strings(allocationList.List)
 	return allocationList, nil
 }
 
@@ -128,7 +152,7 @@ func (sc *StorageSmartContract) addAllocation(alloc *StorageAllocation,
 		return "", common.NewErrorf("add_allocation_failed",
 			"Failed to get allocation list: %v", err)
 	}
-	all, err := sc.getAllAllocationsList(balances)
+	all, err := sc.getAllocationsPartitions(balances)
 	if err != nil {
 		return "", common.NewErrorf("add_allocation_failed",
 			"Failed to get allocation list: %v", err)
@@ -147,9 +171,12 @@ func (sc *StorageSmartContract) addAllocation(alloc *StorageAllocation,
 		return "", common.NewError("add_allocation_failed", err.Error())
 	}
 
-	all.List.add(alloc.ID)
+	if err = all.add(&partitionItem{ID: alloc.ID}, balances); err != nil {
+		return "", common.NewErrorf("add_allocation_failed",
+			"adding to all allocations list: %v", err)
+	}
 
-	if _, err = balances.InsertTrieNode(ALL_ALLOCATIONS_KEY, all); err != nil {
+	if err = all.save(balances); err != nil {
 		return "", common.NewErrorf("add_allocation_failed",
 			"saving all allocations list: %v", err)
 	}
@@ -176,6 +203,7 @@ type newAllocationRequest struct {
 	WritePriceRange            PriceRange       `json:"write_price_range"`
 	MaxChallengeCompletionTime time.Duration    `json:"max_challenge_completion_time"`
 	DiversifyBlobbers          bool             `json:"diversify_blobbers"`
+	MinReputation              float64          `json:"min_reputation"`
 }
 
 // storageAllocation from the request
@@ -192,6 +220,7 @@ func (nar *newAllocationRequest) storageAllocation() (sa *StorageAllocation) {
 	sa.WritePriceRange = nar.WritePriceRange
 	sa.MaxChallengeCompletionTime = nar.MaxChallengeCompletionTime
 	sa.DiverseBlobbers = nar.DiversifyBlobbers
+	sa.MinReputation = nar.MinReputation
 	return
 }
 
@@ -231,20 +260,21 @@ func (sc *StorageSmartContract) addBlobbersOffers(sa *StorageAllocation,
 }
 
 // update blobbers list in the all blobbers list
-func updateBlobbersInAll(all *StorageNodes, update []*StorageNode,
+func updateBlobbersInAll(all *partitions, update []*StorageNode,
 	balances chainstate.StateContextI) (err error) {
 
 	// update the blobbers in all blobbers list
 	for _, b := range update {
-		all.Nodes.update(b)
 		// don't replace if blobber has removed from the all blobbers list;
 		// for example, if the blobber has removed, then it shouldn't be
 		// in the all blobbers list
+		if _, err = updateBlobberInAll(all, b, balances); err != nil {
+			return fmt.Errorf("can't update all blobber list: %v", err)
+		}
 	}
 
 	// save
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, all)
-	if err != nil {
+	if err = all.save(balances); err != nil {
 		return fmt.Errorf("can't save all blobber list: %v", err)
 	}
 
@@ -308,13 +338,13 @@ func (sc *StorageSmartContract) newAllocationRequestInternal(
 	mintNewTokens bool,
 	balances chainstate.StateContextI,
 ) (resp string, err error) {
-	var allBlobbersList *StorageNodes
-	allBlobbersList, err = sc.getBlobbersList(balances)
+	var allBlobbersList *partitions
+	allBlobbersList, err = sc.getBlobbersPartitions(balances)
 	if err != nil {
 		return "", common.NewErrorf("allocation_creation_failed",
 			"getting blobber list: %v", err)
 	}
-	if len(allBlobbersList.Nodes) == 0 {
+	if allBlobbersList.NumItems == 0 {
 		return "", common.NewError("allocation_creation_failed",
 			"No Blobbers registered. Failed to create a storage allocation")
 	}
@@ -339,7 +369,7 @@ func (sc *StorageSmartContract) newAllocationRequestInternal(
 	}
 
 	blobberNodes, bSize, err := sc.selectBlobbers(
-		t.CreationDate, *allBlobbersList, sa, seed, balances)
+		t.CreationDate, allBlobbersList, sa, seed, balances)
 	if err != nil {
 		return "", common.NewErrorf("allocation_creation_failed", "%v", err)
 	}
@@ -403,7 +433,7 @@ func (sc *StorageSmartContract) newAllocationRequestInternal(
 
 func (sc *StorageSmartContract) selectBlobbers(
 	creationDate common.Timestamp,
-	allBlobbersList StorageNodes,
+	allBlobbersList *partitions,
 	sa *StorageAllocation,
 	randomSeed int64,
 	balances chainstate.StateContextI,
@@ -424,9 +454,12 @@ func (sc *StorageSmartContract) selectBlobbers(
 	var size = sa.DataShards + sa.ParityShards
 	// size of allocation for a blobber
 	var bSize = (sa.Size + int64(size-1)) / int64(size)
-	var list = sa.filterBlobbers(allBlobbersList.Nodes.copy(), creationDate,
-		bSize, filterHealthyBlobbers(creationDate),
-		sc.filterBlobbersByFreeSpace(creationDate, bSize, balances))
+	var list []*StorageNode
+	list, err = sc.sampleBlobbers(creationDate, conf, allBlobbersList, sa,
+		size, bSize, randomSeed, balances)
+	if err != nil {
+		return nil, 0, fmt.Errorf("sampling blobbers: %v", err)
+	}
 
 	if len(list) < size {
 		return nil, 0, errors.New("Not enough blobbers to honor the allocation")
@@ -457,13 +490,93 @@ func (sc *StorageSmartContract) selectBlobbers(
 			}
 			blobberNodes = append(blobberNodes, sa.diversifyBlobbers(list, size-len(blobberNodes))...)
 		} else {
-			blobberNodes = randomizeNodes(list, blobberNodes, size, randomSeed)
+			blobberNodes = randomizeNodesByReputation(list, blobberNodes, size,
+				randomSeed, conf.reputation())
 		}
 	}
 
 	return blobberNodes[:size], bSize, nil
 }
 
+// blobbersSampleFactor is the number of the candidates, in terms of number
+// of blobbers required by an allocation, the blobbers selection samples
+const blobbersSampleFactor = 4
+
+// allocationBlobberFilters used to select blobbers of the allocation
+// in addition to its terms
+func (sc *StorageSmartContract) allocationBlobberFilters(
+	creationDate common.Timestamp, conf *scConfig, sa *StorageAllocation,
+	bSize int64, balances chainstate.StateContextI) []filterBlobberFunc {
+
+	return []filterBlobberFunc{
+		filterHealthyBlobbers(creationDate),
+		filterBlobbersByReputation(conf.reputation(), sa.MinReputation),
+		sc.filterBlobbersByFreeSpace(creationDate, bSize, balances),
+	}
+}
+
+// sampleBlobbers returns the blobbers matching the allocation from randomly
+// chosen partitions of the all blobbers registry, the preferred blobbers
+// are picked up by their URLs
+func (sc *StorageSmartContract) sampleBlobbers(creationDate common.Timestamp,
+	conf *scConfig, all *partitions, sa *StorageAllocation, size int,
+	bSize int64, randomSeed int64, balances chainstate.StateContextI) (
+	list []*StorageNode, err error) {
+
+	var (
+		filters = sc.allocationBlobberFilters(creationDate, conf, sa, bSize,
+			balances)
+		ids = make(map[string]bool)
+	)
+
+	var preferred []*StorageNode
+	for _, url := range sa.PreferredBlobbers {
+		var id string
+		id, err = sc.getBlobberIDByURL(all, url, balances)
+		if err == util.ErrValueNotPresent {
+			continue // invalid preferred blobber
+		}
+		if err != nil {
+			return
+		}
+		var it *partitionItem
+		if it, err = all.get(id, balances); err != nil {
+			return
+		}
+		var b *StorageNode
+		if b, err = blobberOfItem(it); err != nil {
+			return
+		}
+		preferred = append(preferred, b)
+	}
+	for _, b := range sa.filterBlobbers(preferred, creationDate, bSize,
+		filters...) {
+		if !ids[b.ID] {
+			list, ids[b.ID] = append(list, b), true
+		}
+	}
+
+	var r = rand.New(rand.NewSource(randomSeed))
+	err = all.sample(r, balances, func(pt *partition) (bool, error) {
+		var nodes, err = blobbersOfItems(pt.Items)
+		if err != nil {
+			return false, err
+		}
+		var i int
+		for _, b := range nodes {
+			if !ids[b.ID] {
+				nodes[i], i = b, i+1 // skip the preferred ones
+			}
+		}
+		for _, b := range sa.filterBlobbers(nodes[:i], creationDate, bSize,
+			filters...) {
+			list, ids[b.ID] = append(list, b), true
+		}
+		return len(list) < size*blobbersSampleFactor, nil
+	})
+	return
+}
+
 type updateAllocationRequest struct {
 	ID           string           `json:"id"`              // allocation id
 	OwnerID      string           `json:"owner_id"`        // Owner of the allocation
@@ -580,7 +693,7 @@ func (sc *StorageSmartContract) closeAllocation(t *transaction.Transaction,
 	return string(alloc.Encode()), nil // closing
 }
 
-func (sc *StorageSmartContract) saveUpdatedAllocation(all *StorageNodes,
+func (sc *StorageSmartContract) saveUpdatedAllocation(all *partitions,
 	alloc *StorageAllocation, blobbers []*StorageNode,
 	balances chainstate.StateContextI) (err error) {
 
@@ -703,7 +816,7 @@ func (sc *StorageSmartContract) adjustChallengePool(alloc *StorageAllocation,
 // here we use new terms of blobbers
 func (sc *StorageSmartContract) extendAllocation(
 	t *transaction.Transaction,
-	all *StorageNodes,
+	all *partitions,
 	alloc *StorageAllocation,
 	blobbers []*StorageNode,
 	uar *updateAllocationRequest,
@@ -843,7 +956,7 @@ func (sc *StorageSmartContract) extendAllocation(
 // reduceAllocation reduces size or/and expiration (no one can be increased);
 // here we use the same terms of related blobbers
 func (sc *StorageSmartContract) reduceAllocation(t *transaction.Transaction,
-	all *StorageNodes, alloc *StorageAllocation, blobbers []*StorageNode,
+	all *partitions, alloc *StorageAllocation, blobbers []*StorageNode,
 	uar *updateAllocationRequest, balances chainstate.StateContextI,
 ) (err error) {
 	var (
@@ -929,13 +1042,13 @@ func (sc *StorageSmartContract) updateAllocationRequestInternal(
 	balances chainstate.StateContextI,
 ) (resp string, err error) {
 
-	var all *StorageNodes // all blobbers list
-	if all, err = sc.getBlobbersList(balances); err != nil {
+	var all *partitions // all blobbers list
+	if all, err = sc.getBlobbersPartitions(balances); err != nil {
 		return "", common.NewError("allocation_updating_failed",
 			"can't get all blobbers list: "+err.Error())
 	}
 
-	if len(all.Nodes) == 0 {
+	if all.NumItems == 0 {
 		return "", common.NewError("allocation_updating_failed",
 			"empty blobbers list")
 	}
@@ -1058,22 +1171,6 @@ func getPreferredBlobbers(preferredBlobbers []string, allBlobbers []*StorageNode
 	return
 }
 
-func randomizeNodes(in []*StorageNode, out []*StorageNode, n int, seed int64) []*StorageNode {
-	nOut := minInt(len(in), n)
-	nOut = maxInt(1, nOut)
-	randGen := rand.New(rand.NewSource(seed))
-	for {
-		i := randGen.Intn(len(in))
-		if !checkExists(in[i], out) {
-			out = append(out, in[i])
-		}
-		if len(out) >= nOut {
-			break
-		}
-	}
-	return out
-}
-
 func minInt(x, y int) int {
 	if x < y {
 		return x
@@ -1138,44 +1235,19 @@ func (sc *StorageSmartContract) canceledPassRates(alloc *StorageAllocation,
 	var failed, succesful int64 = 0, 0
 	// range over all related blobbers
 	for _, d := range alloc.BlobberDetails {
-		// check out blobber challenges
-		var bc *BlobberChallenge
-		bc, err = sc.getBlobberChallenge(d.BlobberID, balances)
-		if err != nil && err != util.ErrValueNotPresent {
-			return nil, fmt.Errorf("getting blobber challenge: %v", err)
-		}
-		// no blobber challenges, no failures
-		if err == util.ErrValueNotPresent || len(bc.Challenges) == 0 {
-			passRates, err = append(passRates, 1.0), nil
-			continue // no challenges for the blobber
-		}
-		if d.Stats == nil {
-			d.Stats = new(StorageAllocationStats) // make sure
+		var (
+			passRate float64
+			counted  bool
+		)
+		if passRate, counted, err = sc.canceledPassRate(d, now,
+			balances); err != nil {
+			return nil, err
 		}
-		// all expired open challenges are failed, all other
-		// challenges we are treating as successful
-		for _, c := range bc.Challenges {
-			if c.Response != nil {
-				continue // already accepted, already rewarded/penalized
-			}
-			var expire = c.Created + toSeconds(d.Terms.ChallengeCompletionTime)
-			if expire < now {
-				d.Stats.FailedChallenges++
-			} else {
-				d.Stats.SuccessChallenges++
-			}
-		}
-		d.Stats.OpenChallenges = 0
-		d.Stats.TotalChallenges = d.Stats.SuccessChallenges + d.Stats.FailedChallenges
-		if d.Stats.TotalChallenges == 0 {
-			passRates = append(passRates, 1.0)
-			continue
+		passRates = append(passRates, passRate)
+		if counted {
+			succesful += d.Stats.SuccessChallenges
+			failed += d.Stats.FailedChallenges
 		}
-		// success rate for the blobber allocation
-		//fmt.Println("pass rate i", i, "successful", d.Stats.SuccessChallenges, "failed", d.Stats.FailedChallenges)
-		passRates = append(passRates, float64(d.Stats.SuccessChallenges)/float64(d.Stats.TotalChallenges))
-		succesful += d.Stats.SuccessChallenges
-		failed += d.Stats.FailedChallenges
 	}
 	alloc.Stats.SuccessChallenges = succesful
 	alloc.Stats.FailedChallenges = failed
@@ -1184,6 +1256,48 @@ func (sc *StorageSmartContract) canceledPassRates(alloc *StorageAllocation,
 	return passRates, nil
 }
 
+// canceledPassRate of a blobber of an allocation, the counted is false
+// if the blobber has no challenges
+func (sc *StorageSmartContract) canceledPassRate(d *BlobberAllocation,
+	now common.Timestamp, balances chainstate.StateContextI) (
+	passRate float64, counted bool, err error) {
+
+	// check out blobber challenges
+	var bc *BlobberChallenge
+	bc, err = sc.getBlobberChallenge(d.BlobberID, balances)
+	if err != nil && err != util.ErrValueNotPresent {
+		return 0, false, fmt.Errorf("getting blobber challenge: %v", err)
+	}
+	// no blobber challenges, no failures
+	if err == util.ErrValueNotPresent || len(bc.Challenges) == 0 {
+		return 1.0, false, nil // no challenges for the blobber
+	}
+	if d.Stats == nil {
+		d.Stats = new(StorageAllocationStats) // make sure
+	}
+	// all expired open challenges are failed, all other
+	// challenges we are treating as successful
+	for _, c := range bc.Challenges {
+		if c.Response != nil {
+			continue // already accepted, already rewarded/penalized
+		}
+		var expire = c.Created + toSeconds(d.Terms.ChallengeCompletionTime)
+		if expire < now {
+			d.Stats.FailedChallenges++
+		} else {
+			d.Stats.SuccessChallenges++
+		}
+	}
+	d.Stats.OpenChallenges = 0
+	d.Stats.TotalChallenges = d.Stats.SuccessChallenges + d.Stats.FailedChallenges
+	if d.Stats.TotalChallenges == 0 {
+		return 1.0, false, nil
+	}
+	// success rate for the blobber allocation
+	return float64(d.Stats.SuccessChallenges) /
+		float64(d.Stats.TotalChallenges), true, nil
+}
+
 // If blobbers doesn't provide their services, then user can use this
 // cancel_allocation transaction to close allocation and unlock all tokens
 // of write pool back to himself. The cacnel_allocation doesn't pays min_lock
@@ -1376,8 +1490,8 @@ func (sc *StorageSmartContract) finishAllocation(
 			"invalid state: can't get related blobbers: "+err.Error())
 	}
 
-	var allb *StorageNodes
-	if allb, err = sc.getBlobbersList(balances); err != nil {
+	var allb *partitions
+	if allb, err = sc.getBlobbersPartitions(balances); err != nil {
 		return common.NewError("fini_alloc_failed",
 			"can't get all blobbers list: "+err.Error())
 	}
@@ -1438,7 +1552,11 @@ func (sc *StorageSmartContract) finishAllocation(
 				"saving blobber "+d.BlobberID+": "+err.Error())
 		}
 		// update the blobber in all (replace with existing one)
-		allb.Nodes.update(b)
+		if _, err = updateBlobberInAll(allb, b, balances); err != nil {
+			return common.NewError("fini_alloc_failed",
+				"updating blobber "+d.BlobberID+" in all blobbers list: "+
+					err.Error())
+		}
 	}
 	cp.Balance = cpLeft - passPayments
 	// move challenge pool rest to write pool
@@ -1450,8 +1568,7 @@ func (sc *StorageSmartContract) finishAllocation(
 	}
 
 	// save all blobbers list
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allb)
-	if err != nil {
+	if err = allb.save(balances); err != nil {
 		return common.NewError("fini_alloc_failed",
 			"saving all blobbers list: "+err.Error())
 	}
@@ -1470,19 +1587,23 @@ func (sc *StorageSmartContract) finishAllocation(
 
 	alloc.Finalized = true
 
-	var all *Allocations
-	if all, err = sc.getAllAllocationsList(balances); err != nil {
+	var all *partitions
+	if all, err = sc.getAllocationsPartitions(balances); err != nil {
 		return common.NewError("fini_alloc_failed",
 			"getting all allocations list: "+err.Error())
 	}
 
-	if !all.List.remove(alloc.ID) {
+	var removed bool
+	if removed, err = all.remove(alloc.ID, balances); err != nil {
+		return common.NewError("fini_alloc_failed",
+			"removing from all allocations list: "+err.Error())
+	}
+	if !removed {
 		return common.NewError("fini_alloc_failed",
 			"invalid state: allocation not found in all allocations list")
 	}
 
-	_, err = balances.InsertTrieNode(ALL_ALLOCATIONS_KEY, all)
-	if err != nil {
+	if err = all.save(balances); err != nil {
 		return common.NewError("fini_alloc_failed",
 			"saving all allocations list: "+err.Error())
 	}
@@ -248,6 +248,24 @@ func (aps allocationPools) blobberCut(allocID, blobberID string,
 	return
 }
 
+// replaceBlobber moves tokens of the blobber of the allocation to
+// its replacement
+func (aps allocationPools) replaceBlobber(allocID, oldID, newID string) {
+	for _, ap := range aps.allocationCut(allocID) {
+		var bp, ok = ap.Blobbers.get(oldID)
+		if !ok {
+			continue
+		}
+		ap.Blobbers.remove(oldID)
+		if nbp, ok := ap.Blobbers.get(newID); ok {
+			nbp.Balance += bp.Balance
+			continue
+		}
+		bp.BlobberID = newID
+		ap.Blobbers.add(bp)
+	}
+}
+
 func (aps allocationPools) allocUntil(allocID string, until common.Timestamp) (
 	value state.Balance) {
 
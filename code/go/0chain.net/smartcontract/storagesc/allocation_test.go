@@ -51,6 +51,7 @@ func TestSelectBlobbers(t *testing.T) {
 		parityShards         int
 		allocSize            int64
 		expiration           common.Timestamp
+		minReputation        float64
 	}
 	type want struct {
 		blobberIds []int
@@ -74,7 +75,7 @@ func TestSelectBlobbers(t *testing.T) {
 
 	setup := func(
 		t *testing.T, args args,
-	) (StorageSmartContract, StorageAllocation, StorageNodes, chainState.StateContextI) {
+	) (StorageSmartContract, StorageAllocation, *partitions, chainState.StateContextI) {
 		var balances = &mocks.StateContextI{}
 		var ssc = StorageSmartContract{
 			SmartContract: sci.NewSC(ADDRESS),
@@ -90,13 +91,14 @@ func TestSelectBlobbers(t *testing.T) {
 			ReadPriceRange:    PriceRange{mockMinPrice, mockMaxPrice},
 			WritePriceRange:   PriceRange{mockMinPrice, mockMaxPrice},
 			DiverseBlobbers:   args.diverseBlobbers,
+			MinReputation:     args.minReputation,
 		}
 		for i := 0; i < args.numPreferredBlobbers; i++ {
 			sa.PreferredBlobbers = append(sa.PreferredBlobbers, mockURL+strconv.Itoa(i))
 		}
-		var sNodes = StorageNodes{}
+		var sNodes = newPartitions(allBlobbersName, blobbersPartitionSize)
 		for i := 0; i < args.numBlobbers; i++ {
-			sNodes.Nodes.add(makeMockBlobber(i))
+			sNodes.appendItem(newBlobberItem(makeMockBlobber(i)))
 			sp := stakePool{
 				Pools: map[string]*delegatePool{
 					mockPoolId: {},
@@ -116,6 +118,18 @@ func TestSelectBlobbers(t *testing.T) {
 		}
 		balances.On("GetTrieNode", scConfigKey(ssc.ID)).Return(conf, nil).Once()
 
+		for i, url := range sa.PreferredBlobbers {
+			if i < args.numBlobbers {
+				balances.On("GetTrieNode", blobberURLKey(url)).Return(
+					&blobberURL{ID: mockBlobberId + strconv.Itoa(i)}, nil,
+				).Once()
+			} else {
+				balances.On("GetTrieNode", blobberURLKey(url)).Return(
+					nil, util.ErrValueNotPresent,
+				).Once()
+			}
+		}
+
 		return ssc, sa, sNodes, balances
 	}
 
@@ -149,7 +163,22 @@ func TestSelectBlobbers(t *testing.T) {
 				expiration:           common.Timestamp(common.ToTime(now).Add(confMinAllocDuration).Unix()),
 			},
 			want: want{
-				blobberIds: []int{0, 1, 5, 3, 2},
+				blobberIds: []int{0, 1, 4, 5, 3},
+			},
+		},
+		{
+			name: "test_min_reputation",
+			args: args{
+				diverseBlobbers: false,
+				numBlobbers:     6,
+				dataShards:      5,
+				allocSize:       confMinAllocSize,
+				expiration:      common.Timestamp(common.ToTime(now).Add(confMinAllocDuration).Unix()),
+				minReputation:   defaultReputationConfig.InitialScore + 0.1,
+			},
+			want: want{
+				err:    true,
+				errMsg: "Not enough blobbers to honor the allocation",
 			},
 		},
 		{
@@ -646,11 +675,12 @@ func TestStorageSmartContract_addBlobbersOffers(t *testing.T) {
 
 func Test_updateBlobbersInAll(t *testing.T) {
 	var (
-		all        StorageNodes
+		all        = newPartitions(allBlobbersName, blobbersPartitionSize)
+		ssc        = newTestStorageSC()
 		balances   = newTestBalances(t, false)
 		b1, b2, b3 StorageNode
 		u1, u2     StorageNode
-		decode     StorageNodes
+		decode     *StorageNodes
 
 		err error
 	)
@@ -658,18 +688,18 @@ func Test_updateBlobbersInAll(t *testing.T) {
 	b1.ID, b2.ID, b3.ID = "b1", "b2", "b3"
 	b1.Capacity, b2.Capacity, b3.Capacity = 100, 100, 100
 
-	all.Nodes = []*StorageNode{&b1, &b2, &b3}
+	for _, b := range []*StorageNode{&b1, &b2, &b3} {
+		all.appendItem(newBlobberItem(b))
+	}
 
 	u1.ID, u2.ID = "b1", "b2"
 	u1.Capacity, u2.Capacity = 200, 200
 
-	err = updateBlobbersInAll(&all, []*StorageNode{&u1, &u2}, balances)
+	err = updateBlobbersInAll(all, []*StorageNode{&u1, &u2}, balances)
 	require.NoError(t, err)
 
-	var allSeri, ok = balances.tree[ALL_BLOBBERS_KEY]
-	require.True(t, ok)
-	require.NotNil(t, allSeri)
-	require.NoError(t, decode.Decode(allSeri.Encode()))
+	decode, err = ssc.getBlobbersList(balances)
+	require.NoError(t, err)
 
 	require.Len(t, decode.Nodes, 3)
 	assert.Equal(t, "b1", decode.Nodes[0].ID)
@@ -789,6 +819,7 @@ func TestStorageSmartContract_newAllocationRequest(t *testing.T) {
 	var allBlobbers = newTestAllBlobbers()
 	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allBlobbers)
 	require.NoError(t, err)
+	mustMigrateRegistries(t, ssc, balances)
 
 	// 3.
 
@@ -845,8 +876,7 @@ func TestStorageSmartContract_newAllocationRequest(t *testing.T) {
 	// make the blobbers health
 	allBlobbers.Nodes[0].LastHealthCheck = tx.CreationDate
 	allBlobbers.Nodes[1].LastHealthCheck = tx.CreationDate
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allBlobbers)
-	require.NoError(t, err)
+	mustUpdateAllBlobbers(t, ssc, allBlobbers, balances)
 
 	_, err = ssc.newAllocationRequest(&tx, mustEncode(t, &nar), balances)
 	requireErrMsg(t, err, errMsg7)
@@ -870,8 +900,7 @@ func TestStorageSmartContract_newAllocationRequest(t *testing.T) {
 
 	allBlobbers.Nodes[0].Used = 5 * GB
 	allBlobbers.Nodes[1].Used = 10 * GB
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allBlobbers)
-	require.NoError(t, err)
+	mustUpdateAllBlobbers(t, ssc, allBlobbers, balances)
 
 	tx.Value = 400
 	_, err = ssc.newAllocationRequest(&tx, mustEncode(t, &nar), balances)
@@ -881,8 +910,7 @@ func TestStorageSmartContract_newAllocationRequest(t *testing.T) {
 
 	allBlobbers.Nodes[0].Used = 5 * GB
 	allBlobbers.Nodes[1].Used = 10 * GB
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allBlobbers)
-	require.NoError(t, err)
+	mustUpdateAllBlobbers(t, ssc, allBlobbers, balances)
 
 	balances.balances[clientID] = 1100
 
@@ -1083,6 +1111,7 @@ func createNewTestAllocation(t *testing.T, ssc *StorageSmartContract,
 	allBlobbers.Nodes[1].LastHealthCheck = tx.CreationDate
 	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allBlobbers)
 	require.NoError(t, err)
+	mustMigrateRegistries(t, ssc, balances)
 
 	nar.ReadPriceRange = PriceRange{Min: 10, Max: 40}
 	nar.WritePriceRange = PriceRange{Min: 100, Max: 400}
@@ -1110,8 +1139,7 @@ func createNewTestAllocation(t *testing.T, ssc *StorageSmartContract,
 
 	allBlobbers.Nodes[0].Used = 5 * GB
 	allBlobbers.Nodes[1].Used = 10 * GB
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allBlobbers)
-	require.NoError(t, err)
+	mustUpdateAllBlobbers(t, ssc, allBlobbers, balances)
 
 	balances.(*testBalances).balances[clientID] = 1100
 
@@ -1644,11 +1672,11 @@ func Test_preferred_blobbers(t *testing.T) {
 
 		var updateBlobber = func(t *testing.T, b *StorageNode) {
 			t.Helper()
-			var all, err = ssc.getBlobbersList(balances)
+			var all, err = ssc.getBlobbersPartitions(balances)
 			require.NoError(t, err)
-			all.Nodes.update(b)
-			_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, all)
+			_, err = updateBlobberInAll(all, b, balances)
 			require.NoError(t, err)
+			require.NoError(t, all.save(balances))
 			_, err = balances.InsertTrieNode(b.GetKey(ssc.ID), b)
 			require.NoError(t, err)
 		}
@@ -4,29 +4,185 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 
 	cstate "0chain.net/chaincore/chain/state"
 	"0chain.net/chaincore/state"
 	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/encryption"
 	"0chain.net/core/util"
 )
 
 const blobberHealthTime = 60 * 60 // 1 Hour
 
+// blobberURLKey is the key of the reference of a blobber by its URL
+func blobberURLKey(url string) datastore.Key {
+	return datastore.Key(ADDRESS + encryption.Hash("blobber_url:"+url))
+}
+
+// blobberURL refers a blobber of the all blobbers registry by its URL
+type blobberURL struct {
+	ID string `json:"id"`
+}
+
+func (bu *blobberURL) Encode() []byte {
+	var b, _ = json.Marshal(bu)
+	return b
+}
+
+func (bu *blobberURL) Decode(b []byte) error {
+	return json.Unmarshal(b, bu)
+}
+
+func newBlobberItem(b *StorageNode) *partitionItem {
+	var data, _ = json.Marshal(b)
+	return &partitionItem{ID: b.ID, Data: data}
+}
+
+func blobberOfItem(it *partitionItem) (b *StorageNode, err error) {
+	b = new(StorageNode)
+	if err = json.Unmarshal(it.Data, b); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return
+}
+
+func blobbersOfItems(items []*partitionItem) (list []*StorageNode, err error) {
+	list = make([]*StorageNode, 0, len(items))
+	for _, it := range items {
+		var b *StorageNode
+		if b, err = blobberOfItem(it); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return
+}
+
+func legacyBlobbersItems(b []byte) (items []*partitionItem, err error) {
+	var all StorageNodes
+	if err = all.Decode(b); err != nil {
+		return
+	}
+	for _, b := range all.Nodes {
+		items = append(items, newBlobberItem(b))
+	}
+	return
+}
+
+// getBlobbersPartitions returns the registry of the blobbers accepting
+// new allocations
+func (sc *StorageSmartContract) getBlobbersPartitions(
+	balances cstate.StateContextI) (*partitions, error) {
+
+	return getPartitions(allBlobbersName, blobbersPartitionSize,
+		ALL_BLOBBERS_KEY, balances)
+}
+
+// getBlobbersList returns all blobbers of the registry sorted by ID,
+// it loads all partitions and should be used by REST handlers and
+// transactions those process every blobber anyway
 func (sc *StorageSmartContract) getBlobbersList(balances cstate.StateContextI) (*StorageNodes, error) {
-	allBlobbersList := &StorageNodes{}
-	allBlobbersBytes, err := balances.GetTrieNode(ALL_BLOBBERS_KEY)
-	if allBlobbersBytes == nil {
-		return allBlobbersList, nil
+	all, err := sc.getBlobbersPartitions(balances)
+	if err != nil {
+		return nil, err
 	}
-	err = json.Unmarshal(allBlobbersBytes.Encode(), allBlobbersList)
+	items, err := all.all(balances)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+		return nil, err
 	}
+	allBlobbersList := &StorageNodes{}
+	if allBlobbersList.Nodes, err = blobbersOfItems(items); err != nil {
+		return nil, err
+	}
+	sort.Slice(allBlobbersList.Nodes, func(i, j int) bool {
+		return allBlobbersList.Nodes[i].ID < allBlobbersList.Nodes[j].ID
+	})
 	return allBlobbersList, nil
 }
 
+// getBlobberIDByURL returns ID of a blobber of the registry by its URL,
+// util.ErrValueNotPresent if the registry doesn't have such blobber
+func (sc *StorageSmartContract) getBlobberIDByURL(all *partitions,
+	url string, balances cstate.StateContextI) (id string, err error) {
+
+	var val util.Serializable
+	if val, err = balances.GetTrieNode(blobberURLKey(url)); err != nil {
+		return
+	}
+	var bu blobberURL
+	if err = bu.Decode(val.Encode()); err != nil {
+		return "", fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return bu.ID, nil
+}
+
+// addBlobberToAll adds or replaces a blobber in the registry keeping
+// the blobber's URL reference
+func addBlobberToAll(all *partitions, b *StorageNode,
+	balances cstate.StateContextI) (err error) {
+
+	var it *partitionItem
+	if it, err = all.get(b.ID, balances); err == nil {
+		var saved *StorageNode
+		if saved, err = blobberOfItem(it); err != nil {
+			return
+		}
+		if saved.BaseURL != b.BaseURL {
+			if err = deleteBlobberURL(saved.BaseURL, balances); err != nil {
+				return
+			}
+		}
+	} else if err != util.ErrValueNotPresent {
+		return
+	}
+
+	if err = all.add(newBlobberItem(b), balances); err != nil {
+		return
+	}
+	_, err = balances.InsertTrieNode(blobberURLKey(b.BaseURL),
+		&blobberURL{ID: b.ID})
+	return
+}
+
+// updateBlobberInAll replaces a blobber of the registry, a blobber
+// removed from the registry is not added back
+func updateBlobberInAll(all *partitions, b *StorageNode,
+	balances cstate.StateContextI) (ok bool, err error) {
+
+	return all.update(newBlobberItem(b), balances)
+}
+
+// removeBlobberFromAll removes a blobber and its URL reference
+func removeBlobberFromAll(all *partitions, id string,
+	balances cstate.StateContextI) (ok bool, err error) {
+
+	var it *partitionItem
+	if it, err = all.get(id, balances); err == util.ErrValueNotPresent {
+		return false, nil
+	} else if err != nil {
+		return
+	}
+	var b *StorageNode
+	if b, err = blobberOfItem(it); err != nil {
+		return
+	}
+	if ok, err = all.remove(id, balances); err != nil || !ok {
+		return
+	}
+	return true, deleteBlobberURL(b.BaseURL, balances)
+}
+
+func deleteBlobberURL(url string, balances cstate.StateContextI) (err error) {
+	_, err = balances.DeleteTrieNode(blobberURLKey(url))
+	if err == util.ErrValueNotPresent || err == util.ErrNodeNotFound {
+		err = nil // nothing to delete
+	}
+	return
+}
+
 func (sc *StorageSmartContract) getBlobberBytes(blobberID string,
 	balances cstate.StateContextI) (b []byte, err error) {
 
@@ -73,7 +229,7 @@ func updateBlobberInList(list []*StorageNode, update *StorageNode) (ok bool) {
 
 // update existing blobber, or reborn a deleted one
 func (sc *StorageSmartContract) updateBlobber(t *transaction.Transaction,
-	conf *scConfig, blobber *StorageNode, blobbers *StorageNodes,
+	conf *scConfig, blobber *StorageNode, blobbers *partitions,
 	balances cstate.StateContextI,
 ) (err error) {
 	// check terms
@@ -98,9 +254,12 @@ func (sc *StorageSmartContract) updateBlobber(t *transaction.Transaction,
 
 	blobber.LastHealthCheck = t.CreationDate
 	blobber.Used = savedBlobber.Used
+	blobber.Reputation = savedBlobber.reputation(conf.reputation())
 
 	// update the list
-	blobbers.Nodes.add(blobber)
+	if err = addBlobberToAll(blobbers, blobber, balances); err != nil {
+		return fmt.Errorf("can't update all blobbers list: %v", err)
+	}
 
 	// update statistics
 	sc.statIncr(statUpdateBlobber)
@@ -134,7 +293,7 @@ func (sc *StorageSmartContract) updateBlobber(t *transaction.Transaction,
 
 // remove blobber (when a blobber provides capacity = 0)
 func (sc *StorageSmartContract) removeBlobber(t *transaction.Transaction,
-	blobber *StorageNode, blobbers *StorageNodes, balances cstate.StateContextI,
+	blobber *StorageNode, blobbers *partitions, balances cstate.StateContextI,
 ) (err error) {
 	// get saved blobber
 	savedBlobber, err := sc.getBlobber(blobber.ID, balances)
@@ -144,10 +303,13 @@ func (sc *StorageSmartContract) removeBlobber(t *transaction.Transaction,
 
 	// set to zero explicitly, for "direct" calls
 	blobber.Capacity = 0
+	blobber.Reputation = savedBlobber.Reputation
 
 	// remove from the all list, since the blobber can't accept new allocations
 	if savedBlobber.Capacity > 0 {
-		blobbers.Nodes.remove(blobber.ID)
+		if _, err = removeBlobberFromAll(blobbers, blobber.ID, balances); err != nil {
+			return fmt.Errorf("can't remove from all blobbers list: %v", err)
+		}
 		sc.statIncr(statRemoveBlobber)
 		sc.statDecr(statNumberOfBlobbers)
 	}
@@ -174,7 +336,7 @@ func (sc *StorageSmartContract) addBlobber(t *transaction.Transaction,
 	}
 
 	// get registered blobbers
-	blobbers, err := sc.getBlobbersList(balances)
+	blobbers, err := sc.getBlobbersPartitions(balances)
 	if err != nil {
 		return "", common.NewError("add_or_update_blobber_failed",
 			"Failed to get blobber list: "+err.Error())
@@ -190,6 +352,7 @@ func (sc *StorageSmartContract) addBlobber(t *transaction.Transaction,
 	// set transaction information
 	blobber.ID = t.ClientID
 	blobber.PublicKey = t.PublicKey
+	blobber.Reputation = nil // maintained by the SC
 
 	// insert, update or remove blobber
 	if err = sc.insertBlobber(t, conf, blobber, blobbers, balances); err != nil {
@@ -197,8 +360,7 @@ func (sc *StorageSmartContract) addBlobber(t *transaction.Transaction,
 	}
 
 	// save all the blobbers
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, blobbers)
-	if err != nil {
+	if err = blobbers.save(balances); err != nil {
 		return "", common.NewError("add_or_update_blobber_failed",
 			"saving all blobbers: "+err.Error())
 	}
@@ -224,8 +386,8 @@ func (sc *StorageSmartContract) updateBlobberSettings(t *transaction.Transaction
 			"can't get config: "+err.Error())
 	}
 
-	var blobbers *StorageNodes
-	if blobbers, err = sc.getBlobbersList(balances); err != nil {
+	var blobbers *partitions
+	if blobbers, err = sc.getBlobbersPartitions(balances); err != nil {
 		return "", common.NewError("update_blobber_settings_failed",
 			"failed to get blobber list: "+err.Error())
 	}
@@ -266,8 +428,7 @@ func (sc *StorageSmartContract) updateBlobberSettings(t *transaction.Transaction
 	}
 
 	// save all the blobbers
-	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, blobbers)
-	if err != nil {
+	if err = blobbers.save(balances); err != nil {
 		return "", common.NewError("update_blobber_settings_failed",
 			"saving all blobbers: "+err.Error())
 	}
@@ -291,7 +452,7 @@ func filterHealthyBlobbers(now common.Timestamp) filterBlobberFunc {
 func (sc *StorageSmartContract) blobberHealthCheck(t *transaction.Transaction,
 	_ []byte, balances cstate.StateContextI,
 ) (string, error) {
-	all, err := sc.getBlobbersList(balances)
+	all, err := sc.getBlobbersPartitions(balances)
 	if err != nil {
 		return "", common.NewError("blobber_health_check_failed",
 			"Failed to get blobber list: "+err.Error())
@@ -303,18 +464,39 @@ func (sc *StorageSmartContract) blobberHealthCheck(t *transaction.Transaction,
 			"can't get the blobber "+t.ClientID+": "+err.Error())
 	}
 
+	var conf *scConfig
+	if conf, err = sc.getConfig(balances, true); err != nil {
+		return "", common.NewError("blobber_health_check_failed",
+			"can't get config: "+err.Error())
+	}
+
+	var rc = conf.reputation()
+	blobber.reputation(rc).healthCheck(
+		healthCheckUptime(blobber.LastHealthCheck, t.CreationDate), rc)
 	blobber.LastHealthCheck = t.CreationDate
 
-	var i, ok = all.Nodes.getIndex(t.ClientID)
+	var it *partitionItem
 	// if blobber has been removed, then it shouldn't send the health check
 	// transactions
-	if !ok {
+	if it, err = all.get(t.ClientID, balances); err == util.ErrValueNotPresent {
 		return "", common.NewError("blobber_health_check_failed", "blobber "+
 			t.ClientID+" not found in all blobbers list")
+	} else if err != nil {
+		return "", common.NewError("blobber_health_check_failed",
+			"can't get blobber from all blobbers list: "+err.Error())
+	}
+	var found *StorageNode
+	if found, err = blobberOfItem(it); err != nil {
+		return "", common.NewError("blobber_health_check_failed",
+			"can't decode blobber of all blobbers list: "+err.Error())
 	}
-	var found = all.Nodes[i]
 	found.LastHealthCheck = t.CreationDate
-	if _, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, all); err != nil {
+	found.Reputation = blobber.Reputation
+	if _, err = updateBlobberInAll(all, found, balances); err != nil {
+		return "", common.NewError("blobber_health_check_failed",
+			"can't update all blobbers list: "+err.Error())
+	}
+	if err = all.save(balances); err != nil {
 		return "", common.NewError("blobber_health_check_failed",
 			"can't save all blobbers list: "+err.Error())
 	}
@@ -15,15 +15,25 @@ import (
 
 // insert new blobber, filling its stake pool
 func (sc *StorageSmartContract) insertBlobber(t *transaction.Transaction,
-	conf *scConfig, blobber *StorageNode, blobbers *StorageNodes,
+	conf *scConfig, blobber *StorageNode, blobbers *partitions,
 	balances cstate.StateContextI
 ) (err error) {
 	// check for duplicates
-	for _, b := range blobbers.Nodes {
-		if b.ID == blobber.ID || b.BaseURL == blobber.BaseURL {
-			return sc.updateBlobber(t, conf, blobber, blobbers, balances)
+	var ok bool
+	if ok, err = blobbers.has(blobber.ID, balances); err != nil {
+		return fmt.Errorf("checking all blobbers list: %v", err)
+	}
+	if !ok {
+		_, err = sc.getBlobberIDByURL(blobbers, blobber.BaseURL, balances)
+		if err == nil {
+			ok = true
+		} else if err != util.ErrValueNotPresent {
+			return fmt.Errorf("checking all blobbers list: %v", err)
 		}
 	}
+	if ok {
+		return sc.updateBlobber(t, conf, blobber, blobbers, balances)
+	}
 
 	// check blobber values
 	if err = blobber.validate(conf); err != nil {
@@ -31,6 +41,7 @@ func (sc *StorageSmartContract) insertBlobber(t *transaction.Transaction,
 	}
 
 	blobber.LastHealthCheck = t.CreationDate // set to now
+	blobber.Reputation = newBlobberReputation(conf.reputation())
 
 	// the stake pool can be created by related validator
 	var sp *stakePool
@@ -44,7 +55,10 @@ func (sc *StorageSmartContract) insertBlobber(t *transaction.Transaction,
 		return fmt.Errorf("saving stake pool: %v", err)
 	}
 
-	blobbers.Nodes.add(blobber) // add to all
+	// add to all
+	if err = addBlobberToAll(blobbers, blobber, balances); err != nil {
+		return fmt.Errorf("can't add to all blobbers list: %v", err)
+	}
 
 	// statistic
 	sc.statIncr(statAddBlobber)
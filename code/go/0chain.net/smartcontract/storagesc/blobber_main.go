@@ -8,20 +8,31 @@ import (
 
 	cstate "0chain.net/chaincore/chain/state"
 	"0chain.net/chaincore/transaction"
+	"0chain.net/core/util"
 )
 
 
 // insert new blobber, filling its stake pool
 func (sc *StorageSmartContract) insertBlobber(t *transaction.Transaction,
-	conf *scConfig, blobber *StorageNode, blobbers *StorageNodes,
+	conf *scConfig, blobber *StorageNode, blobbers *partitions,
 	balances cstate.StateContextI,
 ) (err error) {
 	// check for duplicates
-	for _, b := range blobbers.Nodes {
-		if b.ID == blobber.ID || b.BaseURL == blobber.BaseURL {
-			return sc.updateBlobber(t, conf, blobber, blobbers, balances)
+	var ok bool
+	if ok, err = blobbers.has(blobber.ID, balances); err != nil {
+		return fmt.Errorf("checking all blobbers list: %v", err)
+	}
+	if !ok {
+		_, err = sc.getBlobberIDByURL(blobbers, blobber.BaseURL, balances)
+		if err == nil {
+			ok = true
+		} else if err != util.ErrValueNotPresent {
+			return fmt.Errorf("checking all blobbers list: %v", err)
 		}
 	}
+	if ok {
+		return sc.updateBlobber(t, conf, blobber, blobbers, balances)
+	}
 
 	// check params
 	if err = blobber.validate(conf); err != nil {
@@ -29,6 +40,7 @@ func (sc *StorageSmartContract) insertBlobber(t *transaction.Transaction,
 	}
 
 	blobber.LastHealthCheck = t.CreationDate // set to now
+	blobber.Reputation = newBlobberReputation(conf.reputation())
 
 	// create stake pool
 	var sp *stakePool
@@ -43,7 +55,9 @@ func (sc *StorageSmartContract) insertBlobber(t *transaction.Transaction,
 	}
 
 	// update the list
-	blobbers.Nodes.add(blobber)
+	if err = addBlobberToAll(blobbers, blobber, balances); err != nil {
+		return fmt.Errorf("can't add to all blobbers list: %v", err)
+	}
 
 	// update statistic
 	sc.statIncr(statAddBlobber)
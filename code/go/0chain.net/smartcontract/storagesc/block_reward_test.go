@@ -153,7 +153,7 @@ func TestPayBlobberBlockRewards(t *testing.T) {
 			}
 			balances.On("GetTrieNode", stakePoolKey(ssc.ID, id)).Return(&sPool, nil).Once()
 		}
-		balances.On("GetTrieNode", ALL_BLOBBERS_KEY).Return(blobbers, nil).Once()
+		mockAllBlobbersRegistry(balances, blobbers)
 
 		for i, sPool := range sPools {
 			i := i
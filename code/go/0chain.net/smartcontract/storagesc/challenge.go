@@ -5,9 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"math/rand"
-	"sort"
-	"strconv"
 	"time"
 
 	"0chain.net/chaincore/block"
@@ -16,7 +13,6 @@ import (
 	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
 	"0chain.net/core/datastore"
-	"0chain.net/core/encryption"
 	. "0chain.net/core/logging"
 	"0chain.net/core/util"
 
@@ -444,6 +440,15 @@ func (sc *StorageSmartContract) verifyChallenge(t *transaction.Transaction,
 			return "", common.NewError("challenge_reward_error", err.Error())
 		}
 
+		err = sc.updateBlobberReputation(t.ClientID, balances,
+			func(r *BlobberReputation, rc *reputationConfig) {
+				r.challenge(true, false, rc)
+			})
+		if err != nil {
+			return "", common.NewError("challenge_reward_error",
+				"updating blobber reputation: "+err.Error())
+		}
+
 		// save allocation object
 		_, err = balances.InsertTrieNode(alloc.GetKey(sc.ID), alloc)
 		if err != nil {
@@ -481,12 +486,23 @@ func (sc *StorageSmartContract) verifyChallenge(t *transaction.Transaction,
 		sc.challengeResolved(balances, false)
 		Logger.Info("Challenge failed", zap.Any("challenge", challResp.ID))
 
+		var penalty = details.Penalty
 		err = sc.blobberPenalty(t, alloc, prev, blobberChall, details,
 			validators, balances)
 		if err != nil {
 			return "", common.NewError("challenge_penalty_error", err.Error())
 		}
 
+		var slashed = details.Penalty > penalty
+		err = sc.updateBlobberReputation(t.ClientID, balances,
+			func(r *BlobberReputation, rc *reputationConfig) {
+				r.challenge(false, slashed, rc)
+			})
+		if err != nil {
+			return "", common.NewError("challenge_penalty_error",
+				"updating blobber reputation: "+err.Error())
+		}
+
 		// save allocation object
 		_, err = balances.InsertTrieNode(alloc.GetKey(sc.ID), alloc)
 		if err != nil {
@@ -571,60 +587,70 @@ func (sc *StorageSmartContract) generateChallenges(t *transaction.Transaction,
 	}
 	numChallenges := int64(math.Min(rated,
 		float64(conf.MaxChallengesPerGeneration)))
-	hashString := encryption.Hash(t.Hash + b.PrevHash)
-	var randomSeed uint64
-	randomSeed, err = strconv.ParseUint(hashString[0:16], 16, 64)
+
+	// the selection is deterministic function of the round VRF output,
+	// see challenge_selection.go
+	var selection *ChallengeSelection
+	selection, err = sc.roundChallengeSelection(b.Round, b.RoundRandomSeed,
+		balances)
 	if err != nil {
-		Logger.Error("Error in creating seed for creating challenges",
-			zap.Error(err))
-		return err
+		return common.NewErrorf("generate_challenges",
+			"can't get challenge selection of the round: %v", err)
 	}
-	r := rand.New(rand.NewSource(int64(randomSeed)))
+	var sel = selection.selector()
 
 	// select allocations for the challenges
 
-	var validators *ValidatorNodes
-	if validators, err = sc.getValidatorsList(balances); err != nil {
+	var validators *partitions
+	if validators, err = sc.getValidatorsPartitions(balances); err != nil {
 		return common.NewErrorf("adding_challenge_error",
 			"error getting the validators list: %v", err)
 	}
 
-	if len(validators.Nodes) == 0 {
+	if validators.NumItems == 0 {
 		return common.NewError("no_validators",
 			"not enough validators for the challenge")
 	}
 
-	var all *Allocations
-	if all, err = sc.getAllAllocationsList(balances); err != nil {
+	var all *partitions
+	if all, err = sc.getAllocationsPartitions(balances); err != nil {
 		return common.NewErrorf("adding_challenge_error",
 			"error getting the allocation list: %v", err)
 	}
 
-	if len(all.List) == 0 {
+	if all.NumItems == 0 {
 		return common.NewError("adding_challenge_error",
 			"no allocations at this time")
 	}
 
-	var selectAlloc = func(i int) (alloc *StorageAllocation, err error) {
-		alloc, err = sc.getAllocation(all.List[i], balances)
+	var selectAlloc = func(ch *SelectedChallenge) (
+		alloc *StorageAllocation, err error) {
+
+		ch.NumAllocations = all.NumItems
+		ch.AllocationIndex = sel.allocationIndex(ch.Index, all.NumItems)
+		var it *partitionItem
+		if it, err = all.itemAt(ch.AllocationIndex, balances); err != nil {
+			return nil, common.NewErrorf("adding_challenge_error",
+				"error getting allocation: %v", err)
+		}
+		ch.AllocationID = it.ID
+		alloc, err = sc.getAllocation(it.ID, balances)
 		if err != nil && err != util.ErrValueNotPresent {
 			return nil, common.NewErrorf("adding_challenge_error",
 				"unexpected error getting allocation: %v", err)
 		}
 		if err == util.ErrValueNotPresent {
 			Logger.Error("client state has invalid allocations",
-				zap.Any("allocation_list", all.List),
-				zap.Any("selected_allocation", all.List[i]))
+				zap.Any("selected_allocation", it.ID))
 			return nil, common.NewErrorf("invalid_allocation",
 				"client state has invalid allocations")
 		}
-		if alloc.Expiration < t.CreationDate {
-			return nil, nil
-		}
-		if alloc.Stats == nil {
-			return nil, nil
-		}
-		if alloc.Stats.NumWrites > 0 {
+		switch {
+		case alloc.Expiration < t.CreationDate:
+			ch.Skipped = "expired"
+		case alloc.Stats == nil || alloc.Stats.NumWrites == 0:
+			ch.Skipped = "no writes"
+		default:
 			return alloc, nil // found
 		}
 		return nil, nil
@@ -636,103 +662,98 @@ func (sc *StorageSmartContract) generateChallenges(t *transaction.Transaction,
 
 	var alloc *StorageAllocation
 
-	for i := int64(0); i < numChallenges; i++ {
+	for k := int64(0); k < numChallenges; k++ {
 
 		// looking for allocation with NumWrites > 0
 
-		alloc, err = selectAlloc(r.Intn(len(all.List)))
+		var ch = &SelectedChallenge{Index: selection.nextIndex()}
+		alloc, err = selectAlloc(ch)
 		if err != nil {
 			return err
 		}
 
 		if alloc == nil {
+			selection.Challenges = append(selection.Challenges, ch)
 			continue // try another one
 		}
 
 		// found
 
-		challengeID := encryption.Hash(hashString + strconv.FormatInt(i, 10))
-		var challengeSeed uint64
-		challengeSeed, err = strconv.ParseUint(challengeID[0:16], 16, 64)
-		if err != nil {
-			Logger.Error("Error in creating challenge seed", zap.Error(err),
-				zap.Any("challengeID", challengeID))
-			continue
-		}
 		// statistics
-		var (
-			tp              = time.Now()
-			challengeString string
-		)
-		challengeString, err = sc.addChallenge(alloc, validators, challengeID,
-			t.CreationDate, r, int64(challengeSeed), balances)
+		var tp = time.Now()
+		var selected *SelectedChallenge
+		selected, err = sel.selectChallenge(alloc, validators, ch.Index,
+			balances)
+		if err == nil {
+			_, err = sc.addChallenge(alloc, selected, validators,
+				t.CreationDate, balances)
+		}
 		if err != nil {
 			Logger.Error("Error in adding challenge", zap.Error(err),
-				zap.Any("challengeString", challengeString))
+				zap.Any("allocation", alloc.ID))
+			ch.Skipped = err.Error()
+			selection.Challenges = append(selection.Challenges, ch)
 			continue
 		}
+		selected.NumAllocations = ch.NumAllocations
+		selected.AllocationIndex = ch.AllocationIndex
+		selection.Challenges = append(selection.Challenges, selected)
 		if tm := sc.SmartContractExecutionStats["challenge_request"]; tm != nil {
 			if timer, ok := tm.(metrics.Timer); ok {
 				timer.Update(time.Since(tp))
 			}
 		}
 	}
+
+	if err = sc.saveChallengeSelection(selection, balances); err != nil {
+		return common.NewError("generate_challenges", err.Error())
+	}
 	return nil
 }
 
+// addChallenge of the allocation with selected blobber and validators
 func (sc *StorageSmartContract) addChallenge(alloc *StorageAllocation,
-	validators *ValidatorNodes, challengeID string,
-	creationDate common.Timestamp, r *rand.Rand, challengeSeed int64,
-	balances c_state.StateContextI) (resp string, err error) {
-
-	sort.SliceStable(alloc.Blobbers, func(i, j int) bool {
-		return alloc.Blobbers[i].ID < alloc.Blobbers[j].ID
-	})
-
-	selectedBlobberObj := &StorageNode{}
-
-	blobberAllocation := &BlobberAllocation{}
-	blobberAllocation.Stats = &StorageAllocationStats{}
-
-	for _, ri := range r.Perm(len(alloc.Blobbers)) {
-		selectedBlobberObj = alloc.Blobbers[ri]
-		_, ok := alloc.BlobberMap[selectedBlobberObj.ID]
-		if !ok {
-			Logger.Error("Selected blobber not found in allocation state",
-				zap.Any("selected_blobber", selectedBlobberObj),
-				zap.Any("blobber_map", alloc.BlobberMap))
-			return "", common.NewError("invalid_parameters",
-				"Blobber is not part of the allocation. Could not find blobber")
-		}
-		blobberAllocation = alloc.BlobberMap[selectedBlobberObj.ID]
-		if blobberAllocation.AllocationRoot != "" {
-			break // found
+	selected *SelectedChallenge, validators *partitions,
+	creationDate common.Timestamp, balances c_state.StateContextI) (
+	resp string, err error) {
+
+	var selectedBlobberObj *StorageNode
+	for _, b := range alloc.Blobbers {
+		if b.ID == selected.BlobberID {
+			selectedBlobberObj = b
+			break
 		}
 	}
-
-	if blobberAllocation.AllocationRoot == "" {
-		return "", common.NewErrorf("no_blobber_writes", "no blobber writes, "+
-			"challenge generation not possible, allocation %s, blobber: %s",
-			alloc.ID, blobberAllocation.BlobberID)
+	var blobberAllocation, ok = alloc.BlobberMap[selected.BlobberID]
+	if selectedBlobberObj == nil || !ok {
+		Logger.Error("Selected blobber not found in allocation state",
+			zap.Any("selected_blobber", selected.BlobberID),
+			zap.Any("blobber_map", alloc.BlobberMap))
+		return "", common.NewError("invalid_parameters",
+			"Blobber is not part of the allocation. Could not find blobber")
 	}
 
-	selectedValidators := make([]*ValidationNode, 0)
-	perm := r.Perm(len(validators.Nodes))
-	for i := 0; i < minInt(len(validators.Nodes), alloc.DataShards+1); i++ {
-		if validators.Nodes[perm[i]].ID != selectedBlobberObj.ID {
-			selectedValidators = append(selectedValidators,
-				validators.Nodes[perm[i]])
+	var selectedValidators = make([]*ValidationNode, 0,
+		len(selected.ValidatorIndices))
+	for _, x := range selected.ValidatorIndices {
+		var it *partitionItem
+		if it, err = validators.itemAt(x, balances); err != nil {
+			return "", common.NewErrorf("adding_challenge_error",
+				"error getting validator: %v", err)
 		}
-		if len(selectedValidators) >= alloc.DataShards {
-			break
+		var v = new(ValidationNode)
+		if err = json.Unmarshal(it.Data, v); err != nil {
+			return "", common.NewErrorf("adding_challenge_error",
+				"error decoding validator: %v", err)
 		}
+		selectedValidators = append(selectedValidators, v)
 	}
 
 	var storageChallenge StorageChallenge
-	storageChallenge.ID = challengeID
+	storageChallenge.ID = selected.ChallengeID
 	storageChallenge.Validators = selectedValidators
 	storageChallenge.Blobber = selectedBlobberObj
-	storageChallenge.RandomNumber = challengeSeed
+	storageChallenge.RandomNumber = selected.RandomNumber
 	storageChallenge.AllocationID = alloc.ID
 
 	storageChallenge.AllocationRoot = blobberAllocation.AllocationRoot
@@ -0,0 +1,416 @@
+package storagesc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	cstate "0chain.net/chaincore/chain/state"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/encryption"
+	"0chain.net/core/util"
+	"0chain.net/smartcontract"
+)
+
+// Challenge selection.
+//
+// Challenges generated in a round are deterministic function of the round
+// VRF output (RoundRandomSeed of the block), the challenge index in the
+// round, the allocation ID and the registries of the SC. Every value used
+// by the selection is
+//
+//     value(parts...) = first 8 bytes of SHA3-256("seed:part1:part2:...")
+//
+// as big-endian unsigned integer, where the seed is decimal RoundRandomSeed.
+// Indices of challenges in a round start from zero and continue over all
+// challenges generation transactions of the round. For the i-th challenge
+//
+//  1. allocation is the value("allocation", i) % N item of the allocations
+//     registry of N items;
+//  2. blobber is the value("blobber", allocation_id, i) % M item of the M
+//     blobbers of the allocation with writes, sorted by ID;
+//  3. validators are items of the validators registry of N items with
+//     indices of the first min(N, data_shards + 1) elements of permutation
+//     of [0; N), where the k-th element swapped with the element
+//     k + value("validator", allocation_id, i, k) % (N - k); the challenged
+//     blobber is excluded, and at most data_shards validators are used;
+//  4. random number used by the blobber to choose the file and its block
+//     is int64(value("file", allocation_id, i));
+//  5. challenge ID is hex of SHA3-256("seed:challenge:round:allocation_id:i").
+//
+// An index is skipped if the allocation is expired or has no writes. The
+// inputs and results of the selection of last rounds are kept by the SC and
+// provided by the /challenge_selection?round= endpoint, the endpoint checks
+// the results recomputing them from the inputs. The registries are not kept,
+// the inputs include the snapshot of their items used by the selection: the
+// allocation ID and the IDs of the validator candidates.
+
+// challengeSelectionHistory is number of the last rounds their selection is
+// kept by the SC, the selection of a round replaces the one of the round
+// challengeSelectionHistory rounds before
+const challengeSelectionHistory = 100
+
+// challengeSelector selects challenges of a round
+type challengeSelector struct {
+	round int64
+	seed  int64
+}
+
+func newChallengeSelector(round, seed int64) *challengeSelector {
+	return &challengeSelector{round: round, seed: seed}
+}
+
+func (cs *challengeSelector) input(parts ...interface{}) string {
+	var ss = make([]string, 0, len(parts)+1)
+	ss = append(ss, strconv.FormatInt(cs.seed, 10))
+	for _, p := range parts {
+		ss = append(ss, fmt.Sprint(p))
+	}
+	return strings.Join(ss, ":")
+}
+
+// value of the selection
+func (cs *challengeSelector) value(parts ...interface{}) uint64 {
+	var hash = encryption.Hash(cs.input(parts...))
+	var v, err = strconv.ParseUint(hash[:16], 16, 64)
+	if err != nil {
+		panic(err) // must never happen, SHA3-256 hex
+	}
+	return v
+}
+
+// allocationIndex of i-th challenge in allocations registry of n items
+func (cs *challengeSelector) allocationIndex(i int64, n int) int {
+	return int(cs.value("allocation", i) % uint64(n))
+}
+
+// blobberIndex of i-th challenge in n blobbers with writes
+func (cs *challengeSelector) blobberIndex(allocID string, i int64,
+	n int) int {
+
+	return int(cs.value("blobber", allocID, i) % uint64(n))
+}
+
+// validatorIndices of i-th challenge in validators registry of n items,
+// it returns k first elements of the permutation
+func (cs *challengeSelector) validatorIndices(allocID string, i int64,
+	n, k int) (indices []int) {
+
+	if k > n {
+		k = n
+	}
+	var swapped = make(map[int]int) // sparse permutation of [0; n)
+	var at = func(x int) int {
+		if v, ok := swapped[x]; ok {
+			return v
+		}
+		return x
+	}
+	indices = make([]int, 0, k)
+	for x := 0; x < k; x++ {
+		var y = x + int(cs.value("validator", allocID, i, x)%uint64(n-x))
+		var vx, vy = at(x), at(y)
+		swapped[x], swapped[y] = vy, vx
+		indices = append(indices, vy)
+	}
+	return
+}
+
+// randomNumber of i-th challenge used by blobber to choose file
+func (cs *challengeSelector) randomNumber(allocID string, i int64) int64 {
+	return int64(cs.value("file", allocID, i))
+}
+
+// challengeID of i-th challenge
+func (cs *challengeSelector) challengeID(allocID string, i int64) string {
+	return encryption.Hash(cs.input("challenge", cs.round, allocID, i))
+}
+
+// blobbersWithWrites of the allocation sorted by ID
+func blobbersWithWrites(alloc *StorageAllocation) (ids []string) {
+	for _, b := range alloc.Blobbers {
+		var ba, ok = alloc.BlobberMap[b.ID]
+		if ok && ba.AllocationRoot != "" {
+			ids = append(ids, b.ID)
+		}
+	}
+	sort.Strings(ids)
+	return
+}
+
+// SelectedChallenge is inputs and results of selection of a challenge.
+type SelectedChallenge struct {
+	Index int64 `json:"index"`
+	// NumAllocations is size of the allocations registry.
+	NumAllocations  int    `json:"num_allocations"`
+	AllocationIndex int    `json:"allocation_index"`
+	AllocationID    string `json:"allocation_id"`
+	// Skipped is reason of skipping the index, the rest fields are empty.
+	Skipped string `json:"skipped,omitempty"`
+	// Blobbers of the allocation with writes, sorted by ID.
+	Blobbers  []string `json:"blobbers,omitempty"`
+	BlobberID string   `json:"blobber_id,omitempty"`
+	// NumValidators is size of the validators registry, and DataShards of
+	// the allocation is number of the validators required.
+	NumValidators int `json:"num_validators,omitempty"`
+	DataShards    int `json:"data_shards,omitempty"`
+	// Candidates is IDs of the validators registry items at the first
+	// indices of the permutation, up to the last selected validator.
+	Candidates       []string `json:"candidates,omitempty"`
+	ValidatorIndices []int    `json:"validator_indices,omitempty"`
+	Validators       []string `json:"validators,omitempty"`
+	RandomNumber     int64    `json:"random_number,omitempty"`
+	ChallengeID      string   `json:"challenge_id,omitempty"`
+}
+
+// ChallengeSelection of a round.
+type ChallengeSelection struct {
+	Round int64 `json:"round"`
+	// Seed is the round VRF output.
+	Seed       int64                `json:"seed"`
+	Challenges []*SelectedChallenge `json:"challenges"`
+}
+
+func challengeSelectionKey(scKey string, round int64) datastore.Key {
+	return datastore.Key(scKey + ":challengeselection:" +
+		strconv.FormatInt(round%challengeSelectionHistory, 10))
+}
+
+func (cs *ChallengeSelection) Encode() []byte {
+	var b, err = json.Marshal(cs)
+	if err != nil {
+		panic(err) // must never happen
+	}
+	return b
+}
+
+func (cs *ChallengeSelection) Decode(b []byte) error {
+	return json.Unmarshal(b, cs)
+}
+
+func (cs *ChallengeSelection) selector() *challengeSelector {
+	return newChallengeSelector(cs.Round, cs.Seed)
+}
+
+// nextIndex of a challenge in the round
+func (cs *ChallengeSelection) nextIndex() int64 {
+	return int64(len(cs.Challenges))
+}
+
+// verify the selection recomputing the results from the inputs
+func (cs *ChallengeSelection) verify() (err error) {
+	var sel = cs.selector()
+	for i, ch := range cs.Challenges {
+		if ch.Index != int64(i) {
+			return fmt.Errorf("challenge %d: unexpected index %d", i, ch.Index)
+		}
+		if ch.NumAllocations <= 0 ||
+			sel.allocationIndex(ch.Index, ch.NumAllocations) !=
+				ch.AllocationIndex {
+			return fmt.Errorf("challenge %d: allocation index mismatch", i)
+		}
+		if ch.Skipped != "" {
+			continue
+		}
+		if len(ch.Blobbers) == 0 ||
+			ch.Blobbers[sel.blobberIndex(ch.AllocationID, ch.Index,
+				len(ch.Blobbers))] != ch.BlobberID {
+			return fmt.Errorf("challenge %d: blobber mismatch", i)
+		}
+		if !ch.validatorsOfCandidates(sel.validatorIndices(ch.AllocationID,
+			ch.Index, ch.NumValidators, ch.DataShards+1)) {
+			return fmt.Errorf("challenge %d: validators mismatch", i)
+		}
+		if sel.randomNumber(ch.AllocationID, ch.Index) != ch.RandomNumber {
+			return fmt.Errorf("challenge %d: random number mismatch", i)
+		}
+		if sel.challengeID(ch.AllocationID, ch.Index) != ch.ChallengeID {
+			return fmt.Errorf("challenge %d: challenge ID mismatch", i)
+		}
+	}
+	return
+}
+
+// validatorsOfCandidates checks the validators are the candidates at the
+// indices of the permutation in the same order, excluding the challenged
+// blobber only, up to the data shards
+func (ch *SelectedChallenge) validatorsOfCandidates(indices []int) bool {
+	if len(ch.Candidates) > len(indices) ||
+		len(ch.Validators) != len(ch.ValidatorIndices) {
+		return false
+	}
+	var n int // selected
+	for k, id := range ch.Candidates {
+		if n == ch.DataShards {
+			return false // not a candidate
+		}
+		if id == ch.BlobberID {
+			continue
+		}
+		if n == len(ch.Validators) || ch.Validators[n] != id ||
+			ch.ValidatorIndices[n] != indices[k] {
+			return false
+		}
+		n++
+	}
+	// all the candidates are used if not enough validators
+	return n == len(ch.Validators) &&
+		(n == ch.DataShards || len(ch.Candidates) == len(indices))
+}
+
+// getChallengeSelection of the round
+func (sc *StorageSmartContract) getChallengeSelection(round int64,
+	balances cstate.StateContextI) (cs *ChallengeSelection, err error) {
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(challengeSelectionKey(sc.ID, round))
+	if err != nil {
+		return
+	}
+	cs = new(ChallengeSelection)
+	if err = cs.Decode(val.Encode()); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	if cs.Round != round {
+		return nil, util.ErrValueNotPresent // replaced by a next round
+	}
+	return
+}
+
+// roundChallengeSelection returns selection of the round to continue, or
+// a new one
+func (sc *StorageSmartContract) roundChallengeSelection(round, seed int64,
+	balances cstate.StateContextI) (cs *ChallengeSelection, err error) {
+
+	cs, err = sc.getChallengeSelection(round, balances)
+	if err == util.ErrValueNotPresent {
+		return &ChallengeSelection{Round: round, Seed: seed}, nil
+	}
+	return
+}
+
+// saveChallengeSelection of the round replacing the selection of the round
+// out of the kept history
+func (sc *StorageSmartContract) saveChallengeSelection(cs *ChallengeSelection,
+	balances cstate.StateContextI) (err error) {
+
+	_, err = balances.InsertTrieNode(challengeSelectionKey(sc.ID, cs.Round), cs)
+	if err != nil {
+		return fmt.Errorf("saving challenge selection: %v", err)
+	}
+	return
+}
+
+// selectValidators of i-th challenge from the validators registry, it
+// returns the selected validators, their indices in the registry and IDs
+// of the candidates used
+func (cs *challengeSelector) selectValidators(validators *partitions,
+	alloc *StorageAllocation, blobberID string, i int64,
+	balances cstate.StateContextI) (selected []*ValidationNode,
+	indices []int, candidates []string, err error) {
+
+	for _, x := range cs.validatorIndices(alloc.ID, i, validators.NumItems,
+		alloc.DataShards+1) {
+
+		var it *partitionItem
+		if it, err = validators.itemAt(x, balances); err != nil {
+			return
+		}
+		candidates = append(candidates, it.ID)
+		if it.ID == blobberID {
+			continue
+		}
+		var v = new(ValidationNode)
+		if err = json.Unmarshal(it.Data, v); err != nil {
+			return nil, nil, nil, fmt.Errorf("decoding validator %s: %v",
+				it.ID, err)
+		}
+		selected = append(selected, v)
+		indices = append(indices, x)
+		if len(selected) >= alloc.DataShards {
+			break
+		}
+	}
+	return
+}
+
+// selectChallenge of the allocation, the allocation should have writes
+func (cs *challengeSelector) selectChallenge(alloc *StorageAllocation,
+	validators *partitions, i int64, balances cstate.StateContextI) (
+	sc *SelectedChallenge, err error) {
+
+	sc = &SelectedChallenge{
+		Index:         i,
+		AllocationID:  alloc.ID,
+		Blobbers:      blobbersWithWrites(alloc),
+		NumValidators: validators.NumItems,
+		DataShards:    alloc.DataShards,
+	}
+	if len(sc.Blobbers) == 0 {
+		return nil, common.NewErrorf("no_blobber_writes", "no blobber writes, "+
+			"challenge generation not possible, allocation %s", alloc.ID)
+	}
+	sc.BlobberID = sc.Blobbers[cs.blobberIndex(alloc.ID, i, len(sc.Blobbers))]
+
+	var selected []*ValidationNode
+	selected, sc.ValidatorIndices, sc.Candidates, err = cs.selectValidators(
+		validators, alloc, sc.BlobberID, i, balances)
+	if err != nil {
+		return nil, common.NewErrorf("adding_challenge_error",
+			"error selecting validators: %v", err)
+	}
+	for _, v := range selected {
+		sc.Validators = append(sc.Validators, v.ID)
+	}
+	sc.RandomNumber = cs.randomNumber(alloc.ID, i)
+	sc.ChallengeID = cs.challengeID(alloc.ID, i)
+	return
+}
+
+//
+// REST handler
+//
+
+// challengeSelectionInfo is the selection with result of its verification
+type challengeSelectionInfo struct {
+	*ChallengeSelection
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ChallengeSelectionHandler returns challenges selection of given round,
+// verifying it; the selection of the last rounds with challenges only
+// is kept by the SC
+func (sc *StorageSmartContract) ChallengeSelectionHandler(
+	ctx context.Context, params url.Values, balances cstate.StateContextI) (
+	resp interface{}, err error) {
+
+	var round int64
+	if round, err = strconv.ParseInt(params.Get("round"), 10, 64); err != nil {
+		return nil, common.NewErrBadRequest("invalid round: " + err.Error())
+	}
+
+	var cs *ChallengeSelection
+	if cs, err = sc.getChallengeSelection(round, balances); err != nil {
+		if errors.Is(err, util.ErrValueNotPresent) {
+			err = fmt.Errorf("%w: no challenges generated in the round, "+
+				"or the round is out of the kept history", err)
+		}
+		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true,
+			"can't get challenge selection")
+	}
+
+	var info = &challengeSelectionInfo{ChallengeSelection: cs}
+	if err = cs.verify(); err != nil {
+		info.Error = err.Error()
+	} else {
+		info.Verified = true
+	}
+	return info, nil
+}
@@ -0,0 +1,232 @@
+package storagesc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_challengeSelector_validatorIndices(t *testing.T) {
+	var cs = newChallengeSelector(10, 150)
+
+	for _, n := range []int{1, 2, 5, 10, 100} {
+		for _, k := range []int{1, 3, n, n + 5} {
+			var indices = cs.validatorIndices("alloc", 5, n, k)
+			var expected = k
+			if expected > n {
+				expected = n
+			}
+			require.Len(t, indices, expected)
+			var seen = make(map[int]bool)
+			for _, x := range indices {
+				require.True(t, x >= 0 && x < n)
+				require.False(t, seen[x], "duplicate index")
+				seen[x] = true
+			}
+			// prefix of the same permutation
+			require.Equal(t, indices,
+				cs.validatorIndices("alloc", 5, n, n)[:expected])
+		}
+	}
+
+	// all the elements for full permutation
+	var indices = cs.validatorIndices("alloc", 1, 10, 10)
+	sort.Ints(indices)
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, indices)
+}
+
+func Test_challengeSelector_deterministic(t *testing.T) {
+	var (
+		a = newChallengeSelector(10, 150)
+		b = newChallengeSelector(10, 150)
+		c = newChallengeSelector(10, 151)
+	)
+	require.Equal(t, a.allocationIndex(3, 1000), b.allocationIndex(3, 1000))
+	require.Equal(t, a.blobberIndex("alloc", 3, 100),
+		b.blobberIndex("alloc", 3, 100))
+	require.Equal(t, a.validatorIndices("alloc", 3, 100, 10),
+		b.validatorIndices("alloc", 3, 100, 10))
+	require.Equal(t, a.randomNumber("alloc", 3), b.randomNumber("alloc", 3))
+	require.Equal(t, a.challengeID("alloc", 3), b.challengeID("alloc", 3))
+
+	require.NotEqual(t, a.randomNumber("alloc", 3), c.randomNumber("alloc", 3))
+	require.NotEqual(t, a.randomNumber("alloc", 3), a.randomNumber("alloc", 4))
+	require.NotEqual(t, a.randomNumber("alloc", 3), a.randomNumber("other", 3))
+	require.NotEqual(t, a.challengeID("alloc", 3), c.challengeID("alloc", 3))
+	require.NotEqual(t, a.challengeID("alloc", 3),
+		newChallengeSelector(11, 150).challengeID("alloc", 3))
+}
+
+// the values of the documented selection, independent of the implementation
+func Test_challengeSelector_knownAnswer(t *testing.T) {
+	var cs = newChallengeSelector(10, 150)
+
+	// first 8 bytes of SHA3-256("150:allocation:0")
+	require.EqualValues(t, uint64(10129735617975321275),
+		cs.value("allocation", 0))
+	require.EqualValues(t, uint64(18178741416584860197),
+		cs.value("blobber", "alloc", 3))
+	require.Equal(t, 533, cs.allocationIndex(3, 1000))
+	require.Equal(t, 97, cs.blobberIndex("alloc", 3, 100))
+	require.Equal(t, []int{3, 9, 7, 6, 1, 8, 4, 5, 2, 0},
+		cs.validatorIndices("alloc", 3, 10, 10))
+	require.EqualValues(t, -3765828962617662059, cs.randomNumber("alloc", 3))
+	// SHA3-256("150:challenge:10:alloc:3")
+	require.Equal(t,
+		"0c5a426e191331f73c8c8e666293b5fd697a93547095008617f4afd064961889",
+		cs.challengeID("alloc", 3))
+}
+
+func newTestChallengeSelection(t *testing.T, balances *testBalances,
+	round, seed int64, n int) (cs *ChallengeSelection) {
+
+	var validators = newPartitions(allValidatorsName, validatorsPartitionSize)
+	for i := 0; i < 20; i++ {
+		validators.appendItem(newValidatorItem(&ValidationNode{
+			ID: "b" + strconv.Itoa(i),
+		}))
+	}
+	require.NoError(t, validators.save(balances))
+
+	var alloc = &StorageAllocation{
+		ID:         "alloc",
+		DataShards: 4,
+		BlobberMap: make(map[string]*BlobberAllocation),
+	}
+	for i := 0; i < 6; i++ {
+		var id = "b" + strconv.Itoa(i)
+		alloc.Blobbers = append(alloc.Blobbers, &StorageNode{ID: id})
+		alloc.BlobberMap[id] = &BlobberAllocation{AllocationRoot: "root"}
+	}
+
+	cs = &ChallengeSelection{Round: round, Seed: seed}
+	var sel = cs.selector()
+	for i := 0; i < n; i++ {
+		var selected, err = sel.selectChallenge(alloc, validators,
+			cs.nextIndex(), balances)
+		require.NoError(t, err)
+		selected.NumAllocations = 10
+		selected.AllocationIndex = sel.allocationIndex(selected.Index, 10)
+		require.NotContains(t, selected.Validators, selected.BlobberID)
+		require.Len(t, selected.Validators, alloc.DataShards)
+		cs.Challenges = append(cs.Challenges, selected)
+	}
+	cs.Challenges = append(cs.Challenges, &SelectedChallenge{
+		Index:           cs.nextIndex(),
+		NumAllocations:  10,
+		AllocationIndex: sel.allocationIndex(cs.nextIndex(), 10),
+		AllocationID:    "expired_alloc",
+		Skipped:         "expired",
+	})
+	return
+}
+
+func TestChallengeSelection_verify(t *testing.T) {
+	var (
+		balances = newTestBalances(t, false)
+		cs       = newTestChallengeSelection(t, balances, 10, 150, 10)
+	)
+	require.NoError(t, cs.verify())
+
+	for name, tamper := range map[string]func(ch *SelectedChallenge){
+		"index":            func(ch *SelectedChallenge) { ch.Index++ },
+		"allocation index": func(ch *SelectedChallenge) { ch.AllocationIndex = (ch.AllocationIndex + 1) % ch.NumAllocations },
+		"blobber":          func(ch *SelectedChallenge) { ch.BlobberID = "other" },
+		"validators": func(ch *SelectedChallenge) {
+			ch.ValidatorIndices[0], ch.ValidatorIndices[1] = ch.ValidatorIndices[1], ch.ValidatorIndices[0]
+		},
+		"validator IDs": func(ch *SelectedChallenge) { ch.Validators[0] = "other" },
+		"candidates": func(ch *SelectedChallenge) {
+			ch.Candidates[0], ch.Candidates[1] = ch.Candidates[1], ch.Candidates[0]
+		},
+		"extra candidate": func(ch *SelectedChallenge) {
+			ch.Candidates = append(ch.Candidates, "other")
+		},
+		// a candidate other than the blobber excluded
+		"excluded": func(ch *SelectedChallenge) {
+			ch.Validators = ch.Validators[1:]
+			ch.ValidatorIndices = ch.ValidatorIndices[1:]
+		},
+		"random number": func(ch *SelectedChallenge) { ch.RandomNumber++ },
+		"challenge ID":  func(ch *SelectedChallenge) { ch.ChallengeID = "other" },
+	} {
+		var tampered = newTestChallengeSelection(t, balances, 10, 150, 10)
+		tamper(tampered.Challenges[5])
+		require.Error(t, tampered.verify(), name)
+	}
+
+	// other seed
+	cs.Seed++
+	require.Error(t, cs.verify())
+}
+
+func TestStorageSmartContract_saveChallengeSelection(t *testing.T) {
+	var (
+		ssc      = newTestStorageSC()
+		balances = newTestBalances(t, false)
+		last     = int64(challengeSelectionHistory + 5)
+	)
+	for round := int64(1); round <= last; round++ {
+		var cs = &ChallengeSelection{Round: round, Seed: round}
+		require.NoError(t, ssc.saveChallengeSelection(cs, balances))
+		// the same round again
+		require.NoError(t, ssc.saveChallengeSelection(cs, balances))
+	}
+
+	for round := int64(1); round <= last; round++ {
+		var cs, err = ssc.getChallengeSelection(round, balances)
+		if round <= last-challengeSelectionHistory {
+			require.Error(t, err, fmt.Sprint(round))
+			continue
+		}
+		require.NoError(t, err)
+		require.EqualValues(t, round, cs.Seed)
+	}
+
+	cs, err := ssc.roundChallengeSelection(last, 0, balances)
+	require.NoError(t, err)
+	require.EqualValues(t, last, cs.Seed)
+	cs, err = ssc.roundChallengeSelection(last+1, 10, balances)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, cs.Seed)
+	require.Zero(t, cs.nextIndex())
+}
+
+func TestStorageSmartContract_ChallengeSelectionHandler(t *testing.T) {
+	var (
+		ssc      = newTestStorageSC()
+		balances = newTestBalances(t, false)
+		ctx      = context.Background()
+		cs       = newTestChallengeSelection(t, balances, 10, 150, 5)
+	)
+	require.NoError(t, ssc.saveChallengeSelection(cs, balances))
+
+	var resp, err = ssc.ChallengeSelectionHandler(ctx,
+		url.Values{"round": {"10"}}, balances)
+	require.NoError(t, err)
+	var info = resp.(*challengeSelectionInfo)
+	require.True(t, info.Verified)
+	require.Empty(t, info.Error)
+	require.Len(t, info.Challenges, 6)
+
+	_, err = ssc.ChallengeSelectionHandler(ctx,
+		url.Values{"round": {"11"}}, balances)
+	require.Error(t, err)
+	_, err = ssc.ChallengeSelectionHandler(ctx,
+		url.Values{"round": {"x"}}, balances)
+	require.Error(t, err)
+
+	cs.Challenges[2].RandomNumber++
+	require.NoError(t, ssc.saveChallengeSelection(cs, balances))
+	resp, err = ssc.ChallengeSelectionHandler(ctx,
+		url.Values{"round": {"10"}}, balances)
+	require.NoError(t, err)
+	info = resp.(*challengeSelectionInfo)
+	require.False(t, info.Verified)
+	require.Contains(t, info.Error, "challenge 2")
+}
@@ -12,7 +12,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/require"
-	"math/rand"
 	"strconv"
 	"strings"
 	"testing"
@@ -32,26 +31,25 @@ func TestAddChallenge(t *testing.T) {
 		numBlobbers   int
 		numValidators int
 		dataShards    int
-		randomSeed    int
+		randomSeed    int64
 	}
 
 	type args struct {
-		alloc         *StorageAllocation
-		validators    *ValidatorNodes
-		challengeID   string
-		creationDate  common.Timestamp
-		r             *rand.Rand
-		challengeSeed int64
-		balances      cstate.StateContextI
+		alloc        *StorageAllocation
+		validators   *partitions
+		creationDate common.Timestamp
+		selector     *challengeSelector
+		balances     cstate.StateContextI
 	}
 
 	type want struct {
+		blobber    int
 		validators []int
 		error      bool
 		errorMsg   string
 	}
 
-	parametersToArgs := func(p parameters) args {
+	parametersToArgs := func(t *testing.T, p parameters) args {
 		var blobbers = []*StorageNode{}
 		var blobberMap = make(map[string]*BlobberAllocation)
 		for i := 0; i < p.numBlobbers; i++ {
@@ -64,12 +62,17 @@ func TestAddChallenge(t *testing.T) {
 				Stats:          &StorageAllocationStats{},
 			}
 		}
-		var validators = ValidatorNodes{}
+		var balances = &mockStateContext{
+			store: make(map[datastore.Key]util.Serializable),
+		}
+		var validators = newPartitions(allValidatorsName,
+			validatorsPartitionSize)
 		for i := 0; i < p.numValidators; i++ {
-			validators.Nodes = append(validators.Nodes, &ValidationNode{
+			validators.appendItem(newValidatorItem(&ValidationNode{
 				ID: strconv.Itoa(i),
-			})
+			}))
 		}
+		require.NoError(t, validators.save(balances))
 		return args{
 			alloc: &StorageAllocation{
 				Blobbers:   blobbers,
@@ -77,15 +80,15 @@ func TestAddChallenge(t *testing.T) {
 				DataShards: p.dataShards,
 				Stats:      &StorageAllocationStats{},
 			},
-			validators: &validators,
-			r:          rand.New(rand.NewSource(int64(p.randomSeed))),
-			balances: &mockStateContext{
-				store: make(map[datastore.Key]util.Serializable),
-			},
+			validators: validators,
+			selector:   newChallengeSelector(1, p.randomSeed),
+			balances:   balances,
 		}
 	}
 
-	validate := func(t *testing.T, resp string, err error, p parameters, want want) {
+	validate := func(t *testing.T, resp string, err error,
+		selected *SelectedChallenge, p parameters, want want) {
+
 		require.EqualValues(t, want.error, err != nil)
 		if want.error {
 			require.EqualValues(t, want.errorMsg, err.Error())
@@ -98,9 +101,19 @@ func TestAddChallenge(t *testing.T) {
 		} else {
 			require.EqualValues(t, len(challenge.Validators), p.numValidators-1)
 		}
+		require.EqualValues(t, strconv.Itoa(want.blobber), challenge.Blobber.ID)
+		require.Len(t, challenge.Validators, len(want.validators))
 		for i, v := range want.validators {
 			require.EqualValues(t, strconv.Itoa(v), challenge.Validators[i].ID)
 		}
+		require.EqualValues(t, selected.ChallengeID, challenge.ID)
+		require.EqualValues(t, selected.BlobberID, challenge.Blobber.ID)
+		require.EqualValues(t, selected.RandomNumber, challenge.RandomNumber)
+		require.Len(t, selected.Validators, len(challenge.Validators))
+		for i, v := range challenge.Validators {
+			require.NotEqual(t, challenge.Blobber.ID, v.ID)
+			require.EqualValues(t, selected.Validators[i], v.ID)
+		}
 	}
 
 	tests := []struct {
@@ -117,7 +130,9 @@ func TestAddChallenge(t *testing.T) {
 				randomSeed:    1,
 			},
 			want: want{
-				validators: []int{6, 3, 8, 4},
+				// candidates 0, 1, 8, 6, 2 without the blobber
+				blobber:    1,
+				validators: []int{0, 8, 6, 2},
 			},
 		},
 		{
@@ -129,7 +144,8 @@ func TestAddChallenge(t *testing.T) {
 				randomSeed:    1,
 			},
 			want: want{
-				validators: []int{3, 0, 1, 4, 2},
+				blobber:    3,
+				validators: []int{2, 5, 4, 0, 1},
 			},
 		},
 		{
@@ -142,7 +158,7 @@ func TestAddChallenge(t *testing.T) {
 			},
 			want: want{
 				error:    true,
-				errorMsg: "no_blobber_writes: no blobber writes, challenge generation not possible, allocation , blobber: ",
+				errorMsg: "no_blobber_writes: no blobber writes, challenge generation not possible, allocation ",
 			},
 		},
 	}
@@ -150,14 +166,19 @@ func TestAddChallenge(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			args := parametersToArgs(tt.parameters)
+			args := parametersToArgs(t, tt.parameters)
 			var ssc = &StorageSmartContract{
 				SmartContract: sci.NewSC(ADDRESS),
 			}
 
-			resp, err := ssc.addChallenge(args.alloc, args.validators, args.challengeID,
-				args.creationDate, args.r, args.challengeSeed, args.balances)
-			validate(t, resp, err, tt.parameters, tt.want)
+			var resp string
+			selected, err := args.selector.selectChallenge(args.alloc,
+				args.validators, 0, args.balances)
+			if err == nil {
+				resp, err = ssc.addChallenge(args.alloc, selected,
+					args.validators, args.creationDate, args.balances)
+			}
+			validate(t, resp, err, selected, tt.parameters, tt.want)
 		})
 	}
 }
@@ -156,6 +156,9 @@ type scConfig struct {
 	MaxCharge float64 `json:"max_charge"`
 
 	BlockReward *blockReward `json:"block_reward"`
+
+	// Reputation related configurations of blobbers.
+	Reputation *reputationConfig `json:"reputation"`
 }
 
 func (sc *scConfig) validate() (err error) {
@@ -304,6 +307,11 @@ func (sc *scConfig) validate() (err error) {
 		return fmt.Errorf("negative block_reward.bobber_usage_weight: %v",
 			sc.BlockReward.BlobberUsageWeight)
 	}
+	if sc.Reputation != nil {
+		if err = sc.Reputation.validate(); err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -445,6 +453,20 @@ func getConfiguredConfig() (conf *scConfig, err error) {
 		scc.GetFloat64(pfx+"block_reward.blobber_capacity_ratio"),
 		scc.GetFloat64(pfx+"block_reward.blobber_usage_ratio"),
 	)
+	// reputation
+	conf.Reputation = new(reputationConfig)
+	conf.Reputation.ChallengesWindow = scc.GetInt(
+		pfx + "reputation.challenges_window")
+	conf.Reputation.HealthChecksWindow = scc.GetInt(
+		pfx + "reputation.health_checks_window")
+	conf.Reputation.InitialScore = scc.GetFloat64(
+		pfx + "reputation.initial_score")
+	conf.Reputation.PassRateWeight = scc.GetFloat64(
+		pfx + "reputation.pass_rate_weight")
+	conf.Reputation.UptimeWeight = scc.GetFloat64(
+		pfx + "reputation.uptime_weight")
+	conf.Reputation.PenaltyWeight = scc.GetFloat64(
+		pfx + "reputation.penalty_weight")
 
 	err = conf.validate()
 	return
@@ -524,6 +546,15 @@ func (ssc *StorageSmartContract) updateConfig(t *transaction.Transaction,
 			"unauthorized access - only the owner can update the variables")
 	}
 
+	var enabled bool
+	if enabled, err = ssc.governance().Enabled(); err != nil {
+		return "", common.NewError("update_config", err.Error())
+	}
+	if enabled {
+		return "", common.NewError("update_config",
+			"configurations are changed by governance proposals")
+	}
+
 	var conf *scConfig
 	if conf, err = ssc.getConfig(balances, true); err != nil {
 		return "", common.NewError("update_config",
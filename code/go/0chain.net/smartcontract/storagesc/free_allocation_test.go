@@ -18,6 +18,34 @@ import (
 	"time"
 )
 
+// mockAllBlobbersRegistry sets up the expectations of the all blobbers
+// registry migrated from the legacy list, the registry can be saved
+func mockAllBlobbersRegistry(balances *mocks.StateContextI,
+	all *StorageNodes) {
+
+	var p = newPartitions(allBlobbersName, blobbersPartitionSize)
+	for _, b := range all.Nodes {
+		p.appendItem(newBlobberItem(b))
+	}
+	balances.On("GetTrieNode", partitionsKey(allBlobbersName)).Return(p, nil)
+	for i := 0; i < p.NumPartitions; i++ {
+		balances.On(
+			"GetTrieNode", partitionKey(allBlobbersName, i),
+		).Return(p.loaded[i], nil).Maybe()
+		balances.On(
+			"InsertTrieNode", partitionKey(allBlobbersName, i), mock.Anything,
+		).Return("", nil).Maybe()
+	}
+	for _, b := range all.Nodes {
+		balances.On(
+			"GetTrieNode", partitionLocationKey(allBlobbersName, b.ID),
+		).Return(&partitionLocation{Partition: p.locations[b.ID]}, nil).Maybe()
+	}
+	balances.On(
+		"InsertTrieNode", partitionsKey(allBlobbersName), mock.Anything,
+	).Return("", nil).Maybe()
+}
+
 func TestAddFreeStorageAssigner(t *testing.T) {
 	const (
 		mockCooperationId        = "mock cooperation id"
@@ -261,9 +289,7 @@ func TestFreeAllocationRequest(t *testing.T) {
 
 		balances.On("GetTrieNode", scConfigKey(ssc.ID)).Return(conf, nil)
 
-		balances.On("GetTrieNode", ALL_BLOBBERS_KEY).Return(
-			mockAllBlobbers, nil,
-		).Once()
+		mockAllBlobbersRegistry(balances, mockAllBlobbers)
 
 		for _, blobber := range mockAllBlobbers.Nodes {
 			balances.On(
@@ -277,9 +303,6 @@ func TestFreeAllocationRequest(t *testing.T) {
 			).Return("", nil).Once()
 		}
 
-		balances.On(
-			"InsertTrieNode", ALL_BLOBBERS_KEY, mock.Anything,
-		).Return("", nil).Once()
 		balances.On(
 			"GetTrieNode", writePoolKey(ssc.ID, p.marker.Recipient),
 		).Return(nil, util.ErrValueNotPresent).Once()
@@ -295,17 +318,29 @@ func TestFreeAllocationRequest(t *testing.T) {
 		balances.On(
 			"GetTrieNode", clientAlloc.GetKey(ssc.ID),
 		).Return(nil, util.ErrValueNotPresent).Once()
+		balances.On(
+			"GetTrieNode", partitionsKey(allAllocationsName),
+		).Return(nil, util.ErrValueNotPresent).Once()
 		balances.On(
 			"GetTrieNode", ALL_ALLOCATIONS_KEY,
-		).Return(&Allocations{}, nil).Once()
+		).Return(nil, util.ErrValueNotPresent).Once()
+		balances.On(
+			"GetTrieNode", partitionLocationKey(allAllocationsName, txn.Hash),
+		).Return(nil, util.ErrValueNotPresent).Once()
 
 		allocation := StorageAllocation{ID: txn.Hash}
 		balances.On(
 			"GetTrieNode", allocation.GetKey(ssc.ID),
 		).Return(nil, util.ErrValueNotPresent).Once()
-		balances.On(
-			"InsertTrieNode", ALL_ALLOCATIONS_KEY, mock.Anything,
-		).Return("", nil).Once()
+		for _, key := range []string{
+			partitionKey(allAllocationsName, 0),
+			partitionLocationKey(allAllocationsName, txn.Hash),
+			partitionsKey(allAllocationsName),
+		} {
+			balances.On(
+				"InsertTrieNode", key, mock.Anything,
+			).Return("", nil).Once()
+		}
 		balances.On(
 			"InsertTrieNode", clientAlloc.GetKey(ssc.ID), mock.Anything,
 		).Return("", nil).Once()
@@ -581,9 +616,7 @@ func TestUpdateFreeStorageRequest(t *testing.T) {
 
 		balances.On("GetTrieNode", scConfigKey(ssc.ID)).Return(conf, nil).Once()
 
-		balances.On("GetTrieNode", ALL_BLOBBERS_KEY).Return(
-			mockAllBlobbers, nil,
-		).Once()
+		mockAllBlobbersRegistry(balances, mockAllBlobbers)
 
 		ca := ClientAllocation{
 			ClientID:    p.marker.Recipient,
@@ -629,9 +662,6 @@ func TestUpdateFreeStorageRequest(t *testing.T) {
 			"InsertTrieNode", sa.GetKey(ssc.ID), mock.Anything,
 		).Return("", nil).Once()
 
-		balances.On(
-			"InsertTrieNode", ALL_BLOBBERS_KEY, mock.Anything,
-		).Return("", nil).Once()
 		balances.On(
 			"GetTrieNode", writePoolKey(ssc.ID, p.marker.Recipient),
 		).Return(&writePool{}, nil).Once()
@@ -0,0 +1,58 @@
+package storagesc
+
+import (
+	"errors"
+	"fmt"
+
+	chainstate "0chain.net/chaincore/chain/state"
+	"0chain.net/smartcontract/governance"
+)
+
+// governance of the storage SC configurations, it replaces the owner's
+// update_config function when enabled
+func (ssc *StorageSmartContract) governance() *governance.Governance {
+	return governance.New(ssc.ID, "smart_contracts.storagesc.", ssc)
+}
+
+// updatedConfig returns current configurations with given setting changed
+func (ssc *StorageSmartContract) updatedConfig(key, value string,
+	balances chainstate.StateContextI) (conf *scConfig, err error) {
+
+	// the minted is state of the SC, and the time_unit can't be changed
+	// once applied
+	if key == "minted" || key == "time_unit" {
+		return nil, fmt.Errorf("%s can't be changed", key)
+	}
+	if conf, err = ssc.getConfig(balances, true); err != nil {
+		return nil, errors.New("can't get config: " + err.Error())
+	}
+	var minted = conf.Minted
+	if err = governance.SetSetting(conf, key, value); err != nil {
+		return
+	}
+	if err = conf.validate(); err != nil {
+		return
+	}
+	conf.Minted = minted
+	return
+}
+
+// ValidateSetting implements governance.Settings interface.
+func (ssc *StorageSmartContract) ValidateSetting(key, value string,
+	balances chainstate.StateContextI) (err error) {
+
+	_, err = ssc.updatedConfig(key, value, balances)
+	return
+}
+
+// ApplySetting implements governance.Settings interface.
+func (ssc *StorageSmartContract) ApplySetting(key, value string,
+	balances chainstate.StateContextI) (err error) {
+
+	var conf *scConfig
+	if conf, err = ssc.updatedConfig(key, value, balances); err != nil {
+		return
+	}
+	_, err = balances.InsertTrieNode(scConfigKey(ssc.ID), conf)
+	return
+}
@@ -0,0 +1,63 @@
+package storagesc
+
+import (
+	"testing"
+	"time"
+
+	"0chain.net/chaincore/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageSmartContract_governanceSettings(t *testing.T) {
+	var (
+		ssc      = newTestStorageSC()
+		balances = newTestBalances(t, false)
+		conf     = setConfig(t, balances)
+		err      error
+	)
+	conf.Minted = 10e10
+	conf.BlockReward = new(blockReward)
+	conf.FreeAllocationSettings.Duration = 24 * time.Hour
+	conf.FreeAllocationSettings.ReadPriceRange = PriceRange{Min: 0, Max: 1e10}
+	conf.FreeAllocationSettings.WritePriceRange = PriceRange{Min: 0, Max: 1e10}
+	mustSave(t, scConfigKey(ADDRESS), conf, balances)
+
+	require.Error(t, ssc.ValidateSetting("minted", "0", balances))
+	require.Error(t, ssc.ValidateSetting("time_unit", `"1h"`, balances))
+	require.Error(t, ssc.ValidateSetting("unknown", "0", balances))
+	require.Error(t, ssc.ValidateSetting("validator_reward", "2", balances))
+	require.NoError(t, ssc.ValidateSetting("validator_reward", "0.5",
+		balances))
+
+	require.NoError(t, ssc.ApplySetting("readpool.min_lock", "20",
+		balances))
+	require.NoError(t, ssc.ApplySetting("max_challenge_completion_time",
+		`"10m"`, balances))
+
+	conf, err = ssc.getConfig(balances, false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 20, conf.ReadPool.MinLock)
+	assert.Equal(t, "10m0s", conf.MaxChallengeCompletionTime.String())
+	assert.EqualValues(t, 10e10, conf.Minted)
+	assert.Equal(t, 0.025, conf.ValidatorReward)
+
+	// the owner can't update the configurations when governance enabled
+	const pfx = "smart_contracts.storagesc.governance."
+	for key, value := range map[string]interface{}{
+		"voting_period": 100,
+		"quorum":        0.5,
+		"threshold":     0.5,
+	} {
+		var prev = config.SmartContractConfig.Get(pfx + key)
+		config.SmartContractConfig.Set(pfx+key, value)
+		defer config.SmartContractConfig.Set(pfx+key, prev)
+	}
+
+	var tx = newTransaction(owner, ADDRESS, 0, 0)
+	balances.setTransaction(t, tx)
+	_, err = ssc.updateConfig(tx, mustEncode(t, conf), balances)
+	requireErrMsg(t, err,
+		"update_config: configurations are changed by governance proposals")
+}
@@ -32,6 +32,12 @@ func (ssc *StorageSmartContract) GetBlobberHandler(ctx context.Context,
 		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true, "can't get blobber")
 	}
 
+	rc, err := ssc.getReputationConfig(balances)
+	if err != nil {
+		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true, cantGetConfigErrMsg)
+	}
+	bl.reputation(rc) // for blobbers registered before the scoring
+
 	return bl, nil
 }
 
@@ -44,6 +50,13 @@ func (ssc *StorageSmartContract) GetBlobbersHandler(ctx context.Context,
 	if err != nil {
 		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true, "can't get blobbers list")
 	}
+	rc, err := ssc.getReputationConfig(balances)
+	if err != nil {
+		return nil, smartcontract.NewErrNoResourceOrErrInternal(err, true, cantGetConfigErrMsg)
+	}
+	for _, b := range blobbers.Nodes {
+		b.reputation(rc) // for blobbers registered before the scoring
+	}
 	return blobbers, nil
 }
 
@@ -85,12 +98,12 @@ func (ssc *StorageSmartContract) GetAllocationMinLockHandler(ctx context.Context
 		return "", common.NewErrInternal("can't decode allocation request", err.Error())
 	}
 
-	var allBlobbersList *StorageNodes
-	allBlobbersList, err = ssc.getBlobbersList(balances)
+	var allBlobbersList *partitions
+	allBlobbersList, err = ssc.getBlobbersPartitions(balances)
 	if err != nil {
 		return "", common.NewErrInternal("can't get blobbers list", err.Error())
 	}
-	if len(allBlobbersList.Nodes) == 0 {
+	if allBlobbersList.NumItems == 0 {
 		return "", common.NewErrInternal("can't get blobbers list",
 			"no blobbers found")
 	}
@@ -98,7 +111,7 @@ func (ssc *StorageSmartContract) GetAllocationMinLockHandler(ctx context.Context
 	var sa = request.storageAllocation()
 
 	blobberNodes, bSize, err := ssc.selectBlobbers(
-		creationDate, *allBlobbersList, sa, int64(creationDate), balances)
+		creationDate, allBlobbersList, sa, int64(creationDate), balances)
 	if err != nil {
 		return "", common.NewErrInternal("selecting blobbers", err.Error())
 	}
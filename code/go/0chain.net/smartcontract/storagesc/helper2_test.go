@@ -101,3 +101,11 @@ func zcnToInt64(token float64) int64 {
 func zcnToBalance(token float64) state.Balance {
 	return state.Balance(token * float64(x10))
 }
+
+func (sc *mockStateContext) EmitEvent(event *transaction.Event) {
+	sc.ctx.EmitEvent(event)
+}
+
+func (sc *mockStateContext) GetEvents() []*transaction.Event {
+	return sc.ctx.GetEvents()
+}
@@ -1,6 +1,7 @@
 package storagesc
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"strconv"
@@ -328,6 +329,36 @@ func mustSave(t testing.TB, key datastore.Key, val util.Serializable,
 	require.NoError(t, err)
 }
 
+// mustMigrateRegistries migrates the legacy lists to the registries
+// repeating the migrate_registries transaction until it's done
+func mustMigrateRegistries(t testing.TB, ssc *StorageSmartContract,
+	balances chainState.StateContextI) {
+
+	for {
+		var tx = newTransaction(owner, ssc.ID, 0, 0)
+		var resp, err = ssc.migrateRegistries(tx, nil, balances)
+		require.NoError(t, err)
+		var res migrateRegistriesResponse
+		require.NoError(t, json.Unmarshal([]byte(resp), &res))
+		if res.Done {
+			return
+		}
+	}
+}
+
+// mustUpdateAllBlobbers updates the blobbers in the all blobbers registry
+func mustUpdateAllBlobbers(t testing.TB, ssc *StorageSmartContract,
+	list *StorageNodes, balances chainState.StateContextI) {
+
+	var all, err = ssc.getBlobbersPartitions(balances)
+	require.NoError(t, err)
+	for _, b := range list.Nodes {
+		_, err = updateBlobberInAll(all, b, balances)
+		require.NoError(t, err)
+	}
+	require.NoError(t, all.save(balances))
+}
+
 func setConfig(t testing.TB, balances chainState.StateContextI) (
 	conf *scConfig) {
 
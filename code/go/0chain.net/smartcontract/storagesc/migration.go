@@ -0,0 +1,169 @@
+package storagesc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cstate "0chain.net/chaincore/chain/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/util"
+)
+
+// maxMigratedItems is the number of legacy items migrated by one
+// migrate_registries transaction
+const maxMigratedItems = 5000
+
+// legacyItemsFunc decodes all items of a legacy list
+type legacyItemsFunc func(b []byte) ([]*partitionItem, error)
+
+// registryMigration describes migration of a legacy list to its registry,
+// the after function is called for every migrated item
+type registryMigration struct {
+	name   string
+	size   int
+	legacy datastore.Key
+	items  legacyItemsFunc
+	after  func(it *partitionItem, balances cstate.StateContextI) error
+}
+
+var registryMigrations = []registryMigration{
+	{
+		name:   allBlobbersName,
+		size:   blobbersPartitionSize,
+		legacy: ALL_BLOBBERS_KEY,
+		items:  legacyBlobbersItems,
+		after:  saveBlobberURL,
+	},
+	{
+		name:   allValidatorsName,
+		size:   validatorsPartitionSize,
+		legacy: ALL_VALIDATORS_KEY,
+		items:  legacyValidatorsItems,
+	},
+	{
+		name:   allAllocationsName,
+		size:   allocationsPartitionSize,
+		legacy: ALL_ALLOCATIONS_KEY,
+		items:  legacyAllocationsItems,
+	},
+}
+
+// saveBlobberURL creates URL reference of a migrated blobber
+func saveBlobberURL(it *partitionItem, balances cstate.StateContextI) (
+	err error) {
+
+	var b *StorageNode
+	if b, err = blobberOfItem(it); err != nil {
+		return
+	}
+	_, err = balances.InsertTrieNode(blobberURLKey(b.BaseURL),
+		&blobberURL{ID: b.ID})
+	return
+}
+
+// migrate up to max items of the legacy list, the legacy list is removed
+// once all its items are in the registry
+func (rm *registryMigration) migrate(max int,
+	balances cstate.StateContextI) (migrated int, done bool, err error) {
+
+	var (
+		p  *partitions
+		ok bool
+	)
+	if p, ok, err = loadPartitions(rm.name, rm.size, balances); err != nil {
+		return
+	}
+	if ok && p.Legacy == "" {
+		return 0, true, nil // migrated or created without a legacy list
+	}
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(rm.legacy)
+	if err == util.ErrValueNotPresent {
+		return 0, true, nil // nothing to migrate
+	}
+	if err != nil {
+		return
+	}
+	var items []*partitionItem
+	if items, err = rm.items(val.Encode()); err != nil {
+		return 0, false, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+
+	p.Legacy = rm.legacy
+	var end = p.LegacyMigrated + max
+	if end > len(items) {
+		end = len(items)
+	}
+	for _, it := range items[p.LegacyMigrated:end] {
+		if err = p.add(it, balances); err != nil {
+			return
+		}
+		if rm.after != nil {
+			if err = rm.after(it, balances); err != nil {
+				return
+			}
+		}
+		migrated++
+	}
+	p.LegacyMigrated = end
+
+	if end == len(items) {
+		if _, err = balances.DeleteTrieNode(rm.legacy); err != nil {
+			return 0, false, fmt.Errorf("removing legacy %s list: %v",
+				rm.name, err)
+		}
+		p.Legacy, p.LegacyMigrated, done = "", 0, true
+	}
+	return migrated, done, p.save(balances)
+}
+
+type migrateRegistriesResponse struct {
+	Migrated int  `json:"migrated"`
+	Done     bool `json:"done"`
+}
+
+// migrateRegistries is SC function used by SC owner to migrate the legacy
+// lists of blobbers, validators and allocations to their registries, it
+// migrates up to maxMigratedItems items per transaction and should be
+// repeated until it reports done; a registry isn't available until its
+// migration is over
+func (sc *StorageSmartContract) migrateRegistries(t *transaction.Transaction,
+	input []byte, balances cstate.StateContextI) (resp string, err error) {
+
+	if t.ClientID != owner {
+		return "", common.NewError("migrate_registries",
+			"unauthorized access - only the owner can migrate the registries")
+	}
+
+	var res = migrateRegistriesResponse{Done: true}
+	for _, rm := range registryMigrations {
+		if res.Migrated >= maxMigratedItems {
+			res.Done = false
+			break
+		}
+		var (
+			migrated int
+			done     bool
+		)
+		migrated, done, err = rm.migrate(maxMigratedItems-res.Migrated,
+			balances)
+		if err != nil {
+			return "", common.NewError("migrate_registries",
+				fmt.Sprintf("migrating %s: %v", rm.name, err))
+		}
+		res.Migrated += migrated
+		if !done {
+			res.Done = false
+			break
+		}
+	}
+
+	var b []byte
+	if b, err = json.Marshal(&res); err != nil {
+		return "", common.NewError("migrate_registries", err.Error())
+	}
+	return string(b), nil
+}
@@ -309,6 +309,8 @@ type StorageNode struct {
 	PublicKey       string                 `json:"-"`
 	// StakePoolSettings used initially to create and setup stake pool.
 	StakePoolSettings stakePoolSettings `json:"stake_pool_settings"`
+	// Reputation maintained by the SC, it can't be set by the blobber.
+	Reputation *BlobberReputation `json:"reputation,omitempty"`
 }
 
 // validate the blobber configurations
@@ -337,12 +339,12 @@ func (sn *StorageNode) GetKey(globalKey string) datastore.Key {
 }
 
 func (sn *StorageNode) Encode() []byte {
-	buff, _ := json.Marshal(sn)
+	buff, _ := util.EncodeValue(sn)
 	return buff
 }
 
 func (sn *StorageNode) Decode(input []byte) error {
-	err := json.Unmarshal(input, sn)
+	err := util.DecodeValue(input, sn)
 	if err != nil {
 		return err
 	}
@@ -354,7 +356,7 @@ type StorageNodes struct {
 }
 
 func (sn *StorageNodes) Decode(input []byte) error {
-	err := json.Unmarshal(input, sn)
+	err := util.DecodeValue(input, sn)
 	if err != nil {
 		return err
 	}
@@ -362,7 +364,7 @@ func (sn *StorageNodes) Decode(input []byte) error {
 }
 
 func (sn *StorageNodes) Encode() []byte {
-	buff, _ := json.Marshal(sn)
+	buff, _ := util.EncodeValue(sn)
 	return buff
 }
 
@@ -563,6 +565,8 @@ type StorageAllocation struct {
 	ReadPriceRange             PriceRange    `json:"read_price_range"`
 	WritePriceRange            PriceRange    `json:"write_price_range"`
 	MaxChallengeCompletionTime time.Duration `json:"max_challenge_completion_time"`
+	// MinReputation is minimal reputation score of blobbers requested.
+	MinReputation float64 `json:"min_reputation,omitempty"`
 
 	// ChallengeCompletionTime is max challenge completion time of
 	// all blobbers of the allocation.
@@ -631,6 +635,10 @@ func (sa *StorageAllocation) validate(now common.Timestamp,
 		return errors.New("invalid number of data shards")
 	}
 
+	if sa.MinReputation < 0.0 || 1.0 < sa.MinReputation {
+		return errors.New("min_reputation not in [0; 1] range")
+	}
+
 	if sa.OwnerPublicKey == "" {
 		return errors.New("missing owner public key")
 	}
@@ -900,7 +908,7 @@ func (sn *StorageAllocation) GetKey(globalKey string) datastore.Key {
 }
 
 func (sn *StorageAllocation) Decode(input []byte) error {
-	err := json.Unmarshal(input, sn)
+	err := util.DecodeValue(input, sn)
 	if err != nil {
 		return err
 	}
@@ -915,7 +923,7 @@ func (sn *StorageAllocation) Decode(input []byte) error {
 }
 
 func (sn *StorageAllocation) Encode() []byte {
-	buff, _ := json.Marshal(sn)
+	buff, _ := util.EncodeValue(sn)
 	return buff
 }
 
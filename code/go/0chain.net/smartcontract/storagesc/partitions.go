@@ -0,0 +1,476 @@
+package storagesc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	cstate "0chain.net/chaincore/chain/state"
+	"0chain.net/core/common"
+	"0chain.net/core/datastore"
+	"0chain.net/core/encryption"
+	"0chain.net/core/util"
+)
+
+// sizes of the partitions of the registries, the size of a registry is
+// stored in its index node, thus changing a size here affects only
+// the registries created after the change
+const (
+	blobbersPartitionSize    = 50
+	validatorsPartitionSize  = 50
+	allocationsPartitionSize = 100
+)
+
+// names of the partitioned registries
+const (
+	allBlobbersName    = "all_blobbers"
+	allValidatorsName  = "all_validators"
+	allAllocationsName = "all_allocations"
+)
+
+func partitionsKey(name string) datastore.Key {
+	return datastore.Key(ADDRESS + encryption.Hash(name+":partitions"))
+}
+
+func partitionKey(name string, i int) datastore.Key {
+	return datastore.Key(ADDRESS +
+		encryption.Hash(name+":partition:"+strconv.Itoa(i)))
+}
+
+func partitionLocationKey(name, id string) datastore.Key {
+	return datastore.Key(ADDRESS + encryption.Hash(name+":location:"+id))
+}
+
+// partitionItem is an item of a partitioned registry, the data is
+// the JSON of the item, it can be empty for the lists of IDs
+type partitionItem struct {
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// partition is a fixed-size bucket of a registry
+type partition struct {
+	Items []*partitionItem `json:"items"`
+}
+
+func (pt *partition) Encode() []byte {
+	var b, _ = util.EncodeValue(pt)
+	return b
+}
+
+func (pt *partition) Decode(b []byte) error {
+	return util.DecodeValue(b, pt)
+}
+
+func (pt *partition) find(id string) (i int, ok bool) {
+	for i, it := range pt.Items {
+		if it.ID == id {
+			return i, true
+		}
+	}
+	return
+}
+
+// partitionLocation is a node referring the partition of an item
+type partitionLocation struct {
+	Partition int `json:"partition"`
+}
+
+func (pl *partitionLocation) Encode() []byte {
+	var b, _ = util.EncodeValue(pl)
+	return b
+}
+
+func (pl *partitionLocation) Decode(b []byte) error {
+	return util.DecodeValue(b, pl)
+}
+
+// partitions is the index node of a registry split into fixed-size
+// partitions; every partition and every item location is a node of its
+// own, thus adding, updating or removing an item touches a constant number
+// of nodes regardless the registry size; all partitions but the last one
+// are always full, that allows to pick a uniformly random item loading
+// a single partition
+type partitions struct {
+	Name          string `json:"name"`
+	PartitionSize int    `json:"partition_size"`
+	NumPartitions int    `json:"num_partitions"`
+	NumItems      int    `json:"num_items"`
+
+	// Legacy is the not partitioned list the registry is being migrated
+	// from by the migrate_registries function, and LegacyMigrated is the
+	// number of its items migrated; the registry isn't available until
+	// the migration is over
+	Legacy         datastore.Key `json:"legacy,omitempty"`
+	LegacyMigrated int           `json:"legacy_migrated,omitempty"`
+
+	loaded    map[int]*partition // loaded partitions
+	changed   map[int]bool       // changed partitions
+	locations map[string]int     // unsaved locations, -1 is removed
+	prevNum   int                // number of saved partitions
+}
+
+func newPartitions(name string, size int) (p *partitions) {
+	p = new(partitions)
+	p.Name = name
+	p.PartitionSize = size
+	p.init()
+	return
+}
+
+func (p *partitions) init() {
+	p.loaded = make(map[int]*partition)
+	p.changed = make(map[int]bool)
+	p.locations = make(map[string]int)
+	p.prevNum = p.NumPartitions
+}
+
+func (p *partitions) Encode() []byte {
+	var b, _ = util.EncodeValue(p)
+	return b
+}
+
+func (p *partitions) Decode(b []byte) error {
+	return util.DecodeValue(b, p)
+}
+
+// errRegistryNotMigrated is returned for a registry not migrated from
+// its legacy list yet
+var errRegistryNotMigrated = errors.New("registry is not migrated yet")
+
+// loadPartitions loads index of a registry, or returns a new one and false
+// if the registry doesn't exist yet
+func loadPartitions(name string, size int, balances cstate.StateContextI) (
+	p *partitions, ok bool, err error) {
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(partitionsKey(name))
+	if err == util.ErrValueNotPresent {
+		return newPartitions(name, size), false, nil
+	}
+	if err != nil {
+		return
+	}
+	p = new(partitions)
+	if err = p.Decode(val.Encode()); err != nil {
+		return nil, false, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	p.init()
+	return p, true, nil
+}
+
+// getPartitions loads index of a registry; a registry replacing a legacy
+// list isn't available until the list is migrated by the
+// migrate_registries function, the list is never decoded here
+func getPartitions(name string, size int, legacy datastore.Key,
+	balances cstate.StateContextI) (p *partitions, err error) {
+
+	var ok bool
+	if p, ok, err = loadPartitions(name, size, balances); err != nil {
+		return
+	}
+	if p.Legacy != "" {
+		return nil, fmt.Errorf("%w: %s", errRegistryNotMigrated, name)
+	}
+	if ok {
+		return
+	}
+	_, err = balances.GetTrieNode(legacy)
+	if err == util.ErrValueNotPresent {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s", errRegistryNotMigrated, name)
+}
+
+// getPartition loads i-th partition
+func (p *partitions) getPartition(i int, balances cstate.StateContextI) (
+	pt *partition, err error) {
+
+	if i < 0 || i >= p.NumPartitions {
+		return nil, fmt.Errorf("partition %d out of range", i)
+	}
+	if pt = p.loaded[i]; pt != nil {
+		return
+	}
+
+	var val util.Serializable
+	if val, err = balances.GetTrieNode(partitionKey(p.Name, i)); err != nil {
+		return nil, fmt.Errorf("getting partition %d: %v", i, err)
+	}
+	pt = new(partition)
+	if err = pt.Decode(val.Encode()); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	p.loaded[i] = pt
+	return
+}
+
+// location of an item, false if the registry doesn't have it
+func (p *partitions) location(id string, balances cstate.StateContextI) (
+	i int, ok bool, err error) {
+
+	if i, ok = p.locations[id]; ok {
+		return i, i >= 0, nil
+	}
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(partitionLocationKey(p.Name, id))
+	if err == util.ErrValueNotPresent {
+		return 0, false, nil
+	}
+	if err != nil {
+		return
+	}
+	var pl partitionLocation
+	if err = pl.Decode(val.Encode()); err != nil {
+		return 0, false, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return pl.Partition, true, nil
+}
+
+// get an item by its ID, util.ErrValueNotPresent if missing
+func (p *partitions) get(id string, balances cstate.StateContextI) (
+	it *partitionItem, err error) {
+
+	var i, ok, lerr = p.location(id, balances)
+	if lerr != nil {
+		return nil, lerr
+	}
+	if !ok {
+		return nil, util.ErrValueNotPresent
+	}
+
+	var pt *partition
+	if pt, err = p.getPartition(i, balances); err != nil {
+		return
+	}
+	var j int
+	if j, ok = pt.find(id); !ok {
+		return nil, fmt.Errorf("invalid state: item %s not found in"+
+			" partition %d of %s", id, i, p.Name)
+	}
+	return pt.Items[j], nil
+}
+
+func (p *partitions) has(id string, balances cstate.StateContextI) (
+	ok bool, err error) {
+
+	_, ok, err = p.location(id, balances)
+	return
+}
+
+// appendItem to the last partition, or to a new one, if the last is full;
+// the last partition must be loaded
+func (p *partitions) appendItem(it *partitionItem) {
+	var last = p.NumPartitions - 1
+	if last < 0 || len(p.loaded[last].Items) >= p.PartitionSize {
+		last = p.NumPartitions
+		p.NumPartitions++
+		p.loaded[last] = new(partition)
+	}
+	var pt = p.loaded[last]
+	pt.Items = append(pt.Items, it)
+	p.changed[last] = true
+	p.locations[it.ID] = last
+	p.NumItems++
+}
+
+// add an item or replace existing one
+func (p *partitions) add(it *partitionItem, balances cstate.StateContextI) (
+	err error) {
+
+	var ok bool
+	if ok, err = p.update(it, balances); err != nil || ok {
+		return
+	}
+	if p.NumPartitions > 0 {
+		if _, err = p.getPartition(p.NumPartitions-1, balances); err != nil {
+			return
+		}
+	}
+	p.appendItem(it)
+	return
+}
+
+// update an existing item, false if the registry doesn't have it
+func (p *partitions) update(it *partitionItem, balances cstate.StateContextI) (
+	ok bool, err error) {
+
+	var i int
+	if i, ok, err = p.location(it.ID, balances); err != nil || !ok {
+		return
+	}
+	var pt *partition
+	if pt, err = p.getPartition(i, balances); err != nil {
+		return false, err
+	}
+	var j int
+	if j, ok = pt.find(it.ID); !ok {
+		return false, fmt.Errorf("invalid state: item %s not found in"+
+			" partition %d of %s", it.ID, i, p.Name)
+	}
+	pt.Items[j] = it
+	p.changed[i] = true
+	return true, nil
+}
+
+// remove an item moving the last item of the last partition in its place,
+// false if the registry doesn't have it
+func (p *partitions) remove(id string, balances cstate.StateContextI) (
+	ok bool, err error) {
+
+	var i int
+	if i, ok, err = p.location(id, balances); err != nil || !ok {
+		return
+	}
+
+	var pt, lpt *partition
+	if pt, err = p.getPartition(i, balances); err != nil {
+		return false, err
+	}
+	var last = p.NumPartitions - 1
+	if lpt, err = p.getPartition(last, balances); err != nil {
+		return false, err
+	}
+
+	var j int
+	if j, ok = pt.find(id); !ok {
+		return false, fmt.Errorf("invalid state: item %s not found in"+
+			" partition %d of %s", id, i, p.Name)
+	}
+
+	var moved = lpt.Items[len(lpt.Items)-1]
+	lpt.Items = lpt.Items[:len(lpt.Items)-1]
+	if moved.ID != id {
+		pt.Items[j] = moved
+		p.locations[moved.ID] = i
+	}
+	p.changed[i], p.changed[last] = true, true
+	p.locations[id] = -1
+	p.NumItems--
+
+	if len(lpt.Items) == 0 {
+		p.NumPartitions--
+		delete(p.loaded, last)
+		delete(p.changed, last)
+	}
+	return true, nil
+}
+
+// all items of the registry, used by the REST handlers and the
+// transactions that process every item anyway
+func (p *partitions) all(balances cstate.StateContextI) (
+	items []*partitionItem, err error) {
+
+	items = make([]*partitionItem, 0, p.NumItems)
+	for i := 0; i < p.NumPartitions; i++ {
+		var pt *partition
+		if pt, err = p.getPartition(i, balances); err != nil {
+			return nil, err
+		}
+		items = append(items, pt.Items...)
+	}
+	return
+}
+
+// random item of the registry, every item has the same chance
+func (p *partitions) random(r *rand.Rand, balances cstate.StateContextI) (
+	it *partitionItem, err error) {
+
+	if p.NumItems == 0 {
+		return nil, errors.New("empty " + p.Name)
+	}
+	return p.itemAt(r.Intn(p.NumItems), balances)
+}
+
+// itemAt returns n-th item of the registry, all partitions except the last
+// one are full, thus the item is in the n / size partition
+func (p *partitions) itemAt(n int, balances cstate.StateContextI) (
+	it *partitionItem, err error) {
+
+	if n < 0 || n >= p.NumItems {
+		return nil, fmt.Errorf("item %d of %s out of range", n, p.Name)
+	}
+	var pt *partition
+	if pt, err = p.getPartition(n/p.PartitionSize, balances); err != nil {
+		return
+	}
+	if n%p.PartitionSize >= len(pt.Items) {
+		return nil, fmt.Errorf("invalid state: partition %d of %s isn't full",
+			n/p.PartitionSize, p.Name)
+	}
+	return pt.Items[n%p.PartitionSize], nil
+}
+
+// sample calls the given function for partitions in random order until
+// the function returns false or the partitions are over
+func (p *partitions) sample(r *rand.Rand, balances cstate.StateContextI,
+	f func(pt *partition) (next bool, err error)) (err error) {
+
+	for _, i := range r.Perm(p.NumPartitions) {
+		var pt *partition
+		if pt, err = p.getPartition(i, balances); err != nil {
+			return
+		}
+		var next bool
+		if next, err = f(pt); err != nil || !next {
+			return
+		}
+	}
+	return
+}
+
+// save changed partitions, locations and the index
+func (p *partitions) save(balances cstate.StateContextI) (err error) {
+	var changed = make([]int, 0, len(p.changed))
+	for i := range p.changed {
+		changed = append(changed, i)
+	}
+	sort.Ints(changed)
+	for _, i := range changed {
+		_, err = balances.InsertTrieNode(partitionKey(p.Name, i), p.loaded[i])
+		if err != nil {
+			return fmt.Errorf("saving partition %d: %v", i, err)
+		}
+	}
+	for i := p.NumPartitions; i < p.prevNum; i++ {
+		if _, err = balances.DeleteTrieNode(partitionKey(p.Name, i)); err != nil {
+			return fmt.Errorf("removing partition %d: %v", i, err)
+		}
+	}
+
+	var ids = make([]string, 0, len(p.locations))
+	for id := range p.locations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		var key = partitionLocationKey(p.Name, id)
+		if i := p.locations[id]; i < 0 {
+			_, err = balances.DeleteTrieNode(key)
+			if err == util.ErrValueNotPresent || err == util.ErrNodeNotFound {
+				err = nil // added and removed by the same transaction
+			}
+		} else {
+			_, err = balances.InsertTrieNode(key, &partitionLocation{i})
+		}
+		if err != nil {
+			return fmt.Errorf("saving location of %s: %v", id, err)
+		}
+	}
+
+	if _, err = balances.InsertTrieNode(partitionsKey(p.Name), p); err != nil {
+		return fmt.Errorf("saving %s index: %v", p.Name, err)
+	}
+
+	p.changed = make(map[int]bool)
+	p.locations = make(map[string]int)
+	p.prevNum = p.NumPartitions
+	return
+}
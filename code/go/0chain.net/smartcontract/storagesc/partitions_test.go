@@ -0,0 +1,388 @@
+package storagesc
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"0chain.net/core/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPartitions(t testing.TB, balances *testBalances, name string,
+	size, n int) (p *partitions) {
+
+	p = newPartitions(name, size)
+	for i := 0; i < n; i++ {
+		p.appendItem(&partitionItem{ID: fmt.Sprintf("item_%d", i)})
+	}
+	require.NoError(t, p.save(balances))
+	return
+}
+
+func requirePartitionsValid(t *testing.T, p *partitions,
+	balances *testBalances) {
+
+	var items, err = p.all(balances)
+	require.NoError(t, err)
+	require.Len(t, items, p.NumItems)
+	for i := 0; i < p.NumPartitions; i++ {
+		var pt, err = p.getPartition(i, balances)
+		require.NoError(t, err)
+		if i < p.NumPartitions-1 {
+			require.Len(t, pt.Items, p.PartitionSize)
+		}
+		require.NotEmpty(t, pt.Items)
+		for _, it := range pt.Items {
+			var loc, ok, err = p.location(it.ID, balances)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, i, loc)
+		}
+	}
+}
+
+func Test_partitions(t *testing.T) {
+	var (
+		balances = newTestBalances(t, false)
+		p        = newTestPartitions(t, balances, "test", 3, 7)
+		err      error
+	)
+
+	p, err = getPartitions("test", 10, "", balances)
+	require.NoError(t, err)
+	require.Equal(t, 3, p.PartitionSize)
+	require.Equal(t, 3, p.NumPartitions)
+	require.Equal(t, 7, p.NumItems)
+	requirePartitionsValid(t, p, balances)
+
+	// add, update
+	require.NoError(t, p.add(&partitionItem{ID: "item_7"}, balances))
+	require.NoError(t, p.add(&partitionItem{ID: "item_7",
+		Data: []byte(`"data"`)}, balances))
+	require.Equal(t, 8, p.NumItems)
+	var ok bool
+	ok, err = p.update(&partitionItem{ID: "item_8"}, balances)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, p.save(balances))
+
+	var it *partitionItem
+	it, err = p.get("item_7", balances)
+	require.NoError(t, err)
+	require.Equal(t, `"data"`, string(it.Data))
+	_, err = p.get("item_8", balances)
+	require.Equal(t, util.ErrValueNotPresent, err)
+
+	// remove
+	for _, id := range []string{"item_0", "item_7", "item_6", "item_4"} {
+		ok, err = p.remove(id, balances)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	ok, err = p.remove("item_0", balances)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, p.save(balances))
+
+	p, err = getPartitions("test", 3, "", balances)
+	require.NoError(t, err)
+	require.Equal(t, 2, p.NumPartitions)
+	require.Equal(t, 4, p.NumItems)
+	requirePartitionsValid(t, p, balances)
+	_, err = balances.GetTrieNode(partitionKey("test", 2))
+	require.Equal(t, util.ErrValueNotPresent, err)
+	_, err = balances.GetTrieNode(partitionLocationKey("test", "item_0"))
+	require.Equal(t, util.ErrValueNotPresent, err)
+
+	// random
+	var (
+		r    = rand.New(rand.NewSource(1))
+		seen = make(map[string]bool)
+	)
+	for i := 0; i < 100; i++ {
+		it, err = p.random(r, balances)
+		require.NoError(t, err)
+		seen[it.ID] = true
+	}
+	require.Len(t, seen, 4)
+}
+
+func Test_partitions_migration(t *testing.T) {
+	var (
+		balances = newTestBalances(t, false)
+		ssc      = newTestStorageSC()
+		blobbers = new(StorageNodes)
+		all      = &Allocations{}
+		tx       = newTransaction(owner, ssc.ID, 0, 0)
+		resp     string
+		err      error
+	)
+	blobbers.Nodes.add(&StorageNode{ID: "b1", BaseURL: "http://b1"})
+	mustSave(t, ALL_BLOBBERS_KEY, blobbers, balances)
+	for i := 0; i < 2*maxMigratedItems+250; i++ {
+		all.List.add(fmt.Sprintf("alloc_%05d", i))
+	}
+	mustSave(t, ALL_ALLOCATIONS_KEY, all, balances)
+
+	_, err = ssc.getBlobbersPartitions(balances)
+	require.True(t, errors.Is(err, errRegistryNotMigrated))
+	_, err = ssc.getAllocationsPartitions(balances)
+	require.True(t, errors.Is(err, errRegistryNotMigrated))
+	var p *partitions
+	p, err = ssc.getValidatorsPartitions(balances)
+	require.NoError(t, err) // no legacy list
+	require.Zero(t, p.NumItems)
+
+	_, err = ssc.migrateRegistries(newTransaction("client", ssc.ID, 0, 0),
+		nil, balances)
+	requireErrMsg(t, err, "migrate_registries: unauthorized access - "+
+		"only the owner can migrate the registries")
+
+	// the blobber and a part of the allocations
+	resp, err = ssc.migrateRegistries(tx, nil, balances)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"migrated":5000,"done":false}`, resp)
+
+	p, err = ssc.getBlobbersPartitions(balances)
+	require.NoError(t, err)
+	require.Equal(t, 1, p.NumItems)
+	var id string
+	id, err = ssc.getBlobberIDByURL(p, "http://b1", balances)
+	require.NoError(t, err)
+	require.Equal(t, "b1", id)
+	_, err = balances.GetTrieNode(ALL_BLOBBERS_KEY)
+	require.Equal(t, util.ErrValueNotPresent, err)
+
+	_, err = ssc.getAllocationsPartitions(balances)
+	require.True(t, errors.Is(err, errRegistryNotMigrated))
+	p, _, err = loadPartitions(allAllocationsName, allocationsPartitionSize,
+		balances)
+	require.NoError(t, err)
+	require.EqualValues(t, ALL_ALLOCATIONS_KEY, p.Legacy)
+	require.Equal(t, maxMigratedItems-1, p.LegacyMigrated)
+	require.Equal(t, maxMigratedItems-1, p.NumItems)
+
+	resp, err = ssc.migrateRegistries(tx, nil, balances)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"migrated":5000,"done":false}`, resp)
+	resp, err = ssc.migrateRegistries(tx, nil, balances)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"migrated":251,"done":true}`, resp)
+	resp, err = ssc.migrateRegistries(tx, nil, balances)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"migrated":0,"done":true}`, resp)
+
+	_, err = balances.GetTrieNode(ALL_ALLOCATIONS_KEY)
+	require.Equal(t, util.ErrValueNotPresent, err)
+	p, err = ssc.getAllocationsPartitions(balances)
+	require.NoError(t, err)
+	require.Empty(t, p.Legacy)
+	require.Equal(t, len(all.List), p.NumItems)
+	requirePartitionsValid(t, p, balances)
+
+	var ok bool
+	ok, err = p.remove("alloc_00100", balances)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, p.save(balances))
+
+	all.List.remove("alloc_00100")
+	var list *Allocations
+	list, err = ssc.getAllAllocationsList(balances)
+	require.NoError(t, err)
+	require.EqualValues(t, all.List, list.List)
+}
+
+func Test_blobbersPartitions_url(t *testing.T) {
+	var (
+		balances = newTestBalances(t, false)
+		ssc      = newTestStorageSC()
+		all      = new(StorageNodes)
+	)
+	all.Nodes.add(&StorageNode{ID: "b1", BaseURL: "http://b1"})
+	all.Nodes.add(&StorageNode{ID: "b2", BaseURL: "http://b2"})
+	mustSave(t, ALL_BLOBBERS_KEY, all, balances)
+	mustMigrateRegistries(t, ssc, balances)
+
+	var p, err = ssc.getBlobbersPartitions(balances)
+	require.NoError(t, err)
+	var id string
+	id, err = ssc.getBlobberIDByURL(p, "http://b2", balances)
+	require.NoError(t, err)
+	require.Equal(t, "b2", id)
+
+	require.NoError(t, addBlobberToAll(p, &StorageNode{ID: "b2",
+		BaseURL: "http://b3"}, balances))
+	require.NoError(t, p.save(balances))
+
+	p, err = ssc.getBlobbersPartitions(balances)
+	require.NoError(t, err)
+	_, err = ssc.getBlobberIDByURL(p, "http://b2", balances)
+	require.Equal(t, util.ErrValueNotPresent, err)
+	id, err = ssc.getBlobberIDByURL(p, "http://b3", balances)
+	require.NoError(t, err)
+	require.Equal(t, "b2", id)
+	id, err = ssc.getBlobberIDByURL(p, "http://b1", balances)
+	require.NoError(t, err)
+	require.Equal(t, "b1", id)
+
+	var ok bool
+	ok, err = removeBlobberFromAll(p, "b1", balances)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, p.save(balances))
+	_, err = ssc.getBlobberIDByURL(p, "http://b1", balances)
+	require.Equal(t, util.ErrValueNotPresent, err)
+
+	var list *StorageNodes
+	list, err = ssc.getBlobbersList(balances)
+	require.NoError(t, err)
+	require.Len(t, list.Nodes, 1)
+	require.Equal(t, "http://b3", list.Nodes[0].BaseURL)
+}
+
+const (
+	benchBlobbers    = 10 * 1000
+	benchAllocations = 1000 * 1000
+)
+
+func newBenchBlobbers(b *testing.B, balances *testBalances) (
+	all *StorageNodes, p *partitions) {
+
+	all = new(StorageNodes)
+	p = newPartitions(allBlobbersName, blobbersPartitionSize)
+	for i := 0; i < benchBlobbers; i++ {
+		var sn = &StorageNode{
+			ID:       fmt.Sprintf("blobber_%05d", i),
+			BaseURL:  fmt.Sprintf("http://blobber_%05d", i),
+			Capacity: 10 * GB,
+		}
+		all.Nodes = append(all.Nodes, sn)
+		p.appendItem(newBlobberItem(sn))
+	}
+	require.NoError(b, p.save(balances))
+	return
+}
+
+// the legacy list is loaded and saved entirely by every transaction
+// changing a blobber, and the partitioned registry loads a partition
+func Benchmark_blobbersRegistry(b *testing.B) {
+	var (
+		balances = newTestBalances(b, false)
+		all, _   = newBenchBlobbers(b, balances)
+		ssc      = newTestStorageSC()
+	)
+	mustSave(b, ALL_BLOBBERS_KEY, all, balances)
+
+	b.Run("legacy update", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var val, err = balances.GetTrieNode(ALL_BLOBBERS_KEY)
+			require.NoError(b, err)
+			var list StorageNodes
+			require.NoError(b, list.Decode(val.Encode()))
+			var sn = list.Nodes[i%benchBlobbers]
+			sn.Used++
+			list.Nodes.update(sn)
+			balances.InsertTrieNode(ALL_BLOBBERS_KEY, &list)
+		}
+	})
+
+	b.Run("partitions update", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var p, err = ssc.getBlobbersPartitions(balances)
+			require.NoError(b, err)
+			var it *partitionItem
+			it, err = p.get(fmt.Sprintf("blobber_%05d", i%benchBlobbers),
+				balances)
+			require.NoError(b, err)
+			var sn *StorageNode
+			sn, err = blobberOfItem(it)
+			require.NoError(b, err)
+			sn.Used++
+			_, err = updateBlobberInAll(p, sn, balances)
+			require.NoError(b, err)
+			require.NoError(b, p.save(balances))
+		}
+	})
+
+	b.Run("partitions sample", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var p, err = ssc.getBlobbersPartitions(balances)
+			require.NoError(b, err)
+			var (
+				r    = rand.New(rand.NewSource(int64(i)))
+				list []*StorageNode
+			)
+			err = p.sample(r, balances, func(pt *partition) (bool, error) {
+				var nodes, err = blobbersOfItems(pt.Items)
+				list = append(list, nodes...)
+				return len(list) < 10*blobbersSampleFactor, err
+			})
+			require.NoError(b, err)
+		}
+	})
+}
+
+func Benchmark_allocationsRegistry(b *testing.B) {
+	var (
+		balances = newTestBalances(b, false)
+		ssc      = newTestStorageSC()
+		all      = &Allocations{List: make(sortedList, 0, benchAllocations)}
+	)
+	for i := 0; i < benchAllocations; i++ {
+		all.List = append(all.List, fmt.Sprintf("alloc_%07d", i))
+	}
+	mustSave(b, ALL_ALLOCATIONS_KEY, all, balances)
+
+	b.Run("legacy add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var val, err = balances.GetTrieNode(ALL_ALLOCATIONS_KEY)
+			require.NoError(b, err)
+			var list Allocations
+			require.NoError(b, list.Decode(val.Encode()))
+			list.List.add(fmt.Sprintf("new_%d", i))
+			balances.InsertTrieNode(ALL_ALLOCATIONS_KEY, &list)
+		}
+	})
+
+	mustSave(b, ALL_ALLOCATIONS_KEY, all, balances)
+	var rm = registryMigrations[2] // allocations at once
+	var _, done, err = rm.migrate(benchAllocations, balances)
+	require.NoError(b, err)
+	require.True(b, done)
+
+	b.Run("partitions add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var p, err = ssc.getAllocationsPartitions(balances)
+			require.NoError(b, err)
+			err = p.add(&partitionItem{ID: fmt.Sprintf("new_%d", i)},
+				balances)
+			require.NoError(b, err)
+			require.NoError(b, p.save(balances))
+		}
+	})
+
+	b.Run("partitions remove", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var p, err = ssc.getAllocationsPartitions(balances)
+			require.NoError(b, err)
+			_, err = p.remove(fmt.Sprintf("alloc_%07d", i%benchAllocations),
+				balances)
+			require.NoError(b, err)
+			require.NoError(b, p.save(balances))
+		}
+	})
+
+	b.Run("partitions random", func(b *testing.B) {
+		var r = rand.New(rand.NewSource(1))
+		for i := 0; i < b.N; i++ {
+			var p, err = ssc.getAllocationsPartitions(balances)
+			require.NoError(b, err)
+			_, err = p.random(r, balances)
+			require.NoError(b, err)
+		}
+	})
+}
@@ -39,6 +39,12 @@ func (rp *readPool) removeEmpty(allocID string, ap []*allocationPool) {
 	rp.Pools.removeEmpty(allocID, ap)
 }
 
+// replaceBlobber moves tokens of the blobber of the allocation to
+// its replacement
+func (rp *readPool) replaceBlobber(allocID, oldID, newID string) {
+	rp.Pools.replaceBlobber(allocID, oldID, newID)
+}
+
 // Encode implements util.Serializable interface.
 func (rp *readPool) Encode() []byte {
 	var b, err = json.Marshal(rp)
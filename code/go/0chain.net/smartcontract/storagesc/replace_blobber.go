@@ -0,0 +1,470 @@
+package storagesc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	chainstate "0chain.net/chaincore/chain/state"
+	"0chain.net/chaincore/state"
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+	"0chain.net/core/util"
+)
+
+// replaceBlobberRequest is request to replace a blobber of an allocation.
+type replaceBlobberRequest struct {
+	AllocationID string `json:"allocation_id"`
+	// BlobberID is ID of the blobber to replace.
+	BlobberID string `json:"blobber_id"`
+	// PreferredBlobber is optional URL of the replacement, a random blobber
+	// matching the allocation is selected if it's empty.
+	PreferredBlobber string `json:"preferred_blobber,omitempty"`
+}
+
+func (rbr *replaceBlobberRequest) decode(b []byte) error {
+	return json.Unmarshal(b, rbr)
+}
+
+// selectReplacementBlobber returns the preferred blobber or a random one,
+// matching the allocation and not being a blobber of the allocation
+func (sc *StorageSmartContract) selectReplacementBlobber(
+	t *transaction.Transaction, conf *scConfig, all *partitions,
+	alloc *StorageAllocation, size int64, preferred string,
+	balances chainstate.StateContextI) (blobber *StorageNode, err error) {
+
+	var filters = sc.allocationBlobberFilters(t.CreationDate, conf, alloc,
+		size, balances)
+
+	if preferred != "" {
+		var id string
+		id, err = sc.getBlobberIDByURL(all, preferred, balances)
+		if err == util.ErrValueNotPresent {
+			return nil, errors.New("invalid preferred blobber URL")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting preferred blobber: %v", err)
+		}
+		if _, ok := alloc.BlobberMap[id]; ok {
+			return nil, errors.New("preferred blobber is already" +
+				" a blobber of the allocation")
+		}
+		var it *partitionItem
+		if it, err = all.get(id, balances); err != nil {
+			return nil, fmt.Errorf("getting preferred blobber: %v", err)
+		}
+		if blobber, err = blobberOfItem(it); err != nil {
+			return nil, fmt.Errorf("decoding preferred blobber: %v", err)
+		}
+		var list = alloc.filterBlobbers([]*StorageNode{blobber},
+			t.CreationDate, size, filters...)
+		if len(list) == 0 {
+			return nil, errors.New("preferred blobber doesn't match" +
+				" the allocation")
+		}
+		return blobber, nil
+	}
+
+	var seed int64
+	if seed, err = strconv.ParseInt(t.Hash[0:8], 16, 64); err != nil {
+		return nil, errors.New("failed to create seed")
+	}
+
+	var (
+		r    = rand.New(rand.NewSource(seed))
+		list []*StorageNode
+	)
+	err = all.sample(r, balances, func(pt *partition) (bool, error) {
+		var nodes, err = blobbersOfItems(pt.Items)
+		if err != nil {
+			return false, err
+		}
+		var i int
+		for _, b := range nodes {
+			if _, ok := alloc.BlobberMap[b.ID]; !ok {
+				nodes[i], i = b, i+1 // skip blobbers of the allocation
+			}
+		}
+		list = append(list, alloc.filterBlobbers(nodes[:i], t.CreationDate,
+			size, filters...)...)
+		return len(list) < blobbersSampleFactor, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sampling blobbers: %v", err)
+	}
+	if len(list) == 0 {
+		return nil, errors.New("no blobbers matching the allocation")
+	}
+
+	return randomizeNodesByReputation(list, nil, 1, seed,
+		conf.reputation())[0], nil
+}
+
+// removeAllocationChallenges removes open challenges of the allocation
+// relinking the rest ones, it returns number of removed challenges
+func (sn *BlobberChallenge) removeAllocationChallenges(allocID string) (
+	removed int) {
+
+	var (
+		prevID string
+		i      int
+	)
+	if sn.LatestCompletedChallenge != nil {
+		prevID = sn.LatestCompletedChallenge.ID
+	}
+	for _, c := range sn.Challenges {
+		if c.AllocationID == allocID {
+			delete(sn.ChallengeMap, c.ID)
+			removed++
+			continue
+		}
+		c.PrevID, prevID = prevID, c.ID
+		sn.Challenges[i], i = c, i+1
+	}
+	sn.Challenges = sn.Challenges[:i]
+	return
+}
+
+// settleReplacedBlobber pays the replaced blobber its part of the challenge
+// pool by its challenges pass rate and the rest of its min_lock_demand;
+// the rest of its challenge pool part is moved back to the write pool
+// for the replacement blobber, its read pool tokens are moved to the
+// replacement too; the read pool is optional
+func (sc *StorageSmartContract) settleReplacedBlobber(
+	t *transaction.Transaction, conf *scConfig, alloc *StorageAllocation,
+	d *BlobberAllocation, replacementID string, wp *writePool, rp *readPool,
+	cp *challengePool, sp *stakePool, balances chainstate.StateContextI) (
+	err error) {
+
+	var open int64
+	if d.Stats != nil {
+		open = d.Stats.OpenChallenges
+	}
+
+	var passRate float64
+	if passRate, _, err = sc.canceledPassRate(d, t.CreationDate,
+		balances); err != nil {
+		return fmt.Errorf("calculating challenges pass rate: %v", err)
+	}
+
+	if alloc.Stats == nil {
+		alloc.Stats = &StorageAllocationStats{}
+	}
+	if alloc.Stats.OpenChallenges -= open; alloc.Stats.OpenChallenges < 0 {
+		alloc.Stats.OpenChallenges = 0
+	}
+
+	// the challenge pool part of the blobber by the pass rate
+	var share = minBalance(d.ChallengePoolIntegralValue, cp.Balance)
+	if move := state.Balance(float64(share) * passRate); move > 0 {
+		var reward state.Balance
+		reward, err = transferReward(sc.ID, *cp.ZcnPool, sp, move, balances)
+		if err != nil {
+			return fmt.Errorf("moving tokens to stake pool of %s: %v",
+				d.BlobberID, err)
+		}
+		sp.Rewards.Blobber += reward
+		cp.Balance -= move
+		d.Spent += move
+		d.FinalReward += move
+		share -= move
+	}
+	d.ChallengePoolIntegralValue = 0
+
+	// the min_lock_demand rest, if it isn't revoked by failed challenges
+	var fctrml = conf.FailedChallengesToRevokeMinLock
+	if d.Stats == nil || d.Stats.FailedChallenges < int64(fctrml) {
+		var lack = minBalance(d.MinLockDemand-d.Spent,
+			wp.blobberBalance(alloc.ID, d.BlobberID, t.CreationDate))
+		if lack > 0 {
+			err = wp.moveToChallenge(alloc.ID, d.BlobberID, cp,
+				t.CreationDate, lack)
+			if err != nil {
+				return fmt.Errorf("moving min_lock_demand of %s to"+
+					" challenge pool: %v", d.BlobberID, err)
+			}
+			var reward state.Balance
+			reward, err = transferReward(sc.ID, *cp.ZcnPool, sp, lack,
+				balances)
+			if err != nil {
+				return fmt.Errorf("paying min_lock for %s: %v", d.BlobberID,
+					err)
+			}
+			sp.Rewards.Blobber += reward
+			cp.Balance -= lack
+			d.Spent += lack
+			d.FinalReward += lack
+		}
+	}
+
+	// re-lock the rest tokens of the blobber for its replacement
+	wp.replaceBlobber(alloc.ID, d.BlobberID, replacementID)
+	if rp != nil {
+		rp.replaceBlobber(alloc.ID, d.BlobberID, replacementID)
+	}
+	alloc.MovedBack += share
+	err = cp.moveToWritePool(alloc.ID, replacementID, alloc.Until(), wp, share)
+	if err != nil {
+		return fmt.Errorf("moving challenge pool part of %s back to"+
+			" write pool: %v", d.BlobberID, err)
+	}
+
+	return
+}
+
+// replaceBlobberRequest replaces a blobber of an allocation with the
+// preferred or a random blobber matching the allocation. The replaced
+// blobber gets its challenge pool part by its challenges pass rate and the
+// rest of its min_lock_demand, as the cancel_allocation does. Rest tokens
+// of the replaced blobber in the write pool and in the owner's read pool
+// are re-locked for the replacement, and the transaction can lock more
+// tokens for it; the tokens should cover the min_lock_demand of the
+// replacement.
+func (sc *StorageSmartContract) replaceBlobberRequest(
+	t *transaction.Transaction, input []byte,
+	balances chainstate.StateContextI) (resp string, err error) {
+
+	var req replaceBlobberRequest
+	if err = req.decode(input); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"malformed request: "+err.Error())
+	}
+	if req.AllocationID == "" || req.BlobberID == "" {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"missing allocation_id or blobber_id")
+	}
+
+	var alloc *StorageAllocation
+	if alloc, err = sc.getAllocation(req.AllocationID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get allocation: "+err.Error())
+	}
+
+	if alloc.Owner != t.ClientID {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"only owner can replace a blobber of an allocation")
+	}
+
+	if alloc.Finalized {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"allocation is finalized")
+	}
+
+	if alloc.Expiration < t.CreationDate {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"trying to replace a blobber of expired allocation")
+	}
+
+	var (
+		i = -1
+		d *BlobberAllocation
+	)
+	for j, bd := range alloc.BlobberDetails {
+		if bd.BlobberID == req.BlobberID {
+			i, d = j, bd
+			break
+		}
+	}
+	if d == nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"blobber is not a blobber of the allocation")
+	}
+
+	var conf *scConfig
+	if conf, err = sc.getConfig(balances, true); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get SC configurations: "+err.Error())
+	}
+
+	var all *partitions
+	if all, err = sc.getBlobbersPartitions(balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get all blobbers list: "+err.Error())
+	}
+
+	var selected *StorageNode
+	selected, err = sc.selectReplacementBlobber(t, conf, all, alloc, d.Size,
+		req.PreferredBlobber, balances)
+	if err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"selecting replacement blobber: "+err.Error())
+	}
+
+	// related pools
+
+	var wp *writePool
+	if wp, err = sc.getWritePool(alloc.Owner, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get write pool: "+err.Error())
+	}
+
+	// the owner can have no read pool
+	var rp *readPool
+	rp, err = sc.getReadPool(alloc.Owner, balances)
+	if err != nil && err != util.ErrValueNotPresent {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get read pool: "+err.Error())
+	}
+
+	var cp *challengePool
+	if cp, err = sc.getChallengePool(alloc.ID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get challenge pool: "+err.Error())
+	}
+
+	var osp, nsp *stakePool
+	if osp, err = sc.getStakePool(d.BlobberID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get stake pool of "+d.BlobberID+": "+err.Error())
+	}
+	if nsp, err = sc.getStakePool(selected.ID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get stake pool of "+selected.ID+": "+err.Error())
+	}
+
+	// settle the replaced blobber
+
+	err = sc.settleReplacedBlobber(t, conf, alloc, d, selected.ID, wp, rp,
+		cp, osp, balances)
+	if err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed", err.Error())
+	}
+
+	delete(osp.Offers, alloc.ID)
+
+	// the replacement
+
+	var nd = &BlobberAllocation{
+		BlobberID:    selected.ID,
+		AllocationID: alloc.ID,
+		Size:         d.Size,
+		Terms:        selected.Terms,
+		Stats:        &StorageAllocationStats{},
+	}
+	nd.MinLockDemand = nd.Terms.minLockDemand(sizeInGB(nd.Size),
+		alloc.restDurationInTimeUnits(t.CreationDate))
+
+	if d.Stats != nil {
+		alloc.Stats.UsedSize -= d.Stats.UsedSize // lost with the blobber
+	}
+	alloc.BlobberDetails[i] = nd
+	delete(alloc.BlobberMap, d.BlobberID)
+	alloc.BlobberMap[nd.BlobberID] = nd
+
+	var blobbers []*StorageNode
+	for _, b := range alloc.Blobbers {
+		if b.ID != d.BlobberID {
+			blobbers = append(blobbers, b)
+		}
+	}
+	blobbers = append(blobbers, selected)
+	sort.SliceStable(blobbers, func(i, j int) bool {
+		return blobbers[i].ID < blobbers[j].ID
+	})
+	alloc.Blobbers = blobbers
+
+	// challenge_completion_time can be increased only
+	var cctIncreased bool
+	if selected.Terms.ChallengeCompletionTime > alloc.ChallengeCompletionTime {
+		alloc.ChallengeCompletionTime = selected.Terms.ChallengeCompletionTime
+		cctIncreased = true
+	}
+
+	nsp.addOffer(alloc, nd)
+
+	// lock tokens for the replacement only, if this transaction provides them
+	if t.Value > 0 {
+		var only = &StorageAllocation{
+			ID:             alloc.ID,
+			BlobberDetails: []*BlobberAllocation{nd},
+		}
+		if _, err = wp.fill(t, only, alloc.Until(), false, balances); err != nil {
+			return "", common.NewError("alloc_replace_blobber_failed",
+				"write pool filling: "+err.Error())
+		}
+	}
+
+	var locked = wp.blobberBalance(alloc.ID, nd.BlobberID, t.CreationDate)
+	if locked < nd.MinLockDemand {
+		return "", common.NewErrorf("alloc_replace_blobber_failed",
+			"not enough tokens in write pool to honor min_lock_demand of"+
+				" the replacement blobber: %d < %d", locked, nd.MinLockDemand)
+	}
+
+	// save all
+
+	var bc *BlobberChallenge
+	bc, err = sc.getBlobberChallenge(d.BlobberID, balances)
+	if err != nil && err != util.ErrValueNotPresent {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get blobber challenges: "+err.Error())
+	}
+	if err == nil && bc.removeAllocationChallenges(alloc.ID) > 0 {
+		_, err = balances.InsertTrieNode(bc.GetKey(sc.ID), bc)
+		if err != nil {
+			return "", common.NewError("alloc_replace_blobber_failed",
+				"saving blobber challenges: "+err.Error())
+		}
+	}
+
+	if err = osp.save(sc.ID, d.BlobberID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"saving stake pool of "+d.BlobberID+": "+err.Error())
+	}
+	if err = nsp.save(sc.ID, nd.BlobberID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"saving stake pool of "+nd.BlobberID+": "+err.Error())
+	}
+	if cctIncreased {
+		for _, ba := range alloc.BlobberDetails {
+			if ba == nd {
+				continue // already added with the new expiration
+			}
+			if err = sc.updateSakePoolOffer(ba, alloc, balances); err != nil {
+				return "", common.NewError("alloc_replace_blobber_failed",
+					err.Error())
+			}
+		}
+	}
+
+	if err = wp.save(sc.ID, alloc.Owner, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"saving write pool: "+err.Error())
+	}
+	if rp != nil {
+		if err = rp.save(sc.ID, alloc.Owner, balances); err != nil {
+			return "", common.NewError("alloc_replace_blobber_failed",
+				"saving read pool: "+err.Error())
+		}
+	}
+	if err = cp.save(sc.ID, alloc.ID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"saving challenge pool: "+err.Error())
+	}
+
+	// update blobbers used capacity
+
+	var replaced, replacement *StorageNode
+	if replaced, err = sc.getBlobber(d.BlobberID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get blobber "+d.BlobberID+": "+err.Error())
+	}
+	if replacement, err = sc.getBlobber(nd.BlobberID, balances); err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"can't get blobber "+nd.BlobberID+": "+err.Error())
+	}
+	replaced.Used -= d.Size
+	replacement.Used += nd.Size
+
+	err = sc.saveUpdatedAllocation(all, alloc,
+		[]*StorageNode{replaced, replacement}, balances)
+	if err != nil {
+		return "", common.NewError("alloc_replace_blobber_failed",
+			"saving allocation: "+err.Error())
+	}
+
+	return string(alloc.Encode()), nil
+}
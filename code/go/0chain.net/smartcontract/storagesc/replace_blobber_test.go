@@ -0,0 +1,406 @@
+package storagesc
+
+import (
+	"testing"
+	"time"
+
+	"0chain.net/chaincore/transaction"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobberChallenge_removeAllocationChallenges(t *testing.T) {
+	var bc BlobberChallenge
+	bc.LatestCompletedChallenge = &StorageChallenge{ID: "c0"}
+	for _, c := range []*StorageChallenge{
+		{ID: "c1", AllocationID: "a1"},
+		{ID: "c2", AllocationID: "a2"},
+		{ID: "c3", AllocationID: "a1"},
+		{ID: "c4", AllocationID: "a2"},
+	} {
+		require.True(t, bc.addChallenge(c))
+	}
+
+	require.Equal(t, 2, bc.removeAllocationChallenges("a1"))
+	require.Len(t, bc.Challenges, 2)
+	require.Len(t, bc.ChallengeMap, 2)
+	assert.Equal(t, "c2", bc.Challenges[0].ID)
+	assert.Equal(t, "c0", bc.Challenges[0].PrevID)
+	assert.Equal(t, "c4", bc.Challenges[1].ID)
+	assert.Equal(t, "c2", bc.Challenges[1].PrevID)
+
+	require.Zero(t, bc.removeAllocationChallenges("a1"))
+}
+
+func Test_writePool_replaceBlobber(t *testing.T) {
+	var wp writePool
+	wp.Pools.add(&allocationPool{
+		AllocationID: "a1",
+		ExpireAt:     10,
+		Blobbers: blobberPools{
+			{BlobberID: "b1", Balance: 10},
+			{BlobberID: "b2", Balance: 20},
+		},
+	})
+	wp.Pools.add(&allocationPool{
+		AllocationID: "a1",
+		ExpireAt:     20,
+		Blobbers: blobberPools{
+			{BlobberID: "b2", Balance: 5},
+			{BlobberID: "b3", Balance: 7},
+		},
+	})
+	wp.Pools.add(&allocationPool{
+		AllocationID: "a2",
+		ExpireAt:     20,
+		Blobbers:     blobberPools{{BlobberID: "b2", Balance: 100}},
+	})
+
+	require.EqualValues(t, 25, wp.blobberBalance("a1", "b2", 5))
+	require.EqualValues(t, 5, wp.blobberBalance("a1", "b2", 15))
+
+	wp.replaceBlobber("a1", "b2", "b3")
+	require.Zero(t, wp.blobberBalance("a1", "b2", 5))
+	require.EqualValues(t, 32, wp.blobberBalance("a1", "b3", 5))
+	require.EqualValues(t, 100, wp.blobberBalance("a2", "b2", 5))
+}
+
+func TestStorageSmartContract_replaceBlobberRequest(t *testing.T) {
+
+	const (
+		txHash, clientID, pubKey = "a5f4c3d2_tx_hex", "client_hex",
+			"pub_key_hex"
+		rtxHash = "b6e5d4c3_tx_hex"
+
+		errMsg1 = "alloc_replace_blobber_failed: malformed request: " +
+			"invalid character '}' looking for beginning of value"
+		errMsg2 = "alloc_replace_blobber_failed: " +
+			"missing allocation_id or blobber_id"
+		errMsg3 = "alloc_replace_blobber_failed: " +
+			"can't get allocation: value not present"
+		errMsg4 = "alloc_replace_blobber_failed: " +
+			"only owner can replace a blobber of an allocation"
+		errMsg5 = "alloc_replace_blobber_failed: " +
+			"blobber is not a blobber of the allocation"
+		errMsg6 = "alloc_replace_blobber_failed: selecting replacement " +
+			"blobber: preferred blobber is already a blobber of the allocation"
+		errMsg7 = "alloc_replace_blobber_failed: selecting replacement " +
+			"blobber: no blobbers matching the allocation"
+		errMsg8 = "alloc_replace_blobber_failed: selecting replacement " +
+			"blobber: invalid preferred blobber URL"
+		errMsg9 = "alloc_replace_blobber_failed: not enough tokens in " +
+			"write pool to honor min_lock_demand of the replacement " +
+			"blobber: 118 < 1125"
+	)
+
+	var (
+		ssc      = newTestStorageSC()
+		balances = newTestBalances(t, false)
+
+		tx, rtx transaction.Transaction
+		conf    *scConfig
+
+		resp string
+		err  error
+	)
+
+	// create allocation using b1 and b2
+
+	tx.Hash = txHash
+	tx.Value = 400
+	tx.ClientID = clientID
+	tx.ToClientID = ADDRESS
+	tx.CreationDate = toSeconds(2 * time.Hour)
+
+	balances.setTransaction(t, &tx)
+
+	conf = setConfig(t, balances)
+	conf.MaxChallengeCompletionTime = 20 * time.Second
+	conf.MinAllocDuration = 20 * time.Second
+	conf.MinAllocSize = 20 * GB
+	conf.TimeUnit = 2 * time.Minute
+
+	_, err = balances.InsertTrieNode(scConfigKey(ssc.ID), conf)
+	require.NoError(t, err)
+
+	var allBlobbers = newTestAllBlobbers()
+	allBlobbers.Nodes[0].LastHealthCheck = tx.CreationDate
+	allBlobbers.Nodes[1].LastHealthCheck = tx.CreationDate
+	_, err = balances.InsertTrieNode(ALL_BLOBBERS_KEY, allBlobbers)
+	require.NoError(t, err)
+	mustMigrateRegistries(t, ssc, balances)
+
+	var (
+		sp1, sp2, sp3 = newStakePool(), newStakePool(), newStakePool()
+		dp1, dp2, dp3 = new(delegatePool), new(delegatePool), new(delegatePool)
+	)
+	dp1.Balance, dp2.Balance, dp3.Balance = 20e10, 20e10, 20e10
+	dp1.DelegateID, dp2.DelegateID, dp3.DelegateID = "d1", "d2", "d3"
+	sp1.Pools["hash1"], sp2.Pools["hash2"], sp3.Pools["hash3"] = dp1, dp2, dp3
+	require.NoError(t, sp1.save(ssc.ID, "b1", balances))
+	require.NoError(t, sp2.save(ssc.ID, "b2", balances))
+	require.NoError(t, sp3.save(ssc.ID, "b3", balances))
+
+	var nar newAllocationRequest
+	nar.Owner = clientID
+	nar.OwnerPublicKey = pubKey
+	nar.ReadPriceRange = PriceRange{Min: 10, Max: 40}
+	nar.WritePriceRange = PriceRange{Min: 100, Max: 400}
+	nar.Size = 20 * GB
+	nar.DataShards = 1
+	nar.ParityShards = 1
+	nar.Expiration = tx.CreationDate + toSeconds(100*time.Second)
+	nar.MaxChallengeCompletionTime = 200 * time.Hour
+
+	balances.balances[clientID] = 1100
+	_, err = ssc.newAllocationRequest(&tx, mustEncode(t, &nar), balances)
+	require.NoError(t, err)
+
+	// 1. malformed request
+
+	rtx.Hash = rtxHash
+	rtx.ClientID = clientID
+	rtx.ToClientID = ADDRESS
+	rtx.CreationDate = tx.CreationDate + 10
+	balances.setTransaction(t, &rtx)
+
+	_, err = ssc.replaceBlobberRequest(&rtx, []byte("} malformed {"),
+		balances)
+	requireErrMsg(t, err, errMsg1)
+
+	// 2. missing fields
+
+	var req replaceBlobberRequest
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg2)
+
+	// 3. unknown allocation
+
+	req.AllocationID, req.BlobberID = "unknown", "b2"
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg3)
+
+	// 4. not owner
+
+	req.AllocationID = txHash
+	rtx.ClientID = "another"
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg4)
+
+	// 5. not a blobber of the allocation
+
+	rtx.ClientID = clientID
+	req.BlobberID = "b3"
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg5)
+
+	// 6. preferred blobber is already a blobber of the allocation
+
+	req.BlobberID = "b2"
+	req.PreferredBlobber = allBlobbers.Nodes[0].BaseURL
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg6)
+
+	// 7. no blobbers to replace with
+
+	req.PreferredBlobber = ""
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg7)
+
+	// register b3
+	var b3 = &StorageNode{
+		ID:      "b3",
+		BaseURL: "http://blobber3.test.ru:9100/api",
+		Terms: Terms{
+			ReadPrice:               30,
+			WritePrice:              300,
+			MinLockDemand:           0.5,
+			MaxOfferDuration:        300 * time.Second,
+			ChallengeCompletionTime: 20 * time.Second,
+		},
+		Capacity:        20 * GB,
+		LastHealthCheck: tx.CreationDate,
+	}
+	mustSave(t, b3.GetKey(ssc.ID), b3, balances)
+	var all *partitions
+	all, err = ssc.getBlobbersPartitions(balances)
+	require.NoError(t, err)
+	require.NoError(t, addBlobberToAll(all, b3, balances))
+	require.NoError(t, all.save(balances))
+
+	// 8. invalid preferred blobber
+
+	req.PreferredBlobber = "http://unknown.test.ru:9100/api"
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg8)
+
+	// b2 challenges: expired, open and open of another allocation
+
+	var b2 *StorageNode
+	b2, err = ssc.getBlobber("b2", balances)
+	require.NoError(t, err)
+	genChall(t, ssc, "b2", int64(tx.CreationDate)-20, "", "ch1", 1, nil,
+		txHash, b2, "root", balances)
+	genChall(t, ssc, "b2", int64(rtx.CreationDate), "ch1", "ch2", 2, nil,
+		txHash, b2, "root", balances)
+	genChall(t, ssc, "b2", int64(rtx.CreationDate), "ch2", "ch3", 3, nil,
+		"another_alloc", b2, "root", balances)
+
+	// b2 challenge pool part
+	var (
+		alloc *StorageAllocation
+		wp    *writePool
+		cp    *challengePool
+	)
+	alloc, err = ssc.getAllocation(txHash, balances)
+	require.NoError(t, err)
+	wp, err = ssc.getWritePool(clientID, balances)
+	require.NoError(t, err)
+	cp, err = ssc.getChallengePool(txHash, balances)
+	require.NoError(t, err)
+	require.NoError(t, wp.moveToChallenge(txHash, "b2", cp, rtx.CreationDate,
+		90))
+	alloc.BlobberMap["b2"].ChallengePoolIntegralValue = 90
+	alloc.BlobberMap["b2"].Stats.OpenChallenges = 2
+	alloc.Stats.OpenChallenges = 2
+	require.NoError(t, wp.save(ssc.ID, clientID, balances))
+	require.NoError(t, cp.save(ssc.ID, txHash, balances))
+	mustSave(t, alloc.GetKey(ssc.ID), alloc, balances)
+
+	// 9. not enough tokens for min lock demand of b3: 222 (b2 tokens)
+	// - 90 (moved to challenge pool) + 30 (challenge pool part left by
+	// failed challenges) - 44 (min lock demand rest of b2)
+
+	req.PreferredBlobber = b3.BaseURL
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, errMsg9)
+
+	// read pool of the owner
+	var rp = new(readPool)
+	rp.Pools.add(&allocationPool{
+		AllocationID: txHash,
+		ExpireAt:     alloc.Until(),
+		Blobbers: blobberPools{
+			{BlobberID: "b1", Balance: 10},
+			{BlobberID: "b2", Balance: 50},
+		},
+	})
+	require.NoError(t, rp.save(ssc.ID, clientID, balances))
+
+	// 10. ok
+
+	balances.balances[clientID] = 1100
+	rtx.Value = 1100
+	resp, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	require.NoError(t, err)
+
+	var aresp StorageAllocation
+	require.NoError(t, aresp.Decode([]byte(resp)))
+
+	require.Len(t, aresp.Blobbers, 2)
+	assert.Equal(t, "b1", aresp.Blobbers[0].ID)
+	assert.Equal(t, "b3", aresp.Blobbers[1].ID)
+	assert.Equal(t, 20*time.Second, aresp.ChallengeCompletionTime)
+	assert.Zero(t, aresp.Stats.OpenChallenges)
+	assert.EqualValues(t, 30, aresp.MovedBack)
+
+	require.Len(t, aresp.BlobberDetails, 2)
+	assert.Equal(t, &BlobberAllocation{
+		BlobberID:     "b3",
+		AllocationID:  txHash,
+		Size:          10 * GB,
+		Stats:         &StorageAllocationStats{},
+		Terms:         b3.Terms,
+		MinLockDemand: 1125, // (wp * (size/GB) * mld) * rest_time_units
+	}, aresp.BlobberDetails[1])
+
+	// saved allocation
+	alloc, err = ssc.getAllocation(txHash, balances)
+	require.NoError(t, err)
+	assert.EqualValues(t, aresp.BlobberDetails, alloc.BlobberDetails)
+
+	// b2 got 60 of the challenge pool and the rest of its min lock demand
+	var sp *stakePool
+	sp, err = ssc.getStakePool("b2", balances)
+	require.NoError(t, err)
+	assert.Nil(t, sp.findOffer(txHash))
+	assert.EqualValues(t, 60+44, sp.Rewards.Blobber)
+
+	sp, err = ssc.getStakePool("b3", balances)
+	require.NoError(t, err)
+	if assert.NotNil(t, sp.findOffer(txHash)) {
+		assert.EqualValues(t, 3000, sp.findOffer(txHash).Lock)
+		assert.Equal(t, alloc.Until(), sp.findOffer(txHash).Expire)
+	}
+
+	// offer of b1 extended by the new challenge completion time
+	sp, err = ssc.getStakePool("b1", balances)
+	require.NoError(t, err)
+	if assert.NotNil(t, sp.findOffer(txHash)) {
+		assert.Equal(t, alloc.Until(), sp.findOffer(txHash).Expire)
+	}
+
+	// pools
+	cp, err = ssc.getChallengePool(txHash, balances)
+	require.NoError(t, err)
+	assert.Zero(t, cp.Balance)
+
+	wp, err = ssc.getWritePool(clientID, balances)
+	require.NoError(t, err)
+	assert.Zero(t, wp.blobberBalance(txHash, "b2", rtx.CreationDate))
+	assert.EqualValues(t, 118+1100,
+		wp.blobberBalance(txHash, "b3", rtx.CreationDate))
+
+	rp, err = ssc.getReadPool(clientID, balances)
+	require.NoError(t, err)
+	require.Len(t, rp.Pools, 1)
+	assert.Equal(t, blobberPools{
+		{BlobberID: "b1", Balance: 10},
+		{BlobberID: "b3", Balance: 50},
+	}, rp.Pools[0].Blobbers)
+
+	// blobbers
+	b2, err = ssc.getBlobber("b2", balances)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10*GB, b2.Used)
+	b3, err = ssc.getBlobber("b3", balances)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10*GB, b3.Used)
+
+	var list *StorageNodes
+	list, err = ssc.getBlobbersList(balances)
+	require.NoError(t, err)
+	for _, b := range list.Nodes {
+		switch b.ID {
+		case "b2":
+			assert.EqualValues(t, 10*GB, b.Used)
+		case "b3":
+			assert.EqualValues(t, 10*GB, b.Used)
+		}
+	}
+
+	// challenges of the allocation removed from b2
+	var bc *BlobberChallenge
+	bc, err = ssc.getBlobberChallenge("b2", balances)
+	require.NoError(t, err)
+	require.Len(t, bc.Challenges, 1)
+	assert.Equal(t, "ch3", bc.Challenges[0].ID)
+	assert.Equal(t, "", bc.Challenges[0].PrevID)
+
+	// random replacement of b1 selects the only free b2, but 11 tokens
+	// left of b1 after its min lock demand don't cover the b2 one
+	rtx.Value = 0
+	req.BlobberID, req.PreferredBlobber = "b1", ""
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, "alloc_replace_blobber_failed: not enough tokens "+
+		"in write pool to honor min_lock_demand of the replacement "+
+		"blobber: 11 < 93")
+
+	// 11. expired allocation
+
+	rtx.CreationDate = aresp.Expiration + 1
+	_, err = ssc.replaceBlobberRequest(&rtx, mustEncode(t, &req), balances)
+	requireErrMsg(t, err, "alloc_replace_blobber_failed: "+
+		"trying to replace a blobber of expired allocation")
+}
@@ -0,0 +1,308 @@
+package storagesc
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	cstate "0chain.net/chaincore/chain/state"
+	"0chain.net/core/common"
+	"0chain.net/core/util"
+)
+
+// reputationConfig represents blobbers reputation scoring configurations.
+type reputationConfig struct {
+	// ChallengesWindow is number of the latest challenges the rolling pass
+	// and penalty rates of a blobber are averaged over.
+	ChallengesWindow int `json:"challenges_window"`
+	// HealthChecksWindow is number of the latest health checks the rolling
+	// uptime of a blobber is averaged over.
+	HealthChecksWindow int `json:"health_checks_window"`
+	// InitialScore is reputation score of a newly registered blobber,
+	// value in [0; 1] range.
+	InitialScore float64 `json:"initial_score"`
+	// PassRateWeight is weight of the challenges pass rate in the score.
+	PassRateWeight float64 `json:"pass_rate_weight"`
+	// UptimeWeight is weight of the uptime in the score.
+	UptimeWeight float64 `json:"uptime_weight"`
+	// PenaltyWeight is weight of the rate of not slashed challenges
+	// in the score.
+	PenaltyWeight float64 `json:"penalty_weight"`
+}
+
+// defaultReputationConfig used for SC configurations saved without
+// the reputation section
+var defaultReputationConfig = reputationConfig{
+	ChallengesWindow:   100,
+	HealthChecksWindow: 100,
+	InitialScore:       0.8,
+	PassRateWeight:     0.6,
+	UptimeWeight:       0.2,
+	PenaltyWeight:      0.2,
+}
+
+func (rc *reputationConfig) validate() (err error) {
+	if rc.ChallengesWindow < 1 {
+		return fmt.Errorf("invalid reputation.challenges_window < 1: %v",
+			rc.ChallengesWindow)
+	}
+	if rc.HealthChecksWindow < 1 {
+		return fmt.Errorf("invalid reputation.health_checks_window < 1: %v",
+			rc.HealthChecksWindow)
+	}
+	if rc.InitialScore < 0.0 || 1.0 < rc.InitialScore {
+		return fmt.Errorf("reputation.initial_score not in [0; 1] range: %v",
+			rc.InitialScore)
+	}
+	if rc.PassRateWeight < 0 {
+		return fmt.Errorf("negative reputation.pass_rate_weight: %v",
+			rc.PassRateWeight)
+	}
+	if rc.UptimeWeight < 0 {
+		return fmt.Errorf("negative reputation.uptime_weight: %v",
+			rc.UptimeWeight)
+	}
+	if rc.PenaltyWeight < 0 {
+		return fmt.Errorf("negative reputation.penalty_weight: %v",
+			rc.PenaltyWeight)
+	}
+	if rc.totalWeight() == 0 {
+		return errors.New("zero reputation weights")
+	}
+	return
+}
+
+func (rc *reputationConfig) totalWeight() float64 {
+	return rc.PassRateWeight + rc.UptimeWeight + rc.PenaltyWeight
+}
+
+// reputation configurations, or default ones
+func (conf *scConfig) reputation() *reputationConfig {
+	if conf.Reputation == nil {
+		return &defaultReputationConfig
+	}
+	return conf.Reputation
+}
+
+// getReputationConfig returns saved reputation configurations or configured
+// ones if SC configurations are not saved yet, it never saves them
+func (sc *StorageSmartContract) getReputationConfig(
+	balances cstate.StateContextI) (rc *reputationConfig, err error) {
+
+	var conf *scConfig
+	if conf, err = sc.getConfig(balances, false); err == util.ErrValueNotPresent {
+		conf, err = getConfiguredConfig()
+	}
+	if err != nil {
+		return
+	}
+	return conf.reputation(), nil
+}
+
+// BlobberReputation is on-chain reputation of a blobber the SC maintains
+// from its challenges and health checks history.
+type BlobberReputation struct {
+	// statistic
+	ChallengesPassed int64 `json:"challenges_passed"`
+	ChallengesFailed int64 `json:"challenges_failed"`
+	Penalties        int64 `json:"penalties"`
+	HealthChecks     int64 `json:"health_checks"`
+	// rolling rates, values in [0; 1] range
+	PassRate    float64 `json:"pass_rate"`
+	PenaltyRate float64 `json:"penalty_rate"`
+	Uptime      float64 `json:"uptime"`
+	// Score is the weighted rolling rates, value in [0; 1] range.
+	Score float64 `json:"score"`
+}
+
+// newBlobberReputation with rolling rates resulting the initial score
+func newBlobberReputation(rc *reputationConfig) (r *BlobberReputation) {
+	r = new(BlobberReputation)
+	r.PassRate = rc.InitialScore
+	r.PenaltyRate = 1.0 - rc.InitialScore
+	r.Uptime = rc.InitialScore
+	r.score(rc)
+	return
+}
+
+// rolling average of the latest window samples
+func rolling(avg, sample float64, window int) float64 {
+	return avg + (sample-avg)/float64(window)
+}
+
+func boolSample(b bool) float64 {
+	if b {
+		return 1.0
+	}
+	return 0.0
+}
+
+func (r *BlobberReputation) score(rc *reputationConfig) {
+	var score = (rc.PassRateWeight*r.PassRate +
+		rc.UptimeWeight*r.Uptime +
+		rc.PenaltyWeight*(1.0-r.PenaltyRate)) / rc.totalWeight()
+	if score < 0.0 {
+		score = 0.0
+	} else if score > 1.0 {
+		score = 1.0
+	}
+	r.Score = score
+}
+
+// challenge resolved
+func (r *BlobberReputation) challenge(passed, penalized bool,
+	rc *reputationConfig) {
+
+	if passed {
+		r.ChallengesPassed++
+	} else {
+		r.ChallengesFailed++
+	}
+	if penalized {
+		r.Penalties++
+	}
+	r.PassRate = rolling(r.PassRate, boolSample(passed), rc.ChallengesWindow)
+	r.PenaltyRate = rolling(r.PenaltyRate, boolSample(penalized),
+		rc.ChallengesWindow)
+	r.score(rc)
+}
+
+// health check with given uptime since previous one
+func (r *BlobberReputation) healthCheck(uptime float64,
+	rc *reputationConfig) {
+
+	r.HealthChecks++
+	r.Uptime = rolling(r.Uptime, uptime, rc.HealthChecksWindow)
+	r.score(rc)
+}
+
+// healthCheckUptime is part of time between the health checks the blobber
+// has been expected to be available; a blobber sends health checks
+// every blobberHealthTime at least
+func healthCheckUptime(last, now common.Timestamp) float64 {
+	var elapsed = now - last
+	if last == 0 || elapsed <= blobberHealthTime {
+		return 1.0
+	}
+	return float64(blobberHealthTime) / float64(elapsed)
+}
+
+// reputation of the blobber, it's created for blobbers registered
+// before the reputation scoring
+func (sn *StorageNode) reputation(rc *reputationConfig) *BlobberReputation {
+	if sn.Reputation == nil {
+		sn.Reputation = newBlobberReputation(rc)
+	}
+	return sn.Reputation
+}
+
+// reputationScore of the blobber not changing it
+func (sn *StorageNode) reputationScore(rc *reputationConfig) float64 {
+	if sn.Reputation == nil {
+		return rc.InitialScore
+	}
+	return sn.Reputation.Score
+}
+
+// updateBlobberReputation updates reputation of the blobber in the blobber
+// node and in the all blobbers registry; blobbers already removed are
+// ignored
+func (sc *StorageSmartContract) updateBlobberReputation(id string,
+	balances cstate.StateContextI,
+	update func(r *BlobberReputation, rc *reputationConfig)) (err error) {
+
+	var conf *scConfig
+	if conf, err = sc.getConfig(balances, true); err != nil {
+		return fmt.Errorf("can't get SC configurations: %v", err)
+	}
+
+	var blobber *StorageNode
+	if blobber, err = sc.getBlobber(id, balances); err == util.ErrValueNotPresent {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("can't get blobber: %v", err)
+	}
+
+	var rc = conf.reputation()
+	update(blobber.reputation(rc), rc)
+
+	var all *partitions
+	if all, err = sc.getBlobbersPartitions(balances); err != nil {
+		return fmt.Errorf("can't get all blobbers list: %v", err)
+	}
+	var it *partitionItem
+	if it, err = all.get(id, balances); err == nil {
+		var found *StorageNode
+		if found, err = blobberOfItem(it); err != nil {
+			return fmt.Errorf("can't decode blobber of all blobbers list: %v",
+				err)
+		}
+		found.Reputation = blobber.Reputation
+		if _, err = updateBlobberInAll(all, found, balances); err != nil {
+			return fmt.Errorf("can't update all blobbers list: %v", err)
+		}
+		if err = all.save(balances); err != nil {
+			return fmt.Errorf("can't save all blobbers list: %v", err)
+		}
+	} else if err != util.ErrValueNotPresent {
+		return fmt.Errorf("can't get blobber from all blobbers list: %v", err)
+	}
+
+	_, err = balances.InsertTrieNode(blobber.GetKey(sc.ID), blobber)
+	if err != nil {
+		return fmt.Errorf("can't save blobber: %v", err)
+	}
+	return
+}
+
+// filterBlobbersByReputation kicks blobbers with reputation score less
+// than given minimum
+func filterBlobbersByReputation(rc *reputationConfig,
+	min float64) filterBlobberFunc {
+
+	return filterBlobberFunc(func(b *StorageNode) (kick bool) {
+		return b.reputationScore(rc) < min
+	})
+}
+
+// minReputationWeight keeps chances of blobbers with zero reputation score
+// to be selected
+const minReputationWeight = 0.01
+
+// randomizeNodesByReputation picks up nodes not selected yet until n
+// nodes selected, chances of a node to be picked up are proportional
+// to its reputation score
+func randomizeNodesByReputation(in, out []*StorageNode, n int, seed int64,
+	rc *reputationConfig) []*StorageNode {
+
+	var (
+		nodes   = make([]*StorageNode, 0, len(in))
+		weights = make([]float64, 0, len(in))
+		total   float64
+	)
+	for _, b := range in {
+		if checkExists(b, out) || checkExists(b, nodes) {
+			continue
+		}
+		var w = b.reputationScore(rc) + minReputationWeight
+		nodes, weights, total = append(nodes, b), append(weights, w), total+w
+	}
+
+	var randGen = rand.New(rand.NewSource(seed))
+	for len(out) < n && len(nodes) > 0 {
+		var (
+			x = randGen.Float64() * total
+			i int
+		)
+		for i = 0; i < len(nodes)-1; i++ {
+			if x < weights[i] {
+				break
+			}
+			x -= weights[i]
+		}
+		out, total = append(out, nodes[i]), total-weights[i]
+		nodes = append(nodes[:i], nodes[i+1:]...)
+		weights = append(weights[:i], weights[i+1:]...)
+	}
+	return out
+}
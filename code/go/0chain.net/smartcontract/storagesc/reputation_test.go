@@ -0,0 +1,164 @@
+package storagesc
+
+import (
+	"fmt"
+	"testing"
+
+	"0chain.net/chaincore/transaction"
+	"0chain.net/core/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_reputationConfig_validate(t *testing.T) {
+	var rc = defaultReputationConfig
+	require.NoError(t, rc.validate())
+
+	rc.ChallengesWindow = 0
+	require.Error(t, rc.validate())
+
+	rc = defaultReputationConfig
+	rc.InitialScore = 1.5
+	require.Error(t, rc.validate())
+
+	rc = defaultReputationConfig
+	rc.PassRateWeight, rc.UptimeWeight, rc.PenaltyWeight = 0, 0, 0
+	require.Error(t, rc.validate())
+}
+
+func TestBlobberReputation(t *testing.T) {
+	var (
+		rc = &reputationConfig{
+			ChallengesWindow:   10,
+			HealthChecksWindow: 10,
+			InitialScore:       0.5,
+			PassRateWeight:     2,
+			UptimeWeight:       1,
+			PenaltyWeight:      1,
+		}
+		r = newBlobberReputation(rc)
+	)
+	require.InDelta(t, 0.5, r.Score, 1e-9)
+
+	for i := 0; i < 100; i++ {
+		r.challenge(true, false, rc)
+		r.healthCheck(1.0, rc)
+	}
+	require.EqualValues(t, 100, r.ChallengesPassed)
+	require.EqualValues(t, 100, r.HealthChecks)
+	require.InDelta(t, 1.0, r.Score, 1e-3)
+
+	var good = r.Score
+	r.challenge(false, true, rc)
+	require.EqualValues(t, 1, r.ChallengesFailed)
+	require.EqualValues(t, 1, r.Penalties)
+	require.InDelta(t, 0.9, r.PassRate, 1e-3)
+	require.InDelta(t, 0.1, r.PenaltyRate, 1e-3)
+	require.True(t, r.Score < good)
+
+	for i := 0; i < 100; i++ {
+		r.challenge(false, true, rc)
+		r.healthCheck(0.0, rc)
+	}
+	require.InDelta(t, 0.0, r.Score, 1e-3)
+}
+
+func Test_healthCheckUptime(t *testing.T) {
+	require.Equal(t, 1.0, healthCheckUptime(0, 100))
+	require.Equal(t, 1.0, healthCheckUptime(100, 100+blobberHealthTime))
+	require.Equal(t, 0.5, healthCheckUptime(100, 100+2*blobberHealthTime))
+}
+
+func Test_randomizeNodesByReputation(t *testing.T) {
+	var (
+		rc    = &defaultReputationConfig
+		nodes []*StorageNode
+	)
+	for i := 0; i < 10; i++ {
+		var b = &StorageNode{ID: fmt.Sprintf("b%d", i)}
+		b.reputation(rc).Score = 0.0
+		if i%2 == 0 {
+			b.Reputation.Score = 1.0
+		}
+		nodes = append(nodes, b)
+	}
+
+	var reliable int
+	for seed := int64(0); seed < 100; seed++ {
+		var out = randomizeNodesByReputation(nodes,
+			[]*StorageNode{nodes[0]}, 4, seed, rc)
+		require.Len(t, out, 4)
+		var ids = make(map[string]bool)
+		for _, b := range out {
+			require.False(t, ids[b.ID])
+			ids[b.ID] = true
+			if b.Reputation.Score == 1.0 {
+				reliable++
+			}
+		}
+		require.Equal(t, nodes[0], out[0])
+	}
+	// 3 of 4 picked up blobbers are reliable ones almost always
+	require.True(t, reliable > 390, reliable)
+
+	require.Len(t, randomizeNodesByReputation(nodes, nil, 20, 1, rc), 10)
+}
+
+func TestStorageSmartContract_updateBlobberReputation(t *testing.T) {
+	var (
+		balances = newTestBalances(t, false)
+		ssc      = newTestStorageSC()
+		conf     = setConfig(t, balances)
+		b        = &StorageNode{ID: "blobber", BaseURL: "http://blobber"}
+		err      error
+	)
+	mustSave(t, b.GetKey(ssc.ID), b, balances)
+	var all *partitions
+	all, err = ssc.getBlobbersPartitions(balances)
+	require.NoError(t, err)
+	require.NoError(t, addBlobberToAll(all, b, balances))
+	require.NoError(t, all.save(balances))
+
+	err = ssc.updateBlobberReputation(b.ID, balances,
+		func(r *BlobberReputation, rc *reputationConfig) {
+			r.challenge(false, true, rc)
+		})
+	require.NoError(t, err)
+
+	var want = newBlobberReputation(conf.reputation())
+	want.challenge(false, true, conf.reputation())
+
+	var saved *StorageNode
+	saved, err = ssc.getBlobber(b.ID, balances)
+	require.NoError(t, err)
+	require.Equal(t, want, saved.Reputation)
+
+	var list *StorageNodes
+	list, err = ssc.getBlobbersList(balances)
+	require.NoError(t, err)
+	require.Len(t, list.Nodes, 1)
+	require.Equal(t, want, list.Nodes[0].Reputation)
+
+	// removed blobbers are ignored
+	err = ssc.updateBlobberReputation("removed", balances,
+		func(r *BlobberReputation, rc *reputationConfig) {
+			t.Fatal("unexpected update")
+		})
+	require.NoError(t, err)
+
+	// health check
+	var tx = &transaction.Transaction{
+		ClientID:     b.ID,
+		CreationDate: common.Timestamp(4 * blobberHealthTime),
+	}
+	saved.LastHealthCheck = common.Timestamp(2 * blobberHealthTime)
+	mustSave(t, saved.GetKey(ssc.ID), saved, balances)
+	_, err = ssc.blobberHealthCheck(tx, nil, balances)
+	require.NoError(t, err)
+	want.healthCheck(0.5, conf.reputation())
+
+	saved, err = ssc.getBlobber(b.ID, balances)
+	require.NoError(t, err)
+	require.Equal(t, want, saved.Reputation)
+	require.Equal(t, tx.CreationDate, saved.LastHealthCheck)
+}
@@ -11,6 +11,7 @@ import (
 	sci "0chain.net/chaincore/smartcontractinterface"
 	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
+	"0chain.net/smartcontract/governance"
 	metrics "github.com/rcrowley/go-metrics"
 )
 
@@ -49,6 +50,13 @@ func (ssc *StorageSmartContract) setSC(sc *sci.SmartContract, bcContext sci.BCCo
 	// sc configurations
 	ssc.SmartContract.RestHandlers["/getConfig"] = ssc.getConfigHandler
 	ssc.SmartContractExecutionStats["update_config"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "update_config"), nil)
+	// registries
+	ssc.SmartContractExecutionStats["migrate_registries"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "migrate_registries"), nil)
+	// governance
+	ssc.governance().SetRestHandlers(ssc.SmartContract.RestHandlers)
+	for _, fn := range governance.Functions {
+		ssc.SmartContractExecutionStats[fn] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, fn), nil)
+	}
 	// reading / writing
 	ssc.SmartContract.RestHandlers["/latestreadmarker"] = ssc.LatestReadMarkerHandler
 	ssc.SmartContractExecutionStats["read_redeem"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "read_redeem"), nil)
@@ -61,6 +69,7 @@ func (ssc *StorageSmartContract) setSC(sc *sci.SmartContract, bcContext sci.BCCo
 	ssc.SmartContractExecutionStats["update_allocation_request"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "update_allocation_request"), nil)
 	ssc.SmartContractExecutionStats["finalize_allocation"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "finalize_allocation"), nil)
 	ssc.SmartContractExecutionStats["cancel_allocation"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "cancel_allocation"), nil)
+	ssc.SmartContractExecutionStats["alloc_replace_blobber"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "alloc_replace_blobber"), nil)
 	ssc.SmartContractExecutionStats["free_allocation_request"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "free_allocation_request"), nil)
 	ssc.SmartContractExecutionStats["free_update_allocation"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "update_free_storage"), nil)
 	ssc.SmartContractExecutionStats["add_curator"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "add_curator"), nil)
@@ -68,6 +77,7 @@ func (ssc *StorageSmartContract) setSC(sc *sci.SmartContract, bcContext sci.BCCo
 	// challenge
 	ssc.SmartContract.RestHandlers["/openchallenges"] = ssc.OpenChallengeHandler
 	ssc.SmartContract.RestHandlers["/getchallenge"] = ssc.GetChallengeHandler
+	ssc.SmartContract.RestHandlers["/challenge_selection"] = ssc.ChallengeSelectionHandler
 	ssc.SmartContractExecutionStats["challenge_request"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "challenge_request"), nil)
 	ssc.SmartContractExecutionStats["challenge_response"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "challenge_response"), nil)
 	ssc.SmartContractExecutionStats["generate_challenges"] = metrics.GetOrRegisterTimer(fmt.Sprintf("sc:%v:func:%v", ssc.ID, "generate_challenges"), nil)
@@ -169,6 +179,11 @@ func (sc *StorageSmartContract) Execute(t *transaction.Transaction,
 	funcName string, input []byte, balances chainstate.StateContextI) (
 	resp string, err error) {
 
+	// apply accepted configurations changes first
+	if err = sc.governance().ApplyDue(balances); err != nil {
+		return "", common.NewError("governance_failed", err.Error())
+	}
+
 	switch funcName {
 
 	// read/write markers
@@ -211,6 +226,8 @@ func (sc *StorageSmartContract) Execute(t *transaction.Transaction,
 		resp, err = sc.finalizeAllocation(t, input, balances)
 	case "cancel_allocation":
 		resp, err = sc.cancelAllocationRequest(t, input, balances)
+	case "alloc_replace_blobber":
+		resp, err = sc.replaceBlobberRequest(t, input, balances)
 
 	// free allocations
 
@@ -285,6 +302,15 @@ func (sc *StorageSmartContract) Execute(t *transaction.Transaction,
 	case "update_config":
 		resp, err = sc.updateConfig(t, input, balances)
 
+	// registries
+
+	case "migrate_registries":
+		resp, err = sc.migrateRegistries(t, input, balances)
+
+	case governance.FuncStakeLock, governance.FuncStakeUnlock,
+		governance.FuncPropose, governance.FuncVote:
+		resp, err = sc.governance().Execute(t, funcName, input, balances)
+
 	default:
 		err = common.NewError("invalid_storage_function_name",
 			"Invalid storage function called")
@@ -0,0 +1,57 @@
+package storagesc
+
+import (
+	sci "0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/core/datastore"
+	"0chain.net/core/util"
+)
+
+// stateNodeTypes - the storage SC nodes of the state, the types detected by
+// fields go from the most to the least specific ones
+var stateNodeTypes = []sci.StateNodeType{
+	{Name: "all_blobbers", Keys: []datastore.Key{ALL_BLOBBERS_KEY},
+		New: func() interface{} { return &StorageNodes{} }},
+	{Name: "all_validators", Keys: []datastore.Key{ALL_VALIDATORS_KEY},
+		New: func() interface{} { return &ValidatorNodes{} }},
+	{Name: "all_allocations", Keys: []datastore.Key{ALL_ALLOCATIONS_KEY},
+		New: func() interface{} { return &Allocations{} }},
+	{Name: "storage_stats", Keys: []datastore.Key{STORAGE_STATS_KEY},
+		New: func() interface{} { return &StorageStats{} }},
+	{Name: "config", Keys: []datastore.Key{scConfigKey(ADDRESS)},
+		New: func() interface{} { return &scConfig{} }},
+	{Name: "partitions", Fields: []string{"name", "partition_size", "num_partitions"},
+		New: func() interface{} { return &partitions{} }},
+	{Name: "partition", Fields: []string{"items"},
+		New: func() interface{} { return &partition{} }},
+	{Name: "partition_location", Fields: []string{"partition"},
+		New: func() interface{} { return &partitionLocation{} }},
+	{Name: "allocation", Fields: []string{"id", "data_shards", "blobber_details"},
+		New: func() interface{} { return &StorageAllocation{} }},
+	{Name: "blobber", Fields: []string{"id", "url", "terms", "capacity"},
+		New: func() interface{} { return &StorageNode{} }},
+	{Name: "validator", Fields: []string{"id", "url"},
+		New: func() interface{} { return &ValidationNode{} }},
+	{Name: "blobber_challenge", Fields: []string{"blobber_id", "challenges"},
+		New: func() interface{} { return &BlobberChallenge{} }},
+	{Name: "client_allocation", Fields: []string{"client_id", "allocations"},
+		New: func() interface{} { return &ClientAllocation{} }},
+	{Name: "stake_pool", Fields: []string{"pools", "offers", "settings"},
+		New: func() interface{} { return newStakePool() }},
+	{Name: "user_stake_pools", Fields: []string{"pools"},
+		New: func() interface{} { return newUserStakePools() }},
+	{Name: "read_or_write_pool", Fields: []string{"pools"},
+		New: func() interface{} { return &readPool{} }},
+	{Name: "challenge_pool", Fields: []string{"pool"},
+		New: func() interface{} { return newChallengePool() }},
+	{Name: "free_storage_assigner", Fields: []string{"client_id", "individual_limit", "total_limit"},
+		New: func() interface{} { return &freeStorageAssigner{} }},
+	{Name: "blobber_url", Fields: []string{"id"},
+		New: func() interface{} { return &blobberURL{} }},
+}
+
+// DecodeStateNode - implement smartcontractinterface.StateNodeDecoder
+func (ssc *StorageSmartContract) DecodeStateNode(path util.Path, value []byte) (
+	string, interface{}, bool) {
+
+	return sci.DecodeStateNode(stateNodeTypes, path, value)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
-	"math/rand"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,7 +12,6 @@ import (
 	"0chain.net/chaincore/block"
 	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
-	"0chain.net/core/encryption"
 	"0chain.net/core/util"
 
 	"github.com/stretchr/testify/require"
@@ -295,7 +293,8 @@ func Benchmark_generateChallenges(b *testing.B) {
 					require.NoError(b, err)
 
 					tp += 1
-					blk.PrevHash = encryption.Hash(fmt.Sprintf("block-%d", i))
+					blk.Round = tp
+					blk.RoundRandomSeed = tp
 					tx = newTransaction(client.id, ssc.ID, 0, tp)
 					balances.setTransaction(b, tx) // merge into p node db
 				}
@@ -416,8 +415,8 @@ func Benchmark_verifyChallenge(b *testing.B) {
 	b.ResetTimer()
 	b.Log("start benchmark")
 
-	var valids *ValidatorNodes
-	valids, err = ssc.getValidatorsList(balances)
+	var valids *partitions
+	valids, err = ssc.getValidatorsPartitions(balances)
 	require.NoError(b, err)
 
 	// 6. add challenge for an allocation and verify it (successive case)
@@ -438,19 +437,19 @@ func Benchmark_verifyChallenge(b *testing.B) {
 				tp += 1
 				allocID = allocs[i%len(allocs)]
 
-				var (
-					r     = rand.New(rand.NewSource(tp))
-					alloc *StorageAllocation
-				)
+				var alloc *StorageAllocation
 				alloc, err = ssc.getAllocation(allocID, balances)
 				require.NoError(b, err)
 
 				var (
-					challID    = encryption.Hash(fmt.Sprintf("chall-%d", tp))
+					selected   *SelectedChallenge
 					challBytes string
 				)
-				challBytes, err = ssc.addChallenge(alloc, valids, challID,
-					common.Timestamp(tp), r, tp, balances)
+				selected, err = newChallengeSelector(tp, tp).selectChallenge(
+					alloc, valids, 0, balances)
+				require.NoError(b, err)
+				challBytes, err = ssc.addChallenge(alloc, selected, valids,
+					common.Timestamp(tp), balances)
 				require.NoError(b, err)
 
 				var chall StorageChallenge
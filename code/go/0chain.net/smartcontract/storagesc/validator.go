@@ -9,16 +9,54 @@ import (
 	"0chain.net/core/common"
 )
 
+func newValidatorItem(v *ValidationNode) *partitionItem {
+	var data, _ = json.Marshal(v)
+	return &partitionItem{ID: v.ID, Data: data}
+}
+
+func validatorsOfItems(items []*partitionItem) (list []*ValidationNode, err error) {
+	list = make([]*ValidationNode, 0, len(items))
+	for _, it := range items {
+		var v = new(ValidationNode)
+		if err = json.Unmarshal(it.Data, v); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return
+}
+
+func legacyValidatorsItems(b []byte) (items []*partitionItem, err error) {
+	var all ValidatorNodes
+	if err = all.Decode(b); err != nil {
+		return
+	}
+	for _, v := range all.Nodes {
+		items = append(items, newValidatorItem(v))
+	}
+	return
+}
+
+func (sc *StorageSmartContract) getValidatorsPartitions(
+	balances c_state.StateContextI) (*partitions, error) {
+
+	return getPartitions(allValidatorsName, validatorsPartitionSize,
+		ALL_VALIDATORS_KEY, balances)
+}
+
 func (sc *StorageSmartContract) getValidatorsList(balances c_state.StateContextI) (*ValidatorNodes, error) {
-	allValidatorsList := &ValidatorNodes{}
-	allValidatorsBytes, err := balances.GetTrieNode(ALL_VALIDATORS_KEY)
-	if allValidatorsBytes == nil {
-		return allValidatorsList, nil
+	all, err := sc.getValidatorsPartitions(balances)
+	if err != nil {
+		return nil, common.NewError("getValidatorsList_failed", "Failed to retrieve existing validators list")
 	}
-	err = json.Unmarshal(allValidatorsBytes.Encode(), allValidatorsList)
+	items, err := all.all(balances)
 	if err != nil {
 		return nil, common.NewError("getValidatorsList_failed", "Failed to retrieve existing validators list")
 	}
+	allValidatorsList := &ValidatorNodes{}
+	if allValidatorsList.Nodes, err = validatorsOfItems(items); err != nil {
+		return nil, common.NewError("getValidatorsList_failed", "Failed to retrieve existing validators list")
+	}
 	sort.SliceStable(allValidatorsList.Nodes, func(i, j int) bool {
 		return allValidatorsList.Nodes[i].ID < allValidatorsList.Nodes[j].ID
 	})
@@ -26,7 +64,7 @@ func (sc *StorageSmartContract) getValidatorsList(balances c_state.StateContextI
 }
 
 func (sc *StorageSmartContract) addValidator(t *transaction.Transaction, input []byte, balances c_state.StateContextI) (string, error) {
-	allValidatorsList, err := sc.getValidatorsList(balances)
+	allValidatorsList, err := sc.getValidatorsPartitions(balances)
 	if err != nil {
 		return "", common.NewError("add_validator_failed", "Failed to get validator list."+err.Error())
 	}
@@ -39,9 +77,15 @@ func (sc *StorageSmartContract) addValidator(t *transaction.Transaction, input [
 	newValidator.PublicKey = t.PublicKey
 	blobberBytes, _ := balances.GetTrieNode(newValidator.GetKey(sc.ID))
 	if blobberBytes == nil {
-		allValidatorsList.Nodes = append(allValidatorsList.Nodes, newValidator)
-		// allValidatorsBytes, _ := json.Marshal(allValidatorsList)
-		balances.InsertTrieNode(ALL_VALIDATORS_KEY, allValidatorsList)
+		err = allValidatorsList.add(newValidatorItem(newValidator), balances)
+		if err != nil {
+			return "", common.NewError("add_validator_failed",
+				"adding to validators list: "+err.Error())
+		}
+		if err = allValidatorsList.save(balances); err != nil {
+			return "", common.NewError("add_validator_failed",
+				"saving validators list: "+err.Error())
+		}
 		balances.InsertTrieNode(newValidator.GetKey(sc.ID), newValidator)
 
 		sc.statIncr(statAddValidator)
@@ -0,0 +1,24 @@
+package storagesc
+
+import (
+	"0chain.net/core/util"
+)
+
+// tags of the typed state values of the storage SC, never reuse a tag
+const (
+	storageAllocationValueTag util.ValueTypeTag = 0x0200 + iota
+	storageNodeValueTag
+	storageNodesValueTag
+	partitionsValueTag
+	partitionValueTag
+	partitionLocationValueTag
+)
+
+func init() {
+	util.RegisterValueType(storageAllocationValueTag, "storagesc.allocation", &StorageAllocation{})
+	util.RegisterValueType(storageNodeValueTag, "storagesc.blobber", &StorageNode{})
+	util.RegisterValueType(storageNodesValueTag, "storagesc.all_blobbers", &StorageNodes{})
+	util.RegisterValueType(partitionsValueTag, "storagesc.partitions", &partitions{})
+	util.RegisterValueType(partitionValueTag, "storagesc.partition", &partition{})
+	util.RegisterValueType(partitionLocationValueTag, "storagesc.partition_location", &partitionLocation{})
+}
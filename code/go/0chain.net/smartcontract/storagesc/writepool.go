@@ -162,6 +162,24 @@ func (wp *writePool) allocPool(allocID string, until common.Timestamp) (
 	return zero
 }
 
+// blobberBalance returns not expired tokens of the blobber of the allocation
+func (wp *writePool) blobberBalance(allocID, blobberID string,
+	now common.Timestamp) (value state.Balance) {
+
+	for _, ap := range wp.blobberCut(allocID, blobberID, now) {
+		if bp, ok := ap.Blobbers.get(blobberID); ok {
+			value += bp.Balance
+		}
+	}
+	return
+}
+
+// replaceBlobber moves tokens of the blobber of the allocation to
+// its replacement
+func (wp *writePool) replaceBlobber(allocID, oldID, newID string) {
+	wp.Pools.replaceBlobber(allocID, oldID, newID)
+}
+
 func (wp *writePool) stat(now common.Timestamp) (aps allocationPoolsStat) {
 	aps = wp.Pools.stat(now)
 	return
@@ -105,3 +105,6 @@ func (tb *testBalances) AddTransfer(t *state.Transfer) error {
 	tb.transfers = append(tb.transfers, t)
 	return nil
 }
+
+func (tb *testBalances) EmitEvent(*transaction.Event)    {}
+func (tb *testBalances) GetEvents() []*transaction.Event { return nil }
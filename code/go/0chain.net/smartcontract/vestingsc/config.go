@@ -4,12 +4,15 @@ import (
 	"0chain.net/core/common"
 	"context"
 	"errors"
+	"fmt"
 	"net/url"
 	"time"
 
 	chainstate "0chain.net/chaincore/chain/state"
 	configpkg "0chain.net/chaincore/config"
 	"0chain.net/chaincore/state"
+	"0chain.net/core/datastore"
+	"0chain.net/core/util"
 )
 
 type config struct {
@@ -36,6 +39,22 @@ func (c *config) validate() (err error) {
 	return
 }
 
+func configKey(vscKey datastore.Key) datastore.Key {
+	return vscKey + ":configurations"
+}
+
+func (c *config) Encode() (b []byte) {
+	var err error
+	if b, err = util.EncodeValue(c); err != nil {
+		panic(err) // must not happen
+	}
+	return
+}
+
+func (c *config) Decode(b []byte) error {
+	return util.DecodeValue(b, c)
+}
+
 //
 // helpers
 //
@@ -62,14 +81,34 @@ func getConfig() (conf *config, err error) {
 	return
 }
 
+// getConfig returns configurations changed by governance, or configurations
+// from sc.yaml if they have never been changed
+func (vsc *VestingSmartContract) getConfig(
+	balances chainstate.StateContextI) (conf *config, err error) {
+
+	var val util.Serializable
+	val, err = balances.GetTrieNode(configKey(vsc.ID))
+	if err == util.ErrValueNotPresent {
+		return getConfig()
+	}
+	if err != nil {
+		return
+	}
+	conf = new(config)
+	if err = conf.Decode(val.Encode()); err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrDecoding, err)
+	}
+	return
+}
+
 //
 // REST-handler
 //
 
-func (vsc *VestingSmartContract) getConfigHandler(context.Context,
-	url.Values, chainstate.StateContextI) (interface{}, error) {
+func (vsc *VestingSmartContract) getConfigHandler(_ context.Context,
+	_ url.Values, balances chainstate.StateContextI) (interface{}, error) {
 
-	res, err := getConfig()
+	res, err := vsc.getConfig(balances)
 	if err != nil {
 		return nil, common.NewErrInternal("can't get config", err.Error())
 	}
@@ -0,0 +1,47 @@
+package vestingsc
+
+import (
+	chainstate "0chain.net/chaincore/chain/state"
+	"0chain.net/smartcontract/governance"
+)
+
+// governance of the vesting SC configurations
+func (vsc *VestingSmartContract) governance() *governance.Governance {
+	return governance.New(vsc.ID, "smart_contracts.vestingsc.", vsc)
+}
+
+// updatedConfig returns current configurations with given setting changed
+func (vsc *VestingSmartContract) updatedConfig(key, value string,
+	balances chainstate.StateContextI) (conf *config, err error) {
+
+	if conf, err = vsc.getConfig(balances); err != nil {
+		return
+	}
+	if err = governance.SetSetting(conf, key, value); err != nil {
+		return
+	}
+	if err = conf.validate(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// ValidateSetting implements governance.Settings interface.
+func (vsc *VestingSmartContract) ValidateSetting(key, value string,
+	balances chainstate.StateContextI) (err error) {
+
+	_, err = vsc.updatedConfig(key, value, balances)
+	return
+}
+
+// ApplySetting implements governance.Settings interface.
+func (vsc *VestingSmartContract) ApplySetting(key, value string,
+	balances chainstate.StateContextI) (err error) {
+
+	var conf *config
+	if conf, err = vsc.updatedConfig(key, value, balances); err != nil {
+		return
+	}
+	_, err = balances.InsertTrieNode(configKey(vsc.ID), conf)
+	return
+}
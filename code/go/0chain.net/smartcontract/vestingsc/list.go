@@ -4,7 +4,6 @@ import (
 	"0chain.net/core/common"
 	"0chain.net/smartcontract"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"sort"
@@ -28,14 +27,14 @@ type clientPools struct {
 
 func (cp *clientPools) Encode() (b []byte) {
 	var err error
-	if b, err = json.Marshal(cp); err != nil {
+	if b, err = util.EncodeValue(cp); err != nil {
 		panic(err) // must not happen
 	}
 	return
 }
 
 func (cp *clientPools) Decode(b []byte) (err error) {
-	return json.Unmarshal(b, cp)
+	return util.DecodeValue(b, cp)
 }
 
 func (cp *clientPools) getIndex(poolID datastore.Key) (i int, ok bool) {
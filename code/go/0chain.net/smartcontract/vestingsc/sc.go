@@ -10,6 +10,7 @@ import (
 	"0chain.net/chaincore/smartcontractinterface"
 	"0chain.net/chaincore/transaction"
 	"0chain.net/core/common"
+	"0chain.net/smartcontract/governance"
 	metrics "github.com/rcrowley/go-metrics"
 )
 
@@ -78,12 +79,24 @@ func (vsc *VestingSmartContract) setSC(sc *smartcontractinterface.SmartContract,
 	// move vested tokens to destinations by pool owner
 	vsc.SmartContractExecutionStats["trigger"] = metrics.GetOrRegisterTimer(
 		fmt.Sprintf("sc:%v:func:%v", vsc.ID, "trigger"), nil)
+
+	// governance of the configurations
+	vsc.governance().SetRestHandlers(vsc.SmartContract.RestHandlers)
+	for _, fn := range governance.Functions {
+		vsc.SmartContractExecutionStats[fn] = metrics.GetOrRegisterTimer(
+			fmt.Sprintf("sc:%v:func:%v", vsc.ID, fn), nil)
+	}
 }
 
 func (vsc *VestingSmartContract) Execute(t *transaction.Transaction,
 	function string, input []byte, balances chainstate.StateContextI) (
 	resp string, err error) {
 
+	// apply accepted configurations changes first
+	if err = vsc.governance().ApplyDue(balances); err != nil {
+		return "", common.NewError("governance_failed", err.Error())
+	}
+
 	switch function {
 
 	case "trigger":
@@ -98,6 +111,10 @@ func (vsc *VestingSmartContract) Execute(t *transaction.Transaction,
 	case "delete":
 		resp, err = vsc.delete(t, input, balances)
 
+	case governance.FuncStakeLock, governance.FuncStakeUnlock,
+		governance.FuncPropose, governance.FuncVote:
+		resp, err = vsc.governance().Execute(t, function, input, balances)
+
 	default:
 		err = common.NewError("vesting_sc_failed",
 			fmt.Sprintf("no function with %q name", function))
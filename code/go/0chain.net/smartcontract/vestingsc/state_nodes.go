@@ -0,0 +1,21 @@
+package vestingsc
+
+import (
+	"0chain.net/chaincore/smartcontractinterface"
+	"0chain.net/core/util"
+)
+
+// stateNodeTypes - the vesting SC nodes of the state
+var stateNodeTypes = []smartcontractinterface.StateNodeType{
+	{Name: "vesting_pool", Fields: []string{"pool", "destinations", "client_id"},
+		New: func() interface{} { return newVestingPool() }},
+	{Name: "client_pools", Fields: []string{"pools"},
+		New: func() interface{} { return new(clientPools) }},
+}
+
+// DecodeStateNode - implement smartcontractinterface.StateNodeDecoder
+func (vsc *VestingSmartContract) DecodeStateNode(path util.Path, value []byte) (
+	string, interface{}, bool) {
+
+	return smartcontractinterface.DecodeStateNode(stateNodeTypes, path, value)
+}
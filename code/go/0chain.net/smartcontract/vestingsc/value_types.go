@@ -0,0 +1,16 @@
+package vestingsc
+
+import (
+	"0chain.net/core/util"
+)
+
+// tags of the typed state values of the vesting SC, never reuse a tag
+const (
+	vestingPoolValueTag util.ValueTypeTag = 0x0300 + iota
+	clientPoolsValueTag
+)
+
+func init() {
+	util.RegisterValueType(vestingPoolValueTag, "vestingsc.vesting_pool", &vestingPool{})
+	util.RegisterValueType(clientPoolsValueTag, "vestingsc.client_pools", &clientPools{})
+}
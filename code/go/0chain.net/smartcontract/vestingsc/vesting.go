@@ -229,7 +229,7 @@ func newVestingPoolFromReqeust(clientID datastore.Key, ar *addRequest) (
 // required util.Serializale interface.
 func (vp *vestingPool) Encode() (b []byte) {
 	var err error
-	if b, err = json.Marshal(vp); err != nil {
+	if b, err = util.EncodeValue(vp); err != nil {
 		panic(err) // must never happen
 	}
 	return
@@ -238,7 +238,7 @@ func (vp *vestingPool) Encode() (b []byte) {
 // Decode the vesting pool to JSON. Implements
 // required util.Serializale interface.
 func (vp *vestingPool) Decode(b []byte) error {
-	return json.Unmarshal(b, vp)
+	return util.DecodeValue(b, vp)
 }
 
 func checkFill(t *transaction.Transaction, balances chainstate.StateContextI) (
@@ -557,7 +557,7 @@ func (vsc *VestingSmartContract) add(t *transaction.Transaction,
 	}
 
 	var conf *config
-	if conf, err = getConfig(); err != nil {
+	if conf, err = vsc.getConfig(balances); err != nil {
 		return "", common.NewError("create_vesting_pool_failed",
 			"can't get SC configurations: "+err.Error())
 	}
@@ -112,6 +112,32 @@ func Test_vestingPool(t *testing.T) {
 	assert.Equal(t, state.Balance(10), inf.Left)
 }
 
+func Test_vestingPool_typedEncoding(t *testing.T) {
+	var vp = newVestingPool()
+	vp.ID, vp.Balance, vp.ClientID = "pool_hex", 40, "client_hex"
+	vp.Destinations = destinations{
+		&destination{ID: "one", Amount: 10, Vested: 5, Last: 11},
+		&destination{ID: "two", Amount: 20},
+	}
+	var legacy = vp.Encode()
+
+	util.SetTypedValueEncoding(true)
+	defer util.SetTypedValueEncoding(false)
+
+	var typed = vp.Encode()
+	require.True(t, util.IsTypedValue(typed))
+	for _, data := range [][]byte{legacy, typed} {
+		var vpd = new(vestingPool)
+		require.NoError(t, vpd.Decode(data))
+		assert.Equal(t, vp, vpd)
+	}
+
+	var cp = &clientPools{Pools: []string{"one", "two"}}
+	var cpd = new(clientPools)
+	require.NoError(t, cpd.Decode(cp.Encode()))
+	assert.Equal(t, cp, cpd)
+}
+
 func TestVestingSmartContract_getPoolBytes_getPool(t *testing.T) {
 	const txHash, clientID = "tx_hash", "client_hex"
 	var (
@@ -106,6 +106,7 @@ func (zrc *ZRC20SmartContract) digPool(t *transaction.Transaction, inputData []b
 	token.Available -= tokensRequested
 	balances.InsertTrieNode(token.getKey(zrc.ID), token)
 	balances.InsertTrieNode(zrcPool.getKey(zrc.ID), zrcPool)
+	emitPoolEvent(balances, "zrc20_dig_pool", zrcPool, transfer.Amount)
 	return resp, nil
 }
 
@@ -135,6 +136,7 @@ func (zrc *ZRC20SmartContract) fillPool(t *transaction.Transaction, inputData []
 	token.Available -= tokensRequested
 	balances.InsertTrieNode(token.getKey(zrc.ID), token)
 	balances.InsertTrieNode(zrcPool.getKey(zrc.ID), zrcPool)
+	emitPoolEvent(balances, "zrc20_fill_pool", zrcPool, transfer.Amount)
 	return resp, nil
 }
 
@@ -161,6 +163,10 @@ func (zrc *ZRC20SmartContract) transferTo(t *transaction.Transaction, inputData
 	}
 	balances.InsertTrieNode(zrcPool.getKey(zrc.ID), zrcPool)
 	balances.InsertTrieNode(otherPool.getKey(zrc.ID), otherPool)
+	emitPoolEvent(balances, "zrc20_transfer", zrcPool, newRequest.Value,
+		transaction.StringAttribute("to_token", otherPool.TokenName),
+		transaction.StringAttribute("to_pool", otherPool.ID),
+		transaction.IntAttribute("to_pool_balance", int64(otherPool.Balance)))
 	return resp, nil
 }
 
@@ -187,6 +193,7 @@ func (zrc *ZRC20SmartContract) drainPool(t *transaction.Transaction, inputData [
 	token.Available += tokensPutBack
 	balances.InsertTrieNode(token.getKey(zrc.ID), token)
 	balances.InsertTrieNode(zrcPool.getKey(zrc.ID), zrcPool)
+	emitPoolEvent(balances, "zrc20_drain_pool", zrcPool, transfer.Amount)
 	return resp, nil
 }
 
@@ -213,9 +220,22 @@ func (zrc *ZRC20SmartContract) emptyPool(t *transaction.Transaction, inputData [
 	token.Available += tokensPutBack
 	balances.InsertTrieNode(token.getKey(zrc.ID), token)
 	balances.DeleteTrieNode(zrcPool.getKey(zrc.ID))
+	emitPoolEvent(balances, "zrc20_empty_pool", zrcPool, transfer.Amount)
 	return resp, nil
 }
 
+// emitPoolEvent - emit the event of the change of the pool by the transaction
+func emitPoolEvent(balances c_state.StateContextI, name string, zrcPool *zrc20Pool,
+	value state.Balance, attributes ...*transaction.EventAttribute) {
+
+	balances.EmitEvent(transaction.NewEvent(name, append([]*transaction.EventAttribute{
+		transaction.StringAttribute("token", zrcPool.TokenName),
+		transaction.StringAttribute("pool", zrcPool.ID),
+		transaction.IntAttribute("value", int64(value)),
+		transaction.IntAttribute("pool_balance", int64(zrcPool.Balance)),
+	}, attributes...)...))
+}
+
 func (zrc *ZRC20SmartContract) getPool(tokenName string, id datastore.Key, balances c_state.StateContextI) (*zrc20Pool, error) {
 	zrcPool := &zrc20Pool{}
 	zrcPool.ID = id